@@ -6,18 +6,39 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/websocket"
+	apiconfig "go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/coinflip/lobby"
+	"go-outpost/internal/api/http-server/handlers/deposit"
+	"go-outpost/internal/api/http-server/handlers/escrow"
 	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/handlers/games"
+	"go-outpost/internal/api/http-server/handlers/hilo"
+	"go-outpost/internal/api/http-server/handlers/jackpot/pot"
 	"go-outpost/internal/api/http-server/handlers/job"
+	"go-outpost/internal/api/http-server/handlers/leaderboard"
+	"go-outpost/internal/api/http-server/handlers/mines"
 	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/handlers/plinko/drop"
 	"go-outpost/internal/api/http-server/handlers/provably_fair"
+	"go-outpost/internal/api/http-server/handlers/roulette/autobet"
+	"go-outpost/internal/api/http-server/handlers/roulette/bet/cancel"
 	"go-outpost/internal/api/http-server/handlers/roulette/bet/save"
+	"go-outpost/internal/api/http-server/handlers/roulette/history"
 	"go-outpost/internal/api/http-server/handlers/roulette/start"
+	"go-outpost/internal/api/http-server/handlers/seed"
+	"go-outpost/internal/api/http-server/handlers/tournament"
 	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/handlers/wallet"
+	"go-outpost/internal/api/http-server/handlers/withdrawal"
+	"go-outpost/internal/api/http-server/middleware/admin"
 	"go-outpost/internal/api/http-server/middleware/logger"
 	"go-outpost/internal/api/repository"
 	"go-outpost/internal/config"
+	"go-outpost/internal/events"
+	"go-outpost/internal/game"
 	"go-outpost/internal/lib/logger/handler/slogpretty"
 	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/payment"
 	"golang.org/x/exp/slog"
 	"net/http"
 	"os"
@@ -37,6 +58,34 @@ func main() {
 	log.Info("Starting server...", slog.String("env", cfg.Env))
 	log.Debug("debug messages are enabled")
 
+	wheel, err := cfg.Roulette.ActiveWheel()
+	if err != nil {
+		log.Error("Failed to load roulette wheel", sl.Err(err))
+		os.Exit(1)
+	}
+
+	rates, err := cfg.Wallets.ExchangeRates()
+	if err != nil {
+		log.Error("Failed to load exchange rates", sl.Err(err))
+		os.Exit(1)
+	}
+
+	// The fake provider settles everything it is handed, so it only ever
+	// serves local runs and other envs must configure a real provider.
+	providers := payment.NewRegistry()
+	if cfg.Env == envLocal {
+		providers.Register(payment.NewFake(cfg.Payments.WebhookSecret))
+	}
+
+	if _, err = providers.Get(cfg.Payments.Provider); err != nil {
+		log.Error("Failed to load payment provider", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("Roulette wheel loaded", slog.String("wheel", cfg.Roulette.Wheel), slog.Int("pockets", len(wheel.Pockets)))
+
+	rouletteLimits := cfg.GameLimits(apiconfig.Roulette)
+
 	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4,utf8&parseTime=True&loc=Local", "root", "123", "localhost:3309", "api")
 
 	db, err := sql.Open("mysql", dsn)
@@ -53,7 +102,9 @@ func main() {
 
 	handler := mysql.New(db)
 
-	conn, _, err := websocket.DefaultDialer.Dial("ws://"+cfg.WSServer.Address+"/ws", nil)
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+cfg.WSServer.Address+"/ws", http.Header{
+		"Authorization": []string{"Bearer " + cfg.WSServer.APIKey},
+	})
 	if err != nil {
 		log.Error("Failed to init storage", sl.Err(err))
 
@@ -71,7 +122,7 @@ func main() {
 	pool.Start()                         // Запуск пул рабочих
 	job.Queue = queue                    // Установка очереди задач
 
-	pusherEvent := event.NewPusherEvent(log, conn)
+	pusherEvent := event.NewPusherEvent(log, conn, events.Default)
 
 	repo := repository.NewTransaction(*handler)
 	rouletteBetRepo := repository.NewBetRepository(*handler)
@@ -79,12 +130,103 @@ func main() {
 	rouletteWinnerRepo := repository.NewRouletteWinnerRepository(*handler)
 	userRepo := repository.NewUserRepository(*handler)
 	provablyFairRepo := repository.NewProvablyFairRepository(*handler)
+	autoBetRepo := repository.NewAutoBetRepository(*handler)
+	auditRepo := repository.NewAuditRepository(*handler)
+	userSeedRepo := repository.NewUserSeedRepository(*handler)
+	escrowRepo := repository.NewEscrowRepository(*handler)
+	coinflipRepo := repository.NewCoinflipRepository(*handler)
+	minesRepo := repository.NewMinesRepository(*handler)
+	jackpotRepo := repository.NewJackpotRepository(*handler)
+	gameBetRepo := repository.NewGameBetRepository(*handler)
+	hiloRepo := repository.NewHiloRepository(*handler)
+	tournamentRepo := repository.NewTournamentRepository(*handler)
+	leaderboardRepo := repository.NewLeaderboardRepository(*handler)
+	depositRepo := repository.NewDepositRepository(*handler)
+	withdrawalRepo := repository.NewWithdrawalRepository(*handler)
 
-	provablyFair := provably_fair.NewProvablyFair(*provablyFairRepo, log)
-	roll := start.NewRouletteRoller(*rouletteWinnerRepo, provablyFair, log)
+	provablyFair := provably_fair.NewProvablyFair(*provablyFairRepo, *userSeedRepo, log)
+	roll := start.NewRouletteRoller(*rouletteWinnerRepo, provablyFair, wheel, log)
 	userBalance := balance.NewBalance(*userRepo, log, pusherEvent)
-	startRoulette := start.NewRouletteStart(log, *rouletteRepo, *rouletteBetRepo, pusherEvent, roll, userBalance, *repo)
-	betSave := place_bet.NewBet(log, *rouletteRepo, rouletteBetRepo, *userRepo, userBalance, *repo)
+	leaderboards := leaderboard.NewLeaderboard(log, *leaderboardRepo, *userRepo, rates)
+	betSave := place_bet.NewBet(log, *rouletteRepo, rouletteBetRepo, *userRepo, userBalance, *repo, wheel,
+		rouletteLimits)
+	betCancel := cancel_bet.NewCancel(log, *rouletteRepo, rouletteBetRepo, *userRepo, userBalance, *repo)
+	autoBetRunner := autobet.NewRunner(log, *autoBetRepo, betSave, rouletteBetRepo, wheel)
+	autoBet := autobet.NewAutoBet(log, *autoBetRepo, *userRepo, wheel, rouletteLimits)
+	settler := start.NewRouletteSettler(log, *rouletteRepo, *rouletteBetRepo, *rouletteWinnerRepo, wheel, userBalance,
+		*repo, leaderboards, autoBetRunner)
+	startRoulette := start.NewRouletteStart(log, *rouletteRepo, *rouletteWinnerRepo, pusherEvent, roll, settler, *repo,
+		autoBetRunner, cfg.Roulette.BetWindow)
+
+	recovery := start.NewRouletteRecovery(log, *rouletteRepo, *auditRepo, startRoulette, settler, cfg.Roulette.Recovery)
+
+	recovered, err := recovery.Recover()
+	if err != nil {
+		log.Error("Failed to recover roulette rounds", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("Roulette rounds recovered", slog.Int("rounds", recovered))
+
+	rouletteHistory := history.NewHistory(log, *rouletteRepo, *rouletteBetRepo, *rouletteWinnerRepo, *provablyFairRepo,
+		wheel)
+	stakes := escrow.NewEscrow(log, *escrowRepo, userBalance, leaderboards)
+	coinflip := lobby.NewCoinflip(log, *coinflipRepo, *userRepo, stakes, *repo, provablyFair, pusherEvent, cfg.Coinflip,
+		cfg.GameLimits(apiconfig.Coinflip))
+
+	jackpot := pot.NewJackpot(log, *jackpotRepo, *userRepo, *provablyFairRepo, stakes, *repo, provablyFair, pusherEvent,
+		cfg.Jackpot, cfg.GameLimits(apiconfig.Jackpot))
+	minesEngine := game.NewMines(cfg.Mines)
+	hiloEngine := game.NewHilo(cfg.Hilo)
+	registry := game.NewRegistry(game.NewRoulette(wheel), game.NewDice(cfg.Dice), game.NewLimbo(cfg.Limbo),
+		game.NewPlinko(cfg.Plinko), minesEngine, hiloEngine)
+	gamePlayer := game.NewPlayer(registry, *gameBetRepo, *userRepo, *repo, games.NewBalance(userBalance),
+		games.NewSeeds(provablyFair),
+		leaderboards, cfg.Limits)
+	minesGame := mines.NewMines(log, *minesRepo, *userRepo, minesEngine, gamePlayer)
+	plinko := drop.NewPlinko(log, *userRepo, gamePlayer, pusherEvent)
+	hiloGame := hilo.NewHilo(log, *hiloRepo, *userRepo, hiloEngine, gamePlayer)
+	gamesHandler := games.NewGames(log, *userRepo, registry, gamePlayer)
+	tournaments := tournament.NewTournaments(log, *tournamentRepo, *userRepo, userBalance, pusherEvent,
+		cfg.Tournament)
+	wallets := wallet.NewWallets(log, *userRepo, userBalance, *repo, cfg.Wallets, rates)
+	deposits := deposit.NewDeposits(log, *depositRepo, *userRepo, userBalance, *repo, providers, pusherEvent,
+		cfg.Payments, cfg.Wallets)
+	withdrawals := withdrawal.NewWithdrawals(log, *withdrawalRepo, *userRepo, userBalance, *repo, providers,
+		pusherEvent, cfg.Payments, cfg.Wallets, rates)
+	seeds := seed.NewSeed(log, *userRepo, provablyFair, []seed.ActiveGames{minesGame, hiloGame})
+
+	expired, err := coinflip.ExpireStale()
+	if err != nil {
+		log.Error("Failed to expire coinflip lobbies", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("Coinflip lobbies expired", slog.Int("lobbies", expired))
+
+	drawn, err := jackpot.DrawStale()
+	if err != nil {
+		log.Error("Failed to draw jackpot pots", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("Jackpot pots drawn", slog.Int("pots", drawn))
+
+	resumed, err := tournaments.Resume()
+	if err != nil {
+		log.Error("Failed to resume tournaments", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("Tournaments resumed", slog.Int("tournaments", resumed))
+
+	sending, err := withdrawals.Resume()
+	if err != nil {
+		log.Error("Failed to resume withdrawals", sl.Err(err))
+		os.Exit(1)
+	}
+
+	log.Info("Withdrawals resumed", slog.Int("withdrawals", sending))
 
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
@@ -94,7 +236,57 @@ func main() {
 	router.Use(middleware.URLFormat)
 
 	router.Post("/roulette/start", startRoulette.New())
+	router.Get("/roulette/history", rouletteHistory.List())
+	router.Get("/roulette/stats", rouletteHistory.Stats())
+	router.Get("/roulette/{uuid}", rouletteHistory.Show())
 	router.Post("/roulette/{uuid}/place-bet", betSave.New())
+	router.Delete("/roulette/{uuid}/bets/{id}", betCancel.New())
+	router.Post("/roulette/auto-bets", autoBet.Create())
+	router.Get("/roulette/auto-bets", autoBet.List())
+	router.Delete("/roulette/auto-bets/{id}", autoBet.Stop())
+	router.Post("/dice/roll", gamesHandler.PlayGame(apiconfig.Dice))
+	router.Get("/fair/seed", seeds.Show())
+	router.Post("/fair/seed/rotate", seeds.Rotate())
+	router.Post("/coinflip/lobbies", coinflip.Create())
+	router.Get("/coinflip/lobbies", coinflip.List())
+	router.Post("/coinflip/lobbies/{uuid}/join", coinflip.Join())
+	router.Post("/mines/games", minesGame.Start())
+	router.Get("/mines/games/active", minesGame.Active())
+	router.Post("/mines/games/{uuid}/reveal", minesGame.Reveal())
+	router.Post("/mines/games/{uuid}/cashout", minesGame.CashOut())
+	router.Post("/plinko/drop", plinko.New())
+	router.Post("/jackpot/deposit", jackpot.Deposit())
+	router.Get("/jackpot/current", jackpot.Current())
+	router.Get("/jackpot/history", jackpot.List())
+	router.Get("/jackpot/{uuid}", jackpot.Show())
+	router.Post("/limbo/play", gamesHandler.PlayGame(apiconfig.Limbo))
+	router.Post("/hilo/games", hiloGame.Start())
+	router.Get("/hilo/games/active", hiloGame.Active())
+	router.Post("/hilo/games/{uuid}/guess", hiloGame.Guess())
+	router.Post("/hilo/games/{uuid}/cashout", hiloGame.CashOut())
+	router.Post("/tournaments", tournaments.Create())
+	router.Get("/tournaments", tournaments.List())
+	router.Get("/tournaments/{uuid}", tournaments.Show())
+	router.Post("/tournaments/{uuid}/join", tournaments.Join())
+	router.Get("/leaderboards/{metric}", leaderboards.List())
+	router.Get("/leaderboards/{metric}/me", leaderboards.Rank())
+	router.Get("/wallets", wallets.List())
+	router.Get("/wallets/rates", wallets.Rates())
+	router.Post("/wallets/swap", wallets.Swap())
+	router.Post("/deposits", deposits.Create())
+	router.Get("/deposits", deposits.List())
+	router.Post("/deposits/webhook/{provider}", deposits.Webhook())
+	router.Post("/withdrawals", withdrawals.Request())
+	router.Get("/withdrawals", withdrawals.List())
+	router.Group(func(r chi.Router) {
+		r.Use(admin.New(log, cfg.Payments.AdminKey))
+		r.Get("/withdrawals/pending", withdrawals.Pending())
+		r.Post("/withdrawals/{uuid}/approve", withdrawals.Approve())
+		r.Post("/withdrawals/{uuid}/reject", withdrawals.Reject())
+	})
+	router.Get("/games", gamesHandler.List())
+	router.Post("/games/{game}/play", gamesHandler.Play())
+	router.Post("/games/{game}/verify", gamesHandler.Verify())
 
 	log.Info("Server started", slog.String("address", cfg.HTTPServer.Address))
 
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"go-outpost/internal/events"
+	"log"
+	"os"
+)
+
+func main() {
+	out := flag.String("out", "docs/asyncapi.json", "path of the generated AsyncAPI document")
+	version := flag.String("version", "1.0.0", "version of the event API")
+	flag.Parse()
+
+	doc, err := events.Default.AsyncAPI("go-outpost realtime events", *version)
+	if err != nil {
+		log.Fatalf("cannot render events schema: %s", err)
+	}
+
+	if err = os.WriteFile(*out, doc, 0o644); err != nil {
+		log.Fatalf("cannot write events schema: %s", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Command roulette-reconcile settles roulette rounds that were rolled but
+// never settled. With -dry-run it only lists them.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/gorilla/websocket"
+	apiconfig "go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/handlers/leaderboard"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/handlers/roulette/autobet"
+	"go-outpost/internal/api/http-server/handlers/roulette/bet/save"
+	"go-outpost/internal/api/http-server/handlers/roulette/start"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/config"
+	"go-outpost/internal/events"
+	"go-outpost/internal/lib/logger/handler/slogpretty"
+	"go-outpost/internal/lib/logger/sl"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"os"
+)
+
+const (
+	envLocal = "local"
+	envDev   = "dev"
+	envProd  = "prod"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list unsettled rounds without settling them")
+	flag.Parse()
+
+	cfg := config.MustLoad()
+
+	log := setupLogger(cfg.Env)
+
+	wheel, err := cfg.Roulette.ActiveWheel()
+	if err != nil {
+		log.Error("Failed to load roulette wheel", sl.Err(err))
+		os.Exit(1)
+	}
+
+	rates, err := cfg.Wallets.ExchangeRates()
+	if err != nil {
+		log.Error("Failed to load exchange rates", sl.Err(err))
+		os.Exit(1)
+	}
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4,utf8&parseTime=True&loc=Local", "root", "123", "localhost:3309", "api")
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		log.Error("Failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	if err = db.Ping(); err != nil {
+		log.Error("Failed to init storage", sl.Err(err))
+		os.Exit(1)
+	}
+
+	handler := mysql.New(db)
+
+	rouletteRepo := repository.NewRouletteRepository(*handler)
+
+	if *dryRun {
+		roulettes, err := rouletteRepo.GetUnsettledRoulettes()
+		if err != nil {
+			log.Error("Failed to find unsettled rounds", sl.Err(err))
+			os.Exit(1)
+		}
+
+		for _, roulette := range roulettes {
+			log.Info("Unsettled round", slog.Int64("roulette_id", roulette.ID), slog.String("uuid", roulette.UUID.String()),
+				slog.Int64("round", roulette.Round))
+		}
+
+		log.Info("Dry run finished", slog.Int("unsettled", len(roulettes)))
+
+		return
+	}
+
+	// Balance events are best effort here, settling must not depend on the ws server.
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+cfg.WSServer.Address+"/ws", http.Header{
+		"Authorization": []string{"Bearer " + cfg.WSServer.APIKey},
+	})
+	if err != nil {
+		log.Warn("Failed to connect to ws server, balance events are dropped", sl.Err(err))
+	} else {
+		defer conn.Close()
+	}
+
+	pusherEvent := event.NewPusherEvent(log, conn, events.Default)
+
+	rouletteBetRepo := repository.NewBetRepository(*handler)
+	rouletteWinnerRepo := repository.NewRouletteWinnerRepository(*handler)
+	userRepo := repository.NewUserRepository(*handler)
+	autoBetRepo := repository.NewAutoBetRepository(*handler)
+	repo := repository.NewTransaction(*handler)
+	leaderboardRepo := repository.NewLeaderboardRepository(*handler)
+
+	userBalance := balance.NewBalance(*userRepo, log, pusherEvent)
+	leaderboards := leaderboard.NewLeaderboard(log, *leaderboardRepo, *userRepo, rates)
+	betSave := place_bet.NewBet(log, *rouletteRepo, rouletteBetRepo, *userRepo, userBalance, *repo, wheel,
+		cfg.GameLimits(apiconfig.Roulette))
+	autoBetRunner := autobet.NewRunner(log, *autoBetRepo, betSave, rouletteBetRepo, wheel)
+	settler := start.NewRouletteSettler(log, *rouletteRepo, *rouletteBetRepo, *rouletteWinnerRepo, wheel, userBalance,
+		*repo, leaderboards, autoBetRunner)
+
+	settled, err := settler.Reconcile()
+	if err != nil {
+		log.Error("Failed to reconcile rounds", sl.Err(err), slog.Int("settled", settled))
+		os.Exit(1)
+	}
+
+	log.Info("Reconciliation finished", slog.Int("settled", settled))
+}
+
+func setupLogger(env string) *slog.Logger {
+	var log *slog.Logger
+
+	switch env {
+	case envLocal:
+		log = setupPrettySlogLogger()
+	case envDev:
+		log = slog.New(
+			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	case envProd:
+		log = slog.New(
+			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	}
+
+	return log
+}
+
+func setupPrettySlogLogger() *slog.Logger {
+	opts := slogpretty.PrettyHandlerOptions{
+		SlogOpts: &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+	}
+
+	handler := opts.NewPrettyHandler(os.Stdout)
+
+	return slog.New(handler)
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"expvar"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go-outpost/internal/api/http-server/middleware/logger"
 	"go-outpost/internal/config"
+	"go-outpost/internal/events"
 	"go-outpost/internal/lib/logger/handler/slogpretty"
 	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/ws/guard"
 	"go-outpost/internal/ws/handler"
+	"go-outpost/internal/ws/middleware/auth"
 	"golang.org/x/exp/slog"
 	"net/http"
 	"os"
@@ -24,16 +31,36 @@ func main() {
 	log.Info("Starting ws server...", slog.String("env", cfg.Env))
 	log.Debug("debug messages are enabled")
 
-	hub := handler.NewHub(log)
+	hub := handler.NewHub(log, cfg.WSServer, events.Default, guard.New(log, cfg.WSServer.Limits))
 
 	hub.RunServer()
 
-	http.HandleFunc("/ws", hub.HandleConnection)
+	api := handler.NewAPI(hub, log)
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Recoverer)
+
+	router.HandleFunc("/ws", hub.HandleConnection)
+	router.Get("/sse", hub.HandleSSE)
+	router.Get("/poll", hub.HandlePoll)
+	router.Get("/events/schema", api.Schema())
+
+	router.Group(func(r chi.Router) {
+		r.Use(logger.New(log))
+		r.Use(auth.New(log, cfg.WSServer.APIKey))
+
+		r.Post("/events", api.PublishEvents())
+		r.Get("/channels", api.ListChannels())
+		r.Get("/channels/{name}/users", api.ListChannelUsers())
+		r.Handle("/debug/vars", expvar.Handler())
+	})
 
 	log.Info("Server started", slog.String("address", cfg.WSServer.Address))
 
 	srv := &http.Server{
 		Addr:         cfg.WSServer.Address,
+		Handler:      router,
 		ReadTimeout:  cfg.WSServer.Timeout,
 		WriteTimeout: cfg.WSServer.Timeout,
 		IdleTimeout:  cfg.WSServer.IdleTimeout,
@@ -0,0 +1,26 @@
+package config
+
+type AutoBetStrategy string
+
+const (
+	StrategyFixed      AutoBetStrategy = "fixed"
+	StrategyMartingale AutoBetStrategy = "martingale"
+)
+
+type AutoBetStatus string
+
+const (
+	AutoBetActive  AutoBetStatus = "active"
+	AutoBetStopped AutoBetStatus = "stopped"
+)
+
+type AutoBetStopReason string
+
+const (
+	StopRoundsCompleted   AutoBetStopReason = "rounds_completed"
+	StopProfitReached     AutoBetStopReason = "profit_reached"
+	StopLossReached       AutoBetStopReason = "loss_reached"
+	StopInsufficientFunds AutoBetStopReason = "insufficient_funds"
+	StopStakeLimit        AutoBetStopReason = "stake_limit"
+	StopCancelled         AutoBetStopReason = "cancelled"
+)
@@ -0,0 +1,16 @@
+package config
+
+type BetType string
+
+const (
+	BetColor  BetType = "color"
+	BetNumber BetType = "number"
+	BetRange  BetType = "range"
+	BetParity BetType = "parity"
+	BetCombo  BetType = "combo"
+)
+
+const (
+	Odd  = "odd"
+	Even = "even"
+)
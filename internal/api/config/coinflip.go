@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+type CoinSide string
+
+const (
+	Heads CoinSide = "heads"
+	Tails CoinSide = "tails"
+)
+
+// CoinSides is the order sides are drawn in, the drawn index picks the side.
+var CoinSides = []CoinSide{Heads, Tails}
+
+// Opposite is the side left to the player joining a lobby.
+func (s CoinSide) Opposite() CoinSide {
+	if s == Heads {
+		return Tails
+	}
+
+	return Heads
+}
+
+// LobbyStatus follows a coinflip lobby from creation until it is resolved
+// between two players or expires without an opponent and is refunded.
+type LobbyStatus string
+
+const (
+	LobbyOpen     LobbyStatus = "open"
+	LobbyResolved LobbyStatus = "resolved"
+	LobbyExpired  LobbyStatus = "expired"
+)
+
+// CoinflipRules holds the rules of the coinflip game. Rake is the percentage
+// of the pot kept by the house.
+type CoinflipRules struct {
+	Rake     float64       `yaml:"rake" env-default:"5"`
+	LobbyTTL time.Duration `yaml:"lobby_ttl" env-default:"10m"`
+}
+
+func (c CoinflipRules) Validate() error {
+	if c.Rake < 0 || c.Rake >= 100 {
+		return fmt.Errorf("coinflip rake %v is invalid", c.Rake)
+	}
+
+	if c.LobbyTTL <= 0 {
+		return fmt.Errorf("coinflip lobby ttl %s is invalid", c.LobbyTTL)
+	}
+
+	return nil
+}
+
+// Split returns what the winner of a pot gets and what the house keeps.
+// The rake is rounded down to the cent in favour of the player.
+func (c CoinflipRules) Split(pot int) (int, int) {
+	rake := int(float64(pot) * c.Rake / 100)
+
+	return pot - rake, rake
+}
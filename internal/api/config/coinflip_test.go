@@ -0,0 +1,34 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCoinflipRulesSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		rake   float64
+		pot    int
+		payout int
+		kept   int
+	}{
+		{name: "five percent", rake: 5, pot: 2000, payout: 1900, kept: 100},
+		{name: "rake rounds down", rake: 5, pot: 30, payout: 29, kept: 1},
+		{name: "no rake", rake: 0, pot: 2000, payout: 2000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payout, kept := CoinflipRules{Rake: tt.rake, LobbyTTL: time.Minute}.Split(tt.pot)
+
+			assert.Equal(t, tt.payout, payout)
+			assert.Equal(t, tt.kept, kept)
+		})
+	}
+
+	assert.Equal(t, Tails, Heads.Opposite())
+	assert.Equal(t, Heads, Tails.Opposite())
+	assert.Error(t, CoinflipRules{Rake: 100, LobbyTTL: time.Minute}.Validate())
+	assert.Error(t, CoinflipRules{Rake: 5}.Validate())
+}
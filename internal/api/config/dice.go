@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DiceSides is the number of results a dice roll can land on, rolls go from
+// 0.00 to 99.99 and are kept in hundredths.
+const DiceSides = 10000
+
+// DiceDirection tells whether a dice bet wins above or below its target.
+type DiceDirection string
+
+const (
+	DiceOver  DiceDirection = "over"
+	DiceUnder DiceDirection = "under"
+)
+
+var ErrInvalidTarget = errors.New("dice target is out of range")
+
+// DiceRules holds the rules of the dice game. Targets are in hundredths like the
+// rolls, HouseEdge is a percentage.
+type DiceRules struct {
+	HouseEdge float64 `yaml:"house_edge" env-default:"1"`
+	MinTarget int     `yaml:"min_target" env-default:"100"`
+	MaxTarget int     `yaml:"max_target" env-default:"9800"`
+}
+
+func (d DiceRules) Validate() error {
+	if d.HouseEdge < 0 || d.HouseEdge >= 100 {
+		return fmt.Errorf("dice house edge %v is invalid", d.HouseEdge)
+	}
+
+	if d.MinTarget < 1 || d.MaxTarget >= DiceSides-1 || d.MinTarget > d.MaxTarget {
+		return fmt.Errorf("dice targets %d-%d are invalid", d.MinTarget, d.MaxTarget)
+	}
+
+	return nil
+}
+
+// ValidateBet checks the target is allowed and the direction is known.
+func (d DiceRules) ValidateBet(target int, direction DiceDirection) error {
+	if direction != DiceOver && direction != DiceUnder {
+		return fmt.Errorf("%w: direction %q", ErrInvalidTarget, direction)
+	}
+
+	if target < d.MinTarget || target > d.MaxTarget {
+		return fmt.Errorf("%w: %d", ErrInvalidTarget, target)
+	}
+
+	return nil
+}
+
+// WinChance is the percentage of rolls that win the bet.
+func (d DiceRules) WinChance(target int, direction DiceDirection) float64 {
+	if direction == DiceUnder {
+		return float64(target) * 100 / DiceSides
+	}
+
+	return float64(DiceSides-1-target) * 100 / DiceSides
+}
+
+// Multiplier is the fair multiplier of the win chance reduced by the house edge.
+func (d DiceRules) Multiplier(target int, direction DiceDirection) float64 {
+	chance := d.WinChance(target, direction)
+	if chance <= 0 {
+		return 0
+	}
+
+	return (100 - d.HouseEdge) / chance
+}
+
+// Wins tells whether a roll wins the bet, a roll equal to the target loses.
+func (d DiceRules) Wins(roll int, target int, direction DiceDirection) bool {
+	if direction == DiceUnder {
+		return roll < target
+	}
+
+	return roll > target
+}
+
+// Payout is the amount paid back for a winning stake, rounded down to the cent.
+func (d DiceRules) Payout(amount int, target int, direction DiceDirection) int {
+	return int(float64(amount) * d.Multiplier(target, direction))
+}
@@ -0,0 +1,11 @@
+package config
+
+// EscrowStatus tracks a stake held for a game until it is resolved. Released
+// funds went to the pot, refunded ones back to the player.
+type EscrowStatus string
+
+const (
+	EscrowHeld     EscrowStatus = "held"
+	EscrowReleased EscrowStatus = "released"
+	EscrowRefunded EscrowStatus = "refunded"
+)
@@ -5,4 +5,21 @@ type Game string
 const (
 	Crash    Game = "crash"
 	Roulette Game = "roulette"
+	Dice     Game = "dice"
+	Coinflip Game = "coinflip"
+	Mines    Game = "mines"
+	Plinko   Game = "plinko"
+	Jackpot  Game = "jackpot"
+	Limbo    Game = "limbo"
+	Hilo     Game = "hilo"
+
+	// Tournament is not a game, it marks the balance movements of tournament
+	// entry fees and prizes.
+	Tournament Game = "tournament"
+	// Swap marks the balance movements of exchanges between wallets.
+	Swap Game = "swap"
+	// Deposit and Withdrawal mark the money moved in and out through a
+	// payment provider, including held and refunded withdrawals.
+	Deposit    Game = "deposit"
+	Withdrawal Game = "withdrawal"
 )
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HiloCards is the deck size of hilo. Cards are drawn with replacement, card
+// c has rank c/4+1 from ace (1) to king (13).
+const (
+	HiloCards = 52
+	HiloRanks = 13
+)
+
+type HiloGuess string
+
+const (
+	HiloHigher HiloGuess = "higher"
+	HiloLower  HiloGuess = "lower"
+)
+
+// HiloStatus follows a hilo game from its first card until a wrong guess or
+// a cash out.
+type HiloStatus string
+
+const (
+	HiloActive    HiloStatus = "active"
+	HiloBusted    HiloStatus = "busted"
+	HiloCashedOut HiloStatus = "cashed_out"
+)
+
+var ErrInvalidGuess = errors.New("guess cannot win from this card")
+
+// HiloRules holds the rules of hilo, HouseEdge is a percentage taken on every
+// guess.
+type HiloRules struct {
+	HouseEdge float64 `yaml:"house_edge" env-default:"1"`
+	MaxSteps  int     `yaml:"max_steps" env-default:"50"`
+}
+
+func (h HiloRules) Validate() error {
+	if h.HouseEdge < 0 || h.HouseEdge >= 100 {
+		return fmt.Errorf("hilo house edge %v is invalid", h.HouseEdge)
+	}
+
+	if h.MaxSteps < 1 {
+		return fmt.Errorf("hilo max steps %d is invalid", h.MaxSteps)
+	}
+
+	return nil
+}
+
+func HiloRank(card int) int {
+	return card/4 + 1
+}
+
+// WinChance is the chance in [0, 1] that the next card wins the guess. Higher
+// wins on the same rank or above, lower on the same rank or below.
+func (h HiloRules) WinChance(rank int, guess HiloGuess) float64 {
+	if guess == HiloHigher {
+		return float64(HiloRanks-rank+1) / HiloRanks
+	}
+
+	return float64(rank) / HiloRanks
+}
+
+// StepMultiplier is what a winning guess multiplies the running multiplier
+// by. A guess that cannot lose is refused, it would only pay less than it
+// risks.
+func (h HiloRules) StepMultiplier(rank int, guess HiloGuess) (float64, error) {
+	if guess != HiloHigher && guess != HiloLower {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidGuess, guess)
+	}
+
+	chance := h.WinChance(rank, guess)
+	if chance >= 1 {
+		return 0, fmt.Errorf("%w: %s from rank %d", ErrInvalidGuess, guess, rank)
+	}
+
+	return (100 - h.HouseEdge) / 100 / chance, nil
+}
+
+// Wins tells whether the next rank wins the guess.
+func (h HiloRules) Wins(rank int, next int, guess HiloGuess) bool {
+	if guess == HiloHigher {
+		return next >= rank
+	}
+
+	return next <= rank
+}
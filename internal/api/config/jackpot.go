@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// PotStatus follows a jackpot pot from its first deposit until a ticket is
+// drawn, or until it is refunded when fewer than two players took part.
+type PotStatus string
+
+const (
+	PotOpen     PotStatus = "open"
+	PotDrawn    PotStatus = "drawn"
+	PotRefunded PotStatus = "refunded"
+)
+
+// JackpotRules holds the rules of the jackpot game. A pot runs for Duration
+// after its first deposit, each cent deposited is one ticket. Deposit limits
+// apply to the total of a player in one pot, Rake is a percentage of the pot.
+type JackpotRules struct {
+	Duration   time.Duration `yaml:"duration" env-default:"60s"`
+	Rake       float64       `yaml:"rake" env-default:"5"`
+	MinDeposit int           `yaml:"min_deposit" env-default:"100"`
+	MaxDeposit int           `yaml:"max_deposit" env-default:"10000000"`
+}
+
+func (j JackpotRules) Validate() error {
+	if j.Duration <= 0 {
+		return fmt.Errorf("jackpot duration %s is invalid", j.Duration)
+	}
+
+	if j.Rake < 0 || j.Rake >= 100 {
+		return fmt.Errorf("jackpot rake %v is invalid", j.Rake)
+	}
+
+	if j.MinDeposit < 1 || (j.MaxDeposit > 0 && j.MinDeposit > j.MaxDeposit) {
+		return fmt.Errorf("jackpot deposits %d-%d are invalid", j.MinDeposit, j.MaxDeposit)
+	}
+
+	return nil
+}
+
+// CheckDeposit applies the deposit limits to a new deposit of a player who
+// already deposited that much into the pot.
+func (j JackpotRules) CheckDeposit(amount int, deposited int) error {
+	if amount < j.MinDeposit || (j.MaxDeposit > 0 && deposited+amount > j.MaxDeposit) {
+		return fmt.Errorf("%w: jackpot deposit of %d after %d", ErrStakeOutOfRange, amount, deposited)
+	}
+
+	return nil
+}
+
+// Split returns what the winner of a pot gets and what the house keeps.
+// The rake is rounded down to the cent in favour of the player.
+func (j JackpotRules) Split(pot int) (int, int) {
+	rake := int(float64(pot) * j.Rake / 100)
+
+	return pot - rake, rake
+}
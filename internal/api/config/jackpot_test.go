@@ -0,0 +1,40 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestJackpotRules(t *testing.T) {
+	rules := JackpotRules{Duration: time.Minute, Rake: 5, MinDeposit: 100, MaxDeposit: 1000}
+
+	tests := []struct {
+		name      string
+		amount    int
+		deposited int
+		wantErr   bool
+	}{
+		{name: "first deposit", amount: 100},
+		{name: "up to the max", amount: 400, deposited: 600},
+		{name: "below the min", amount: 99, wantErr: true},
+		{name: "over the max in total", amount: 500, deposited: 600, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rules.CheckDeposit(tt.amount, tt.deposited)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrStakeOutOfRange)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	payout, rake := rules.Split(10010)
+	assert.Equal(t, 9510, payout)
+	assert.Equal(t, 500, rake)
+
+	assert.NoError(t, rules.Validate())
+	assert.Error(t, JackpotRules{Rake: 5, MinDeposit: 100}.Validate())
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// LeaderboardPeriod is the time window a leaderboard covers. Windows are
+// calendar based in UTC, weeks start on Monday.
+type LeaderboardPeriod string
+
+const (
+	Daily   LeaderboardPeriod = "daily"
+	Weekly  LeaderboardPeriod = "weekly"
+	Monthly LeaderboardPeriod = "monthly"
+	AllTime LeaderboardPeriod = "all_time"
+)
+
+// LeaderboardMetric is what players are ranked on. Profit is payouts minus
+// stakes, BiggestWin the best profit of a single bet.
+type LeaderboardMetric string
+
+const (
+	Wagered    LeaderboardMetric = "wagered"
+	Profit     LeaderboardMetric = "profit"
+	BiggestWin LeaderboardMetric = "biggest_win"
+)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard")
+
+// LeaderboardPeriods lists every window a settled bet is counted in.
+var LeaderboardPeriods = []LeaderboardPeriod{Daily, Weekly, Monthly, AllTime}
+
+// PeriodStart returns the start of the window of period that holds t. Every
+// bet of the all-time window starts at the Unix epoch.
+func PeriodStart(period LeaderboardPeriod, t time.Time) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case Daily:
+		return day, nil
+	case Weekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	case AllTime:
+		return time.Unix(0, 0).UTC(), nil
+	}
+
+	return time.Time{}, ErrInvalidLeaderboard
+}
+
+// CheckLeaderboard accepts the metric and period of a leaderboard request.
+func CheckLeaderboard(metric LeaderboardMetric, period LeaderboardPeriod) error {
+	switch metric {
+	case Wagered, Profit, BiggestWin:
+	default:
+		return ErrInvalidLeaderboard
+	}
+
+	_, err := PeriodStart(period, time.Now())
+
+	return err
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalidMultiplier = errors.New("target multiplier is out of range")
+
+// LimboRules holds the rules of the limbo game. Targets are multipliers with
+// two decimals, HouseEdge is a percentage.
+type LimboRules struct {
+	HouseEdge float64 `yaml:"house_edge" env-default:"1"`
+	MinTarget float64 `yaml:"min_target" env-default:"1.01"`
+	MaxTarget float64 `yaml:"max_target" env-default:"1000000"`
+}
+
+func (l LimboRules) Validate() error {
+	if l.HouseEdge < 0 || l.HouseEdge >= 100 {
+		return fmt.Errorf("limbo house edge %v is invalid", l.HouseEdge)
+	}
+
+	if l.MinTarget <= 1 || l.MinTarget > l.MaxTarget {
+		return fmt.Errorf("limbo targets %v-%v are invalid", l.MinTarget, l.MaxTarget)
+	}
+
+	return nil
+}
+
+func (l LimboRules) ValidateTarget(target float64) error {
+	if target < l.MinTarget || target > l.MaxTarget {
+		return fmt.Errorf("%w: %v", ErrInvalidMultiplier, target)
+	}
+
+	return nil
+}
+
+// Multiplier turns a draw in [0, 1) into the drawn multiplier. A multiplier
+// of at least m comes out with a chance of (100 - edge) / m percent, the
+// result is rounded down to two decimals and never below 1.
+func (l LimboRules) Multiplier(draw float64) float64 {
+	multiplier := (100 - l.HouseEdge) / 100 / (1 - draw)
+
+	// The epsilon keeps float errors such as 98.99999 from losing a cent.
+	return math.Max(1, math.Floor(multiplier*100+1e-9)/100)
+}
+
+// WinChance is the percentage of draws reaching the target.
+func (l LimboRules) WinChance(target float64) float64 {
+	return (100 - l.HouseEdge) / target
+}
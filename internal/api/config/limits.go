@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"go-outpost/internal/lib/money"
+)
+
+// ExposureMode decides what happens to a bet that would push the house
+// exposure over its cap.
+type ExposureMode string
+
+const (
+	ExposureReject ExposureMode = "reject"
+	ExposureScale  ExposureMode = "scale"
+)
+
+var ErrStakeOutOfRange = errors.New("stake is out of range")
+
+// StakeLimit bounds a single bet. Zero means no bound.
+type StakeLimit struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+// Exposure caps the total amount the house would pay out across all players
+// for any single outcome of a round.
+type Exposure struct {
+	Cap  int          `yaml:"cap"`
+	Mode ExposureMode `yaml:"mode"`
+}
+
+// CurrencyLimits are the limits of a game in a currency other than the
+// default one, in its minor units. The exposure mode of the default currency
+// applies when Exposure leaves it empty.
+type CurrencyLimits struct {
+	Stake             StakeLimit             `yaml:"stake"`
+	BetTypes          map[BetType]StakeLimit `yaml:"bet_types"`
+	MaxPayoutPerRound int                    `yaml:"max_payout_per_round"`
+	Exposure          Exposure               `yaml:"exposure"`
+}
+
+// Limits are the betting rules of one game. Amounts are in cents of the
+// default currency and zero disables a limit. A game takes bets in another
+// currency only when it has limits for it in Currencies, which bound every
+// bet type and the exposure the default currency bounds.
+type Limits struct {
+	Stake             StakeLimit                        `yaml:"stake"`
+	BetTypes          map[BetType]StakeLimit            `yaml:"bet_types"`
+	MaxBetsPerRound   int                               `yaml:"max_bets_per_round"`
+	MaxPayoutPerRound int                               `yaml:"max_payout_per_round"`
+	Exposure          Exposure                          `yaml:"exposure"`
+	Currencies        map[money.Currency]CurrencyLimits `yaml:"currencies"`
+}
+
+func (l Limits) Validate() error {
+	stakes := []StakeLimit{l.Stake}
+	for _, stake := range l.BetTypes {
+		stakes = append(stakes, stake)
+	}
+
+	modes := []ExposureMode{l.Exposure.Mode}
+
+	for currency, limits := range l.Currencies {
+		if _, err := currency.MinorUnits(); err != nil {
+			return err
+		}
+
+		for betType := range l.BetTypes {
+			if _, ok := limits.BetTypes[betType]; !ok {
+				return fmt.Errorf("%s bet limit in %s is not set", betType, currency)
+			}
+		}
+
+		if l.Exposure.Cap > 0 && limits.Exposure.Cap <= 0 {
+			return fmt.Errorf("exposure cap in %s is not set", currency)
+		}
+
+		stakes = append(stakes, limits.Stake)
+		for _, stake := range limits.BetTypes {
+			stakes = append(stakes, stake)
+		}
+
+		modes = append(modes, limits.Exposure.Mode)
+	}
+
+	for _, stake := range stakes {
+		if stake.Min < 0 || stake.Max < 0 || (stake.Max > 0 && stake.Min > stake.Max) {
+			return fmt.Errorf("stake limit %d-%d is invalid", stake.Min, stake.Max)
+		}
+	}
+
+	for _, mode := range modes {
+		switch mode {
+		case "", ExposureReject, ExposureScale:
+		default:
+			return fmt.Errorf("exposure mode %q is invalid", mode)
+		}
+	}
+
+	return nil
+}
+
+// CheckStake applies the game stake limit and the one of the bet type.
+func (l Limits) CheckStake(betType BetType, amount int) error {
+	for _, stake := range []StakeLimit{l.Stake, l.BetTypes[betType]} {
+		if amount < stake.Min || (stake.Max > 0 && amount > stake.Max) {
+			return fmt.Errorf("%w: %s bet of %d", ErrStakeOutOfRange, betType, amount)
+		}
+	}
+
+	return nil
+}
+
+// In returns the limits of bets in the currency.
+func (l Limits) In(currency money.Currency) (Limits, error) {
+	if currency == money.Default {
+		return l, nil
+	}
+
+	limits, ok := l.Currencies[currency]
+	if !ok {
+		return Limits{}, fmt.Errorf("%w: %s", ErrCurrencyNotAccepted, currency)
+	}
+
+	exposure := limits.Exposure
+	if exposure.Mode == "" {
+		exposure.Mode = l.Exposure.Mode
+	}
+
+	return Limits{
+		Stake:             limits.Stake,
+		BetTypes:          limits.BetTypes,
+		MaxBetsPerRound:   l.MaxBetsPerRound,
+		MaxPayoutPerRound: limits.MaxPayoutPerRound,
+		Exposure:          exposure,
+	}, nil
+}
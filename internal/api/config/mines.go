@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MinesTiles is the size of the 5x5 mines board, tiles are numbered 0-24.
+const MinesTiles = 25
+
+// MinesStatus follows a mines game from its first tile until the player
+// hits a mine or cashes out.
+type MinesStatus string
+
+const (
+	MinesActive    MinesStatus = "active"
+	MinesBusted    MinesStatus = "busted"
+	MinesCashedOut MinesStatus = "cashed_out"
+)
+
+var ErrInvalidMines = errors.New("mine count is out of range")
+
+// MinesRules holds the rules of the mines game, HouseEdge is a percentage.
+type MinesRules struct {
+	HouseEdge float64 `yaml:"house_edge" env-default:"1"`
+	MinMines  int     `yaml:"min_mines" env-default:"1"`
+	MaxMines  int     `yaml:"max_mines" env-default:"24"`
+}
+
+func (m MinesRules) Validate() error {
+	if m.HouseEdge < 0 || m.HouseEdge >= 100 {
+		return fmt.Errorf("mines house edge %v is invalid", m.HouseEdge)
+	}
+
+	if m.MinMines < 1 || m.MaxMines >= MinesTiles || m.MinMines > m.MaxMines {
+		return fmt.Errorf("mine counts %d-%d are invalid", m.MinMines, m.MaxMines)
+	}
+
+	return nil
+}
+
+func (m MinesRules) ValidateMines(mines int) error {
+	if mines < m.MinMines || mines > m.MaxMines {
+		return fmt.Errorf("%w: %d", ErrInvalidMines, mines)
+	}
+
+	return nil
+}
+
+// Multiplier is the fair odds of revealing that many safe tiles in a row on
+// a board with the given mines, reduced by the house edge. It is 1 before
+// the first tile.
+func (m MinesRules) Multiplier(mines int, revealed int) float64 {
+	if revealed == 0 {
+		return 1
+	}
+
+	odds := 1.0
+	for i := 0; i < revealed; i++ {
+		odds *= float64(MinesTiles-i) / float64(MinesTiles-mines-i)
+	}
+
+	return odds * (100 - m.HouseEdge) / 100
+}
+
+// Payout is what cashing out pays for a stake, rounded down to the cent.
+func (m MinesRules) Payout(amount int, mines int, revealed int) int {
+	return int(float64(amount) * m.Multiplier(mines, revealed))
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DepositStatus follows a deposit from its checkout until the provider
+// reports it paid or abandoned.
+type DepositStatus string
+
+const (
+	DepositPending   DepositStatus = "pending"
+	DepositCompleted DepositStatus = "completed"
+	DepositFailed    DepositStatus = "failed"
+)
+
+// WithdrawalStatus follows a withdrawal. Its amount is held from the wallet
+// once requested, and given back only when it fails. A sending withdrawal
+// was handed to the provider and may have been paid out already.
+type WithdrawalStatus string
+
+const (
+	WithdrawalRequested WithdrawalStatus = "requested"
+	WithdrawalApproved  WithdrawalStatus = "approved"
+	WithdrawalSending   WithdrawalStatus = "sending"
+	WithdrawalSent      WithdrawalStatus = "sent"
+	WithdrawalFailed    WithdrawalStatus = "failed"
+)
+
+var ErrWithdrawalStatus = errors.New("withdrawal cannot move to that status")
+
+// CanMoveTo reports whether a withdrawal may go from s to next. A requested
+// withdrawal is approved or rejected, an approved one is claimed for sending
+// or fails, and a sending one ends sent or, once declined, failed.
+func (s WithdrawalStatus) CanMoveTo(next WithdrawalStatus) bool {
+	switch s {
+	case WithdrawalRequested:
+		return next == WithdrawalApproved || next == WithdrawalFailed
+	case WithdrawalApproved:
+		return next == WithdrawalSending || next == WithdrawalFailed
+	case WithdrawalSending:
+		return next == WithdrawalSent || next == WithdrawalFailed
+	}
+
+	return false
+}
+
+// PaymentRules pick the payment provider and the secret its webhooks are
+// signed with. Withdrawals worth up to AutoApprove cents of the default
+// currency are approved without review, zero reviews every withdrawal.
+// AdminKey is the bearer token operators review withdrawals with.
+type PaymentRules struct {
+	Provider      string `yaml:"provider" env-default:"fake"`
+	WebhookSecret string `yaml:"webhook_secret" env:"PAYMENT_WEBHOOK_SECRET"`
+	AdminKey      string `yaml:"admin_key" env:"PAYMENT_ADMIN_KEY"`
+	AutoApprove   int    `yaml:"auto_approve" env-default:"0"`
+}
+
+func (p PaymentRules) Validate() error {
+	if p.Provider == "" {
+		return fmt.Errorf("payment provider is not set")
+	}
+
+	if p.WebhookSecret == "" {
+		return fmt.Errorf("payment webhook secret is not set")
+	}
+
+	if p.AdminKey == "" {
+		return fmt.Errorf("payment admin key is not set")
+	}
+
+	if p.AutoApprove < 0 {
+		return fmt.Errorf("payment auto approve %d is invalid", p.AutoApprove)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWithdrawalStatusCanMoveTo(t *testing.T) {
+	tests := []struct {
+		from WithdrawalStatus
+		to   WithdrawalStatus
+		want bool
+	}{
+		{from: WithdrawalRequested, to: WithdrawalApproved, want: true},
+		{from: WithdrawalRequested, to: WithdrawalFailed, want: true},
+		{from: WithdrawalRequested, to: WithdrawalSent},
+		{from: WithdrawalApproved, to: WithdrawalSending, want: true},
+		{from: WithdrawalApproved, to: WithdrawalFailed, want: true},
+		{from: WithdrawalApproved, to: WithdrawalSent},
+		{from: WithdrawalApproved, to: WithdrawalRequested},
+		{from: WithdrawalSending, to: WithdrawalSent, want: true},
+		{from: WithdrawalSending, to: WithdrawalFailed, want: true},
+		{from: WithdrawalSending, to: WithdrawalApproved},
+		{from: WithdrawalSent, to: WithdrawalFailed},
+		{from: WithdrawalFailed, to: WithdrawalApproved},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.from.CanMoveTo(tt.to))
+		})
+	}
+}
+
+func TestPaymentRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   PaymentRules
+		wantErr bool
+	}{
+		{name: "valid", rules: PaymentRules{Provider: "fake", WebhookSecret: "secret", AdminKey: "admin",
+			AutoApprove: 10000}},
+		{name: "reviews everything", rules: PaymentRules{Provider: "fake", WebhookSecret: "secret", AdminKey: "admin"}},
+		{name: "no provider", rules: PaymentRules{WebhookSecret: "secret", AdminKey: "admin"}, wantErr: true},
+		{name: "no secret", rules: PaymentRules{Provider: "fake", AdminKey: "admin"}, wantErr: true},
+		{name: "no admin key", rules: PaymentRules{Provider: "fake", WebhookSecret: "secret"}, wantErr: true},
+		{name: "negative auto approve", rules: PaymentRules{Provider: "fake", WebhookSecret: "secret",
+			AdminKey: "admin", AutoApprove: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+type PlinkoRisk string
+
+const (
+	PlinkoLow    PlinkoRisk = "low"
+	PlinkoMedium PlinkoRisk = "medium"
+	PlinkoHigh   PlinkoRisk = "high"
+)
+
+var ErrInvalidPlinko = errors.New("plinko rows or risk are not available")
+
+// PlinkoRules holds the payout tables of the plinko game by risk and row
+// count. A table of n rows has n+1 multipliers, one per slot from left to
+// right.
+type PlinkoRules struct {
+	MinRows int                              `yaml:"min_rows" env-default:"8"`
+	MaxRows int                              `yaml:"max_rows" env-default:"16"`
+	Payouts map[PlinkoRisk]map[int][]float64 `yaml:"payouts"`
+}
+
+func (p PlinkoRules) Validate() error {
+	if p.MinRows < 1 || p.MinRows > p.MaxRows {
+		return fmt.Errorf("plinko rows %d-%d are invalid", p.MinRows, p.MaxRows)
+	}
+
+	for risk, tables := range p.Payouts {
+		switch risk {
+		case PlinkoLow, PlinkoMedium, PlinkoHigh:
+		default:
+			return fmt.Errorf("plinko risk %q is invalid", risk)
+		}
+
+		for rows, table := range tables {
+			if rows < p.MinRows || rows > p.MaxRows {
+				return fmt.Errorf("plinko %s table has %d rows out of range", risk, rows)
+			}
+
+			if len(table) != rows+1 {
+				return fmt.Errorf("plinko %s table of %d rows needs %d slots, has %d", risk, rows, rows+1, len(table))
+			}
+
+			for _, multiplier := range table {
+				if multiplier < 0 {
+					return fmt.Errorf("plinko %s table of %d rows has a negative multiplier", risk, rows)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Table returns the payout table of a drop, or ErrInvalidPlinko when it is
+// not configured.
+func (p PlinkoRules) Table(rows int, risk PlinkoRisk) ([]float64, error) {
+	table, ok := p.Payouts[risk][rows]
+	if !ok || rows < p.MinRows || rows > p.MaxRows {
+		return nil, fmt.Errorf("%w: %d rows, %s risk", ErrInvalidPlinko, rows, risk)
+	}
+
+	return table, nil
+}
@@ -1,29 +1,198 @@
 package config
 
-type RouletteConfig struct {
-	Colors            map[Color]RouletteColorConfig
-	MaxWinProbability int
-}
-
-type RouletteColorConfig struct {
-	Probability float64
-	Multiplier  int
-}
-
-var RouletteWheelConfig = RouletteConfig{
-	Colors: map[Color]RouletteColorConfig{
-		Red: {
-			Probability: 46.6,
-			Multiplier:  2,
-		},
-		Black: {
-			Probability: 46.6,
-			Multiplier:  2,
-		},
-		Green: {
-			Probability: 6.8,
-			Multiplier:  14,
-		},
-	},
-	MaxWinProbability: 100,
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Pocket is a single slot of a roulette wheel. Pockets are listed in wheel
+// order, the provably fair draw picks an index into that list.
+type Pocket struct {
+	Number int   `yaml:"number"`
+	Color  Color `yaml:"color"`
+}
+
+type Payouts struct {
+	Color  map[Color]int `yaml:"color"`
+	Number int           `yaml:"number"`
+	Parity int           `yaml:"parity"`
+}
+
+// Range pays when the landed number is within [From, To].
+type Range struct {
+	Name   string `yaml:"name"`
+	From   int    `yaml:"from"`
+	To     int    `yaml:"to"`
+	Payout int    `yaml:"payout"`
+}
+
+// Combo pays when the landed number is one of Numbers, e.g. the "bait"
+// pockets next to green.
+type Combo struct {
+	Name    string `yaml:"name"`
+	Numbers []int  `yaml:"numbers"`
+	Payout  int    `yaml:"payout"`
+}
+
+type Wheel struct {
+	Pockets []Pocket `yaml:"pockets"`
+	Payouts Payouts  `yaml:"payouts"`
+	Ranges  []Range  `yaml:"ranges"`
+	Combos  []Combo  `yaml:"combos"`
+}
+
+var ErrInvalidBet = errors.New("invalid bet")
+
+func (w Wheel) Validate() error {
+	if len(w.Pockets) == 0 {
+		return errors.New("wheel has no pockets")
+	}
+
+	numbers := make(map[int]bool, len(w.Pockets))
+
+	for _, pocket := range w.Pockets {
+		if numbers[pocket.Number] {
+			return fmt.Errorf("pocket number %d is used twice", pocket.Number)
+		}
+
+		numbers[pocket.Number] = true
+
+		if w.Payouts.Color[pocket.Color] <= 0 {
+			return fmt.Errorf("color %s has no payout", pocket.Color)
+		}
+	}
+
+	names := make(map[string]bool)
+
+	for _, r := range w.Ranges {
+		if names[r.Name] || r.From > r.To || r.Payout <= 0 {
+			return fmt.Errorf("range %q is invalid", r.Name)
+		}
+
+		names[r.Name] = true
+	}
+
+	names = make(map[string]bool)
+
+	for _, c := range w.Combos {
+		if names[c.Name] || len(c.Numbers) == 0 || c.Payout <= 0 {
+			return fmt.Errorf("combo %q is invalid", c.Name)
+		}
+
+		for _, number := range c.Numbers {
+			if !numbers[number] {
+				return fmt.Errorf("combo %q uses unknown number %d", c.Name, number)
+			}
+		}
+
+		names[c.Name] = true
+	}
+
+	return nil
+}
+
+func (w Wheel) HasColor(color Color) bool {
+	for _, pocket := range w.Pockets {
+		if pocket.Color == color {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w Wheel) ColorMultiplier(color Color) int {
+	return w.Payouts.Color[color]
+}
+
+// ValidateBet checks that the wheel offers the bet and that it has a payout.
+func (w Wheel) ValidateBet(betType BetType, value string) error {
+	var ok bool
+
+	switch betType {
+	case BetColor:
+		ok = w.HasColor(Color(value))
+	case BetNumber:
+		number, err := strconv.Atoi(value)
+		ok = err == nil && w.Payouts.Number > 0 && w.hasNumber(number)
+	case BetParity:
+		ok = w.Payouts.Parity > 0 && (value == Odd || value == Even)
+	case BetRange:
+		_, ok = w.rangeByName(value)
+	case BetCombo:
+		_, ok = w.comboByName(value)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s %q", ErrInvalidBet, betType, value)
+	}
+
+	return nil
+}
+
+// Multiplier returns what a bet pays on the landed pocket, 0 when it loses.
+func (w Wheel) Multiplier(betType BetType, value string, pocket Pocket) int {
+	switch betType {
+	case BetColor:
+		if Color(value) == pocket.Color {
+			return w.Payouts.Color[pocket.Color]
+		}
+	case BetNumber:
+		if number, err := strconv.Atoi(value); err == nil && number == pocket.Number {
+			return w.Payouts.Number
+		}
+	case BetParity:
+		if pocket.Number == 0 {
+			return 0
+		}
+
+		if (pocket.Number%2 == 1 && value == Odd) || (pocket.Number%2 == 0 && value == Even) {
+			return w.Payouts.Parity
+		}
+	case BetRange:
+		if r, ok := w.rangeByName(value); ok && pocket.Number >= r.From && pocket.Number <= r.To {
+			return r.Payout
+		}
+	case BetCombo:
+		if c, ok := w.comboByName(value); ok {
+			for _, number := range c.Numbers {
+				if number == pocket.Number {
+					return c.Payout
+				}
+			}
+		}
+	}
+
+	return 0
+}
+
+func (w Wheel) hasNumber(number int) bool {
+	for _, pocket := range w.Pockets {
+		if pocket.Number == number {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w Wheel) rangeByName(name string) (Range, bool) {
+	for _, r := range w.Ranges {
+		if r.Name == name {
+			return r, true
+		}
+	}
+
+	return Range{}, false
+}
+
+func (w Wheel) comboByName(name string) (Combo, bool) {
+	for _, c := range w.Combos {
+		if c.Name == name {
+			return c, true
+		}
+	}
+
+	return Combo{}, false
 }
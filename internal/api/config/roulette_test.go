@@ -0,0 +1,95 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testWheel = Wheel{
+	Pockets: []Pocket{
+		{Number: 1, Color: Red},
+		{Number: 14, Color: Black},
+		{Number: 4, Color: Red},
+		{Number: 0, Color: Green},
+		{Number: 11, Color: Black},
+	},
+	Payouts: Payouts{
+		Color:  map[Color]int{Red: 2, Black: 2, Green: 14},
+		Number: 14,
+		Parity: 2,
+	},
+	Ranges: []Range{{Name: "1-7", From: 1, To: 7, Payout: 2}},
+	Combos: []Combo{{Name: "bait", Numbers: []int{4, 11}, Payout: 7}},
+}
+
+func TestWheelMultiplier(t *testing.T) {
+	tests := []struct {
+		name    string
+		betType BetType
+		value   string
+		pocket  Pocket
+		want    int
+	}{
+		{name: "color wins", betType: BetColor, value: "green", pocket: Pocket{Number: 0, Color: Green}, want: 14},
+		{name: "color loses", betType: BetColor, value: "red", pocket: Pocket{Number: 11, Color: Black}},
+		{name: "number wins", betType: BetNumber, value: "4", pocket: Pocket{Number: 4, Color: Red}, want: 14},
+		{name: "number loses", betType: BetNumber, value: "4", pocket: Pocket{Number: 1, Color: Red}},
+		{name: "odd wins", betType: BetParity, value: Odd, pocket: Pocket{Number: 11, Color: Black}, want: 2},
+		{name: "even wins", betType: BetParity, value: Even, pocket: Pocket{Number: 14, Color: Black}, want: 2},
+		{name: "zero is neither odd nor even", betType: BetParity, value: Even, pocket: Pocket{Number: 0, Color: Green}},
+		{name: "range wins", betType: BetRange, value: "1-7", pocket: Pocket{Number: 4, Color: Red}, want: 2},
+		{name: "range loses", betType: BetRange, value: "1-7", pocket: Pocket{Number: 14, Color: Black}},
+		{name: "bait wins", betType: BetCombo, value: "bait", pocket: Pocket{Number: 11, Color: Black}, want: 7},
+		{name: "bait loses", betType: BetCombo, value: "bait", pocket: Pocket{Number: 0, Color: Green}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, testWheel.Multiplier(tt.betType, tt.value, tt.pocket))
+		})
+	}
+}
+
+func TestWheelValidateBet(t *testing.T) {
+	assert.NoError(t, testWheel.Validate())
+
+	assert.NoError(t, testWheel.ValidateBet(BetColor, "red"))
+	assert.NoError(t, testWheel.ValidateBet(BetNumber, "0"))
+	assert.NoError(t, testWheel.ValidateBet(BetCombo, "bait"))
+
+	assert.ErrorIs(t, testWheel.ValidateBet(BetColor, "blue"), ErrInvalidBet)
+	assert.ErrorIs(t, testWheel.ValidateBet(BetNumber, "36"), ErrInvalidBet)
+	assert.ErrorIs(t, testWheel.ValidateBet(BetParity, "zero"), ErrInvalidBet)
+	assert.ErrorIs(t, testWheel.ValidateBet(BetRange, "19-36"), ErrInvalidBet)
+}
+
+func TestLimitsCheckStake(t *testing.T) {
+	limits := Limits{
+		Stake:    StakeLimit{Min: 10, Max: 1000},
+		BetTypes: map[BetType]StakeLimit{BetNumber: {Max: 100}},
+	}
+
+	tests := []struct {
+		name    string
+		betType BetType
+		amount  int
+		wantErr bool
+	}{
+		{name: "within game limits", betType: BetColor, amount: 1000},
+		{name: "below game minimum", betType: BetColor, amount: 5, wantErr: true},
+		{name: "above game maximum", betType: BetColor, amount: 1001, wantErr: true},
+		{name: "within bet type limits", betType: BetNumber, amount: 100},
+		{name: "above bet type maximum", betType: BetNumber, amount: 101, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.CheckStake(tt.betType, tt.amount)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrStakeOutOfRange)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
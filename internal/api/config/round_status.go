@@ -0,0 +1,28 @@
+package config
+
+type RoundStatus string
+
+// A round accepts bets while it is open and until its bet deadline, is then
+// closed, rolled and finally settled against its own bets. A round that was
+// never rolled may be refunded by the startup recovery instead.
+const (
+	RoundOpen     RoundStatus = "open"
+	RoundClosed   RoundStatus = "closed"
+	RoundRolled   RoundStatus = "rolled"
+	RoundSettled  RoundStatus = "settled"
+	RoundRefunded RoundStatus = "refunded"
+)
+
+// RecoveryPolicy decides what the startup recovery does with rounds that
+// were never rolled. Rolled rounds are always settled, their result is final.
+type RecoveryPolicy string
+
+const (
+	RecoverySettle RecoveryPolicy = "settle"
+	RecoveryRefund RecoveryPolicy = "refund"
+)
+
+// Rolled reports whether the winning pocket of the round is known and public.
+func (s RoundStatus) Rolled() bool {
+	return s == RoundRolled || s == RoundSettled
+}
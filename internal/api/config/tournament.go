@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TournamentMetric is what players of a tournament are ranked by: the total
+// they staked, or the best multiplier of a single bet.
+type TournamentMetric string
+
+const (
+	TournamentWagered    TournamentMetric = "wagered"
+	TournamentMultiplier TournamentMetric = "multiplier"
+)
+
+// TournamentStatus follows a tournament from its creation until its prizes
+// are paid.
+type TournamentStatus string
+
+const (
+	TournamentScheduled TournamentStatus = "scheduled"
+	TournamentRunning   TournamentStatus = "running"
+	TournamentFinished  TournamentStatus = "finished"
+)
+
+var ErrInvalidTournament = errors.New("invalid tournament")
+
+// MultiplierGames are the games whose bets record both a stake and a
+// payout, the only ones a multiplier tournament can rank.
+var MultiplierGames = []Game{Dice, Limbo, Mines, Hilo, Plinko}
+
+// TournamentRules bound the tournaments that can be scheduled. The live
+// leaderboard shows the first LeaderboardSize players and is pushed every
+// LeaderboardInterval while a tournament runs.
+type TournamentRules struct {
+	LeaderboardInterval time.Duration `yaml:"leaderboard_interval" env-default:"30s"`
+	LeaderboardSize     int           `yaml:"leaderboard_size" env-default:"10"`
+	MaxDuration         time.Duration `yaml:"max_duration" env-default:"720h"`
+	MaxPrizes           int           `yaml:"max_prizes" env-default:"100"`
+}
+
+func (t TournamentRules) Validate() error {
+	if t.LeaderboardInterval <= 0 {
+		return fmt.Errorf("tournament leaderboard interval %s is invalid", t.LeaderboardInterval)
+	}
+
+	if t.LeaderboardSize < 1 {
+		return fmt.Errorf("tournament leaderboard size %d is invalid", t.LeaderboardSize)
+	}
+
+	if t.MaxDuration <= 0 {
+		return fmt.Errorf("tournament max duration %s is invalid", t.MaxDuration)
+	}
+
+	if t.MaxPrizes < 1 {
+		return fmt.Errorf("tournament max prizes %d is invalid", t.MaxPrizes)
+	}
+
+	return nil
+}
+
+// CheckTournament validates a tournament before it is scheduled. Prizes are
+// in cents, from the first place down, and may not grow with the rank. No
+// games means every game for the wagered metric.
+func (t TournamentRules) CheckTournament(
+	metric TournamentMetric,
+	games []Game,
+	prizes []int,
+	startsAt time.Time,
+	endsAt time.Time) error {
+	switch metric {
+	case TournamentWagered:
+	case TournamentMultiplier:
+		if len(games) == 0 {
+			return fmt.Errorf("%w: multiplier tournaments need games", ErrInvalidTournament)
+		}
+
+		for _, game := range games {
+			if !isMultiplierGame(game) {
+				return fmt.Errorf("%w: %s bets have no multiplier", ErrInvalidTournament, game)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: metric %q", ErrInvalidTournament, metric)
+	}
+
+	if len(prizes) < 1 || len(prizes) > t.MaxPrizes {
+		return fmt.Errorf("%w: %d prizes", ErrInvalidTournament, len(prizes))
+	}
+
+	for i, prize := range prizes {
+		if prize < 1 || (i > 0 && prize > prizes[i-1]) {
+			return fmt.Errorf("%w: prize %d of place %d", ErrInvalidTournament, prize, i+1)
+		}
+	}
+
+	if !endsAt.After(startsAt) || !endsAt.After(time.Now()) || endsAt.Sub(startsAt) > t.MaxDuration {
+		return fmt.Errorf("%w: runs %s to %s", ErrInvalidTournament, startsAt, endsAt)
+	}
+
+	return nil
+}
+
+func isMultiplierGame(game Game) bool {
+	for _, g := range MultiplierGames {
+		if g == game {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"go-outpost/internal/lib/money"
+)
+
+var ErrCurrencyNotAccepted = errors.New("currency is not accepted")
+
+// WalletRules lists the currencies players can hold a wallet in and their
+// exchange rates, the value of one unit in the default currency as an exact
+// decimal. SwapFee is a percentage kept on every swap between wallets.
+type WalletRules struct {
+	Currencies []money.Currency          `yaml:"currencies"`
+	Rates      map[money.Currency]string `yaml:"rates"`
+	SwapFee    float64                   `yaml:"swap_fee" env-default:"0"`
+}
+
+func (w WalletRules) Validate() error {
+	if !w.Accepts(money.Default) {
+		return fmt.Errorf("wallet currencies %v miss %s", w.Currencies, money.Default)
+	}
+
+	rates, err := w.ExchangeRates()
+	if err != nil {
+		return err
+	}
+
+	for _, currency := range w.Currencies {
+		if _, err = rates.Rate(currency); err != nil {
+			return fmt.Errorf("wallet currency %s: %w", currency, err)
+		}
+	}
+
+	if w.SwapFee < 0 || w.SwapFee >= 100 {
+		return fmt.Errorf("wallet swap fee %v is invalid", w.SwapFee)
+	}
+
+	return nil
+}
+
+// Accepts reports whether players can hold a wallet in the currency.
+func (w WalletRules) Accepts(currency money.Currency) bool {
+	for _, c := range w.Currencies {
+		if c == currency {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w WalletRules) ExchangeRates() (money.Rates, error) {
+	return money.NewRates(w.Rates)
+}
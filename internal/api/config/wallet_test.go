@@ -0,0 +1,73 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/lib/money"
+	"testing"
+)
+
+func TestWalletRulesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   WalletRules
+		wantErr bool
+	}{
+		{name: "valid", rules: WalletRules{Currencies: []money.Currency{money.USD, money.COIN},
+			Rates: map[money.Currency]string{money.COIN: "0.01"}, SwapFee: 1}},
+		{name: "default only", rules: WalletRules{Currencies: []money.Currency{money.USD}}},
+		{name: "no default", rules: WalletRules{Currencies: []money.Currency{money.COIN},
+			Rates: map[money.Currency]string{money.COIN: "0.01"}}, wantErr: true},
+		{name: "missing rate", rules: WalletRules{Currencies: []money.Currency{money.USD, money.BTC}}, wantErr: true},
+		{name: "unknown currency", rules: WalletRules{Currencies: []money.Currency{money.USD, "EUR"},
+			Rates: map[money.Currency]string{"EUR": "1.1"}}, wantErr: true},
+		{name: "fee too high", rules: WalletRules{Currencies: []money.Currency{money.USD}, SwapFee: 100}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLimitsIn(t *testing.T) {
+	limits := Limits{
+		Stake:             StakeLimit{Min: 1, Max: 1000},
+		BetTypes:          map[BetType]StakeLimit{BetNumber: {Max: 100}},
+		MaxPayoutPerRound: 5000,
+		Exposure:          Exposure{Cap: 10000, Mode: ExposureScale},
+		Currencies: map[money.Currency]CurrencyLimits{
+			money.COIN: {
+				Stake:             StakeLimit{Min: 10, Max: 100000},
+				BetTypes:          map[BetType]StakeLimit{BetNumber: {Max: 10000}},
+				MaxPayoutPerRound: 500000,
+				Exposure:          Exposure{Cap: 1000000},
+			},
+		},
+	}
+
+	require.NoError(t, limits.Validate())
+
+	usd, err := limits.In(money.USD)
+	require.NoError(t, err)
+	assert.Equal(t, limits.Stake, usd.Stake)
+
+	coins, err := limits.In(money.COIN)
+	require.NoError(t, err)
+	assert.Equal(t, 500000, coins.MaxPayoutPerRound)
+	assert.Equal(t, Exposure{Cap: 1000000, Mode: ExposureScale}, coins.Exposure)
+	assert.NoError(t, coins.CheckStake(BetNumber, 5000))
+	assert.ErrorIs(t, coins.CheckStake(BetNumber, 5), ErrStakeOutOfRange)
+	assert.ErrorIs(t, coins.CheckStake(BetNumber, 20000), ErrStakeOutOfRange)
+
+	_, err = limits.In(money.BTC)
+	assert.ErrorIs(t, err, ErrCurrencyNotAccepted)
+
+	limits.Currencies[money.BTC] = CurrencyLimits{BetTypes: map[BetType]StakeLimit{BetNumber: {Max: 100}}}
+	assert.Error(t, limits.Validate(), "a currency without its own exposure cap would bypass it")
+}
@@ -0,0 +1,15 @@
+package lobby
+
+import "go-outpost/internal/lib/logger/sl"
+
+// LobbyExpireJob runs when the lobby TTL is over.
+type LobbyExpireJob struct {
+	Coinflip *Coinflip
+	LobbyID  int64
+}
+
+func (job *LobbyExpireJob) Execute() {
+	if err := job.Coinflip.Expire(job.LobbyID); err != nil {
+		job.Coinflip.log.Error("failed to expire coinflip lobby", sl.Err(err))
+	}
+}
@@ -0,0 +1,671 @@
+package lobby
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/escrow"
+	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/handlers/job"
+	"go-outpost/internal/api/http-server/handlers/provably_fair"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"time"
+)
+
+type CreateRequest struct {
+	UserUUID string          `json:"user_uuid" validate:"required"`
+	Amount   money.Money     `json:"amount" validate:"required,min=1"`
+	Side     config.CoinSide `json:"side" validate:"required,oneof=heads tails"`
+}
+
+type JoinRequest struct {
+	UserUUID string `json:"user_uuid" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	Lobby Lobby `json:"lobby"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Lobbies []Lobby `json:"lobbies"`
+}
+
+// Lobby is the public view of a lobby, amounts are decimal strings. The
+// server seed is only shown once the coin is flipped, the client seeds of
+// both players as soon as they are in.
+type Lobby struct {
+	UUID               string             `json:"uuid"`
+	CreatorSide        config.CoinSide    `json:"creator_side"`
+	Stake              money.Money        `json:"stake"`
+	Status             config.LobbyStatus `json:"status"`
+	Result             config.CoinSide    `json:"result,omitempty"`
+	Payout             *money.Money       `json:"payout,omitempty"`
+	ServerSeedHash     string             `json:"server_seed_hash"`
+	ServerSeed         string             `json:"server_seed,omitempty"`
+	CreatorClientSeed  string             `json:"creator_client_seed"`
+	OpponentClientSeed string             `json:"opponent_client_seed,omitempty"`
+	ExpiresAt          time.Time          `json:"expires_at"`
+	CreatedAt          time.Time          `json:"created_at"`
+}
+
+var (
+	ErrNoBalance           = errors.New("user has no balance")
+	ErrInsufficientBalance = errors.New("user has insufficient balance")
+	ErrLobbyClosed         = errors.New("lobby is no longer open")
+	ErrOwnLobby            = errors.New("user cannot join own lobby")
+)
+
+type Coinflip struct {
+	log          *slog.Logger
+	validator    *validator.Validate
+	coinflipRep  repository.CoinflipRepository
+	userRep      repository.UserRepository
+	escrow       *escrow.Escrow
+	transaction  repository.Transaction
+	provablyFair *provably_fair.ProvablyFair
+	event        *event.PusherEvent
+	rules        config.CoinflipRules
+	limits       config.Limits
+}
+
+func NewCoinflip(
+	log *slog.Logger,
+	coinflipRep repository.CoinflipRepository,
+	userRep repository.UserRepository,
+	escrow *escrow.Escrow,
+	transaction repository.Transaction,
+	provablyFair *provably_fair.ProvablyFair,
+	eventClient *event.PusherEvent,
+	rules config.CoinflipRules,
+	limits config.Limits) *Coinflip {
+	return &Coinflip{
+		log:          log,
+		validator:    money.WithValidation(validator.New()),
+		coinflipRep:  coinflipRep,
+		userRep:      userRep,
+		escrow:       escrow,
+		transaction:  transaction,
+		provablyFair: provablyFair,
+		event:        eventClient,
+		rules:        rules,
+		limits:       limits,
+	}
+}
+
+// Create handles POST /coinflip/lobbies, the stake of the creator is held
+// until the lobby is resolved or expires.
+func (c *Coinflip) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.coinflip.lobby.Create"
+
+		var (
+			err   error
+			req   CreateRequest
+			log   *slog.Logger
+			user  *model.User
+			lobby *model.CoinflipLobby
+		)
+
+		log = c.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = c.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		user, err = c.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		lobby, err = c.open(user, int(req.Amount.Minor()), req.Side)
+		if err != nil {
+			log.Error("failed to create lobby", sl.Err(err))
+
+			render.JSON(w, r, lobbyError(err))
+
+			return
+		}
+
+		log.Info("coinflip lobby created", slog.Int64("lobby_id", lobby.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Lobby: lobbyView(lobby)})
+	}
+}
+
+// List handles GET /coinflip/lobbies and returns the lobbies waiting for an opponent.
+func (c *Coinflip) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.coinflip.lobby.List"
+
+		log := c.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		lobbies, err := c.coinflipRep.GetOpenLobbies()
+		if err != nil {
+			log.Error("failed to get lobbies", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to get lobbies", http.StatusInternalServerError))
+
+			return
+		}
+
+		views := make([]Lobby, 0, len(lobbies))
+		for i := range lobbies {
+			views = append(views, lobbyView(&lobbies[i]))
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Lobbies: views})
+	}
+}
+
+// Join handles POST /coinflip/lobbies/{uuid}/join. The opponent stake is held
+// like the creator one and the coin is flipped right away.
+func (c *Coinflip) Join() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.coinflip.lobby.Join"
+
+		var (
+			err   error
+			req   JoinRequest
+			log   *slog.Logger
+			user  *model.User
+			lobby *model.CoinflipLobby
+		)
+
+		log = c.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = c.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		user, err = c.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		lobby, err = c.coinflipRep.FindLobbyByUUID(chi.URLParam(r, "uuid"))
+		if err != nil {
+			log.Error("failed to find lobby", sl.Err(err))
+
+			render.JSON(w, r, lobbyError(err))
+
+			return
+		}
+
+		if err = c.join(lobby, user); err != nil {
+			log.Error("failed to join lobby", sl.Err(err))
+
+			render.JSON(w, r, lobbyError(err))
+
+			return
+		}
+
+		if err = c.resolve(lobby); err != nil {
+			log.Error("failed to resolve lobby", sl.Err(err), slog.Int64("lobby_id", lobby.ID))
+
+			render.JSON(w, r, resp.Error("failed to resolve lobby", http.StatusInternalServerError))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Lobby: lobbyView(lobby)})
+	}
+}
+
+func (c *Coinflip) open(user *model.User, amount int, side config.CoinSide) (*model.CoinflipLobby, error) {
+	const op = "handlers.coinflip.lobby.open"
+
+	var (
+		err        error
+		serverSeed string
+		seed       *model.UserSeed
+		tx         *sql.Tx
+		stakes     *escrow.Tx
+	)
+
+	if err = c.checkStake(user.ID, amount, side); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	serverSeed, err = provably_fair.NewServerSeed()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	seed, err = c.provablyFair.ActiveSeed(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+
+	lobby := &model.CoinflipLobby{
+		UUID:              uuid.New().String(),
+		CreatorID:         user.ID,
+		CreatorSide:       side,
+		Stake:             amount,
+		Status:            config.LobbyOpen,
+		ServerSeed:        serverSeed,
+		ServerSeedHash:    provably_fair.HashServerSeed(serverSeed),
+		CreatorClientSeed: seed.ClientSeed,
+		ExpiresAt:         now.Add(c.rules.LobbyTTL),
+		CreatedAt:         now,
+	}
+
+	tx, err = c.transaction.StartTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stakes = c.escrow.WithTx(tx)
+	defer stakes.Rollback()
+
+	lobby.ID, err = c.coinflipRep.WithTx(tx).SaveLobby(*lobby)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = stakes.Hold(user.ID, amount, config.Coinflip, lobby.ID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.Dispatch(&LobbyExpireJob{Coinflip: c, LobbyID: lobby.ID}, c.rules.LobbyTTL)
+
+	if err = c.event.Trigger(events.CoinflipLobbyCreated{
+		UUID:           lobby.UUID,
+		UserUUID:       user.UUID,
+		Side:           lobby.CreatorSide,
+		Stake:          money.Cents(lobby.Stake),
+		ExpiresAt:      lobby.ExpiresAt,
+		ServerSeedHash: lobby.ServerSeedHash,
+		ClientSeed:     lobby.CreatorClientSeed,
+	}); err != nil {
+		c.log.Error("failed to send lobby created event", sl.Err(err))
+	}
+
+	return lobby, nil
+}
+
+// join takes the free seat of a lobby with the client seed of the opponent
+// and holds the opponent stake on one transaction.
+func (c *Coinflip) join(lobby *model.CoinflipLobby, user *model.User) error {
+	const op = "handlers.coinflip.lobby.join"
+
+	var (
+		err    error
+		seed   *model.UserSeed
+		tx     *sql.Tx
+		stakes *escrow.Tx
+		joined bool
+	)
+
+	if lobby.CreatorID == user.ID {
+		return fmt.Errorf("%s: %w", op, ErrOwnLobby)
+	}
+
+	if lobby.Status != config.LobbyOpen || lobby.OpponentID != nil || !time.Now().Before(lobby.ExpiresAt) {
+		return fmt.Errorf("%s: %w", op, ErrLobbyClosed)
+	}
+
+	if err = c.checkStake(user.ID, lobby.Stake, lobby.CreatorSide.Opposite()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	seed, err = c.provablyFair.ActiveSeed(user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err = c.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stakes = c.escrow.WithTx(tx)
+	defer stakes.Rollback()
+
+	joined, err = c.coinflipRep.WithTx(tx).JoinLobby(lobby.ID, user.ID, seed.ClientSeed)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !joined {
+		return fmt.Errorf("%s: %w", op, ErrLobbyClosed)
+	}
+
+	if _, err = stakes.Hold(user.ID, lobby.Stake, config.Coinflip, lobby.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lobby.OpponentID = &user.ID
+	lobby.OpponentClientSeed = seed.ClientSeed
+
+	if err = c.event.Trigger(events.CoinflipLobbyJoined{
+		UUID:       lobby.UUID,
+		UserUUID:   user.UUID,
+		ClientSeed: lobby.OpponentClientSeed,
+	}); err != nil {
+		c.log.Error("failed to send lobby joined event", sl.Err(err))
+	}
+
+	return nil
+}
+
+// resolve flips the coin of a joined lobby and pays the pot minus the rake to
+// the winner. The flip is drawn from the server seed committed to when the
+// lobby opened and the client seeds of both players, so neither the server
+// nor one player alone picks the side. The lobby is marked resolved, the stakes
+// released, the pot paid and the draw stored on one transaction, so a
+// resolved lobby is always paid.
+func (c *Coinflip) resolve(lobby *model.CoinflipLobby) error {
+	const op = "handlers.coinflip.lobby.resolve"
+
+	var (
+		err      error
+		tx       *sql.Tx
+		stakes   *escrow.Tx
+		draws    *provably_fair.Draws
+		resolved bool
+		pot      int
+		drawID   int64
+		winner   *model.User
+	)
+
+	data := provably_fair.Draw(lobby.ServerSeed, clientSeed(lobby), 0, len(config.CoinSides))
+
+	now := time.Now()
+
+	lobby.Result = config.CoinSides[int(data.Result)]
+	lobby.WinnerID = lobby.OpponentID
+	if lobby.Result == lobby.CreatorSide {
+		lobby.WinnerID = &lobby.CreatorID
+	}
+	lobby.Payout, lobby.Rake = c.rules.Split(lobby.Stake * 2)
+	lobby.ResolvedAt = &now
+
+	tx, err = c.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stakes = c.escrow.WithTx(tx)
+	defer stakes.Rollback()
+
+	resolved, err = c.coinflipRep.WithTx(tx).ResolveLobby(*lobby)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !resolved {
+		return fmt.Errorf("%s: %w", op, ErrLobbyClosed)
+	}
+
+	pot, err = stakes.Release(config.Coinflip, lobby.ID, *lobby.WinnerID, lobby.Payout)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if pot != lobby.Stake*2 {
+		c.log.Warn("released pot does not match the stakes", slog.Int64("lobby_id", lobby.ID), slog.Int("pot", pot))
+	}
+
+	if err = stakes.Pay(*lobby.WinnerID, lobby.Payout, config.Coinflip); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	draws = c.provablyFair.WithTx(tx)
+
+	drawID, err = draws.StoreGameDraw(lobby.ID, config.Coinflip)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = draws.StoreProvablyFair(data, drawID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lobby.Status = config.LobbyResolved
+
+	winner, err = c.userRep.GetUserByID(*lobby.WinnerID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = c.event.Trigger(events.CoinflipLobbyResolved{
+		UUID:       lobby.UUID,
+		Result:     lobby.Result,
+		WinnerUUID: winner.UUID,
+		Payout:     money.Cents(lobby.Payout),
+		ServerSeed: lobby.ServerSeed,
+	}); err != nil {
+		c.log.Error("failed to send lobby resolved event", sl.Err(err))
+	}
+
+	return nil
+}
+
+// Expire closes a lobby nobody joined and refunds the creator stake on one
+// transaction. A lobby that was already closed is left alone, resolving a
+// lobby pays it in the same commit so a closed lobby never holds stakes.
+func (c *Coinflip) Expire(lobbyID int64) error {
+	const op = "handlers.coinflip.lobby.Expire"
+
+	var (
+		err     error
+		lobby   *model.CoinflipLobby
+		tx      *sql.Tx
+		stakes  *escrow.Tx
+		expired bool
+	)
+
+	lobby, err = c.coinflipRep.FindLobbyByID(lobbyID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lobby.Status != config.LobbyOpen {
+		return nil
+	}
+
+	// The seat of a lobby is taken together with the stake, so a joined lobby
+	// holds both stakes and only its flip was interrupted.
+	if lobby.OpponentID != nil {
+		if err = c.resolve(lobby); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+
+	tx, err = c.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stakes = c.escrow.WithTx(tx)
+	defer stakes.Rollback()
+
+	expired, err = c.coinflipRep.WithTx(tx).ExpireLobby(lobby.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !expired {
+		return nil
+	}
+
+	if _, err = stakes.Refund(config.Coinflip, lobby.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.log.Info("coinflip lobby expired", slog.Int64("lobby_id", lobby.ID))
+
+	if err = c.event.Trigger(events.CoinflipLobbyExpired{UUID: lobby.UUID}); err != nil {
+		c.log.Error("failed to send lobby expired event", sl.Err(err))
+	}
+
+	return nil
+}
+
+// ExpireStale expires the lobbies whose expiry passed while the server was
+// down, their jobs were lost with it. It returns the number of lobbies expired.
+func (c *Coinflip) ExpireStale() (int, error) {
+	const op = "handlers.coinflip.lobby.ExpireStale"
+
+	lobbies, err := c.coinflipRep.GetStaleLobbies()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, lobby := range lobbies {
+		if err = c.Expire(lobby.ID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return len(lobbies), nil
+}
+
+func (c *Coinflip) checkStake(userID int64, amount int, side config.CoinSide) error {
+	if err := c.limits.CheckStake(config.BetType(side), amount); err != nil {
+		return err
+	}
+
+	userBalance, err := c.userRep.FindUserBalanceByID(userID, money.Default)
+	if err != nil {
+		return err
+	}
+
+	if userBalance == nil || userBalance.Balance.IsNegative() {
+		return ErrNoBalance
+	}
+
+	if !userBalance.Balance.Covers(money.Cents(amount)) {
+		return ErrInsufficientBalance
+	}
+
+	return nil
+}
+
+// clientSeed joins the client seeds of the creator and the opponent into the
+// client seed the flip is drawn with.
+func clientSeed(lobby *model.CoinflipLobby) string {
+	return lobby.CreatorClientSeed + ":" + lobby.OpponentClientSeed
+}
+
+func lobbyError(err error) resp.Response {
+	switch {
+	case errors.Is(err, repository.ErrLobbyNotFound):
+		return resp.ErrorCode("failed to find lobby", http.StatusNotFound, "lobby_not_found")
+	case errors.Is(err, ErrLobbyClosed):
+		return resp.ErrorCode(ErrLobbyClosed.Error(), http.StatusConflict, "lobby_closed")
+	case errors.Is(err, ErrOwnLobby):
+		return resp.ErrorCode(ErrOwnLobby.Error(), http.StatusBadRequest, "own_lobby")
+	case errors.Is(err, config.ErrStakeOutOfRange):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range")
+	case errors.Is(err, ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, ErrInsufficientBalance), errors.Is(err, repository.ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to process lobby", http.StatusInternalServerError)
+}
+
+func lobbyView(lobby *model.CoinflipLobby) Lobby {
+	view := Lobby{
+		UUID:               lobby.UUID,
+		CreatorSide:        lobby.CreatorSide,
+		Stake:              money.Cents(lobby.Stake),
+		Status:             lobby.Status,
+		Result:             lobby.Result,
+		ServerSeedHash:     lobby.ServerSeedHash,
+		CreatorClientSeed:  lobby.CreatorClientSeed,
+		OpponentClientSeed: lobby.OpponentClientSeed,
+		ExpiresAt:          lobby.ExpiresAt,
+		CreatedAt:          lobby.CreatedAt,
+	}
+
+	if lobby.Status == config.LobbyResolved {
+		payout := money.Cents(lobby.Payout)
+		view.Payout = &payout
+		view.ServerSeed = lobby.ServerSeed
+	}
+
+	return view
+}
@@ -0,0 +1,420 @@
+package deposit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"go-outpost/internal/payment"
+	"golang.org/x/exp/slog"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	historySize = 50
+
+	// maxWebhookSize bounds the body read from a webhook before its
+	// signature is checked.
+	maxWebhookSize = 64 << 10
+)
+
+// Request opens a deposit of Amount in Currency, the default one when none
+// is given.
+type Request struct {
+	UserUUID string         `json:"user_uuid" validate:"required"`
+	Amount   money.Amount   `json:"amount" validate:"required"`
+	Currency money.Currency `json:"currency"`
+}
+
+type Response struct {
+	resp.Response
+	Deposit     Deposit `json:"deposit"`
+	CheckoutURL string  `json:"checkout_url"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Deposits []Deposit `json:"deposits"`
+}
+
+type Deposit struct {
+	UUID        string               `json:"uuid"`
+	Provider    string               `json:"provider"`
+	Currency    money.Currency       `json:"currency"`
+	Amount      money.Money          `json:"amount"`
+	Status      config.DepositStatus `json:"status"`
+	CreatedAt   time.Time            `json:"created_at"`
+	CompletedAt *time.Time           `json:"completed_at"`
+}
+
+var ErrCurrencyMismatch = errors.New("webhook currency does not match the deposit")
+
+type Deposits struct {
+	log         *slog.Logger
+	validator   *validator.Validate
+	depositRep  repository.DepositRepository
+	userRep     repository.UserRepository
+	balance     *balance.Balance
+	transaction repository.Transaction
+	providers   *payment.Registry
+	event       *event.PusherEvent
+	rules       config.PaymentRules
+	wallets     config.WalletRules
+}
+
+func NewDeposits(
+	log *slog.Logger,
+	depositRep repository.DepositRepository,
+	userRep repository.UserRepository,
+	balance *balance.Balance,
+	transaction repository.Transaction,
+	providers *payment.Registry,
+	eventClient *event.PusherEvent,
+	rules config.PaymentRules,
+	wallets config.WalletRules) *Deposits {
+	return &Deposits{
+		log:         log,
+		validator:   validator.New(),
+		depositRep:  depositRep,
+		userRep:     userRep,
+		balance:     balance,
+		transaction: transaction,
+		providers:   providers,
+		event:       eventClient,
+		rules:       rules,
+		wallets:     wallets,
+	}
+}
+
+// Create handles POST /deposits. The deposit stays pending until the
+// provider reports it through the webhook, the player pays at the checkout.
+func (d *Deposits) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.deposit.Create"
+
+		var (
+			err      error
+			req      Request
+			log      *slog.Logger
+			amount   money.Money
+			user     *model.User
+			provider payment.Provider
+			checkout payment.Checkout
+		)
+
+		log = d.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = d.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		if req.Currency == "" {
+			req.Currency = money.Default
+		}
+
+		if !d.wallets.Accepts(req.Currency) {
+			render.JSON(w, r, resp.ErrorCode(config.ErrCurrencyNotAccepted.Error(), http.StatusBadRequest,
+				"currency_not_accepted"))
+
+			return
+		}
+
+		amount, err = req.Amount.In(req.Currency)
+		if err == nil && amount.Minor() < 1 {
+			err = money.ErrInvalidAmount
+		}
+		if err != nil {
+			log.Error("invalid amount", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
+
+			return
+		}
+
+		user, err = d.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		deposit := model.Deposit{
+			UUID:      uuid.New().String(),
+			UserID:    user.ID,
+			Provider:  d.rules.Provider,
+			Currency:  amount.Currency(),
+			Amount:    int(amount.Minor()),
+			Status:    config.DepositPending,
+			CreatedAt: time.Now(),
+		}
+
+		provider, err = d.providers.Get(deposit.Provider)
+		if err == nil {
+			checkout, err = provider.CreateDeposit(deposit)
+		}
+		if err != nil {
+			log.Error("failed to create checkout", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to create checkout", http.StatusBadGateway))
+
+			return
+		}
+
+		deposit.Reference = checkout.Reference
+
+		deposit.ID, err = d.depositRep.SaveDeposit(deposit)
+		if err != nil {
+			log.Error("failed to save deposit", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to save deposit", http.StatusInternalServerError))
+
+			return
+		}
+
+		log.Info("deposit created", slog.Int64("deposit_id", deposit.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Deposit: view(deposit), CheckoutURL: checkout.URL})
+	}
+}
+
+// Webhook handles POST /deposits/webhook/{provider}. Only webhooks the
+// provider signed are read, and a deposit is settled once however often the
+// provider retries.
+func (d *Deposits) Webhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.deposit.Webhook"
+
+		var (
+			err          error
+			log          *slog.Logger
+			body         []byte
+			provider     payment.Provider
+			notification payment.Notification
+		)
+
+		log = d.log.With(
+			slog.String("op", op),
+			slog.String("provider", chi.URLParam(r, "provider")),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		provider, err = d.providers.Get(chi.URLParam(r, "provider"))
+		if err != nil {
+			render.JSON(w, r, resp.Error("failed to find payment provider", http.StatusNotFound))
+
+			return
+		}
+
+		body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+		if err != nil {
+			log.Error("failed to read webhook", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to read webhook", http.StatusBadRequest))
+
+			return
+		}
+
+		notification, err = provider.ParseWebhook(body, r.Header)
+		if err == nil {
+			err = d.settle(provider.Name(), notification)
+		}
+		if err != nil {
+			log.Error("failed to settle deposit", sl.Err(err))
+
+			render.JSON(w, r, webhookError(err))
+
+			return
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// List handles GET /deposits?user_uuid=.
+func (d *Deposits) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.deposit.List"
+
+		var (
+			err      error
+			log      *slog.Logger
+			user     *model.User
+			deposits []model.Deposit
+		)
+
+		log = d.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = d.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		deposits, err = d.depositRep.GetDepositsByUserID(user.ID, historySize)
+		if err != nil {
+			log.Error("failed to get deposits", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to get deposits", http.StatusInternalServerError))
+
+			return
+		}
+
+		views := make([]Deposit, 0, len(deposits))
+		for _, deposit := range deposits {
+			views = append(views, view(deposit))
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Deposits: views})
+	}
+}
+
+// settle ends the pending deposit the notification is about and credits what
+// the provider received, both on one transaction so a failed credit leaves
+// the deposit pending for the provider's retry. A deposit already settled is
+// left alone.
+func (d *Deposits) settle(provider string, n payment.Notification) error {
+	const op = "handlers.deposit.settle"
+
+	var (
+		err     error
+		deposit *model.Deposit
+		user    *model.User
+		tx      *sql.Tx
+		credit  *balance.Tx
+		claimed bool
+	)
+
+	deposit, err = d.depositRep.FindDepositByReference(provider, n.Reference)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deposit.Status != config.DepositPending {
+		return nil
+	}
+
+	amount := money.New(0, deposit.Currency)
+
+	if n.Status == config.DepositCompleted {
+		if n.Amount.Currency() != deposit.Currency {
+			return fmt.Errorf("%s: %w: %s", op, ErrCurrencyMismatch, n.Amount.Currency())
+		}
+
+		amount = n.Amount
+	}
+
+	tx, err = d.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	credit = d.balance.WithTx(tx)
+	defer credit.Rollback()
+
+	claimed, err = d.depositRep.WithTx(tx).SettleDeposit(deposit.ID, n.Status, int(amount.Minor()))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !claimed {
+		return nil
+	}
+
+	if n.Status == config.DepositCompleted && !amount.IsZero() {
+		if err = credit.Income(deposit.UserID, amount, config.Deposit); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = credit.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	d.log.Info("deposit settled",
+		slog.Int64("deposit_id", deposit.ID),
+		slog.String("status", string(n.Status)),
+		slog.String("amount", amount.String()))
+
+	user, err = d.userRep.GetUserByID(deposit.UserID)
+	if err == nil {
+		err = d.event.Trigger(events.DepositUpdated{
+			UUID:     deposit.UUID,
+			UserUUID: user.UUID,
+			Status:   n.Status,
+			Currency: deposit.Currency,
+			Amount:   amount,
+		})
+	}
+	if err != nil {
+		d.log.Error("failed to send deposit event", sl.Err(err), slog.Int64("deposit_id", deposit.ID))
+	}
+
+	return nil
+}
+
+func webhookError(err error) resp.Response {
+	switch {
+	case errors.Is(err, payment.ErrInvalidSignature):
+		return resp.ErrorCode(payment.ErrInvalidSignature.Error(), http.StatusUnauthorized, "invalid_signature")
+	case errors.Is(err, payment.ErrInvalidWebhook):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_webhook")
+	case errors.Is(err, ErrCurrencyMismatch):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_webhook")
+	case errors.Is(err, repository.ErrDepositNotFound):
+		return resp.ErrorCode("failed to find deposit", http.StatusNotFound, "deposit_not_found")
+	}
+
+	return resp.Error("failed to settle deposit", http.StatusInternalServerError)
+}
+
+func view(deposit model.Deposit) Deposit {
+	return Deposit{
+		UUID:        deposit.UUID,
+		Provider:    deposit.Provider,
+		Currency:    deposit.Currency,
+		Amount:      money.New(int64(deposit.Amount), deposit.Currency),
+		Status:      deposit.Status,
+		CreatedAt:   deposit.CreatedAt,
+		CompletedAt: deposit.CompletedAt,
+	}
+}
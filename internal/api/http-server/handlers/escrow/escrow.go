@@ -0,0 +1,42 @@
+package escrow
+
+import (
+	"database/sql"
+	"go-outpost/internal/api/http-server/handlers/leaderboard"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/repository"
+	"golang.org/x/exp/slog"
+)
+
+// Escrow holds player stakes of multiplayer games between the moment they are
+// put in and the resolution of the game. A stake and the balance move it
+// comes with are always written on one transaction.
+type Escrow struct {
+	log         *slog.Logger
+	escrowRep   repository.EscrowRepository
+	balance     *balance.Balance
+	leaderboard leaderboard.Recorder
+}
+
+func NewEscrow(
+	log *slog.Logger,
+	escrowRep repository.EscrowRepository,
+	balance *balance.Balance,
+	leaderboard leaderboard.Recorder) *Escrow {
+	return &Escrow{
+		log:         log,
+		escrowRep:   escrowRep,
+		balance:     balance,
+		leaderboard: leaderboard,
+	}
+}
+
+// WithTx returns the escrow moves on tx, so a game can claim its own state
+// and move the stakes it holds in one commit.
+func (e *Escrow) WithTx(tx *sql.Tx) *Tx {
+	return &Tx{
+		escrow:    e,
+		escrowRep: e.escrowRep.WithTx(tx),
+		balance:   e.balance.WithTx(tx),
+	}
+}
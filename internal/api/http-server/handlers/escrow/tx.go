@@ -0,0 +1,184 @@
+package escrow
+
+import (
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+// Tx moves stakes on a database transaction together with the writes of the
+// game they belong to. Escrows are closed before their money moves, so a
+// stake is never paid twice, and the leaderboards only count a game once
+// Commit succeeds.
+type Tx struct {
+	escrow    *Escrow
+	escrowRep *repository.EscrowRepository
+	balance   *balance.Tx
+	records   []record
+}
+
+type record struct {
+	userID int64
+	game   config.Game
+	amount money.Money
+	payout money.Money
+}
+
+// Hold debits the stake and records it as held for the game. The debit only
+// goes through when the wallet covers it.
+func (t *Tx) Hold(userID int64, amount int, game config.Game, gameID int64) (int64, error) {
+	const op = "handlers.escrow.Tx.Hold"
+
+	if err := t.balance.Outcome(userID, money.Cents(amount), game); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := t.escrowRep.SaveEscrow(model.Escrow{
+		Game:      game,
+		GameID:    gameID,
+		UserID:    userID,
+		Amount:    amount,
+		Status:    config.EscrowHeld,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// Release closes the stakes held for a game once it is resolved, the pot is
+// then paid out through Pay. Every player is counted in the leaderboards
+// with their stakes in the game, the winner with its payout. It returns the
+// released total.
+func (t *Tx) Release(game config.Game, gameID int64, winnerID int64, payout int) (int, error) {
+	const op = "handlers.escrow.Tx.Release"
+
+	escrows, err := t.escrowRep.GetHeldEscrows(game, gameID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	total := 0
+	stakes := make(map[int64]int)
+	players := make([]int64, 0, len(escrows))
+
+	for _, escrow := range escrows {
+		closed, err := t.escrowRep.CloseEscrow(escrow.ID, config.EscrowReleased)
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !closed {
+			continue
+		}
+
+		if _, ok := stakes[escrow.UserID]; !ok {
+			players = append(players, escrow.UserID)
+		}
+
+		stakes[escrow.UserID] += escrow.Amount
+		total += escrow.Amount
+	}
+
+	for _, userID := range players {
+		won := 0
+		if userID == winnerID {
+			won = payout
+		}
+
+		t.records = append(t.records, record{
+			userID: userID,
+			game:   game,
+			amount: money.Cents(stakes[userID]),
+			payout: money.Cents(won),
+		})
+	}
+
+	return total, nil
+}
+
+// Pay credits the winnings of a resolved game out of its released pot.
+func (t *Tx) Pay(userID int64, amount int, game config.Game) error {
+	const op = "handlers.escrow.Tx.Pay"
+
+	if amount <= 0 {
+		return nil
+	}
+
+	if err := t.balance.Income(userID, money.Cents(amount), game); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Refund gives every stake still held for a game back to its player. It
+// returns the number of stakes refunded.
+func (t *Tx) Refund(game config.Game, gameID int64) (int, error) {
+	const op = "handlers.escrow.Tx.Refund"
+
+	escrows, err := t.escrowRep.GetHeldEscrows(game, gameID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	refunded := 0
+
+	for _, escrow := range escrows {
+		closed, err := t.refund(escrow.ID, escrow.UserID, escrow.Amount, game)
+		if err != nil {
+			return refunded, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if closed {
+			refunded++
+		}
+	}
+
+	return refunded, nil
+}
+
+// refund closes a stake and credits it back, it reports whether this call
+// closed the stake.
+func (t *Tx) refund(escrowID int64, userID int64, amount int, game config.Game) (bool, error) {
+	closed, err := t.escrowRep.CloseEscrow(escrowID, config.EscrowRefunded)
+	if err != nil || !closed {
+		return false, err
+	}
+
+	if err = t.balance.Income(userID, money.Cents(amount), game); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Commit commits the transaction, then sends the balance events and counts
+// the released stakes in the leaderboards.
+func (t *Tx) Commit() error {
+	const op = "handlers.escrow.Tx.Commit"
+
+	if err := t.balance.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, r := range t.records {
+		t.escrow.leaderboard.Record(r.userID, r.game, r.amount, r.payout)
+	}
+
+	return nil
+}
+
+// Rollback drops the transaction with its events and records. Rolling back a
+// committed transaction is a no-op, so it is safe to defer.
+func (t *Tx) Rollback() error {
+	t.records = nil
+
+	return t.balance.Rollback()
+}
@@ -4,26 +4,66 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"go-outpost/internal/events"
 	"go-outpost/internal/lib/logger/sl"
 	"golang.org/x/exp/slog"
+	"sync"
 )
 
 type PusherEvent struct {
-	log  *slog.Logger
-	conn *websocket.Conn
+	log      *slog.Logger
+	conn     *websocket.Conn
+	registry *events.Registry
+	// mutex keeps one writer on the connection, events are triggered from
+	// every request goroutine.
+	mutex sync.Mutex
 }
 
 type Message struct {
-	Channel string                 `json:"channel"`
-	Event   string                 `json:"event"`
-	Data    map[string]interface{} `json:"data"`
+	Channel string      `json:"channel"`
+	Event   string      `json:"event"`
+	Version int         `json:"version"`
+	Data    interface{} `json:"data"`
 }
 
-func NewPusherEvent(log *slog.Logger, conn *websocket.Conn) *PusherEvent {
-	return &PusherEvent{
-		log:  log,
-		conn: conn,
+func NewPusherEvent(log *slog.Logger, conn *websocket.Conn, registry *events.Registry) *PusherEvent {
+	p := &PusherEvent{
+		log:      log,
+		conn:     conn,
+		registry: registry,
 	}
+
+	if conn != nil {
+		go p.read()
+	}
+
+	return p
+}
+
+// read keeps reading the connection so the pings of the hub are answered,
+// the hub drops a peer that stops sending pongs. Nothing else is expected.
+func (p *PusherEvent) read() {
+	for {
+		if _, _, err := p.conn.NextReader(); err != nil {
+			p.log.Error("websocket connection closed", sl.Err(err))
+
+			return
+		}
+	}
+}
+
+func NewMessage(e events.Event) Message {
+	return Message{
+		Channel: e.Channel(),
+		Event:   e.Name(),
+		Version: e.Version(),
+		Data:    e,
+	}
+}
+
+// Trigger publishes a typed event.
+func (p *PusherEvent) Trigger(e events.Event) error {
+	return p.TriggerEvent(NewMessage(e))
 }
 
 func (p *PusherEvent) TriggerEvent(m Message) error {
@@ -34,6 +74,12 @@ func (p *PusherEvent) TriggerEvent(m Message) error {
 		msg []byte
 	)
 
+	if err = p.registry.Validate(m.Channel, m.Event, m.Version, m.Data); err != nil {
+		p.log.Error("invalid event payload", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	msg, err = json.Marshal(m)
 
 	if err != nil {
@@ -42,9 +88,19 @@ func (p *PusherEvent) TriggerEvent(m Message) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if p.conn == nil {
+		p.log.Warn("no websocket connection, event dropped", sl.String("event", m.Event))
+
+		return nil
+	}
+
 	p.log.Info("triggering event")
 
-	if err = p.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+	p.mutex.Lock()
+	err = p.conn.WriteMessage(websocket.TextMessage, msg)
+	p.mutex.Unlock()
+
+	if err != nil {
 		p.log.Error("failed to trigger event", sl.Err(err))
 
 		return fmt.Errorf("%s: %w", op, err)
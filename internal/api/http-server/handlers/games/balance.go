@@ -0,0 +1,21 @@
+package games
+
+import (
+	"database/sql"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/game"
+)
+
+// Balance moves the money of the game player through the user balance.
+type Balance struct {
+	balance *balance.Balance
+}
+
+func NewBalance(balance *balance.Balance) *Balance {
+	return &Balance{balance: balance}
+}
+
+// WithTx returns the moves of the user balance on tx.
+func (b *Balance) WithTx(tx *sql.Tx) game.Ledger {
+	return b.balance.WithTx(tx)
+}
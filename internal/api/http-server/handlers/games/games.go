@@ -0,0 +1,305 @@
+package games
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/game"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+)
+
+// PlayRequest is read from the same body as the choices of the game, e.g.
+// {"user_uuid": "...", "amount": 1, "target": 50, "direction": "over"}. The
+// amount is in Currency, the default one when none is given.
+type PlayRequest struct {
+	UserUUID string         `json:"user_uuid" validate:"required"`
+	Amount   money.Amount   `json:"amount" validate:"required"`
+	Currency money.Currency `json:"currency"`
+}
+
+// VerifyRequest is read from the same body as the choices of the bet.
+type VerifyRequest struct {
+	ServerSeed string `json:"server_seed" validate:"required"`
+	ClientSeed string `json:"client_seed" validate:"required"`
+	Nonce      *int   `json:"nonce" validate:"required,min=0"`
+}
+
+type Game struct {
+	Game config.Game `json:"game"`
+	Kind game.Kind   `json:"kind"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Games []Game `json:"games"`
+}
+
+type PlayResponse struct {
+	resp.Response
+	Bet      Bet      `json:"bet"`
+	Fairness Fairness `json:"fairness"`
+}
+
+type Bet struct {
+	UUID     string          `json:"uuid"`
+	Game     config.Game     `json:"game"`
+	Currency money.Currency  `json:"currency"`
+	Amount   money.Money     `json:"amount"`
+	Win      bool            `json:"win"`
+	Payout   money.Money     `json:"payout"`
+	Result   json.RawMessage `json:"result"`
+}
+
+// Fairness lets the player check the draw once the seed pair is rotated and
+// its server seed revealed.
+type Fairness struct {
+	ClientSeed     string `json:"client_seed"`
+	ServerSeedHash string `json:"server_seed_hash"`
+	Nonce          int    `json:"nonce"`
+}
+
+type VerifyResponse struct {
+	resp.Response
+	ServerSeedHash string      `json:"server_seed_hash"`
+	Hash           string      `json:"hash"`
+	Result         interface{} `json:"result"`
+}
+
+type Games struct {
+	log       *slog.Logger
+	validator *validator.Validate
+	userRep   repository.UserRepository
+	registry  *game.Registry
+	player    *game.Player
+}
+
+func NewGames(
+	log *slog.Logger,
+	userRep repository.UserRepository,
+	registry *game.Registry,
+	player *game.Player) *Games {
+	return &Games{
+		log:       log,
+		validator: money.WithValidation(validator.New()),
+		userRep:   userRep,
+		registry:  registry,
+		player:    player,
+	}
+}
+
+// List handles GET /games.
+func (g *Games) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		engines := g.registry.Engines()
+
+		games := make([]Game, 0, len(engines))
+		for _, engine := range engines {
+			games = append(games, Game{Game: engine.Game(), Kind: engine.Kind()})
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Games: games})
+	}
+}
+
+// Play handles POST /games/{game}/play for every instant game.
+func (g *Games) Play() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g.play(w, r, config.Game(chi.URLParam(r, "game")))
+	}
+}
+
+// PlayGame serves an instant game on a route of its own, such as /dice/roll.
+func (g *Games) PlayGame(game config.Game) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g.play(w, r, game)
+	}
+}
+
+// Verify handles POST /games/{game}/verify. It replays a draw from revealed
+// seeds without touching any state.
+func (g *Games) Verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.games.Verify"
+
+		var (
+			err    error
+			req    VerifyRequest
+			log    *slog.Logger
+			body   json.RawMessage
+			engine game.Engine
+			result game.Result
+		)
+
+		log = g.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !g.decode(w, r, log, &body, &req) {
+			return
+		}
+
+		rng := game.RNG{ServerSeed: req.ServerSeed, ClientSeed: req.ClientSeed, Nonce: *req.Nonce}
+
+		engine, err = g.registry.Get(config.Game(chi.URLParam(r, "game")))
+		if err == nil {
+			result, err = game.Verify(engine, body, rng)
+		}
+		if err != nil {
+			log.Error("failed to verify draw", sl.Err(err))
+
+			render.JSON(w, r, gameError(err))
+
+			return
+		}
+
+		render.JSON(w, r, VerifyResponse{
+			Response:       resp.OK(),
+			ServerSeedHash: rng.ServerSeedHash(),
+			Hash:           rng.Hash(),
+			Result:         result.Value,
+		})
+	}
+}
+
+func (g *Games) play(w http.ResponseWriter, r *http.Request, name config.Game) {
+	const op = "handlers.games.Play"
+
+	var (
+		err    error
+		req    PlayRequest
+		log    *slog.Logger
+		body   json.RawMessage
+		amount money.Money
+		user   *model.User
+		bet    *model.GameBet
+		rng    game.RNG
+	)
+
+	log = g.log.With(
+		slog.String("op", op),
+		slog.String("game", string(name)),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	if !g.decode(w, r, log, &body, &req) {
+		return
+	}
+
+	if req.Currency == "" {
+		req.Currency = money.Default
+	}
+
+	amount, err = req.Amount.In(req.Currency)
+	if err == nil && amount.Minor() < 1 {
+		err = money.ErrInvalidAmount
+	}
+	if err != nil {
+		log.Error("invalid amount", sl.Err(err))
+
+		render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
+
+		return
+	}
+
+	user, err = g.userRep.FindUserByUUID(req.UserUUID)
+	if err != nil || user == nil {
+		log.Error("failed to find user", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+		return
+	}
+
+	bet, rng, err = g.player.Play(name, user.ID, amount, body)
+	if err != nil {
+		log.Error("failed to play", sl.Err(err))
+
+		render.JSON(w, r, gameError(err))
+
+		return
+	}
+
+	render.JSON(w, r, PlayResponse{
+		Response: resp.OK(),
+		Bet: Bet{
+			UUID:     bet.UUID,
+			Game:     bet.Game,
+			Currency: bet.Currency,
+			Amount:   money.New(int64(bet.Amount), bet.Currency),
+			Win:      bet.Payout > 0,
+			Payout:   money.New(int64(bet.Payout), bet.Currency),
+			Result:   bet.Result,
+		},
+		Fairness: Fairness{
+			ClientSeed:     rng.ClientSeed,
+			ServerSeedHash: rng.ServerSeedHash(),
+			Nonce:          rng.Nonce,
+		},
+	})
+}
+
+// decode reads the body once, into req and as raw choices for the engine.
+func (g *Games) decode(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	body *json.RawMessage,
+	req interface{}) bool {
+	err := render.DecodeJSON(r.Body, body)
+	if err == nil {
+		err = json.Unmarshal(*body, req)
+	}
+	if err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+		return false
+	}
+
+	if err = g.validator.Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+
+		log.Error("invalid request", sl.Err(err))
+
+		render.JSON(w, r, resp.ValidationError(validateErr))
+
+		return false
+	}
+
+	return true
+}
+
+func gameError(err error) resp.Response {
+	switch {
+	case errors.Is(err, game.ErrUnknownGame):
+		return resp.ErrorCode("failed to find game", http.StatusNotFound, "game_not_found")
+	case errors.Is(err, game.ErrNotInstant):
+		return resp.ErrorCode(game.ErrNotInstant.Error(), http.StatusBadRequest, "not_instant")
+	case errors.Is(err, game.ErrInvalidBet):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_bet")
+	case errors.Is(err, config.ErrCurrencyNotAccepted):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "currency_not_accepted")
+	case errors.Is(err, config.ErrStakeOutOfRange):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range")
+	case errors.Is(err, game.ErrPayoutLimit):
+		return resp.ErrorCode(game.ErrPayoutLimit.Error(), http.StatusConflict, "payout_limit")
+	case errors.Is(err, game.ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, game.ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to play", http.StatusInternalServerError)
+}
@@ -0,0 +1,65 @@
+package games
+
+import (
+	"database/sql"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/provably_fair"
+	"go-outpost/internal/game"
+)
+
+// Seeds draws the games of the game player from the seed pairs kept by
+// provably fair.
+type Seeds struct {
+	provablyFair *provably_fair.ProvablyFair
+}
+
+func NewSeeds(provablyFair *provably_fair.ProvablyFair) *Seeds {
+	return &Seeds{provablyFair: provablyFair}
+}
+
+// Next takes the next nonce of the player's seed pair.
+func (s *Seeds) Next(userID int64) (game.RNG, error) {
+	const op = "handlers.games.Seeds.Next"
+
+	data, err := s.provablyFair.DrawForUser(userID)
+	if err != nil {
+		return game.RNG{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return game.RNG{ServerSeed: data.ServerSeed, ClientSeed: data.ClientSeed, Nonce: data.Nonce}, nil
+}
+
+// Record stores the draw of a bet with what it resolved to on the
+// transaction of the bet.
+func (s *Seeds) Record(
+	tx *sql.Tx,
+	betID int64,
+	userID int64,
+	name config.Game,
+	rng game.RNG,
+	result game.Result) error {
+	const op = "handlers.games.Seeds.Record"
+
+	draws := s.provablyFair.WithTx(tx)
+
+	drawID, err := draws.StoreUserGameDraw(betID, userID, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = draws.StoreProvablyFair(provably_fair.ProvablyFairData{
+		ClientSeed:     rng.ClientSeed,
+		ServerSeed:     rng.ServerSeed,
+		ServerHashSeed: rng.Hash(),
+		Nonce:          rng.Nonce,
+		Result:         result.Draw,
+		Min:            result.Min,
+		Max:            result.Max,
+	}, drawID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,478 @@
+package hilo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	engine "go-outpost/internal/game"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"time"
+)
+
+// StartRequest opens a game staked in Currency, the default one when none is
+// given.
+type StartRequest struct {
+	UserUUID string         `json:"user_uuid" validate:"required"`
+	Amount   money.Amount   `json:"amount" validate:"required"`
+	Currency money.Currency `json:"currency"`
+}
+
+type GuessRequest struct {
+	UserUUID string           `json:"user_uuid" validate:"required"`
+	Guess    config.HiloGuess `json:"guess" validate:"required,oneof=higher lower"`
+}
+
+type CashOutRequest struct {
+	UserUUID string `json:"user_uuid" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	Game Game `json:"game"`
+}
+
+// Game is the player view of a game. Odds are the step multipliers of the
+// next guess, a guess that cannot lose is left out.
+type Game struct {
+	UUID           string                       `json:"uuid"`
+	Currency       money.Currency               `json:"currency"`
+	Amount         money.Money                  `json:"amount"`
+	Cards          []int                        `json:"cards"`
+	Guesses        []config.HiloGuess           `json:"guesses"`
+	Status         config.HiloStatus            `json:"status"`
+	Multiplier     float64                      `json:"multiplier"`
+	Odds           map[config.HiloGuess]float64 `json:"odds,omitempty"`
+	Payout         money.Money                  `json:"payout"`
+	ClientSeed     string                       `json:"client_seed"`
+	ServerSeedHash string                       `json:"server_seed_hash"`
+	Nonce          int                          `json:"nonce"`
+}
+
+var (
+	ErrGameInProgress = errors.New("user has a hilo game in progress")
+	ErrMoveConflict   = errors.New("hilo game changed, retry the move")
+)
+
+// Hilo stores the games and serves the moves, the rules are the hilo engine
+// and the stake, draw and payout go through the game player.
+type Hilo struct {
+	log       *slog.Logger
+	validator *validator.Validate
+	hiloRep   repository.HiloRepository
+	userRep   repository.UserRepository
+	rules     *engine.Hilo
+	player    *engine.Player
+}
+
+func NewHilo(
+	log *slog.Logger,
+	hiloRep repository.HiloRepository,
+	userRep repository.UserRepository,
+	rules *engine.Hilo,
+	player *engine.Player) *Hilo {
+	return &Hilo{
+		log:       log,
+		validator: money.WithValidation(validator.New()),
+		hiloRep:   hiloRep,
+		userRep:   userRep,
+		rules:     rules,
+		player:    player,
+	}
+}
+
+// Start handles POST /hilo/games. The stake is debited and the deck drawn
+// from the next nonce of the player's seed pair.
+func (h *Hilo) Start() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.hilo.Start"
+
+		var (
+			err    error
+			req    StartRequest
+			log    *slog.Logger
+			amount money.Money
+			user   *model.User
+			game   *model.HiloGame
+		)
+
+		log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !h.decode(w, r, log, &req) {
+			return
+		}
+
+		if req.Currency == "" {
+			req.Currency = money.Default
+		}
+
+		amount, err = req.Amount.In(req.Currency)
+		if err == nil && amount.Minor() < 1 {
+			err = money.ErrInvalidAmount
+		}
+		if err != nil {
+			log.Error("invalid amount", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
+
+			return
+		}
+
+		user, err = h.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		game, err = h.start(user.ID, amount)
+		if err != nil {
+			log.Error("failed to start hilo game", sl.Err(err))
+
+			render.JSON(w, r, h.hiloError(err))
+
+			return
+		}
+
+		log.Info("hilo game started", slog.Int64("game_id", game.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: h.view(game)})
+	}
+}
+
+// Active handles GET /hilo/games/active?user_uuid= so a player can resume
+// the game left open by a disconnect or a restart.
+func (h *Hilo) Active() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.hilo.Active"
+
+		var (
+			err  error
+			log  *slog.Logger
+			user *model.User
+			game *model.HiloGame
+		)
+
+		log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = h.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		game, err = h.hiloRep.FindActiveHiloGame(user.ID)
+		if err != nil {
+			log.Error("failed to find hilo game", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find hilo game", http.StatusInternalServerError))
+
+			return
+		}
+
+		if game == nil {
+			render.JSON(w, r, resp.ErrorCode("no hilo game in progress", http.StatusNotFound, "no_game"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: h.view(game)})
+	}
+}
+
+// Guess handles POST /hilo/games/{uuid}/guess.
+func (h *Hilo) Guess() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.hilo.Guess"
+
+		var (
+			err  error
+			req  GuessRequest
+			log  *slog.Logger
+			game *model.HiloGame
+		)
+
+		log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !h.decode(w, r, log, &req) {
+			return
+		}
+
+		game, err = h.findGame(chi.URLParam(r, "uuid"), req.UserUUID)
+		if err == nil {
+			err = h.move(game, func(game *model.HiloGame, now time.Time) error {
+				return h.rules.Guess(game, req.Guess, now)
+			})
+		}
+		if err != nil {
+			log.Error("failed to guess", sl.Err(err))
+
+			render.JSON(w, r, h.hiloError(err))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: h.view(game)})
+	}
+}
+
+// CashOut handles POST /hilo/games/{uuid}/cashout.
+func (h *Hilo) CashOut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.hilo.CashOut"
+
+		var (
+			err  error
+			req  CashOutRequest
+			log  *slog.Logger
+			game *model.HiloGame
+		)
+
+		log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !h.decode(w, r, log, &req) {
+			return
+		}
+
+		game, err = h.findGame(chi.URLParam(r, "uuid"), req.UserUUID)
+		if err == nil {
+			err = h.move(game, func(game *model.HiloGame, now time.Time) error {
+				return h.rules.CashOut(game, now)
+			})
+		}
+		if err != nil {
+			log.Error("failed to cash out", sl.Err(err))
+
+			render.JSON(w, r, h.hiloError(err))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: h.view(game)})
+	}
+}
+
+// HasActiveGame tells whether the player has a game whose deck still depends
+// on the active seed pair.
+func (h *Hilo) HasActiveGame(userID int64) (bool, error) {
+	game, err := h.hiloRep.FindActiveHiloGame(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return game != nil, nil
+}
+
+func (h *Hilo) start(userID int64, amount money.Money) (*model.HiloGame, error) {
+	const op = "handlers.hilo.start"
+
+	var (
+		err  error
+		game *model.HiloGame
+	)
+
+	game, err = h.hiloRep.FindActiveHiloGame(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if game != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrGameInProgress)
+	}
+
+	err = h.player.Start(config.Hilo, userID, amount, nil,
+		func(tx *sql.Tx, _ engine.Wager, result engine.Result, rng engine.RNG) (int64, error) {
+			game = &model.HiloGame{
+				UUID:           uuid.New().String(),
+				UserID:         userID,
+				Currency:       amount.Currency(),
+				Amount:         int(amount.Minor()),
+				Deck:           result.Value.(engine.HiloDeck).Cards,
+				Guesses:        []config.HiloGuess{},
+				Multiplier:     1,
+				Status:         config.HiloActive,
+				ClientSeed:     rng.ClientSeed,
+				ServerSeedHash: rng.ServerSeedHash(),
+				Nonce:          rng.Nonce,
+				CreatedAt:      time.Now(),
+			}
+
+			id, err := h.hiloRep.WithTx(tx).SaveHiloGame(*game)
+			game.ID = id
+
+			return id, err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return game, nil
+}
+
+// move applies a change to a game, stores it and pays the game out when it
+// ended with a cash out. Only the request that stored the move pays and
+// counts the ended game in the leaderboards.
+func (h *Hilo) move(game *model.HiloGame, apply func(game *model.HiloGame, now time.Time) error) error {
+	const op = "handlers.hilo.move"
+
+	guessesBefore := len(game.Guesses)
+
+	err := apply(game, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if game.Status == config.HiloCashedOut {
+		game.Payout, err = h.player.CapPayout(config.Hilo, game.Currency, game.Payout)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// An ended game is stored on the transaction that pays it out, so only the
+	// request that stored the end pays.
+	update := func(hiloRep *repository.HiloRepository) error {
+		stored, err := hiloRep.UpdateHiloGame(*game, guessesBefore)
+		if err != nil {
+			return err
+		}
+
+		if !stored {
+			return ErrMoveConflict
+		}
+
+		return nil
+	}
+
+	if game.Status == config.HiloActive {
+		err = update(&h.hiloRep)
+	} else {
+		err = h.player.Finish(config.Hilo, game.UserID,
+			money.New(int64(game.Amount), game.Currency), money.New(int64(game.Payout), game.Currency),
+			func(tx *sql.Tx) error {
+				return update(h.hiloRep.WithTx(tx))
+			})
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (h *Hilo) findGame(gameUUID string, userUUID string) (*model.HiloGame, error) {
+	user, err := h.userRep.FindUserByUUID(userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	game, err := h.hiloRep.FindHiloGameByUUID(gameUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || game.UserID != user.ID {
+		return nil, repository.ErrHiloGameNotFound
+	}
+
+	return game, nil
+}
+
+func (h *Hilo) decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+		return false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+
+		log.Error("invalid request", sl.Err(err))
+
+		render.JSON(w, r, resp.ValidationError(validateErr))
+
+		return false
+	}
+
+	return true
+}
+
+func (h *Hilo) hiloError(err error) resp.Response {
+	switch {
+	case errors.Is(err, repository.ErrHiloGameNotFound):
+		return resp.ErrorCode("failed to find hilo game", http.StatusNotFound, "game_not_found")
+	case errors.Is(err, ErrGameInProgress):
+		return resp.ErrorCode(ErrGameInProgress.Error(), http.StatusConflict, "game_in_progress")
+	case errors.Is(err, engine.ErrHiloOver):
+		return resp.ErrorCode(engine.ErrHiloOver.Error(), http.StatusConflict, "game_over")
+	case errors.Is(err, ErrMoveConflict):
+		return resp.ErrorCode(ErrMoveConflict.Error(), http.StatusConflict, "move_conflict")
+	case errors.Is(err, engine.ErrNoGuess):
+		return resp.ErrorCode(engine.ErrNoGuess.Error(), http.StatusConflict, "no_guess")
+	case errors.Is(err, config.ErrInvalidGuess):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_guess")
+	case errors.Is(err, config.ErrCurrencyNotAccepted):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "currency_not_accepted")
+	case errors.Is(err, config.ErrStakeOutOfRange):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range")
+	case errors.Is(err, engine.ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, engine.ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to play hilo", http.StatusInternalServerError)
+}
+
+func (h *Hilo) view(game *model.HiloGame) Game {
+	view := Game{
+		UUID:           game.UUID,
+		Currency:       game.Currency,
+		Amount:         money.New(int64(game.Amount), game.Currency),
+		Cards:          game.Cards(),
+		Guesses:        game.Guesses,
+		Status:         game.Status,
+		Multiplier:     game.Multiplier,
+		Payout:         money.New(int64(game.Payout), game.Currency),
+		ClientSeed:     game.ClientSeed,
+		ServerSeedHash: game.ServerSeedHash,
+		Nonce:          game.Nonce,
+	}
+
+	if game.Status == config.HiloActive {
+		view.Odds = h.rules.Odds(game)
+	}
+
+	return view
+}
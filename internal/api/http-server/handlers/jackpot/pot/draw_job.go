@@ -0,0 +1,15 @@
+package pot
+
+import "go-outpost/internal/lib/logger/sl"
+
+// PotDrawJob runs when the timer of a pot is over.
+type PotDrawJob struct {
+	Jackpot *Jackpot
+	PotID   int64
+}
+
+func (job *PotDrawJob) Execute() {
+	if err := job.Jackpot.Draw(job.PotID); err != nil {
+		job.Jackpot.log.Error("failed to draw jackpot pot", sl.Err(err))
+	}
+}
@@ -0,0 +1,309 @@
+package pot
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// Pot is a pot as listed to players. Amounts are decimal strings, the server
+// seed is shown once the pot is closed.
+type Pot struct {
+	UUID           string           `json:"uuid"`
+	Status         config.PotStatus `json:"status"`
+	Total          money.Money      `json:"total"`
+	Tickets        int              `json:"tickets"`
+	WinningTicket  *int             `json:"winning_ticket"`
+	WinnerID       *int64           `json:"winner_id"`
+	Payout         money.Money      `json:"payout"`
+	Rake           money.Money      `json:"rake"`
+	ServerSeedHash string           `json:"server_seed_hash"`
+	ServerSeed     string           `json:"server_seed,omitempty"`
+	EndsAt         time.Time        `json:"ends_at"`
+	DrawnAt        *time.Time       `json:"drawn_at"`
+	Deposits       []Deposit        `json:"deposits,omitempty"`
+}
+
+// Deposit holds the tickets TicketFrom to TicketTo, both included.
+type Deposit struct {
+	UserID     int64       `json:"user_id"`
+	Amount     money.Money `json:"amount"`
+	TicketFrom int         `json:"ticket_from"`
+	TicketTo   int         `json:"ticket_to"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// Fairness lets players replay the draw: the winning ticket is the result of
+// the hash over Max+1 tickets.
+type Fairness struct {
+	ClientSeed   string  `json:"client_seed"`
+	ServerSeed   string  `json:"server_seed"`
+	ResultedHash string  `json:"resulted_hash"`
+	Nonce        int     `json:"nonce"`
+	Result       float64 `json:"result"`
+	Min          int     `json:"min"`
+	Max          int     `json:"max"`
+}
+
+type CurrentResponse struct {
+	resp.Response
+	Pot *Pot `json:"pot"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Pots    []Pot `json:"pots"`
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+	Total   int   `json:"total"`
+}
+
+type ShowResponse struct {
+	resp.Response
+	Pot      Pot       `json:"pot"`
+	Fairness *Fairness `json:"fairness"`
+}
+
+// Current handles GET /jackpot/current, the pot is null when none is open.
+func (j *Jackpot) Current() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.jackpot.pot.Current"
+
+		var (
+			err      error
+			pot      *model.JackpotPot
+			deposits []model.JackpotDeposit
+			view     *Pot
+		)
+
+		log := j.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		pot, err = j.jackpotRep.FindOpenPot()
+		if err == nil && pot != nil {
+			deposits, err = j.jackpotRep.GetDepositsByPotIDs([]int64{pot.ID})
+		}
+
+		if err != nil {
+			log.Error("failed to load jackpot pot", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to load jackpot pot", http.StatusInternalServerError))
+
+			return
+		}
+
+		if pot != nil {
+			v := withDeposits(potView(*pot), deposits)
+			view = &v
+		}
+
+		render.JSON(w, r, CurrentResponse{Response: resp.OK(), Pot: view})
+	}
+}
+
+// List handles GET /jackpot/history?page=&per_page= with the ticket ranges of
+// every pot.
+func (j *Jackpot) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.jackpot.pot.List"
+
+		var (
+			err      error
+			total    int
+			pots     []model.JackpotPot
+			deposits []model.JackpotDeposit
+		)
+
+		log := j.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		page := queryInt(r, "page", 1, 1, 0)
+		perPage := queryInt(r, "per_page", defaultPerPage, 1, maxPerPage)
+
+		total, err = j.jackpotRep.CountFinishedPots()
+		if err == nil {
+			pots, err = j.jackpotRep.GetFinishedPots(perPage, (page-1)*perPage)
+		}
+
+		ids := make([]int64, 0, len(pots))
+		for _, pot := range pots {
+			ids = append(ids, pot.ID)
+		}
+
+		if err == nil {
+			deposits, err = j.jackpotRep.GetDepositsByPotIDs(ids)
+		}
+
+		if err != nil {
+			log.Error("failed to load jackpot history", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to load jackpot history", http.StatusInternalServerError))
+
+			return
+		}
+
+		depositsByPot := make(map[int64][]model.JackpotDeposit, len(pots))
+		for _, deposit := range deposits {
+			depositsByPot[deposit.PotID] = append(depositsByPot[deposit.PotID], deposit)
+		}
+
+		views := make([]Pot, 0, len(pots))
+		for _, pot := range pots {
+			views = append(views, withDeposits(potView(pot), depositsByPot[pot.ID]))
+		}
+
+		render.JSON(w, r, ListResponse{
+			Response: resp.OK(),
+			Pots:     views,
+			Page:     page,
+			PerPage:  perPage,
+			Total:    total,
+		})
+	}
+}
+
+// Show handles GET /jackpot/{uuid}. Fairness data is only shown once the pot
+// is drawn.
+func (j *Jackpot) Show() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.jackpot.pot.Show"
+
+		var (
+			err      error
+			pot      *model.JackpotPot
+			deposits []model.JackpotDeposit
+			pf       *model.ProvablyFair
+			fairness *Fairness
+		)
+
+		log := j.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		pot, err = j.jackpotRep.FindPotByUUID(chi.URLParam(r, "uuid"))
+		if err != nil {
+			log.Error("failed to find pot", sl.Err(err))
+
+			if errors.Is(err, repository.ErrPotNotFound) {
+				render.JSON(w, r, jackpotError(err))
+
+				return
+			}
+
+			render.JSON(w, r, resp.Error("failed to find pot", http.StatusInternalServerError))
+
+			return
+		}
+
+		deposits, err = j.jackpotRep.GetDepositsByPotIDs([]int64{pot.ID})
+		if err == nil && pot.Status == config.PotDrawn {
+			pf, err = j.provablyFairRepo.FindProvablyFairByGame(pot.ID, config.Jackpot)
+		}
+
+		if err != nil {
+			log.Error("failed to load pot", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to load pot", http.StatusInternalServerError))
+
+			return
+		}
+
+		if pf != nil {
+			fairness = &Fairness{
+				ClientSeed:   pf.ClientSeed,
+				ServerSeed:   pf.ServerSeed,
+				ResultedHash: pf.ResultedHash,
+				Nonce:        pf.Nonce,
+				Result:       pf.ResultedRandomNumber,
+				Min:          pf.Min,
+				Max:          pf.Max,
+			}
+		}
+
+		render.JSON(w, r, ShowResponse{
+			Response: resp.OK(),
+			Pot:      withDeposits(potView(*pot), deposits),
+			Fairness: fairness,
+		})
+	}
+}
+
+func potView(pot model.JackpotPot) Pot {
+	view := Pot{
+		UUID:           pot.UUID,
+		Status:         pot.Status,
+		Total:          money.Cents(pot.Total),
+		Tickets:        pot.Total,
+		WinningTicket:  pot.WinningTicket,
+		WinnerID:       pot.WinnerID,
+		Payout:         money.Cents(pot.Payout),
+		Rake:           money.Cents(pot.Rake),
+		ServerSeedHash: pot.ServerSeedHash,
+		EndsAt:         pot.EndsAt,
+		DrawnAt:        pot.DrawnAt,
+	}
+
+	if pot.Status != config.PotOpen {
+		view.ServerSeed = pot.ServerSeed
+	}
+
+	return view
+}
+
+func depositView(deposit model.JackpotDeposit) Deposit {
+	return Deposit{
+		UserID:     deposit.UserID,
+		Amount:     money.Cents(deposit.Amount),
+		TicketFrom: deposit.TicketFrom,
+		TicketTo:   deposit.TicketTo,
+		CreatedAt:  deposit.CreatedAt,
+	}
+}
+
+func withDeposits(view Pot, deposits []model.JackpotDeposit) Pot {
+	view.Deposits = make([]Deposit, 0, len(deposits))
+	for _, deposit := range deposits {
+		view.Deposits = append(view.Deposits, depositView(deposit))
+	}
+
+	return view
+}
+
+// queryInt reads a positive query parameter, max 0 means unbounded.
+func queryInt(r *http.Request, name string, def int, min int, max int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return def
+	}
+
+	if value < min {
+		return min
+	}
+
+	if max > 0 && value > max {
+		return max
+	}
+
+	return value
+}
@@ -0,0 +1,611 @@
+package pot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/escrow"
+	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/handlers/job"
+	"go-outpost/internal/api/http-server/handlers/provably_fair"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	// drawGrace leaves deposits that were accepted right before the end of a
+	// pot the time to be recorded before the draw.
+	drawGrace = 2 * time.Second
+
+	// drawTimeout is how long a draw waits for such deposits before the pot
+	// is refunded instead.
+	drawTimeout = 30 * time.Second
+)
+
+type DepositRequest struct {
+	UserUUID string      `json:"user_uuid" validate:"required"`
+	Amount   money.Money `json:"amount" validate:"required,min=1"`
+}
+
+type DepositResponse struct {
+	resp.Response
+	Pot     Pot     `json:"pot"`
+	Deposit Deposit `json:"deposit"`
+}
+
+var (
+	ErrNoBalance           = errors.New("user has no balance")
+	ErrInsufficientBalance = errors.New("user has insufficient balance")
+	ErrPotClosed           = errors.New("jackpot pot is closed, deposit again")
+)
+
+type Jackpot struct {
+	log              *slog.Logger
+	validator        *validator.Validate
+	jackpotRep       repository.JackpotRepository
+	userRep          repository.UserRepository
+	provablyFairRepo repository.ProvablyFairRepository
+	escrow           *escrow.Escrow
+	transaction      repository.Transaction
+	provablyFair     *provably_fair.ProvablyFair
+	event            *event.PusherEvent
+	rules            config.JackpotRules
+	limits           config.Limits
+}
+
+func NewJackpot(
+	log *slog.Logger,
+	jackpotRep repository.JackpotRepository,
+	userRep repository.UserRepository,
+	provablyFairRepo repository.ProvablyFairRepository,
+	escrow *escrow.Escrow,
+	transaction repository.Transaction,
+	provablyFair *provably_fair.ProvablyFair,
+	eventClient *event.PusherEvent,
+	rules config.JackpotRules,
+	limits config.Limits) *Jackpot {
+	return &Jackpot{
+		log:              log,
+		validator:        money.WithValidation(validator.New()),
+		jackpotRep:       jackpotRep,
+		userRep:          userRep,
+		provablyFairRepo: provablyFairRepo,
+		escrow:           escrow,
+		transaction:      transaction,
+		provablyFair:     provablyFair,
+		event:            eventClient,
+		rules:            rules,
+		limits:           limits,
+	}
+}
+
+// Deposit handles POST /jackpot/deposit. The first deposit opens a pot and
+// starts its timer, the stake is held until the pot is drawn.
+func (j *Jackpot) Deposit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.jackpot.pot.Deposit"
+
+		var (
+			err     error
+			req     DepositRequest
+			log     *slog.Logger
+			user    *model.User
+			pot     *model.JackpotPot
+			deposit *model.JackpotDeposit
+		)
+
+		log = j.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = j.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		user, err = j.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		pot, deposit, err = j.deposit(user.ID, int(req.Amount.Minor()))
+		if err != nil {
+			log.Error("failed to deposit into jackpot", sl.Err(err))
+
+			render.JSON(w, r, jackpotError(err))
+
+			return
+		}
+
+		log.Info("jackpot deposit placed", slog.Int64("pot_id", pot.ID), slog.Int64("deposit_id", deposit.ID))
+
+		render.JSON(w, r, DepositResponse{
+			Response: resp.OK(),
+			Pot:      potView(*pot),
+			Deposit:  depositView(*deposit),
+		})
+	}
+}
+
+// deposit places a stake on the open pot, a pot is opened when there is none.
+// The pot stays locked while the deposit is checked against the cap, its
+// stake held and its tickets sold, all on one transaction.
+func (j *Jackpot) deposit(userID int64, amount int) (*model.JackpotPot, *model.JackpotDeposit, error) {
+	const op = "handlers.jackpot.pot.deposit"
+
+	var (
+		err         error
+		tx          *sql.Tx
+		stakes      *escrow.Tx
+		rep         *repository.JackpotRepository
+		pot         *model.JackpotPot
+		opened      bool
+		deposits    []model.JackpotDeposit
+		userBalance *model.UserBalance
+		total       int
+		added       bool
+	)
+
+	if err = j.limits.CheckStake(config.BetType(config.Jackpot), amount); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err = j.transaction.StartTransaction()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stakes = j.escrow.WithTx(tx)
+	defer stakes.Rollback()
+
+	rep = j.jackpotRep.WithTx(tx)
+
+	pot, opened, err = j.openPot(rep)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deposits, err = rep.GetDepositsByPotIDs([]int64{pot.ID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deposited := 0
+	for _, s := range shares(deposits) {
+		if s.userID == userID {
+			deposited = s.amount
+		}
+	}
+
+	if err = j.rules.CheckDeposit(amount, deposited); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userBalance, err = j.userRep.FindUserBalanceByID(userID, money.Default)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if userBalance == nil || userBalance.Balance.IsNegative() {
+		return nil, nil, fmt.Errorf("%s: %w", op, ErrNoBalance)
+	}
+
+	if !userBalance.Balance.Covers(money.Cents(amount)) {
+		return nil, nil, fmt.Errorf("%s: %w", op, ErrInsufficientBalance)
+	}
+
+	if _, err = stakes.Hold(userID, amount, config.Jackpot, pot.ID); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	total, added, err = rep.AddTickets(pot.ID, amount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !added {
+		return nil, nil, fmt.Errorf("%s: %w", op, ErrPotClosed)
+	}
+
+	pot.Total = total
+
+	deposit := &model.JackpotDeposit{
+		PotID:      pot.ID,
+		UserID:     userID,
+		Amount:     amount,
+		TicketFrom: total - amount,
+		TicketTo:   total - 1,
+		CreatedAt:  time.Now(),
+	}
+
+	deposit.ID, err = rep.SaveDeposit(*deposit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if opened {
+		j.log.Info("jackpot pot opened", slog.Int64("pot_id", pot.ID))
+
+		job.Dispatch(&PotDrawJob{Jackpot: j, PotID: pot.ID}, j.rules.Duration+drawGrace)
+	}
+
+	j.sendPotState(*pot, append(deposits, *deposit))
+
+	return pot, deposit, nil
+}
+
+// openPot locks the pot taking deposits, a new one is opened when there is
+// none and reported so its draw gets scheduled once it is stored. When
+// another deposit opened a pot first that one is used.
+func (j *Jackpot) openPot(rep *repository.JackpotRepository) (*model.JackpotPot, bool, error) {
+	pot, err := rep.LockOpenPot()
+	if err != nil || pot != nil {
+		return pot, false, err
+	}
+
+	serverSeed, err := provably_fair.NewServerSeed()
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+
+	pot = &model.JackpotPot{
+		UUID:           uuid.New().String(),
+		Status:         config.PotOpen,
+		ServerSeed:     serverSeed,
+		ServerSeedHash: provably_fair.HashServerSeed(serverSeed),
+		EndsAt:         now.Add(j.rules.Duration),
+		CreatedAt:      now,
+	}
+
+	pot.ID, err = rep.SavePot(*pot)
+	if errors.Is(err, repository.ErrPotAlreadyOpen) {
+		pot, err = rep.LockOpenPot()
+		if err == nil && pot == nil {
+			err = ErrPotClosed
+		}
+
+		return pot, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return pot, true, nil
+}
+
+// Draw closes a pot once its timer is over. One ticket drawn over all tickets
+// sold picks the winner, who gets the pot minus the rake. A pot with fewer
+// than two players is refunded. Closed pots are left alone. The pot is
+// closed, its stakes released, the winner paid and the draw stored on one
+// transaction.
+func (j *Jackpot) Draw(potID int64) error {
+	const op = "handlers.jackpot.pot.Draw"
+
+	var (
+		err      error
+		pot      *model.JackpotPot
+		deposits []model.JackpotDeposit
+		tx       *sql.Tx
+		stakes   *escrow.Tx
+		rep      *repository.JackpotRepository
+		draws    *provably_fair.Draws
+		closed   bool
+		drawID   int64
+		winner   *model.User
+	)
+
+	log := j.log.With(
+		slog.String("op", op),
+		slog.Int64("pot_id", potID),
+	)
+
+	pot, err = j.jackpotRep.FindPotByID(potID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if pot.Status != config.PotOpen {
+		return nil
+	}
+
+	if wait := time.Until(pot.EndsAt); wait > 0 {
+		job.Dispatch(&PotDrawJob{Jackpot: j, PotID: pot.ID}, wait+drawGrace)
+
+		return nil
+	}
+
+	deposits, err = j.jackpotRep.GetDepositsByPotIDs([]int64{pot.ID})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sold(deposits) != pot.Total {
+		if time.Since(pot.EndsAt) < drawTimeout {
+			log.Warn("jackpot deposits still being recorded, draw postponed")
+
+			job.Dispatch(&PotDrawJob{Jackpot: j, PotID: pot.ID}, drawGrace)
+
+			return nil
+		}
+
+		log.Error("jackpot tickets do not match the deposits, refunding",
+			slog.Int("tickets", pot.Total), slog.Int("deposits", sold(deposits)))
+
+		return j.refund(pot, deposits)
+	}
+
+	if len(shares(deposits)) < 2 {
+		return j.refund(pot, deposits)
+	}
+
+	data := provably_fair.Draw(pot.ServerSeed, pot.UUID, 0, pot.Total)
+
+	ticket := int(data.Result)
+	holder := ticketHolder(deposits, ticket)
+	now := time.Now()
+
+	pot.Status = config.PotDrawn
+	pot.WinningTicket = &ticket
+	pot.WinnerID = &holder.UserID
+	pot.Payout, pot.Rake = j.rules.Split(pot.Total)
+	pot.DrawnAt = &now
+
+	tx, err = j.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stakes = j.escrow.WithTx(tx)
+	defer stakes.Rollback()
+
+	rep = j.jackpotRep.WithTx(tx)
+
+	closed, err = j.lockPot(rep, *pot)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if closed {
+		closed, err = rep.ClosePot(*pot)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !closed {
+		return nil
+	}
+
+	if _, err = stakes.Release(config.Jackpot, pot.ID, holder.UserID, pot.Payout); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Pay(holder.UserID, pot.Payout, config.Jackpot); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	draws = j.provablyFair.WithTx(tx)
+
+	drawID, err = draws.StoreGameDraw(pot.ID, config.Jackpot)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = draws.StoreProvablyFair(data, drawID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("jackpot pot drawn", slog.Int("ticket", ticket), slog.Int64("winner_id", holder.UserID))
+
+	winner, err = j.userRep.GetUserByID(holder.UserID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = j.event.Trigger(events.JackpotDrawn{
+		UUID:          pot.UUID,
+		WinningTicket: ticket,
+		WinnerUUID:    winner.UUID,
+		Payout:        money.Cents(pot.Payout),
+		ServerSeed:    pot.ServerSeed,
+	}); err != nil {
+		log.Error("failed to send jackpot drawn event", sl.Err(err))
+	}
+
+	j.sendPotState(*pot, deposits)
+
+	return nil
+}
+
+// DrawStale draws the pots whose timer ran out while the server was down,
+// their jobs were lost with it. It returns the number of pots closed.
+func (j *Jackpot) DrawStale() (int, error) {
+	const op = "handlers.jackpot.pot.DrawStale"
+
+	pots, err := j.jackpotRep.GetStalePots()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, pot := range pots {
+		if err = j.Draw(pot.ID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return len(pots), nil
+}
+
+// refund closes a pot and gives the stakes back on one transaction.
+func (j *Jackpot) refund(pot *model.JackpotPot, deposits []model.JackpotDeposit) error {
+	const op = "handlers.jackpot.pot.refund"
+
+	var (
+		err    error
+		tx     *sql.Tx
+		stakes *escrow.Tx
+		rep    *repository.JackpotRepository
+		closed bool
+	)
+
+	now := time.Now()
+
+	pot.Status = config.PotRefunded
+	pot.DrawnAt = &now
+
+	tx, err = j.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stakes = j.escrow.WithTx(tx)
+	defer stakes.Rollback()
+
+	rep = j.jackpotRep.WithTx(tx)
+
+	closed, err = j.lockPot(rep, *pot)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if closed {
+		closed, err = rep.ClosePot(*pot)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !closed {
+		return nil
+	}
+
+	if _, err = stakes.Refund(config.Jackpot, pot.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = stakes.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	j.log.Info("jackpot pot refunded", slog.Int64("pot_id", pot.ID))
+
+	j.sendPotState(*pot, deposits)
+
+	return nil
+}
+
+// lockPot locks a pot before it is closed. It reports false when the pot no
+// longer holds the tickets it is closed on, a deposit got in since it was
+// read, and the draw is run again.
+func (j *Jackpot) lockPot(rep *repository.JackpotRepository, pot model.JackpotPot) (bool, error) {
+	locked, err := rep.LockPotByID(pot.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if locked.Status == config.PotOpen && locked.Total != pot.Total {
+		j.log.Warn("jackpot pot changed before the draw, draw postponed", slog.Int64("pot_id", pot.ID))
+
+		job.Dispatch(&PotDrawJob{Jackpot: j, PotID: pot.ID}, drawGrace)
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (j *Jackpot) sendPotState(pot model.JackpotPot, deposits []model.JackpotDeposit) {
+	entries := make([]events.JackpotEntry, 0)
+
+	for _, s := range shares(deposits) {
+		user, err := j.userRep.GetUserByID(s.userID)
+		if err != nil {
+			j.log.Error("failed to find jackpot player", sl.Err(err), slog.Int64("user_id", s.userID))
+
+			return
+		}
+
+		entries = append(entries, events.JackpotEntry{
+			UserUUID: user.UUID,
+			Amount:   money.Cents(s.amount),
+			Chance:   chance(s.amount, pot.Total),
+		})
+	}
+
+	if err := j.event.Trigger(events.JackpotPotUpdated{
+		UUID:           pot.UUID,
+		Status:         pot.Status,
+		Total:          money.Cents(pot.Total),
+		EndsAt:         pot.EndsAt,
+		Entries:        entries,
+		ServerSeedHash: pot.ServerSeedHash,
+	}); err != nil {
+		j.log.Error("failed to send jackpot pot event", sl.Err(err))
+	}
+}
+
+// chance is the percentage of the tickets held.
+func chance(amount int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(amount) * 100 / float64(total)
+}
+
+func jackpotError(err error) resp.Response {
+	switch {
+	case errors.Is(err, repository.ErrPotNotFound):
+		return resp.ErrorCode("failed to find pot", http.StatusNotFound, "pot_not_found")
+	case errors.Is(err, ErrPotClosed):
+		return resp.ErrorCode(ErrPotClosed.Error(), http.StatusConflict, "pot_closed")
+	case errors.Is(err, config.ErrStakeOutOfRange):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range")
+	case errors.Is(err, ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, ErrInsufficientBalance), errors.Is(err, repository.ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to process jackpot", http.StatusInternalServerError)
+}
@@ -0,0 +1,50 @@
+package pot
+
+import "go-outpost/internal/api/http-server/model"
+
+// share is the part of one player in a pot, in first deposit order.
+type share struct {
+	userID int64
+	amount int
+}
+
+// ticketHolder returns the deposit holding the ticket, nil when no deposit
+// covers it.
+func ticketHolder(deposits []model.JackpotDeposit, ticket int) *model.JackpotDeposit {
+	for i := range deposits {
+		if ticket >= deposits[i].TicketFrom && ticket <= deposits[i].TicketTo {
+			return &deposits[i]
+		}
+	}
+
+	return nil
+}
+
+// shares adds up the deposits of each player.
+func shares(deposits []model.JackpotDeposit) []share {
+	index := make(map[int64]int)
+	result := make([]share, 0)
+
+	for _, deposit := range deposits {
+		i, ok := index[deposit.UserID]
+		if !ok {
+			i = len(result)
+			index[deposit.UserID] = i
+			result = append(result, share{userID: deposit.UserID})
+		}
+
+		result[i].amount += deposit.Amount
+	}
+
+	return result
+}
+
+// sold is the number of tickets covered by the deposits.
+func sold(deposits []model.JackpotDeposit) int {
+	total := 0
+	for _, deposit := range deposits {
+		total += deposit.Amount
+	}
+
+	return total
+}
@@ -0,0 +1,42 @@
+package pot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-outpost/internal/api/http-server/model"
+	"testing"
+)
+
+func TestTicketHolder(t *testing.T) {
+	deposits := []model.JackpotDeposit{
+		{ID: 1, UserID: 10, Amount: 100, TicketFrom: 0, TicketTo: 99},
+		{ID: 2, UserID: 20, Amount: 50, TicketFrom: 100, TicketTo: 149},
+		{ID: 3, UserID: 10, Amount: 250, TicketFrom: 150, TicketTo: 399},
+	}
+
+	tests := []struct {
+		name   string
+		ticket int
+		want   int64
+	}{
+		{name: "first ticket", ticket: 0, want: 1},
+		{name: "end of a range", ticket: 99, want: 1},
+		{name: "start of a range", ticket: 100, want: 2},
+		{name: "last ticket", ticket: 399, want: 3},
+		{name: "unsold ticket", ticket: 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holder := ticketHolder(deposits, tt.ticket)
+			if tt.want == 0 {
+				assert.Nil(t, holder)
+
+				return
+			}
+
+			assert.Equal(t, tt.want, holder.ID)
+		})
+	}
+
+	assert.Equal(t, []share{{userID: 10, amount: 350}, {userID: 20, amount: 50}}, shares(deposits))
+	assert.Equal(t, 400, sold(deposits))
+}
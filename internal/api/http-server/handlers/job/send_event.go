@@ -8,13 +8,7 @@ type SendEventJob struct {
 }
 
 func (job *SendEventJob) Execute() {
-	message := event.Message{
-		Channel: job.EventMessage.Channel,
-		Event:   job.EventMessage.Event,
-		Data:    job.EventMessage.Data,
-	}
-
-	err := job.Event.TriggerEvent(message)
+	err := job.Event.TriggerEvent(job.EventMessage)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,299 @@
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// Recorder takes settled bets into the leaderboards. Games call it once per
+// bet with the stake and what was paid back, zero for a lost bet. Refunded
+// bets are not recorded. A failure is logged and never fails the bet.
+type Recorder interface {
+	Record(userID int64, game config.Game, amount money.Money, payout money.Money)
+}
+
+// Scores keeps the leaderboard rows, one per player, game and window.
+type Scores interface {
+	AddBet(userID int64, game config.Game, amount int, payout int, at time.Time) error
+	GetRanking(
+		metric config.LeaderboardMetric,
+		period config.LeaderboardPeriod,
+		start time.Time,
+		game config.Game,
+		limit int,
+		offset int,
+	) ([]model.LeaderboardRank, error)
+	CountRanked(period config.LeaderboardPeriod, start time.Time, game config.Game) (int, error)
+	FindRank(
+		userID int64,
+		metric config.LeaderboardMetric,
+		period config.LeaderboardPeriod,
+		start time.Time,
+		game config.Game,
+	) (*model.LeaderboardRank, error)
+}
+
+type UserFinder interface {
+	FindUserByUUID(uuid string) (*model.User, error)
+}
+
+// Rank is the place of a player as listed.
+type Rank struct {
+	Rank     int         `json:"rank"`
+	UserUUID string      `json:"user_uuid"`
+	Score    money.Money `json:"score"`
+	Bets     int         `json:"bets"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Metric      config.LeaderboardMetric `json:"metric"`
+	Period      config.LeaderboardPeriod `json:"period"`
+	Game        config.Game              `json:"game,omitempty"`
+	PeriodStart time.Time                `json:"period_start"`
+	Ranking     []Rank                   `json:"ranking"`
+	Page        int                      `json:"page"`
+	PerPage     int                      `json:"per_page"`
+	Total       int                      `json:"total"`
+}
+
+type RankResponse struct {
+	resp.Response
+	Metric      config.LeaderboardMetric `json:"metric"`
+	Period      config.LeaderboardPeriod `json:"period"`
+	Game        config.Game              `json:"game,omitempty"`
+	PeriodStart time.Time                `json:"period_start"`
+	Rank        *Rank                    `json:"rank"`
+}
+
+type Leaderboard struct {
+	log            *slog.Logger
+	leaderboardRep Scores
+	userRep        UserFinder
+	rates          money.Rates
+	now            func() time.Time
+}
+
+func NewLeaderboard(
+	log *slog.Logger,
+	leaderboardRep repository.LeaderboardRepository,
+	userRep repository.UserRepository,
+	rates money.Rates) *Leaderboard {
+	return &Leaderboard{
+		log:            log,
+		leaderboardRep: &leaderboardRep,
+		userRep:        &userRep,
+		rates:          rates,
+		now:            time.Now,
+	}
+}
+
+// Record counts a settled bet in the daily, weekly, monthly and all-time
+// leaderboards of its game. Scores are kept in the Default currency, bets in
+// other currencies count at the current rates.
+func (l *Leaderboard) Record(userID int64, game config.Game, amount money.Money, payout money.Money) {
+	const op = "handlers.leaderboard.Record"
+
+	var err error
+
+	if amount, err = l.rates.Convert(amount, money.Default); err == nil {
+		payout, err = l.rates.Convert(payout, money.Default)
+	}
+
+	if err == nil {
+		err = l.leaderboardRep.AddBet(userID, game, int(amount.Minor()), int(payout.Minor()), l.now())
+	}
+
+	if err != nil {
+		l.log.Error("failed to record leaderboard bet", sl.Err(fmt.Errorf("%s: %w", op, err)),
+			slog.Int64("user_id", userID), slog.String("game", string(game)))
+	}
+}
+
+// List handles GET /leaderboards/{metric}?period=&game=&page=&per_page=. The
+// period defaults to daily, no game ranks on every game.
+func (l *Leaderboard) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.leaderboard.List"
+
+		var (
+			err     error
+			start   time.Time
+			total   int
+			ranking []model.LeaderboardRank
+		)
+
+		log := l.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		metric, period, game := board(r)
+
+		page := queryInt(r, "page", 1, 1, 0)
+		perPage := queryInt(r, "per_page", defaultPerPage, 1, maxPerPage)
+
+		start, err = periodStart(metric, period, l.now())
+		if err == nil {
+			total, err = l.leaderboardRep.CountRanked(period, start, game)
+		}
+		if err == nil {
+			ranking, err = l.leaderboardRep.GetRanking(metric, period, start, game, perPage, (page-1)*perPage)
+		}
+		if err != nil {
+			log.Error("failed to load leaderboard", sl.Err(err))
+
+			render.JSON(w, r, leaderboardError(err))
+
+			return
+		}
+
+		views := make([]Rank, 0, len(ranking))
+		for _, rank := range ranking {
+			views = append(views, rankView(rank))
+		}
+
+		render.JSON(w, r, ListResponse{
+			Response:    resp.OK(),
+			Metric:      metric,
+			Period:      period,
+			Game:        game,
+			PeriodStart: start,
+			Ranking:     views,
+			Page:        page,
+			PerPage:     perPage,
+			Total:       total,
+		})
+	}
+}
+
+// Rank handles GET /leaderboards/{metric}/me?user_uuid=&period=&game=. The
+// rank is null while the player has no bet in the window.
+func (l *Leaderboard) Rank() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.leaderboard.Rank"
+
+		var (
+			err   error
+			start time.Time
+			user  *model.User
+			rank  *model.LeaderboardRank
+			view  *Rank
+		)
+
+		log := l.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = l.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		metric, period, game := board(r)
+
+		start, err = periodStart(metric, period, l.now())
+		if err == nil {
+			rank, err = l.leaderboardRep.FindRank(user.ID, metric, period, start, game)
+		}
+		if err != nil && !errors.Is(err, repository.ErrNotRanked) {
+			log.Error("failed to find leaderboard rank", sl.Err(err))
+
+			render.JSON(w, r, leaderboardError(err))
+
+			return
+		}
+
+		if rank != nil {
+			v := rankView(*rank)
+			view = &v
+		}
+
+		render.JSON(w, r, RankResponse{
+			Response:    resp.OK(),
+			Metric:      metric,
+			Period:      period,
+			Game:        game,
+			PeriodStart: start,
+			Rank:        view,
+		})
+	}
+}
+
+func board(r *http.Request) (config.LeaderboardMetric, config.LeaderboardPeriod, config.Game) {
+	period := config.LeaderboardPeriod(r.URL.Query().Get("period"))
+	if period == "" {
+		period = config.Daily
+	}
+
+	return config.LeaderboardMetric(chi.URLParam(r, "metric")), period, config.Game(r.URL.Query().Get("game"))
+}
+
+func periodStart(
+	metric config.LeaderboardMetric,
+	period config.LeaderboardPeriod,
+	now time.Time,
+) (time.Time, error) {
+	if err := config.CheckLeaderboard(metric, period); err != nil {
+		return time.Time{}, err
+	}
+
+	return config.PeriodStart(period, now)
+}
+
+func rankView(rank model.LeaderboardRank) Rank {
+	return Rank{
+		Rank:     rank.Rank,
+		UserUUID: rank.UserUUID,
+		Score:    money.Cents(rank.Score),
+		Bets:     rank.Bets,
+	}
+}
+
+func leaderboardError(err error) resp.Response {
+	if errors.Is(err, config.ErrInvalidLeaderboard) {
+		return resp.ErrorCode("unknown leaderboard metric or period", http.StatusBadRequest, "invalid_leaderboard")
+	}
+
+	return resp.Error("failed to load leaderboard", http.StatusInternalServerError)
+}
+
+func queryInt(r *http.Request, name string, def int, min int, max int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return def
+	}
+
+	if value < min {
+		return min
+	}
+
+	if max > 0 && value > max {
+		return max
+	}
+
+	return value
+}
@@ -0,0 +1,288 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/lib/logger/handler/slogdiscard"
+	"go-outpost/internal/lib/money"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type bet struct {
+	userID int64
+	game   config.Game
+	amount int
+	payout int
+	at     time.Time
+}
+
+type query struct {
+	metric config.LeaderboardMetric
+	period config.LeaderboardPeriod
+	start  time.Time
+	game   config.Game
+	limit  int
+	offset int
+}
+
+// scores records what the handler asks of the repository and answers with a
+// fixed ranking.
+type scores struct {
+	bets    []bet
+	queries []query
+	ranking []model.LeaderboardRank
+}
+
+func (s *scores) AddBet(userID int64, game config.Game, amount int, payout int, at time.Time) error {
+	s.bets = append(s.bets, bet{userID: userID, game: game, amount: amount, payout: payout, at: at})
+
+	return nil
+}
+
+func (s *scores) GetRanking(
+	metric config.LeaderboardMetric,
+	period config.LeaderboardPeriod,
+	start time.Time,
+	game config.Game,
+	limit int,
+	offset int,
+) ([]model.LeaderboardRank, error) {
+	s.queries = append(s.queries, query{metric: metric, period: period, start: start, game: game, limit: limit,
+		offset: offset})
+
+	return s.ranking, nil
+}
+
+func (s *scores) CountRanked(config.LeaderboardPeriod, time.Time, config.Game) (int, error) {
+	return len(s.ranking), nil
+}
+
+func (s *scores) FindRank(
+	userID int64,
+	metric config.LeaderboardMetric,
+	period config.LeaderboardPeriod,
+	start time.Time,
+	game config.Game,
+) (*model.LeaderboardRank, error) {
+	s.queries = append(s.queries, query{metric: metric, period: period, start: start, game: game})
+
+	for _, rank := range s.ranking {
+		if rank.UserID == userID {
+			return &rank, nil
+		}
+	}
+
+	return nil, fmt.Errorf("repository.leaderboard.FindRank: %w", repository.ErrNotRanked)
+}
+
+type users map[string]*model.User
+
+func (u users) FindUserByUUID(uuid string) (*model.User, error) {
+	return u[uuid], nil
+}
+
+func newTestLeaderboard(t *testing.T, s *scores, now time.Time) *Leaderboard {
+	rates, err := money.NewRates(map[money.Currency]string{money.COIN: "0.01", money.BTC: "60000"})
+	require.NoError(t, err)
+
+	return &Leaderboard{
+		log:            slogdiscard.NewDiscardLogger(),
+		leaderboardRep: s,
+		userRep:        users{"alice": {ID: 1, UUID: "alice"}, "bob": {ID: 2, UUID: "bob"}},
+		rates:          rates,
+		now:            func() time.Time { return now },
+	}
+}
+
+func get(l *Leaderboard, target string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Get("/leaderboards/{metric}", l.List())
+	router.Get("/leaderboards/{metric}/me", l.Rank())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+	return w
+}
+
+func TestRecord(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		amount   money.Money
+		payout   money.Money
+		wantBets []bet
+	}{
+		{
+			name:     "won bet",
+			amount:   money.Cents(500),
+			payout:   money.Cents(1200),
+			wantBets: []bet{{userID: 1, game: config.Dice, amount: 500, payout: 1200, at: now}},
+		},
+		{
+			name:     "lost bet",
+			amount:   money.Cents(300),
+			payout:   money.Cents(0),
+			wantBets: []bet{{userID: 1, game: config.Dice, amount: 300, payout: 0, at: now}},
+		},
+		{
+			name:     "bet in another currency counts at the current rate",
+			amount:   money.New(100000, money.BTC),
+			payout:   money.New(250, money.COIN),
+			wantBets: []bet{{userID: 1, game: config.Dice, amount: 6000, payout: 250, at: now}},
+		},
+		{
+			name:   "currency without a rate is not counted",
+			amount: money.New(100, money.Currency("ETH")),
+			payout: money.Cents(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &scores{}
+
+			newTestLeaderboard(t, s, now).Record(1, config.Dice, tt.amount, tt.payout)
+
+			assert.Equal(t, tt.wantBets, s.bets)
+		})
+	}
+}
+
+// march returns midnight UTC of a day of March 2024.
+func march(day int) time.Time {
+	return time.Date(2024, time.March, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriodRanking(t *testing.T) {
+	thursday := time.Date(2024, time.March, 14, 18, 30, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	ranking := []model.LeaderboardRank{
+		{Rank: 1, UserID: 2, UserUUID: "bob", Score: 1500, Bets: 3},
+		{Rank: 2, UserID: 1, UserUUID: "alice", Score: 250, Bets: 1},
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		target    string
+		wantQuery query
+		wantCode  string
+	}{
+		{
+			name:      "daily by default",
+			now:       thursday,
+			target:    "/leaderboards/wagered",
+			wantQuery: query{metric: config.Wagered, period: config.Daily, start: march(14), limit: 20},
+		},
+		{
+			name:      "daily window is in utc",
+			now:       time.Date(2024, time.March, 15, 1, 0, 0, 0, thursday.Location()),
+			target:    "/leaderboards/profit?period=daily",
+			wantQuery: query{metric: config.Profit, period: config.Daily, start: march(14), limit: 20},
+		},
+		{
+			name:   "weekly window starts on monday",
+			now:    thursday,
+			target: "/leaderboards/biggest_win?period=weekly&game=dice",
+			wantQuery: query{metric: config.BiggestWin, period: config.Weekly, start: march(11),
+				game: config.Dice, limit: 20},
+		},
+		{
+			name:   "monthly page",
+			now:    thursday,
+			target: "/leaderboards/wagered?period=monthly&page=3&per_page=10",
+			wantQuery: query{metric: config.Wagered, period: config.Monthly, start: march(1),
+				limit: 10, offset: 20},
+		},
+		{
+			name:      "all time with the page size capped",
+			now:       thursday,
+			target:    "/leaderboards/wagered?period=all_time&per_page=500",
+			wantQuery: query{metric: config.Wagered, period: config.AllTime, start: time.Unix(0, 0).UTC(), limit: 100},
+		},
+		{
+			name:     "unknown period",
+			now:      thursday,
+			target:   "/leaderboards/wagered?period=yearly",
+			wantCode: "invalid_leaderboard",
+		},
+		{
+			name:     "unknown metric",
+			now:      thursday,
+			target:   "/leaderboards/losses",
+			wantCode: "invalid_leaderboard",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &scores{ranking: ranking}
+
+			w := get(newTestLeaderboard(t, s, tt.now), tt.target)
+
+			var res ListResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, res.Code)
+				assert.Empty(t, s.queries)
+
+				return
+			}
+
+			assert.Equal(t, []query{tt.wantQuery}, s.queries)
+
+			assert.Equal(t, http.StatusOK, res.Status)
+			assert.Equal(t, []Rank{
+				{Rank: 1, UserUUID: "bob", Score: money.Cents(1500), Bets: 3},
+				{Rank: 2, UserUUID: "alice", Score: money.Cents(250), Bets: 1},
+			}, res.Ranking)
+			assert.Equal(t, len(ranking), res.Total)
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		ranking  []model.LeaderboardRank
+		target   string
+		wantRank *Rank
+	}{
+		{
+			name:     "ranked player",
+			ranking:  []model.LeaderboardRank{{Rank: 4, UserID: 1, UserUUID: "alice", Score: 700, Bets: 2}},
+			target:   "/leaderboards/wagered/me?user_uuid=alice&period=weekly",
+			wantRank: &Rank{Rank: 4, UserUUID: "alice", Score: money.Cents(700), Bets: 2},
+		},
+		{
+			name:    "player without a bet in the window",
+			ranking: []model.LeaderboardRank{{Rank: 1, UserID: 2, UserUUID: "bob", Score: 700, Bets: 2}},
+			target:  "/leaderboards/wagered/me?user_uuid=alice&period=weekly",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &scores{ranking: tt.ranking}
+
+			w := get(newTestLeaderboard(t, s, now), tt.target)
+
+			var res RankResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+			assert.Equal(t, http.StatusOK, res.Status)
+			assert.Equal(t, tt.wantRank, res.Rank)
+			assert.True(t, march(11).Equal(res.PeriodStart))
+		})
+	}
+}
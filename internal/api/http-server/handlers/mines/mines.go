@@ -0,0 +1,495 @@
+package mines
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	engine "go-outpost/internal/game"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"time"
+)
+
+// StartRequest opens a game staked in Currency, the default one when none is
+// given.
+type StartRequest struct {
+	UserUUID string         `json:"user_uuid" validate:"required"`
+	Amount   money.Amount   `json:"amount" validate:"required"`
+	Currency money.Currency `json:"currency"`
+	Mines    int            `json:"mines" validate:"required,min=1"`
+}
+
+type RevealRequest struct {
+	UserUUID string `json:"user_uuid" validate:"required"`
+	Tile     *int   `json:"tile" validate:"required,min=0"`
+}
+
+type CashOutRequest struct {
+	UserUUID string `json:"user_uuid" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	Game Game `json:"game"`
+}
+
+// Game is the player view of a game. Board lists the mines and is only sent
+// once the game is over.
+type Game struct {
+	UUID           string             `json:"uuid"`
+	Currency       money.Currency     `json:"currency"`
+	Amount         money.Money        `json:"amount"`
+	Mines          int                `json:"mines"`
+	Revealed       []int              `json:"revealed"`
+	Status         config.MinesStatus `json:"status"`
+	Multiplier     float64            `json:"multiplier"`
+	NextMultiplier float64            `json:"next_multiplier,omitempty"`
+	Payout         money.Money        `json:"payout"`
+	Board          []int              `json:"board,omitempty"`
+	ClientSeed     string             `json:"client_seed"`
+	ServerSeedHash string             `json:"server_seed_hash"`
+	Nonce          int                `json:"nonce"`
+}
+
+var (
+	ErrGameInProgress = errors.New("user has a mines game in progress")
+	ErrMoveConflict   = errors.New("mines game changed, retry the move")
+)
+
+// Mines stores the games and serves the moves, the rules are the mines
+// engine and the stake, draw and payout go through the game player.
+type Mines struct {
+	log       *slog.Logger
+	validator *validator.Validate
+	minesRep  repository.MinesRepository
+	userRep   repository.UserRepository
+	rules     *engine.Mines
+	player    *engine.Player
+}
+
+func NewMines(
+	log *slog.Logger,
+	minesRep repository.MinesRepository,
+	userRep repository.UserRepository,
+	rules *engine.Mines,
+	player *engine.Player) *Mines {
+	return &Mines{
+		log:       log,
+		validator: money.WithValidation(validator.New()),
+		minesRep:  minesRep,
+		userRep:   userRep,
+		rules:     rules,
+		player:    player,
+	}
+}
+
+// Start handles POST /mines/games. The stake is debited and the board drawn
+// from the next nonce of the player's seed pair.
+func (m *Mines) Start() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.mines.Start"
+
+		var (
+			err    error
+			req    StartRequest
+			log    *slog.Logger
+			amount money.Money
+			user   *model.User
+			game   *model.MinesGame
+		)
+
+		log = m.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !m.decode(w, r, log, &req) {
+			return
+		}
+
+		if req.Currency == "" {
+			req.Currency = money.Default
+		}
+
+		amount, err = req.Amount.In(req.Currency)
+		if err == nil && amount.Minor() < 1 {
+			err = money.ErrInvalidAmount
+		}
+		if err != nil {
+			log.Error("invalid amount", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
+
+			return
+		}
+
+		user, err = m.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		game, err = m.start(user.ID, amount, req.Mines)
+		if err != nil {
+			log.Error("failed to start mines game", sl.Err(err))
+
+			render.JSON(w, r, m.minesError(err))
+
+			return
+		}
+
+		log.Info("mines game started", slog.Int64("game_id", game.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: m.view(game)})
+	}
+}
+
+// Active handles GET /mines/games/active?user_uuid= so a player can resume
+// the game left open by a disconnect or a restart.
+func (m *Mines) Active() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.mines.Active"
+
+		var (
+			err  error
+			log  *slog.Logger
+			user *model.User
+			game *model.MinesGame
+		)
+
+		log = m.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = m.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		game, err = m.minesRep.FindActiveMinesGame(user.ID)
+		if err != nil {
+			log.Error("failed to find mines game", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find mines game", http.StatusInternalServerError))
+
+			return
+		}
+
+		if game == nil {
+			render.JSON(w, r, resp.ErrorCode("no mines game in progress", http.StatusNotFound, "no_game"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: m.view(game)})
+	}
+}
+
+// Reveal handles POST /mines/games/{uuid}/reveal.
+func (m *Mines) Reveal() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.mines.Reveal"
+
+		var (
+			err  error
+			req  RevealRequest
+			log  *slog.Logger
+			game *model.MinesGame
+		)
+
+		log = m.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !m.decode(w, r, log, &req) {
+			return
+		}
+
+		game, err = m.findGame(chi.URLParam(r, "uuid"), req.UserUUID)
+		if err == nil {
+			err = m.move(game, func(game *model.MinesGame, now time.Time) error {
+				return m.rules.Reveal(game, *req.Tile, now)
+			})
+		}
+		if err != nil {
+			log.Error("failed to reveal tile", sl.Err(err))
+
+			render.JSON(w, r, m.minesError(err))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: m.view(game)})
+	}
+}
+
+// CashOut handles POST /mines/games/{uuid}/cashout.
+func (m *Mines) CashOut() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.mines.CashOut"
+
+		var (
+			err  error
+			req  CashOutRequest
+			log  *slog.Logger
+			game *model.MinesGame
+		)
+
+		log = m.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !m.decode(w, r, log, &req) {
+			return
+		}
+
+		game, err = m.findGame(chi.URLParam(r, "uuid"), req.UserUUID)
+		if err == nil {
+			err = m.move(game, func(game *model.MinesGame, now time.Time) error {
+				return m.rules.CashOut(game, now)
+			})
+		}
+		if err != nil {
+			log.Error("failed to cash out", sl.Err(err))
+
+			render.JSON(w, r, m.minesError(err))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Game: m.view(game)})
+	}
+}
+
+// HasActiveGame tells whether the player has a game whose board still depends
+// on the active seed pair.
+func (m *Mines) HasActiveGame(userID int64) (bool, error) {
+	game, err := m.minesRep.FindActiveMinesGame(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return game != nil, nil
+}
+
+func (m *Mines) start(userID int64, amount money.Money, mines int) (*model.MinesGame, error) {
+	const op = "handlers.mines.start"
+
+	var (
+		err    error
+		game   *model.MinesGame
+		params json.RawMessage
+	)
+
+	game, err = m.minesRep.FindActiveMinesGame(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if game != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrGameInProgress)
+	}
+
+	params, err = json.Marshal(engine.MinesChoice{Mines: mines})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = m.player.Start(config.Mines, userID, amount, params,
+		func(tx *sql.Tx, _ engine.Wager, result engine.Result, rng engine.RNG) (int64, error) {
+			game = &model.MinesGame{
+				UUID:           uuid.New().String(),
+				UserID:         userID,
+				Currency:       amount.Currency(),
+				Amount:         int(amount.Minor()),
+				Mines:          mines,
+				Board:          result.Value.(engine.MinesBoard).Mines,
+				Revealed:       []int{},
+				Status:         config.MinesActive,
+				ClientSeed:     rng.ClientSeed,
+				ServerSeedHash: rng.ServerSeedHash(),
+				Nonce:          rng.Nonce,
+				CreatedAt:      time.Now(),
+			}
+
+			id, err := m.minesRep.WithTx(tx).SaveMinesGame(*game)
+			game.ID = id
+
+			return id, err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return game, nil
+}
+
+// move applies a change to a game, stores it and pays the game out when it
+// ended with a cash out. Only the request that stored the move pays and
+// counts the ended game in the leaderboards.
+func (m *Mines) move(game *model.MinesGame, apply func(game *model.MinesGame, now time.Time) error) error {
+	const op = "handlers.mines.move"
+
+	revealedBefore := len(game.Revealed)
+
+	err := apply(game, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if game.Status == config.MinesCashedOut {
+		game.Payout, err = m.player.CapPayout(config.Mines, game.Currency, game.Payout)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// An ended game is stored on the transaction that pays it out, so only the
+	// request that stored the end pays.
+	update := func(minesRep *repository.MinesRepository) error {
+		stored, err := minesRep.UpdateMinesGame(*game, revealedBefore)
+		if err != nil {
+			return err
+		}
+
+		if !stored {
+			return ErrMoveConflict
+		}
+
+		return nil
+	}
+
+	if game.Status == config.MinesActive {
+		err = update(&m.minesRep)
+	} else {
+		err = m.player.Finish(config.Mines, game.UserID,
+			money.New(int64(game.Amount), game.Currency), money.New(int64(game.Payout), game.Currency),
+			func(tx *sql.Tx) error {
+				return update(m.minesRep.WithTx(tx))
+			})
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (m *Mines) findGame(gameUUID string, userUUID string) (*model.MinesGame, error) {
+	user, err := m.userRep.FindUserByUUID(userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	game, err := m.minesRep.FindMinesGameByUUID(gameUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil || game.UserID != user.ID {
+		return nil, repository.ErrMinesGameNotFound
+	}
+
+	return game, nil
+}
+
+func (m *Mines) decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+		return false
+	}
+
+	if err := m.validator.Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+
+		log.Error("invalid request", sl.Err(err))
+
+		render.JSON(w, r, resp.ValidationError(validateErr))
+
+		return false
+	}
+
+	return true
+}
+
+func (m *Mines) minesError(err error) resp.Response {
+	switch {
+	case errors.Is(err, repository.ErrMinesGameNotFound):
+		return resp.ErrorCode("failed to find mines game", http.StatusNotFound, "game_not_found")
+	case errors.Is(err, ErrGameInProgress):
+		return resp.ErrorCode(ErrGameInProgress.Error(), http.StatusConflict, "game_in_progress")
+	case errors.Is(err, engine.ErrMinesOver):
+		return resp.ErrorCode(engine.ErrMinesOver.Error(), http.StatusConflict, "game_over")
+	case errors.Is(err, ErrMoveConflict):
+		return resp.ErrorCode(ErrMoveConflict.Error(), http.StatusConflict, "move_conflict")
+	case errors.Is(err, engine.ErrNoReveal):
+		return resp.ErrorCode(engine.ErrNoReveal.Error(), http.StatusConflict, "no_reveal")
+	case errors.Is(err, engine.ErrInvalidTile):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_tile")
+	case errors.Is(err, engine.ErrInvalidBet):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_mines")
+	case errors.Is(err, config.ErrCurrencyNotAccepted):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "currency_not_accepted")
+	case errors.Is(err, config.ErrStakeOutOfRange):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range")
+	case errors.Is(err, engine.ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, engine.ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to play mines", http.StatusInternalServerError)
+}
+
+func (m *Mines) view(game *model.MinesGame) Game {
+	view := Game{
+		UUID:           game.UUID,
+		Currency:       game.Currency,
+		Amount:         money.New(int64(game.Amount), game.Currency),
+		Mines:          game.Mines,
+		Revealed:       game.Revealed,
+		Status:         game.Status,
+		Multiplier:     m.rules.Multiplier(game.Mines, len(game.Revealed)),
+		Payout:         money.New(int64(game.Payout), game.Currency),
+		ClientSeed:     game.ClientSeed,
+		ServerSeedHash: game.ServerSeedHash,
+		Nonce:          game.Nonce,
+	}
+
+	if game.Status == config.MinesActive {
+		view.NextMultiplier = m.rules.Multiplier(game.Mines, len(game.Revealed)+1)
+	} else {
+		view.Board = game.Board
+	}
+
+	if game.Status == config.MinesBusted {
+		view.Multiplier = 0
+	}
+
+	return view
+}
@@ -8,12 +8,21 @@ import (
 
 type Handler struct {
 	Conn *sql.DB
+	tx   *sql.Tx
 }
 
 func New(conn *sql.DB) *Handler {
 	return &Handler{Conn: conn}
 }
 
+// WithTx returns a handler whose statements run on tx, so writes of several
+// repositories commit or roll back together.
+func (handler Handler) WithTx(tx *sql.Tx) Handler {
+	handler.tx = tx
+
+	return handler
+}
+
 func (handler *Handler) Execute(statement *sql.Stmt, args ...interface{}) (sql.Result, error) {
 	const op = "mysql.mysql.Execute"
 
@@ -39,7 +48,7 @@ func (handler *Handler) Query(statement string) (*sql.Rows, error) {
 func (handler *Handler) Prepare(statement string) (*sql.Stmt, error) {
 	const op = "mysql.mysql.Prepare"
 
-	stmt, err := handler.Conn.Prepare(statement)
+	stmt, err := handler.prepare(statement)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -50,7 +59,7 @@ func (handler *Handler) Prepare(statement string) (*sql.Stmt, error) {
 func (handler *Handler) PrepareAndExecute(statement string, args ...interface{}) (sql.Result, error) {
 	const op = "mysql.mysql.PrepareAndExecute"
 
-	stmt, err := handler.Conn.Prepare(statement)
+	stmt, err := handler.prepare(statement)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -66,7 +75,7 @@ func (handler *Handler) PrepareAndExecute(statement string, args ...interface{})
 func (handler *Handler) PrepareAndQueryRow(statement string, args ...interface{}) (*sql.Row, error) {
 	const op = "mysql.mysql.PrepareAndQueryRow"
 
-	stmt, err := handler.Conn.Prepare(statement)
+	stmt, err := handler.prepare(statement)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -79,7 +88,7 @@ func (handler *Handler) PrepareAndQueryRow(statement string, args ...interface{}
 func (handler *Handler) PrepareAndQuery(statement string, args ...interface{}) (*sql.Rows, error) {
 	const op = "mysql.mysql.PrepareAndQueryRow"
 
-	stmt, err := handler.Conn.Prepare(statement)
+	stmt, err := handler.prepare(statement)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -102,3 +111,11 @@ func (handler *Handler) StartTransaction() (*sql.Tx, error) {
 
 	return tx, nil
 }
+
+func (handler *Handler) prepare(statement string) (*sql.Stmt, error) {
+	if handler.tx != nil {
+		return handler.tx.Prepare(statement)
+	}
+
+	return handler.Conn.Prepare(statement)
+}
@@ -0,0 +1,236 @@
+package drop
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
+	"go-outpost/internal/game"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+)
+
+// Request drops a ball staked in Currency, the default one when none is
+// given.
+type Request struct {
+	UserUUID string            `json:"user_uuid" validate:"required"`
+	Amount   money.Amount      `json:"amount" validate:"required"`
+	Currency money.Currency    `json:"currency"`
+	Rows     int               `json:"rows" validate:"required,min=1"`
+	Risk     config.PlinkoRisk `json:"risk" validate:"required,oneof=low medium high"`
+}
+
+type Response struct {
+	resp.Response
+	Bet      Bet      `json:"bet"`
+	Fairness Fairness `json:"fairness"`
+}
+
+type Bet struct {
+	UUID       string            `json:"uuid"`
+	Currency   money.Currency    `json:"currency"`
+	Amount     money.Money       `json:"amount"`
+	Rows       int               `json:"rows"`
+	Risk       config.PlinkoRisk `json:"risk"`
+	Path       []int             `json:"path"`
+	Slot       int               `json:"slot"`
+	Multiplier float64           `json:"multiplier"`
+	Payout     money.Money       `json:"payout"`
+}
+
+type Fairness struct {
+	ClientSeed     string `json:"client_seed"`
+	ServerSeedHash string `json:"server_seed_hash"`
+	Nonce          int    `json:"nonce"`
+	Hash           string `json:"hash"`
+}
+
+// Plinko keeps the plinko route and its drop event, the drop itself is played
+// by the plinko engine through the game player.
+type Plinko struct {
+	log       *slog.Logger
+	validator *validator.Validate
+	userRep   repository.UserRepository
+	player    *game.Player
+	event     *event.PusherEvent
+}
+
+func NewPlinko(
+	log *slog.Logger,
+	userRep repository.UserRepository,
+	player *game.Player,
+	eventClient *event.PusherEvent) *Plinko {
+	return &Plinko{
+		log:       log,
+		validator: money.WithValidation(validator.New()),
+		userRep:   userRep,
+		player:    player,
+		event:     eventClient,
+	}
+}
+
+// New handles POST /plinko/drop.
+func (p *Plinko) New() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.plinko.drop.New"
+
+		var (
+			err    error
+			req    Request
+			log    *slog.Logger
+			amount money.Money
+			user   *model.User
+			bet    *model.GameBet
+			drop   game.PlinkoDrop
+			rng    game.RNG
+		)
+
+		log = p.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = p.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		if req.Currency == "" {
+			req.Currency = money.Default
+		}
+
+		amount, err = req.Amount.In(req.Currency)
+		if err == nil && amount.Minor() < 1 {
+			err = money.ErrInvalidAmount
+		}
+		if err != nil {
+			log.Error("invalid amount", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
+
+			return
+		}
+
+		user, err = p.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		bet, rng, err = p.Drop(user.ID, amount, req.Rows, req.Risk)
+		if err == nil {
+			err = json.Unmarshal(bet.Result, &drop)
+		}
+		if err != nil {
+			log.Error("failed to drop plinko ball", sl.Err(err))
+
+			render.JSON(w, r, dropError(err))
+
+			return
+		}
+
+		view := Bet{
+			UUID:       bet.UUID,
+			Currency:   bet.Currency,
+			Amount:     money.New(int64(bet.Amount), bet.Currency),
+			Rows:       drop.Rows,
+			Risk:       drop.Risk,
+			Path:       drop.Path,
+			Slot:       drop.Slot,
+			Multiplier: drop.Multiplier,
+			Payout:     money.New(int64(bet.Payout), bet.Currency),
+		}
+
+		if err = p.event.Trigger(events.PlinkoDropped{
+			UUID:       view.UUID,
+			UserUUID:   user.UUID,
+			Rows:       view.Rows,
+			Risk:       view.Risk,
+			Path:       view.Path,
+			Slot:       view.Slot,
+			Multiplier: view.Multiplier,
+			Currency:   view.Currency,
+			Amount:     view.Amount,
+			Payout:     view.Payout,
+		}); err != nil {
+			log.Error("failed to send drop event", sl.Err(err))
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Bet:      view,
+			Fairness: Fairness{
+				ClientSeed:     rng.ClientSeed,
+				ServerSeedHash: rng.ServerSeedHash(),
+				Nonce:          rng.Nonce,
+				Hash:           rng.Hash(),
+			},
+		})
+	}
+}
+
+// Drop plays a ball of rows and risk through the plinko engine.
+func (p *Plinko) Drop(
+	userID int64,
+	amount money.Money,
+	rows int,
+	risk config.PlinkoRisk) (*model.GameBet, game.RNG, error) {
+	const op = "handlers.plinko.drop.Drop"
+
+	params, err := json.Marshal(game.PlinkoChoice{Rows: rows, Risk: risk})
+	if err != nil {
+		return nil, game.RNG{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bet, rng, err := p.player.Play(config.Plinko, userID, amount, params)
+	if err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bet, rng, nil
+}
+
+func dropError(err error) resp.Response {
+	switch {
+	case errors.Is(err, game.ErrInvalidBet):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_plinko")
+	case errors.Is(err, config.ErrCurrencyNotAccepted):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "currency_not_accepted")
+	case errors.Is(err, config.ErrStakeOutOfRange):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range")
+	case errors.Is(err, game.ErrPayoutLimit):
+		return resp.ErrorCode(game.ErrPayoutLimit.Error(), http.StatusConflict, "payout_limit")
+	case errors.Is(err, game.ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, game.ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to drop plinko ball", http.StatusInternalServerError)
+}
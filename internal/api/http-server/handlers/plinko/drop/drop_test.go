@@ -0,0 +1,130 @@
+package drop
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/provably_fair"
+	"go-outpost/internal/game"
+	"testing"
+)
+
+var plinkoRules = config.PlinkoRules{
+	MinRows: 8,
+	MaxRows: 16,
+	Payouts: map[config.PlinkoRisk]map[int][]float64{
+		config.PlinkoLow: {
+			8:  {5.6, 2.1, 1.1, 1, 0.5, 1, 1.1, 2.1, 5.6},
+			16: {16, 9, 2, 1.4, 1.4, 1.2, 1.1, 1, 0.5, 1, 1.1, 1.2, 1.4, 1.4, 2, 9, 16},
+		},
+		config.PlinkoHigh: {
+			8: {29, 4, 1.5, 0.3, 0.2, 0.3, 1.5, 4, 29},
+		},
+	},
+}
+
+func TestDropDistribution(t *testing.T) {
+	const drops = 4000
+
+	tests := []struct {
+		name string
+		rows int
+		risk config.PlinkoRisk
+	}{
+		{name: "8 rows", rows: 8, risk: config.PlinkoLow},
+		{name: "16 rows", rows: 16, risk: config.PlinkoLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var engine game.Engine = game.NewPlinko(plinkoRules)
+
+			wager, err := engine.Place(100, json.RawMessage(fmt.Sprintf(`{"rows": %d, "risk": %q}`, tt.rows, tt.risk)))
+			require.NoError(t, err)
+
+			slots := make([]int, tt.rows+1)
+			sum := 0
+
+			for nonce := 0; nonce < drops; nonce++ {
+				rng := game.RNG{ServerSeed: "server", ClientSeed: "client", Nonce: nonce}
+
+				result, err := engine.Resolve(wager, rng)
+				require.NoError(t, err)
+
+				drop := result.Value.(game.PlinkoDrop)
+
+				// The ball turns right on every set bit of the draw hash and
+				// lands in the slot of its right turns.
+				require.Equal(t, provably_fair.Bits(rng.Hash(), tt.rows), drop.Path)
+
+				right := 0
+				for _, bit := range drop.Path {
+					right += bit
+				}
+
+				require.Equal(t, right, drop.Slot)
+				require.Equal(t, plinkoRules.Payouts[tt.risk][tt.rows][drop.Slot], drop.Multiplier)
+
+				slots[drop.Slot]++
+				sum += drop.Slot
+			}
+
+			center := tt.rows / 2
+
+			assert.InDelta(t, float64(center), float64(sum)/drops, 0.2)
+			for slot, count := range slots {
+				assert.LessOrEqual(t, count, slots[center], "slot %d", slot)
+			}
+			assert.Less(t, slots[0]+slots[tt.rows], drops/50)
+		})
+	}
+}
+
+func TestDropPayoutTable(t *testing.T) {
+	tests := []struct {
+		name          string
+		params        string
+		slot          int
+		wantMaxPayout int
+		wantPayout    int
+		wantErr       bool
+	}{
+		{name: "edge of the low table", params: `{"rows": 8, "risk": "low"}`, slot: 0,
+			wantMaxPayout: 560, wantPayout: 560},
+		{name: "center of the low table", params: `{"rows": 8, "risk": "low"}`, slot: 4,
+			wantMaxPayout: 560, wantPayout: 50},
+		{name: "edge of the high table", params: `{"rows": 8, "risk": "high"}`, slot: 8,
+			wantMaxPayout: 2900, wantPayout: 2900},
+		{name: "16 rows", params: `{"rows": 16, "risk": "low"}`, slot: 9,
+			wantMaxPayout: 1600, wantPayout: 100},
+		{name: "rows without a table", params: `{"rows": 12, "risk": "low"}`, wantErr: true},
+		{name: "risk without a table", params: `{"rows": 8, "risk": "medium"}`, wantErr: true},
+		{name: "rows out of range", params: `{"rows": 4, "risk": "low"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var engine game.Engine = game.NewPlinko(plinkoRules)
+
+			wager, err := engine.Place(100, json.RawMessage(tt.params))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, game.ErrInvalidBet)
+
+				return
+			}
+			require.NoError(t, err)
+
+			choice := wager.Choice.(game.PlinkoChoice)
+			result := game.Result{Value: game.PlinkoDrop{
+				Rows:       choice.Rows,
+				Risk:       choice.Risk,
+				Slot:       tt.slot,
+				Multiplier: plinkoRules.Payouts[choice.Risk][choice.Rows][tt.slot],
+			}}
+
+			assert.Equal(t, config.BetType(choice.Risk), wager.BetType)
+			assert.Equal(t, tt.wantMaxPayout, wager.MaxPayout)
+			assert.Equal(t, tt.wantPayout, engine.Settle(wager, result))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package provably_fair
+
+import (
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"time"
+)
+
+// Draws stores the draws of games with their fairness data.
+type Draws struct {
+	provablyFairRep *repository.ProvablyFairRepository
+}
+
+func (d *Draws) StoreGameDraw(gameID int64, game config.Game) (int64, error) {
+	const op = "ProvablyFair.Draws.StoreGameDraw"
+
+	now := time.Now()
+
+	id, err := d.provablyFairRep.SaveGameDraw(model.GameDraw{
+		GameID:    gameID,
+		Game:      game,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// StoreUserGameDraw records a draw of a game played by one user.
+func (d *Draws) StoreUserGameDraw(gameID int64, userID int64, game config.Game) (int64, error) {
+	const op = "ProvablyFair.Draws.StoreUserGameDraw"
+
+	now := time.Now()
+
+	id, err := d.provablyFairRep.SaveUserGameDraw(model.GameDraw{
+		GameID:    gameID,
+		UserID:    userID,
+		Game:      game,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (d *Draws) StoreProvablyFair(data ProvablyFairData, drawID int64) error {
+	const op = "ProvablyFair.Draws.StoreProvablyFair"
+
+	now := time.Now()
+
+	err := d.provablyFairRep.SaveProvablyFair(model.ProvablyFair{
+		GameDrawID:           drawID,
+		ClientSeed:           data.ClientSeed,
+		ServerSeed:           data.ServerSeed,
+		ResultedHash:         data.ServerHashSeed,
+		ResultedRandomNumber: data.Result,
+		Min:                  data.Min,
+		Max:                  data.Max,
+		Nonce:                data.Nonce,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
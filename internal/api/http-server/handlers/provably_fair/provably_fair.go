@@ -3,17 +3,16 @@ package provably_fair
 import (
 	"crypto/hmac"
 	"crypto/sha512"
+	"database/sql"
 	"encoding/hex"
-	"fmt"
 	"go-outpost/internal/api/config"
-	"go-outpost/internal/api/http-server/model"
 	"go-outpost/internal/api/repository"
 	"go-outpost/internal/lib/logger/sl"
 	"go-outpost/internal/lib/random"
 	"golang.org/x/exp/slog"
 	"math"
 	"strconv"
-	"time"
+	"sync"
 )
 
 type ProvablyFairRandomizer struct {
@@ -28,7 +27,10 @@ type ProvablyFairRandomizer struct {
 type ProvablyFair struct {
 	ProvablyFairRandomizer *ProvablyFairRandomizer
 	ProvablyFairRepository repository.ProvablyFairRepository
+	UserSeedRepository     repository.UserSeedRepository
 	log                    *slog.Logger
+	// mutex guards the nonce of the randomizer, draws run on many goroutines.
+	mutex sync.Mutex
 }
 
 type ProvablyFairData struct {
@@ -43,6 +45,7 @@ type ProvablyFairData struct {
 
 func NewProvablyFair(
 	ProvablyFairRepository repository.ProvablyFairRepository,
+	UserSeedRepository repository.UserSeedRepository,
 	log *slog.Logger,
 ) *ProvablyFair {
 	return &ProvablyFair{
@@ -52,92 +55,104 @@ func NewProvablyFair(
 			Nonce:      0,
 		},
 		ProvablyFairRepository: ProvablyFairRepository,
+		UserSeedRepository:     UserSeedRepository,
 		log:                    log,
 	}
 }
 
 func (f *ProvablyFair) GetRandomNumber(clientSeed string, maxProvability int) ProvablyFairData {
-	serverSeed := random.NewRandomString(64)
+	result := Draw(random.NewRandomString(64), clientSeed, f.nextNonce(), maxProvability+1)
 
-	f.ProvablyFairRandomizer.Min = 0
-	f.ProvablyFairRandomizer.Max = maxProvability
-	f.ProvablyFairRandomizer.ClientSeed = clientSeed
-	f.ProvablyFairRandomizer.ServerSeed = serverSeed
-
-	result := f.getProvablyFairData()
-
-	f.ProvablyFairRandomizer.Nonce++
+	decimal, _ := strconv.ParseInt(result.ServerHashSeed[:5], 16, 64)
+	result.Result = math.Mod(float64(decimal), 10000) / 100
 
 	return result
 }
 
-func (f *ProvablyFair) getProvablyFairData() ProvablyFairData {
-	h := hmac.New(sha512.New, []byte(f.ProvablyFairRandomizer.ServerSeed))
-	h.Write([]byte(f.ProvablyFairRandomizer.ClientSeed + "-" + strconv.Itoa(f.ProvablyFairRandomizer.Nonce)))
-	hash := hex.EncodeToString(h.Sum(nil))
-
-	partOfHash := hash[:5]
-	decimal, _ := strconv.ParseInt(partOfHash, 16, 64)
+// GetRandomIndex draws an integer in [0, size), used to pick an exact
+// slot such as a roulette pocket.
+func (f *ProvablyFair) GetRandomIndex(clientSeed string, size int) ProvablyFairData {
+	return Draw(random.NewRandomString(64), clientSeed, f.nextNonce(), size)
+}
 
-	result := math.Mod(float64(decimal), 10000) / 100
+// Draw picks an integer in [0, size) from the seed pair and the nonce alone,
+// so a draw whose server seed was committed to beforehand can be replayed by
+// anyone once the seed is revealed.
+func Draw(serverSeed string, clientSeed string, nonce int, size int) ProvablyFairData {
+	hash := Hash(serverSeed, clientSeed, nonce)
 
 	return ProvablyFairData{
-		ClientSeed:     f.ProvablyFairRandomizer.ClientSeed,
-		ServerSeed:     f.ProvablyFairRandomizer.ServerSeed,
+		ClientSeed:     clientSeed,
+		ServerSeed:     serverSeed,
 		ServerHashSeed: hash,
-		Nonce:          f.ProvablyFairRandomizer.Nonce,
-		Result:         result,
-		Min:            f.ProvablyFairRandomizer.Min,
-		Max:            f.ProvablyFairRandomizer.Max,
+		Nonce:          nonce,
+		Result:         float64(IndexFromHash(hash, size)),
+		Min:            0,
+		Max:            size - 1,
 	}
 }
 
-func (f *ProvablyFair) StoreGameDraw(rouletteID int64, game config.Game) (int64, error) {
-	const op = "ProvablyFair.StoreGameDraw"
+// NewServerSeed draws the secret seed of a shared game. Its HashServerSeed
+// is published when the game opens and the seed itself once it is drawn.
+func NewServerSeed() (string, error) {
+	return randomHex(32)
+}
 
-	now := time.Now()
+// IndexFromHash maps the first 32 bits of the hash onto [0, size) without
+// the modulo bias of taking the remainder.
+func IndexFromHash(hash string, size int) int {
+	decimal, _ := strconv.ParseUint(hash[:8], 16, 32)
 
-	gameDrawModel := &model.GameDraw{
-		GameID:    rouletteID,
-		Game:      game,
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
+	return int(float64(decimal) / (1 << 32) * float64(size))
+}
+
+// Hash is the HMAC-SHA512 of "clientSeed-nonce" keyed with the server seed,
+// the value every draw is derived from.
+func Hash(serverSeed string, clientSeed string, nonce int) string {
+	h := hmac.New(sha512.New, []byte(serverSeed))
+	h.Write([]byte(clientSeed + "-" + strconv.Itoa(nonce)))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-	id, err := f.ProvablyFairRepository.SaveGameDraw(*gameDrawModel)
+func (f *ProvablyFair) nextNonce() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	nonce := f.ProvablyFairRandomizer.Nonce
+	f.ProvablyFairRandomizer.Nonce++
+
+	return nonce
+}
+
+func (f *ProvablyFair) StoreGameDraw(rouletteID int64, game config.Game) (int64, error) {
+	id, err := f.draws().StoreGameDraw(rouletteID, game)
 	if err != nil {
-		f.log.Error("failed to get number by color", sl.Err(err))
+		f.log.Error("failed to store game draw", sl.Err(err))
 
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return 0, err
 	}
 
 	return id, nil
 }
 
 func (f *ProvablyFair) StoreProvablyFair(data ProvablyFairData, drawID int64) error {
-	const op = "ProvablyFair.StoreProvablyFair"
-
-	now := time.Now()
-
-	provablyFairModel := &model.ProvablyFair{
-		GameDrawID:           drawID,
-		ClientSeed:           data.ClientSeed,
-		ServerSeed:           data.ServerSeed,
-		ResultedHash:         data.ServerHashSeed,
-		ResultedRandomNumber: data.Result,
-		Min:                  0,
-		Max:                  data.Max,
-		Nonce:                data.Nonce,
-		CreatedAt:            now,
-		UpdatedAt:            now,
-	}
-
-	err := f.ProvablyFairRepository.SaveProvablyFair(*provablyFairModel)
+	err := f.draws().StoreProvablyFair(data, drawID)
 	if err != nil {
-		f.log.Error("failed to get number by color", sl.Err(err))
+		f.log.Error("failed to store provably fair", sl.Err(err))
 
-		return fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
 	return nil
 }
+
+// WithTx returns draws stored on tx, so they commit together with the game
+// they were drawn for.
+func (f *ProvablyFair) WithTx(tx *sql.Tx) *Draws {
+	return &Draws{provablyFairRep: f.ProvablyFairRepository.WithTx(tx)}
+}
+
+func (f *ProvablyFair) draws() *Draws {
+	return &Draws{provablyFairRep: &f.ProvablyFairRepository}
+}
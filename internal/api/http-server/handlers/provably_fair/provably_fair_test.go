@@ -0,0 +1,21 @@
+package provably_fair
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDraw(t *testing.T) {
+	first := Draw("server", "client", 3, 37)
+	again := Draw("server", "client", 3, 37)
+
+	assert.Equal(t, first, again)
+	assert.Equal(t, Hash("server", "client", 3), first.ServerHashSeed)
+	assert.Equal(t, float64(IndexFromHash(first.ServerHashSeed, 37)), first.Result)
+	assert.Equal(t, 36, first.Max)
+
+	for nonce := 0; nonce < 100; nonce++ {
+		result := Draw("server", "client", nonce, 2).Result
+		assert.True(t, result == 0 || result == 1)
+	}
+}
@@ -0,0 +1,70 @@
+package provably_fair
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"strconv"
+)
+
+// Floats derives count numbers in [0, 1) from a seed pair and nonce. Every
+// hash gives 16 numbers of 32 bits, further hashes add a cursor to the
+// message. The first hash is the same as Hash.
+func Floats(serverSeed string, clientSeed string, nonce int, count int) []float64 {
+	floats := make([]float64, 0, count)
+
+	for cursor := 0; len(floats) < count; cursor++ {
+		hash := cursorHash(serverSeed, clientSeed, nonce, cursor)
+
+		for i := 0; i+8 <= len(hash) && len(floats) < count; i += 8 {
+			decimal, _ := strconv.ParseUint(hash[i:i+8], 16, 32)
+
+			floats = append(floats, float64(decimal)/(1<<32))
+		}
+	}
+
+	return floats
+}
+
+// Shuffle returns the numbers 0 to size-1 in the order of a Fisher-Yates
+// shuffle driven by Floats, so anyone holding the seeds can replay it.
+func Shuffle(serverSeed string, clientSeed string, nonce int, size int) []int {
+	order := make([]int, size)
+	for i := range order {
+		order[i] = i
+	}
+
+	floats := Floats(serverSeed, clientSeed, nonce, size)
+
+	for i := size - 1; i > 0; i-- {
+		j := int(floats[size-1-i] * float64(i+1))
+
+		order[i], order[j] = order[j], order[i]
+	}
+
+	return order
+}
+
+func cursorHash(serverSeed string, clientSeed string, nonce int, cursor int) string {
+	if cursor == 0 {
+		return Hash(serverSeed, clientSeed, nonce)
+	}
+
+	h := hmac.New(sha512.New, []byte(serverSeed))
+	h.Write([]byte(clientSeed + "-" + strconv.Itoa(nonce) + "-" + strconv.Itoa(cursor)))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Bits reads count bits from the hash, most significant bit first. A draw of
+// n binary choices such as a plinko path takes them in order.
+func Bits(hash string, count int) []int {
+	raw, _ := hex.DecodeString(hash)
+
+	bits := make([]int, count)
+	for i := range bits {
+		bits[i] = int(raw[i/8]>>(7-i%8)) & 1
+	}
+
+	return bits
+}
@@ -0,0 +1,38 @@
+package provably_fair
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func TestShuffle(t *testing.T) {
+	order := Shuffle("server", "client", 7, 25)
+
+	assert.Equal(t, order, Shuffle("server", "client", 7, 25))
+	assert.NotEqual(t, order, Shuffle("server", "client", 8, 25))
+
+	sorted := append([]int(nil), order...)
+	sort.Ints(sorted)
+
+	for i, n := range sorted {
+		assert.Equal(t, i, n)
+	}
+}
+
+func TestFloats(t *testing.T) {
+	floats := Floats("server", "client", 1, 40)
+
+	assert.Len(t, floats, 40)
+	assert.Equal(t, floats[:16], Floats("server", "client", 1, 16))
+
+	for _, f := range floats {
+		assert.GreaterOrEqual(t, f, 0.0)
+		assert.Less(t, f, 1.0)
+	}
+}
+
+func TestBits(t *testing.T) {
+	assert.Equal(t, []int{1, 0, 1, 0, 0, 0, 0, 0, 1, 1, 1, 1}, Bits("a0f0", 12))
+	assert.Len(t, Bits(Hash("server", "client", 1), 16), 16)
+}
@@ -0,0 +1,129 @@
+package provably_fair
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+// ActiveSeed returns the seed pair of the player, a new one is created on first use.
+func (f *ProvablyFair) ActiveSeed(userID int64) (*model.UserSeed, error) {
+	const op = "ProvablyFair.ActiveSeed"
+
+	seed, err := f.UserSeedRepository.FindActiveSeed(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if seed != nil {
+		return seed, nil
+	}
+
+	seed, err = f.newSeed(userID, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return seed, nil
+}
+
+// RotateSeed retires the current seed pair and starts a new one with the
+// given client seed, or a random one when empty. The retired pair is
+// returned so its server seed can be shown to the player.
+func (f *ProvablyFair) RotateSeed(userID int64, clientSeed string) (*model.UserSeed, *model.UserSeed, error) {
+	const op = "ProvablyFair.RotateSeed"
+
+	current, err := f.ActiveSeed(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = f.UserSeedRepository.RevealSeed(current.ID); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	current.Active = false
+	current.RevealedAt = &now
+
+	next, err := f.newSeed(userID, clientSeed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return current, next, nil
+}
+
+// DrawForUser takes the next nonce of the player's seed pair. Games turn
+// ServerHashSeed into their result and fill in Result, Min and Max.
+func (f *ProvablyFair) DrawForUser(userID int64) (ProvablyFairData, error) {
+	const op = "ProvablyFair.DrawForUser"
+
+	var data ProvablyFairData
+
+	seed, err := f.ActiveSeed(userID)
+	if err != nil {
+		return data, fmt.Errorf("%s: %w", op, err)
+	}
+
+	nonce, err := f.UserSeedRepository.NextNonce(seed.ID)
+	if err != nil {
+		return data, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ProvablyFairData{
+		ClientSeed:     seed.ClientSeed,
+		ServerSeed:     seed.ServerSeed,
+		ServerHashSeed: Hash(seed.ServerSeed, seed.ClientSeed, nonce),
+		Nonce:          nonce,
+	}, nil
+}
+
+func (f *ProvablyFair) newSeed(userID int64, clientSeed string) (*model.UserSeed, error) {
+	serverSeed, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientSeed == "" {
+		clientSeed, err = randomHex(16)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	seed := &model.UserSeed{
+		UserID:         userID,
+		ClientSeed:     clientSeed,
+		ServerSeed:     serverSeed,
+		ServerSeedHash: HashServerSeed(serverSeed),
+		Active:         true,
+		CreatedAt:      time.Now(),
+	}
+
+	seed.ID, err = f.UserSeedRepository.SaveSeed(*seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return seed, nil
+}
+
+// HashServerSeed is the commitment shown to the player before the server seed is revealed.
+func HashServerSeed(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,259 @@
+package autobet
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strconv"
+)
+
+// Request registers a strategy. Rounds, StopOnProfit and StopOnLoss are
+// optional limits, zero means no limit.
+type Request struct {
+	UserUUID     string                 `json:"user_uuid" validate:"required"`
+	Type         config.BetType         `json:"type" validate:"required,oneof=color number range parity combo"`
+	Value        string                 `json:"value" validate:"required"`
+	Amount       money.Money            `json:"amount" validate:"required,min=1"`
+	Strategy     config.AutoBetStrategy `json:"strategy" validate:"omitempty,oneof=fixed martingale"`
+	Rounds       int                    `json:"rounds" validate:"min=0"`
+	StopOnProfit money.Money            `json:"stop_on_profit" validate:"min=0"`
+	StopOnLoss   money.Money            `json:"stop_on_loss" validate:"min=0"`
+}
+
+type Response struct {
+	resp.Response
+	AutoBet *model.AutoBet `json:"auto_bet,omitempty"`
+}
+
+type ListResponse struct {
+	resp.Response
+	AutoBets []model.AutoBet `json:"auto_bets"`
+}
+
+type AutoBet struct {
+	log        *slog.Logger
+	validator  *validator.Validate
+	autoBetRep repository.AutoBetRepository
+	userRep    repository.UserRepository
+	wheel      config.Wheel
+	limits     config.Limits
+}
+
+func NewAutoBet(
+	log *slog.Logger,
+	autoBetRep repository.AutoBetRepository,
+	userRep repository.UserRepository,
+	wheel config.Wheel,
+	limits config.Limits) *AutoBet {
+	return &AutoBet{
+		log:        log,
+		validator:  money.WithValidation(validator.New()),
+		autoBetRep: autoBetRep,
+		userRep:    userRep,
+		wheel:      wheel,
+		limits:     limits,
+	}
+}
+
+// Create handles POST /roulette/auto-bets.
+func (a *AutoBet) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.roulette.autobet.Create"
+
+		var (
+			err     error
+			req     Request
+			log     *slog.Logger
+			user    *model.User
+			autoBet model.AutoBet
+		)
+
+		log = a.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = a.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		if err = a.wheel.ValidateBet(req.Type, req.Value); err != nil {
+			log.Error("invalid bet", sl.Err(err))
+
+			render.JSON(w, r, resp.Error(err.Error(), http.StatusBadRequest))
+
+			return
+		}
+
+		if err = a.limits.CheckStake(req.Type, int(req.Amount.Minor())); err != nil {
+			log.Error("invalid bet", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range"))
+
+			return
+		}
+
+		user, err = a.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		if req.Strategy == "" {
+			req.Strategy = config.StrategyFixed
+		}
+
+		autoBet = model.AutoBet{
+			UserID:        user.ID,
+			Type:          req.Type,
+			Value:         req.Value,
+			BaseAmount:    int(req.Amount.Minor()),
+			CurrentAmount: int(req.Amount.Minor()),
+			Strategy:      req.Strategy,
+			Rounds:        req.Rounds,
+			StopOnProfit:  int(req.StopOnProfit.Minor()),
+			StopOnLoss:    int(req.StopOnLoss.Minor()),
+			Status:        config.AutoBetActive,
+		}
+
+		autoBet.ID, err = a.autoBetRep.SaveAutoBet(autoBet)
+		if err != nil {
+			log.Error("failed to save auto bet", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to save auto bet", http.StatusInternalServerError))
+
+			return
+		}
+
+		log.Info("auto bet saved", slog.Int64("id", autoBet.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), AutoBet: &autoBet})
+	}
+}
+
+// List handles GET /roulette/auto-bets?user_uuid=.
+func (a *AutoBet) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.roulette.autobet.List"
+
+		var (
+			err      error
+			log      *slog.Logger
+			user     *model.User
+			autoBets []model.AutoBet
+		)
+
+		log = a.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = a.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		autoBets, err = a.autoBetRep.GetAutoBetsByUserID(user.ID)
+		if err != nil {
+			log.Error("failed to get auto bets", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to get auto bets", http.StatusInternalServerError))
+
+			return
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), AutoBets: autoBets})
+	}
+}
+
+// Stop handles DELETE /roulette/auto-bets/{id}?user_uuid=. The strategy is
+// kept for history and only marked as stopped.
+func (a *AutoBet) Stop() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.roulette.autobet.Stop"
+
+		var (
+			err     error
+			log     *slog.Logger
+			id      int64
+			user    *model.User
+			autoBet *model.AutoBet
+		)
+
+		log = a.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err = strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, resp.Error("invalid auto bet id", http.StatusBadRequest))
+
+			return
+		}
+
+		user, err = a.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		autoBet, err = a.autoBetRep.FindAutoBetByID(id)
+		if err != nil || autoBet == nil || autoBet.UserID != user.ID {
+			log.Error("failed to find auto bet", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find auto bet", http.StatusNotFound))
+
+			return
+		}
+
+		if autoBet.Status == config.AutoBetActive {
+			stop(autoBet, config.StopCancelled)
+
+			if err = a.autoBetRep.UpdateAutoBet(*autoBet); err != nil {
+				log.Error("failed to stop auto bet", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("failed to stop auto bet", http.StatusInternalServerError))
+
+				return
+			}
+		}
+
+		log.Info("auto bet stopped", slog.Int64("id", autoBet.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), AutoBet: autoBet})
+	}
+}
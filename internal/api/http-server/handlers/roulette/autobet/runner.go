@@ -0,0 +1,229 @@
+package autobet
+
+import (
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	place_bet "go-outpost/internal/api/http-server/handlers/roulette/bet/save"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+)
+
+type BetPlacer interface {
+	PlaceBets(
+		roulette *model.Roulette,
+		userID int64,
+		currency money.Currency,
+		bets []model.RouletteBet) ([]model.RouletteBet, error)
+}
+
+type BetFinder interface {
+	FindBetByID(id int64) (*model.RouletteBet, error)
+}
+
+// Runner places the bets of active strategies on every round and moves them
+// forward once the round is settled. Strategies live in the database only,
+// so a restart picks them up again on the next round.
+type Runner struct {
+	log        *slog.Logger
+	autoBetRep repository.AutoBetRepository
+	placer     BetPlacer
+	betRep     BetFinder
+	wheel      config.Wheel
+}
+
+func NewRunner(
+	log *slog.Logger,
+	autoBetRep repository.AutoBetRepository,
+	placer BetPlacer,
+	betRep BetFinder,
+	wheel config.Wheel) *Runner {
+	return &Runner{
+		log:        log,
+		autoBetRep: autoBetRep,
+		placer:     placer,
+		betRep:     betRep,
+		wheel:      wheel,
+	}
+}
+
+// PlaceRound places the next bet of every active strategy on the round.
+// Strategies are staked in the default currency.
+func (r *Runner) PlaceRound(roulette *model.Roulette) error {
+	const op = "handlers.roulette.autobet.PlaceRound"
+
+	var (
+		err      error
+		log      *slog.Logger
+		autoBets []model.AutoBet
+		placed   []model.RouletteBet
+	)
+
+	log = r.log.With(
+		slog.String("op", op),
+		slog.Int64("roulette_id", roulette.ID),
+	)
+
+	autoBets, err = r.autoBetRep.GetActiveAutoBets()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, autoBet := range autoBets {
+		placed, err = r.placer.PlaceBets(roulette, autoBet.UserID, money.Default, []model.RouletteBet{betFor(autoBet)})
+
+		switch {
+		case err == nil:
+			autoBet.PendingRouletteID = roulette.ID
+			autoBet.PendingBetID = placed[0].ID
+
+			if placed[0].Amount != autoBet.CurrentAmount {
+				log.Info("auto bet scaled down by the exposure cap",
+					slog.Int64("auto_bet_id", autoBet.ID), slog.Int("amount", placed[0].Amount))
+			}
+		case errors.Is(err, place_bet.ErrInsufficientBalance), errors.Is(err, place_bet.ErrNoBalance):
+			stop(&autoBet, config.StopInsufficientFunds)
+		case errors.Is(err, config.ErrStakeOutOfRange):
+			stop(&autoBet, config.StopStakeLimit)
+		case errors.Is(err, place_bet.ErrBettingClosed):
+			log.Warn("betting closed before auto bets were placed", slog.Int64("auto_bet_id", autoBet.ID))
+
+			continue
+		case errors.Is(err, place_bet.ErrTooManyBets):
+			log.Info("user already placed bets on this round, skipping", slog.Int64("auto_bet_id", autoBet.ID))
+
+			continue
+		default:
+			log.Error("failed to place auto bet", slog.Int64("auto_bet_id", autoBet.ID), sl.Err(err))
+
+			continue
+		}
+
+		if err = r.autoBetRep.UpdateAutoBet(autoBet); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("auto bet placed", slog.Any("auto_bet", autoBet))
+	}
+
+	return nil
+}
+
+// Settle advances the strategies that had a bet on the settled round. The
+// stored bet is used rather than the strategy stake, the exposure cap may have
+// scaled it down. The pending round is cleared so settling the same round
+// again is a no-op. A bet the player cancelled does not count towards the
+// strategy.
+func (r *Runner) Settle(rouletteID int64, pocket config.Pocket) error {
+	const op = "handlers.roulette.autobet.Settle"
+
+	var (
+		err      error
+		autoBets []model.AutoBet
+		bet      *model.RouletteBet
+		payout   int
+	)
+
+	autoBets, err = r.autoBetRep.GetActiveAutoBets()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, autoBet := range autoBets {
+		if autoBet.PendingRouletteID != rouletteID {
+			continue
+		}
+
+		bet, err = r.betRep.FindBetByID(autoBet.PendingBetID)
+		switch {
+		case err == nil:
+			payout = bet.Amount * r.wheel.Multiplier(bet.Type, bet.Value, pocket)
+
+			Advance(&autoBet, bet.Amount, payout)
+		case !errors.Is(err, repository.ErrBetNotFound):
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		autoBet.PendingRouletteID = 0
+		autoBet.PendingBetID = 0
+
+		if err = r.autoBetRep.UpdateAutoBet(autoBet); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Release forgets a refunded round, its bet does not count towards the strategy.
+func (r *Runner) Release(rouletteID int64) error {
+	const op = "handlers.roulette.autobet.Release"
+
+	autoBets, err := r.autoBetRep.GetActiveAutoBets()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, autoBet := range autoBets {
+		if autoBet.PendingRouletteID != rouletteID {
+			continue
+		}
+
+		autoBet.PendingRouletteID = 0
+		autoBet.PendingBetID = 0
+
+		if err = r.autoBetRep.UpdateAutoBet(autoBet); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Advance books the payout of the last bet against the stake it was placed
+// with and works out the next stake.
+// Martingale doubles the stake after a loss and goes back to the base amount
+// after a win. The strategy stops as soon as one of its limits is reached.
+func Advance(a *model.AutoBet, stake int, payout int) {
+	a.RoundsPlayed++
+	a.Profit += payout - stake
+
+	if a.Strategy == config.StrategyMartingale {
+		if payout > 0 {
+			a.CurrentAmount = a.BaseAmount
+		} else {
+			a.CurrentAmount *= 2
+		}
+	}
+
+	switch {
+	case a.StopOnProfit > 0 && a.Profit >= a.StopOnProfit:
+		stop(a, config.StopProfitReached)
+	case a.StopOnLoss > 0 && -a.Profit >= a.StopOnLoss:
+		stop(a, config.StopLossReached)
+	case a.Rounds > 0 && a.RoundsPlayed >= a.Rounds:
+		stop(a, config.StopRoundsCompleted)
+	}
+}
+
+func stop(a *model.AutoBet, reason config.AutoBetStopReason) {
+	a.Status = config.AutoBetStopped
+	a.StopReason = reason
+}
+
+func betFor(a model.AutoBet) model.RouletteBet {
+	bet := model.RouletteBet{
+		Type:   a.Type,
+		Value:  a.Value,
+		Amount: a.CurrentAmount,
+	}
+
+	if a.Type == config.BetColor {
+		bet.Color = config.Color(a.Value)
+	}
+
+	return bet
+}
@@ -0,0 +1,82 @@
+package autobet
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"testing"
+)
+
+func TestAdvance(t *testing.T) {
+	tests := []struct {
+		name    string
+		autoBet model.AutoBet
+		stake   int
+		payout  int
+		want    model.AutoBet
+	}{
+		{
+			name:    "fixed keeps the stake",
+			autoBet: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, Status: config.AutoBetActive},
+			stake:   100,
+			payout:  0,
+			want: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, RoundsPlayed: 1,
+				Profit: -100, Status: config.AutoBetActive},
+		},
+		{
+			name:    "martingale doubles after a loss",
+			autoBet: model.AutoBet{Strategy: config.StrategyMartingale, BaseAmount: 100, CurrentAmount: 200, Profit: -100, RoundsPlayed: 1, Status: config.AutoBetActive},
+			stake:   200,
+			payout:  0,
+			want: model.AutoBet{Strategy: config.StrategyMartingale, BaseAmount: 100, CurrentAmount: 400, RoundsPlayed: 2,
+				Profit: -300, Status: config.AutoBetActive},
+		},
+		{
+			name:    "martingale resets after a win",
+			autoBet: model.AutoBet{Strategy: config.StrategyMartingale, BaseAmount: 100, CurrentAmount: 400, Profit: -300, RoundsPlayed: 2, Status: config.AutoBetActive},
+			stake:   400,
+			payout:  800,
+			want: model.AutoBet{Strategy: config.StrategyMartingale, BaseAmount: 100, CurrentAmount: 100, RoundsPlayed: 3,
+				Profit: 100, Status: config.AutoBetActive},
+		},
+		{
+			name:    "stops on profit",
+			autoBet: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, StopOnProfit: 100, Status: config.AutoBetActive},
+			stake:   100,
+			payout:  200,
+			want: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, StopOnProfit: 100,
+				RoundsPlayed: 1, Profit: 100, Status: config.AutoBetStopped, StopReason: config.StopProfitReached},
+		},
+		{
+			name:    "stops on loss",
+			autoBet: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, StopOnLoss: 100, Status: config.AutoBetActive},
+			stake:   100,
+			payout:  0,
+			want: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, StopOnLoss: 100,
+				RoundsPlayed: 1, Profit: -100, Status: config.AutoBetStopped, StopReason: config.StopLossReached},
+		},
+		{
+			name:    "stops after the last round",
+			autoBet: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, Rounds: 2, RoundsPlayed: 1, Status: config.AutoBetActive},
+			stake:   100,
+			payout:  200,
+			want: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, Rounds: 2,
+				RoundsPlayed: 2, Profit: 100, Status: config.AutoBetStopped, StopReason: config.StopRoundsCompleted},
+		},
+		{
+			name:    "profit follows the stake that was placed",
+			autoBet: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, Status: config.AutoBetActive},
+			stake:   50,
+			payout:  100,
+			want: model.AutoBet{Strategy: config.StrategyFixed, BaseAmount: 100, CurrentAmount: 100, RoundsPlayed: 1,
+				Profit: 50, Status: config.AutoBetActive},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Advance(&tt.autoBet, tt.stake, tt.payout)
+
+			assert.Equal(t, tt.want, tt.autoBet)
+		})
+	}
+}
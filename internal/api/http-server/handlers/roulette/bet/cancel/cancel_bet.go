@@ -0,0 +1,175 @@
+package cancel_bet
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/roulette/bet/save"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Response struct {
+	resp.Response
+}
+
+type Cancel struct {
+	log         *slog.Logger
+	rouletteRep repository.RouletteRepository
+	betRep      *repository.RouletteBetRepository
+	userRep     repository.UserRepository
+	balance     *balance.Balance
+	transaction repository.Transaction
+}
+
+func NewCancel(
+	log *slog.Logger,
+	rouletteRep repository.RouletteRepository,
+	betRep *repository.RouletteBetRepository,
+	userRep repository.UserRepository,
+	balance *balance.Balance,
+	transaction repository.Transaction) *Cancel {
+	return &Cancel{
+		log:         log,
+		rouletteRep: rouletteRep,
+		betRep:      betRep,
+		userRep:     userRep,
+		balance:     balance,
+		transaction: transaction,
+	}
+}
+
+// New handles DELETE /roulette/{uuid}/bets/{id}?user_uuid=. The bet is
+// removed and its amount refunded as long as the round accepts bets.
+func (c *Cancel) New() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.bet.cancel.New"
+
+		var (
+			err      error
+			log      *slog.Logger
+			betID    int64
+			roulette *model.Roulette
+			user     *model.User
+			bet      *model.RouletteBet
+			tx       *sql.Tx
+			deleted  bool
+			refund   *balance.Tx
+		)
+
+		log = c.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		betID, err = strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, resp.Error("invalid bet id", http.StatusBadRequest))
+
+			return
+		}
+
+		roulette, err = c.rouletteRep.FindRouletteByUUID(chi.URLParam(r, "uuid"))
+		if err != nil {
+			log.Error("failed to find start", sl.Err(err))
+
+			if errors.Is(err, repository.ErrRouletteNotFound) {
+				render.JSON(w, r, resp.ErrorCode("failed to find start", http.StatusNotFound, "roulette_not_found"))
+
+				return
+			}
+
+			render.JSON(w, r, resp.Error("failed to find start", http.StatusInternalServerError))
+
+			return
+		}
+
+		if err = place_bet.CheckBetWindow(roulette, time.Now()); err != nil {
+			log.Info("bet cancellation rejected", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusConflict, "betting_closed"))
+
+			return
+		}
+
+		user, err = c.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		bet, err = c.betRep.FindBetByID(betID)
+		if err != nil || bet.RouletteID != roulette.ID || bet.UserID != user.ID {
+			log.Error("failed to find bet", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode("failed to find bet", http.StatusNotFound, "bet_not_found"))
+
+			return
+		}
+
+		tx, err = c.transaction.StartTransaction()
+		if err != nil {
+			log.Error("failed to start transaction", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to start transaction", http.StatusInternalServerError))
+
+			return
+		}
+
+		refund = c.balance.WithTx(tx)
+		defer refund.Rollback()
+
+		// The delete and the refund commit together, and only the request
+		// that deleted the bet refunds it.
+		deleted, err = c.betRep.WithTx(tx).DeleteBet(bet.ID)
+		if err != nil {
+			log.Error("failed to delete bet", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to cancel bet", http.StatusInternalServerError))
+
+			return
+		}
+
+		if !deleted {
+			log.Info("bet already cancelled or settled", slog.Int64("bet_id", bet.ID))
+
+			render.JSON(w, r, resp.ErrorCode("failed to find bet", http.StatusNotFound, "bet_not_found"))
+
+			return
+		}
+
+		if err = refund.Income(user.ID, money.New(int64(bet.Amount), bet.Currency), config.Roulette); err != nil {
+			log.Error("failed to refund bet", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to refund bet", http.StatusInternalServerError))
+
+			return
+		}
+
+		if err = refund.Commit(); err != nil {
+			log.Error("failed to commit transaction", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to commit transaction", http.StatusInternalServerError))
+
+			return
+		}
+
+		log.Info("bet cancelled", slog.Int64("bet_id", bet.ID), slog.Int("amount", bet.Amount))
+
+		render.JSON(w, r, Response{Response: resp.OK()})
+	}
+}
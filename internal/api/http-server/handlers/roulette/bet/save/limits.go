@@ -0,0 +1,135 @@
+package place_bet
+
+import (
+	"errors"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/money"
+)
+
+var (
+	ErrExposureLimit = errors.New("house exposure limit reached for this round")
+	ErrPayoutLimit   = errors.New("potential payout exceeds the round limit")
+)
+
+// applyLimits checks the new bets of a user against the bets already on the
+// round. With the scale exposure mode the new bets come back scaled down so
+// that no outcome pays more than the cap in total.
+func applyLimits(
+	limits config.Limits,
+	wheel config.Wheel,
+	roundBets []model.RouletteBet,
+	userID int64,
+	bets []model.RouletteBet,
+) ([]model.RouletteBet, error) {
+	var err error
+
+	if limits.Exposure.Cap > 0 {
+		bets, err = capExposure(limits, wheel, roundBets, bets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if limits.MaxPayoutPerRound > 0 {
+		userBets := append([]model.RouletteBet(nil), bets...)
+		for _, bet := range roundBets {
+			if bet.UserID == userID {
+				userBets = append(userBets, bet)
+			}
+		}
+
+		if maxPayout(payouts(wheel, userBets)) > limits.MaxPayoutPerRound {
+			return nil, ErrPayoutLimit
+		}
+	}
+
+	return bets, nil
+}
+
+// inCurrency returns the bets placed in currency.
+func inCurrency(bets []model.RouletteBet, currency money.Currency) []model.RouletteBet {
+	same := make([]model.RouletteBet, 0, len(bets))
+	for _, bet := range bets {
+		if bet.Currency == currency {
+			same = append(same, bet)
+		}
+	}
+
+	return same
+}
+
+func capExposure(
+	limits config.Limits,
+	wheel config.Wheel,
+	roundBets []model.RouletteBet,
+	bets []model.RouletteBet,
+) ([]model.RouletteBet, error) {
+	current := payouts(wheel, roundBets)
+	added := payouts(wheel, bets)
+
+	// The largest share of the new bets that fits, as num/den.
+	num, den := 1, 1
+
+	for i := range current {
+		if added[i] == 0 || current[i]+added[i] <= limits.Exposure.Cap {
+			continue
+		}
+
+		room := limits.Exposure.Cap - current[i]
+		if room <= 0 {
+			return nil, ErrExposureLimit
+		}
+
+		if room*den < num*added[i] {
+			num, den = room, added[i]
+		}
+	}
+
+	if num == den {
+		return bets, nil
+	}
+
+	if limits.Exposure.Mode != config.ExposureScale {
+		return nil, ErrExposureLimit
+	}
+
+	scaled := make([]model.RouletteBet, 0, len(bets))
+
+	for _, bet := range bets {
+		bet.Amount = bet.Amount * num / den
+
+		if bet.Amount <= 0 || limits.CheckStake(bet.Type, bet.Amount) != nil {
+			return nil, ErrExposureLimit
+		}
+
+		scaled = append(scaled, bet)
+	}
+
+	return scaled, nil
+}
+
+// payouts returns what the bets pay in total for every pocket of the wheel.
+func payouts(wheel config.Wheel, bets []model.RouletteBet) []int {
+	result := make([]int, len(wheel.Pockets))
+
+	for i, pocket := range wheel.Pockets {
+		for _, bet := range bets {
+			result[i] += bet.Amount * wheel.Multiplier(bet.Type, bet.Value, pocket)
+		}
+	}
+
+	return result
+}
+
+func maxPayout(payouts []int) int {
+	max := 0
+
+	for _, payout := range payouts {
+		if payout > max {
+			max = payout
+		}
+	}
+
+	return max
+}
@@ -0,0 +1,136 @@
+package place_bet
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/money"
+	"testing"
+)
+
+func TestApplyLimits(t *testing.T) {
+	wheel := config.Wheel{
+		Pockets: []config.Pocket{{Number: 0, Color: config.Green}, {Number: 1, Color: config.Red}, {Number: 2, Color: config.Black}},
+		Payouts: config.Payouts{Color: map[config.Color]int{config.Green: 14, config.Red: 2, config.Black: 2}},
+	}
+
+	red := func(userID int64, amount int) model.RouletteBet {
+		return model.RouletteBet{Type: config.BetColor, Value: string(config.Red), Amount: amount, UserID: userID}
+	}
+
+	tests := []struct {
+		name      string
+		limits    config.Limits
+		roundBets []model.RouletteBet
+		bets      []model.RouletteBet
+		want      []model.RouletteBet
+		wantErr   error
+	}{
+		{
+			name:   "no limits",
+			limits: config.Limits{},
+			bets:   []model.RouletteBet{red(1, 1000)},
+			want:   []model.RouletteBet{red(1, 1000)},
+		},
+		{
+			name:      "under the exposure cap",
+			limits:    config.Limits{Exposure: config.Exposure{Cap: 1000}},
+			roundBets: []model.RouletteBet{red(2, 200)},
+			bets:      []model.RouletteBet{red(1, 300)},
+			want:      []model.RouletteBet{red(1, 300)},
+		},
+		{
+			name:      "exposure rejected",
+			limits:    config.Limits{Exposure: config.Exposure{Cap: 1000, Mode: config.ExposureReject}},
+			roundBets: []model.RouletteBet{red(2, 400)},
+			bets:      []model.RouletteBet{red(1, 200)},
+			wantErr:   ErrExposureLimit,
+		},
+		{
+			name:      "exposure scaled",
+			limits:    config.Limits{Exposure: config.Exposure{Cap: 1000, Mode: config.ExposureScale}},
+			roundBets: []model.RouletteBet{red(2, 400)},
+			bets:      []model.RouletteBet{red(1, 200)},
+			want:      []model.RouletteBet{red(1, 100)},
+		},
+		{
+			name: "scaled below the minimum stake",
+			limits: config.Limits{
+				Stake:    config.StakeLimit{Min: 150},
+				Exposure: config.Exposure{Cap: 1000, Mode: config.ExposureScale},
+			},
+			roundBets: []model.RouletteBet{red(2, 400)},
+			bets:      []model.RouletteBet{red(1, 200)},
+			wantErr:   ErrExposureLimit,
+		},
+		{
+			name:      "cap already reached",
+			limits:    config.Limits{Exposure: config.Exposure{Cap: 800, Mode: config.ExposureScale}},
+			roundBets: []model.RouletteBet{red(2, 400)},
+			bets:      []model.RouletteBet{red(1, 100)},
+			wantErr:   ErrExposureLimit,
+		},
+		{
+			name:      "payout limit counts earlier bets of the user",
+			limits:    config.Limits{MaxPayoutPerRound: 1000},
+			roundBets: []model.RouletteBet{red(1, 300), red(2, 5000)},
+			bets:      []model.RouletteBet{red(1, 300)},
+			wantErr:   ErrPayoutLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyLimits(tt.limits, wheel, tt.roundBets, 1, tt.bets)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyLimitsInCurrency(t *testing.T) {
+	wheel := config.Wheel{
+		Pockets: []config.Pocket{{Number: 0, Color: config.Green}, {Number: 1, Color: config.Red}},
+		Payouts: config.Payouts{Color: map[config.Color]int{config.Green: 14, config.Red: 2}},
+	}
+
+	red := func(userID int64, amount int) model.RouletteBet {
+		return model.RouletteBet{Type: config.BetColor, Value: string(config.Red), Amount: amount, UserID: userID,
+			Currency: money.COIN}
+	}
+
+	limits, err := config.Limits{
+		Exposure: config.Exposure{Cap: 1000, Mode: config.ExposureReject},
+		Currencies: map[money.Currency]config.CurrencyLimits{
+			money.COIN: {Exposure: config.Exposure{Cap: 100000}},
+		},
+	}.In(money.COIN)
+	require.NoError(t, err)
+
+	roundBets := []model.RouletteBet{red(2, 40000)}
+
+	got, err := applyLimits(limits, wheel, roundBets, 1, []model.RouletteBet{red(1, 5000)})
+	require.NoError(t, err)
+	assert.Equal(t, []model.RouletteBet{red(1, 5000)}, got)
+
+	_, err = applyLimits(limits, wheel, roundBets, 1, []model.RouletteBet{red(1, 20000)})
+	assert.ErrorIs(t, err, ErrExposureLimit)
+}
+
+func TestInCurrency(t *testing.T) {
+	bets := []model.RouletteBet{
+		{ID: 1, Currency: money.Default, Amount: 100},
+		{ID: 2, Currency: "EUR", Amount: 200},
+		{ID: 3, Currency: money.Default, Amount: 300},
+	}
+
+	assert.Equal(t, []model.RouletteBet{bets[0], bets[2]}, inCurrency(bets, money.Default))
+	assert.Equal(t, []model.RouletteBet{bets[1]}, inCurrency(bets, "EUR"))
+	assert.Empty(t, inCurrency(bets, "BTC"))
+}
@@ -2,6 +2,8 @@ package place_bet
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -11,26 +13,59 @@ import (
 	"go-outpost/internal/api/http-server/model"
 	"go-outpost/internal/api/repository"
 	resp "go-outpost/internal/lib/api/response"
-	"go-outpost/internal/lib/converter"
 	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
 	"golang.org/x/exp/slog"
 	"net/http"
+	"time"
 )
 
+// Request places bets staked in Currency, the default one when none is
+// given.
 type Request struct {
-	BetRequest []BetRequest `json:"bets" validate:"required,min=1,max=2"`
-	UserUUID   string       `json:"user_uuid" validate:"required"`
+	BetRequest []BetRequest   `json:"bets" validate:"required,min=1"`
+	UserUUID   string         `json:"user_uuid" validate:"required"`
+	Currency   money.Currency `json:"currency"`
 }
 
+// BetRequest describes one bet. Type defaults to a color bet, in which case
+// Color may be sent instead of Value for older clients.
 type BetRequest struct {
-	Color  config.Color `json:"color" validate:"required"`
-	Amount float64      `json:"amount" validate:"required,min=0.01"`
+	Type   config.BetType `json:"type" validate:"omitempty,oneof=color number range parity combo"`
+	Value  string         `json:"value" validate:"required_without=Color"`
+	Color  config.Color   `json:"color"`
+	Amount money.Amount   `json:"amount" validate:"required"`
+}
+
+func (b BetRequest) normalize() BetRequest {
+	if b.Type == "" {
+		b.Type = config.BetColor
+	}
+
+	if b.Type == config.BetColor && b.Value == "" {
+		b.Value = string(b.Color)
+	}
+
+	if b.Type == config.BetColor {
+		b.Color = config.Color(b.Value)
+	} else {
+		b.Color = ""
+	}
+
+	return b
 }
 
 type Response struct {
 	resp.Response
 }
 
+var (
+	ErrNoBalance           = errors.New("user has no balance")
+	ErrInsufficientBalance = errors.New("user has insufficient balance")
+	ErrTooManyBets         = errors.New("too many bets on this round")
+	ErrBettingClosed       = errors.New("betting is closed for this round")
+)
+
 type BetCounter interface {
 	CountBetsByRouletteAndUser(rouletteID int64, userID int64) (int, error)
 }
@@ -38,6 +73,8 @@ type BetCounter interface {
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=BetSaver
 type BetSaver interface {
 	SaveBet(bet model.RouletteBet) (int64, error)
+	GetBetsByRouletteID(rouletteID int64) ([]model.RouletteBet, error)
+	WithTx(tx *sql.Tx) *repository.RouletteBetRepository
 	BetCounter
 }
 
@@ -47,8 +84,10 @@ type Bet struct {
 	rouletteRep repository.RouletteRepository
 	betSaver    BetSaver
 	userRep     repository.UserRepository
-	balance     balance.Interface
+	balance     *balance.Balance
 	transaction repository.Transaction
+	wheel       config.Wheel
+	limits      config.Limits
 }
 
 func NewBet(
@@ -56,16 +95,20 @@ func NewBet(
 	rouletteRep repository.RouletteRepository,
 	betSaver BetSaver,
 	userRep repository.UserRepository,
-	balance balance.Interface,
-	transaction repository.Transaction) *Bet {
+	balance *balance.Balance,
+	transaction repository.Transaction,
+	wheel config.Wheel,
+	limits config.Limits) *Bet {
 	return &Bet{
 		log:         log,
-		validator:   validator.New(),
+		validator:   money.WithValidation(validator.New()),
 		rouletteRep: rouletteRep,
 		betSaver:    betSaver,
 		userRep:     userRep,
 		balance:     balance,
 		transaction: transaction,
+		wheel:       wheel,
+		limits:      limits,
 	}
 }
 
@@ -74,18 +117,15 @@ func (b *Bet) New() http.HandlerFunc {
 		const op = "handlers.bet.save.New"
 
 		var (
-			err             error
-			req             Request
-			log             *slog.Logger
-			uuidStr         string
-			roulette        *model.Roulette
-			user            *model.User
-			betCount        int
-			rouletteBet     model.RouletteBet
-			id              int64
-			convertedAmount int
-			userBalance     *model.UserBalance
-			tx              *sql.Tx
+			err      error
+			req      Request
+			log      *slog.Logger
+			uuidStr  string
+			roulette *model.Roulette
+			user     *model.User
+			bets     []model.RouletteBet
+			amount   money.Money
+			tx       *sql.Tx
 		)
 
 		log = b.log.With(
@@ -135,7 +175,7 @@ func (b *Bet) New() http.HandlerFunc {
 		if err != nil {
 			log.Error("failed to find start", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("failed to find start", http.StatusNotFound))
+			render.JSON(w, r, b.placeBetsError(err))
 
 			return
 		}
@@ -143,7 +183,7 @@ func (b *Bet) New() http.HandlerFunc {
 		log.Info("roulette found", slog.Any("roulette", roulette))
 
 		user, err = b.userRep.FindUserByUUID(req.UserUUID)
-		if err != nil {
+		if err != nil || user == nil {
 			log.Error("failed to find user", sl.Err(err))
 
 			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
@@ -153,117 +193,243 @@ func (b *Bet) New() http.HandlerFunc {
 
 		log.Info("user found", slog.Any("user", user))
 
-		userBalance, err = b.userRep.FindUserBalanceByID(user.ID)
-		if err != nil {
-			log.Error("failed to find user balance", sl.Err(err))
-
-			render.JSON(w, r, resp.Error("failed to find user balance", http.StatusInternalServerError))
-
-			return
+		if req.Currency == "" {
+			req.Currency = money.Default
 		}
 
-		log.Info("user balance found", slog.Any("user_balance", userBalance))
+		for _, bet := range req.BetRequest {
+			bet = bet.normalize()
 
-		if userBalance.Balance < 0 {
-			log.Error("user has no balance", sl.Err(err))
+			amount, err = bet.Amount.In(req.Currency)
+			if err == nil && amount.Minor() < 1 {
+				err = money.ErrInvalidAmount
+			}
+			if err != nil {
+				log.Error("invalid amount", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("user has no balance", http.StatusNotFound))
+				render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
 
-			return
-		}
+				if err = tx.Rollback(); err != nil {
+					log.Error("failed to rollback transaction", sl.Err(err))
+				}
 
-		log.Info("user has balance", slog.Any("user_balance", userBalance))
+				return
+			}
 
-		totalBetAmount := 0.0
-		for _, bet := range req.BetRequest {
-			totalBetAmount += bet.Amount
+			bets = append(bets, model.RouletteBet{
+				Type:   bet.Type,
+				Value:  bet.Value,
+				Color:  bet.Color,
+				Amount: int(amount.Minor()),
+			})
 		}
 
-		convertedAmount = converter.ConvertAmountFloatToInt(totalBetAmount)
+		if _, err = b.PlaceBets(roulette, user.ID, req.Currency, bets); err != nil {
+			log.Error("failed to place bets", sl.Err(err))
 
-		if userBalance.Balance < convertedAmount {
-			log.Error("user has insufficient balance", sl.Err(err))
+			render.JSON(w, r, b.placeBetsError(err))
 
-			render.JSON(w, r, resp.Error("user has insufficient balance", http.StatusNotFound))
+			if err = tx.Rollback(); err != nil {
+				log.Error("failed to rollback transaction", sl.Err(err))
+			}
 
 			return
 		}
 
-		log.Info("user has sufficient balance", slog.Any("user_balance", userBalance))
-
-		betCount, err = b.betSaver.CountBetsByRouletteAndUser(roulette.ID, user.ID)
-		if err != nil {
-			log.Error("failed to count bets", sl.Err(err))
+		if err = tx.Commit(); err != nil {
+			log.Error("failed to commit transaction", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("failed to count bets", http.StatusInternalServerError))
+			render.JSON(w, r, resp.Error("failed to commit transaction", http.StatusInternalServerError))
 
 			return
 		}
 
-		log.Info("bet count", slog.Any("bet_count", betCount))
+		responseOK(w, r)
+	}
+}
 
-		if betCount+len(req.BetRequest) > 2 {
-			log.Info("user has already placed 2 bets on this start",
-				slog.Any("user_id", user.ID),
-				slog.Any("roulette_id", roulette.ID))
+// PlaceBets validates the bets of one user on a round against the wheel and
+// the game limits of currency, debits the total stake and stores them. The
+// exposure cap only counts the bets of the round placed in the same currency. Bets may be stored
+// with a lower amount when the exposure cap scales them down. It backs both
+// the HTTP handler and auto-bets, and returns the bets as they were stored.
+// The round is locked while the limits and the balance are checked and the
+// bets stored, so two placements cannot both pass the exposure cap on the
+// same round and no bet lands on a round closed in the meantime.
+func (b *Bet) PlaceBets(
+	roulette *model.Roulette,
+	userID int64,
+	currency money.Currency,
+	bets []model.RouletteBet) ([]model.RouletteBet, error) {
+	const op = "handlers.bet.save.PlaceBets"
+
+	var (
+		err         error
+		log         *slog.Logger
+		betCount    int
+		totalAmount int
+		userBalance *model.UserBalance
+		roundBets   []model.RouletteBet
+		limits      config.Limits
+		lock        *sql.Tx
+		stake       *balance.Tx
+		betRep      *repository.RouletteBetRepository
+	)
+
+	log = b.log.With(
+		slog.String("op", op),
+		slog.Int64("user_id", userID),
+		slog.Int64("roulette_id", roulette.ID),
+	)
+
+	if err = CheckBetWindow(roulette, time.Now()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-			render.JSON(w, r, resp.Error(
-				"user is trying to place more than 2 bets on this start",
-				http.StatusInternalServerError))
+	limits, err = b.limits.In(currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-			return
+	lock, err = b.transaction.StartTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stake = b.balance.WithTx(lock)
+	defer func() {
+		if err := stake.Rollback(); err != nil {
+			log.Error("failed to release round lock", sl.Err(err))
 		}
+	}()
 
-		log.Info("user has not placed 2 bets on this start")
+	betRep = b.betSaver.WithTx(lock)
 
-		if err = b.balance.Outcome(user.ID, convertedAmount, config.Roulette); err != nil {
-			log.Error("failed to update user balance", sl.Err(err))
+	// The round may have closed while waiting for the lock.
+	roulette, err = b.rouletteRep.WithTx(lock).LockRoulette(roulette.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-			render.JSON(w, r, resp.Error("failed to update user balance", http.StatusInternalServerError))
+	if err = CheckBetWindow(roulette, time.Now()); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-			if err = tx.Rollback(); err != nil {
-				log.Error("failed to rollback transaction", sl.Err(err))
-			}
+	for _, bet := range bets {
+		if err = b.wheel.ValidateBet(bet.Type, bet.Value); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
 
-			return
+		if err = limits.CheckStake(bet.Type, bet.Amount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+	}
 
-		log.Info("user balance updated", slog.Any("user_balance", userBalance))
+	betCount, err = betRep.CountBetsByRouletteAndUser(roulette.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-		for _, bet := range req.BetRequest {
-			rouletteBet = model.RouletteBet{
-				Color:      bet.Color,
-				Amount:     converter.ConvertAmountFloatToInt(bet.Amount),
-				RouletteID: roulette.ID,
-				UserID:     user.ID,
-			}
+	log.Info("bet count", slog.Any("bet_count", betCount))
 
-			id, err = b.betSaver.SaveBet(rouletteBet)
-			if err != nil {
-				log.Error("failed to save bet", sl.Err(err))
+	if limits.MaxBetsPerRound > 0 && betCount+len(bets) > limits.MaxBetsPerRound {
+		return nil, fmt.Errorf("%s: %w", op, ErrTooManyBets)
+	}
 
-				render.JSON(w, r, resp.Error("failed to save bet", http.StatusInternalServerError))
+	roundBets, err = betRep.GetBetsByRouletteID(roulette.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-				if err = tx.Rollback(); err != nil {
-					log.Error("failed to rollback transaction", sl.Err(err))
-				}
+	bets, err = applyLimits(limits, b.wheel, inCurrency(roundBets, currency), userID, bets)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-				return
-			}
+	for _, bet := range bets {
+		totalAmount += bet.Amount
+	}
 
-			log.Info("bet saved", slog.Any("id", id))
-		}
+	userBalance, err = b.userRep.FindUserBalanceByID(userID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-		if err = tx.Commit(); err != nil {
-			log.Error("failed to commit transaction", sl.Err(err))
+	if userBalance == nil || userBalance.Balance.IsNegative() {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoBalance)
+	}
 
-			render.JSON(w, r, resp.Error("failed to commit transaction", http.StatusInternalServerError))
+	log.Info("user balance found", slog.Any("user_balance", userBalance))
 
-			return
+	if !userBalance.Balance.Covers(money.New(int64(totalAmount), currency)) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInsufficientBalance)
+	}
+
+	err = stake.Outcome(userID, money.New(int64(totalAmount), currency), config.Roulette)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		err = ErrInsufficientBalance
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user balance updated")
+
+	for i := range bets {
+		bets[i].RouletteID = roulette.ID
+		bets[i].UserID = userID
+		bets[i].Currency = currency
+
+		bets[i].ID, err = betRep.SaveBet(bets[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
-		responseOK(w, r)
+		log.Info("bet saved", slog.Any("id", bets[i].ID))
 	}
+
+	if err = stake.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bets, nil
+}
+
+// CheckBetWindow returns ErrBettingClosed once a round stopped accepting bets.
+func CheckBetWindow(roulette *model.Roulette, now time.Time) error {
+	if roulette.Status != config.RoundOpen || roulette.PlayedAt != nil || !now.Before(roulette.BetDeadline) {
+		return ErrBettingClosed
+	}
+
+	return nil
+}
+
+func (b *Bet) placeBetsError(err error) resp.Response {
+	switch {
+	case errors.Is(err, repository.ErrRouletteNotFound):
+		return resp.ErrorCode("failed to find start", http.StatusNotFound, "roulette_not_found")
+	case errors.Is(err, ErrBettingClosed):
+		return resp.ErrorCode(ErrBettingClosed.Error(), http.StatusConflict, "betting_closed")
+	case errors.Is(err, config.ErrInvalidBet):
+		return resp.Error(err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	case errors.Is(err, ErrTooManyBets):
+		return resp.ErrorCode(fmt.Sprintf("user is trying to place more than %d bets on this start",
+			b.limits.MaxBetsPerRound), http.StatusInternalServerError, "too_many_bets")
+	case errors.Is(err, config.ErrStakeOutOfRange):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "stake_out_of_range")
+	case errors.Is(err, config.ErrCurrencyNotAccepted):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "currency_not_accepted")
+	case errors.Is(err, ErrExposureLimit):
+		return resp.ErrorCode(ErrExposureLimit.Error(), http.StatusConflict, "exposure_limit")
+	case errors.Is(err, ErrPayoutLimit):
+		return resp.ErrorCode(ErrPayoutLimit.Error(), http.StatusConflict, "payout_limit")
+	}
+
+	return resp.Error("failed to place bets", http.StatusInternalServerError)
 }
 
 func responseOK(w http.ResponseWriter, r *http.Request) {
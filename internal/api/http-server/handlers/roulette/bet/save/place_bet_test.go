@@ -1,9 +1,48 @@
 package place_bet
 
 import (
+	"github.com/stretchr/testify/assert"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
 	"testing"
+	"time"
 )
 
 func TestSaveBetHandler(t *testing.T) {
 
 }
+
+func TestCheckBetWindow(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		roulette model.Roulette
+		wantErr  error
+	}{
+		{
+			name:     "open",
+			roulette: model.Roulette{Status: config.RoundOpen, BetDeadline: now.Add(time.Second)},
+		},
+		{
+			name:     "deadline passed",
+			roulette: model.Roulette{Status: config.RoundOpen, BetDeadline: now},
+			wantErr:  ErrBettingClosed,
+		},
+		{
+			name:     "closed",
+			roulette: model.Roulette{Status: config.RoundClosed, BetDeadline: now.Add(time.Second)},
+			wantErr:  ErrBettingClosed,
+		},
+		{
+			name:     "played",
+			roulette: model.Roulette{Status: config.RoundOpen, BetDeadline: now.Add(time.Second), PlayedAt: &now},
+			wantErr:  ErrBettingClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, CheckBetWindow(&tt.roulette, now), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,361 @@
+package history
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+
+	defaultStatsRounds = 100
+	maxStatsRounds     = 1000
+)
+
+// Round is a rolled round as listed in the history. Amounts are decimal strings,
+// the totals count the bets placed in the default currency.
+type Round struct {
+	UUID         string         `json:"uuid"`
+	Round        int64          `json:"round"`
+	Status       string         `json:"status"`
+	Winner       *config.Pocket `json:"winner"`
+	TotalWagered money.Money    `json:"total_wagered"`
+	TotalPaid    money.Money    `json:"total_paid"`
+	Players      int            `json:"players"`
+	PlayedAt     *time.Time     `json:"played_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type Bet struct {
+	ID       int64          `json:"id"`
+	UserID   int64          `json:"user_id"`
+	Type     config.BetType `json:"type"`
+	Value    string         `json:"value"`
+	Currency money.Currency `json:"currency"`
+	Amount   money.Money    `json:"amount"`
+	Payout   money.Money    `json:"payout"`
+}
+
+// Fairness lets players verify the draw. It is only shown once the round is
+// rolled, together with the winning pocket.
+type Fairness struct {
+	ClientSeed   string  `json:"client_seed"`
+	ServerSeed   string  `json:"server_seed"`
+	ResultedHash string  `json:"resulted_hash"`
+	Nonce        int     `json:"nonce"`
+	Result       float64 `json:"result"`
+	Min          int     `json:"min"`
+	Max          int     `json:"max"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Rounds  []Round `json:"rounds"`
+	Page    int     `json:"page"`
+	PerPage int     `json:"per_page"`
+	Total   int     `json:"total"`
+}
+
+type ShowResponse struct {
+	resp.Response
+	Round    Round     `json:"round"`
+	Bets     []Bet     `json:"bets"`
+	Fairness *Fairness `json:"fairness"`
+}
+
+type StatsResponse struct {
+	resp.Response
+	Stats Stats `json:"stats"`
+}
+
+type History struct {
+	log              *slog.Logger
+	rouletteRep      repository.RouletteRepository
+	rouletteBetRep   repository.RouletteBetRepository
+	rouletteWinRep   repository.RouletteWinnerRepository
+	provablyFairRepo repository.ProvablyFairRepository
+	wheel            config.Wheel
+}
+
+func NewHistory(
+	log *slog.Logger,
+	rouletteRep repository.RouletteRepository,
+	rouletteBetRep repository.RouletteBetRepository,
+	rouletteWinRep repository.RouletteWinnerRepository,
+	provablyFairRepo repository.ProvablyFairRepository,
+	wheel config.Wheel) *History {
+	return &History{
+		log:              log,
+		rouletteRep:      rouletteRep,
+		rouletteBetRep:   rouletteBetRep,
+		rouletteWinRep:   rouletteWinRep,
+		provablyFairRepo: provablyFairRepo,
+		wheel:            wheel,
+	}
+}
+
+// List handles GET /roulette/history?page=&per_page=.
+func (h *History) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.roulette.history.List"
+
+		var (
+			err       error
+			log       *slog.Logger
+			total     int
+			roulettes []model.Roulette
+			wins      []model.RouletteWinner
+			bets      []model.RouletteBet
+		)
+
+		log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		page := queryInt(r, "page", 1, 1, 0)
+		perPage := queryInt(r, "per_page", defaultPerPage, 1, maxPerPage)
+
+		total, err = h.rouletteRep.CountFinishedRoulettes()
+		if err == nil {
+			roulettes, err = h.rouletteRep.GetFinishedRoulettes(perPage, (page-1)*perPage)
+		}
+
+		ids := make([]int64, 0, len(roulettes))
+		for _, roulette := range roulettes {
+			ids = append(ids, roulette.ID)
+		}
+
+		if err == nil {
+			wins, err = h.rouletteWinRep.GetWinsByRouletteIDs(ids)
+		}
+
+		if err == nil {
+			bets, err = h.rouletteBetRep.GetBetsByRouletteIDs(ids)
+		}
+
+		if err != nil {
+			log.Error("failed to load roulette history", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to load roulette history", http.StatusInternalServerError))
+
+			return
+		}
+
+		winsByRound := make(map[int64]*model.RouletteWinner, len(wins))
+		for i := range wins {
+			winsByRound[wins[i].RouletteID] = &wins[i]
+		}
+
+		betsByRound := make(map[int64][]model.RouletteBet, len(roulettes))
+		for _, bet := range bets {
+			betsByRound[bet.RouletteID] = append(betsByRound[bet.RouletteID], bet)
+		}
+
+		rounds := make([]Round, 0, len(roulettes))
+		for _, roulette := range roulettes {
+			rounds = append(rounds, h.summarize(roulette, winsByRound[roulette.ID], betsByRound[roulette.ID]))
+		}
+
+		render.JSON(w, r, ListResponse{
+			Response: resp.OK(),
+			Rounds:   rounds,
+			Page:     page,
+			PerPage:  perPage,
+			Total:    total,
+		})
+	}
+}
+
+// Show handles GET /roulette/{uuid}. The winner and fairness data of a round
+// that was not rolled yet are withheld.
+func (h *History) Show() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.roulette.history.Show"
+
+		var (
+			err      error
+			log      *slog.Logger
+			roulette *model.Roulette
+			win      *model.RouletteWinner
+			bets     []model.RouletteBet
+			pf       *model.ProvablyFair
+			fairness *Fairness
+		)
+
+		log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		roulette, err = h.rouletteRep.FindRouletteByUUID(chi.URLParam(r, "uuid"))
+		if err != nil {
+			log.Error("failed to find roulette", sl.Err(err))
+
+			if errors.Is(err, repository.ErrRouletteNotFound) {
+				render.JSON(w, r, resp.ErrorCode("failed to find roulette", http.StatusNotFound, "roulette_not_found"))
+
+				return
+			}
+
+			render.JSON(w, r, resp.Error("failed to find roulette", http.StatusInternalServerError))
+
+			return
+		}
+
+		bets, err = h.rouletteBetRep.GetBetsByRouletteID(roulette.ID)
+		if err == nil && roulette.Status.Rolled() {
+			win, err = h.rouletteWinRep.FindWinByRouletteID(roulette.ID)
+			if err == nil {
+				pf, err = h.provablyFairRepo.FindProvablyFairByGame(roulette.ID, config.Roulette)
+			}
+		}
+
+		if err != nil {
+			log.Error("failed to load roulette", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to load roulette", http.StatusInternalServerError))
+
+			return
+		}
+
+		if pf != nil {
+			fairness = &Fairness{
+				ClientSeed:   pf.ClientSeed,
+				ServerSeed:   pf.ServerSeed,
+				ResultedHash: pf.ResultedHash,
+				Nonce:        pf.Nonce,
+				Result:       pf.ResultedRandomNumber,
+				Min:          pf.Min,
+				Max:          pf.Max,
+			}
+		}
+
+		views := make([]Bet, 0, len(bets))
+		for _, bet := range bets {
+			views = append(views, Bet{
+				ID:       bet.ID,
+				UserID:   bet.UserID,
+				Type:     bet.Type,
+				Value:    bet.Value,
+				Currency: bet.Currency,
+				Amount:   money.New(int64(bet.Amount), bet.Currency),
+				Payout:   money.New(int64(h.payout(bet, win)), bet.Currency),
+			})
+		}
+
+		render.JSON(w, r, ShowResponse{
+			Response: resp.OK(),
+			Round:    h.summarize(*roulette, win, bets),
+			Bets:     views,
+			Fairness: fairness,
+		})
+	}
+}
+
+// Stats handles GET /roulette/stats?rounds=.
+func (h *History) Stats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.roulette.history.Stats"
+
+		var (
+			err  error
+			log  *slog.Logger
+			wins []model.RouletteWinner
+		)
+
+		log = h.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		wins, err = h.rouletteWinRep.GetLastWins(queryInt(r, "rounds", defaultStatsRounds, 1, maxStatsRounds))
+		if err != nil {
+			log.Error("failed to load roulette wins", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to load roulette stats", http.StatusInternalServerError))
+
+			return
+		}
+
+		render.JSON(w, r, StatsResponse{Response: resp.OK(), Stats: NewStats(h.wheel, wins)})
+	}
+}
+
+func (h *History) summarize(roulette model.Roulette, win *model.RouletteWinner, bets []model.RouletteBet) Round {
+	round := Round{
+		UUID:      roulette.UUID.String(),
+		Round:     roulette.Round,
+		Status:    string(roulette.Status),
+		PlayedAt:  roulette.PlayedAt,
+		CreatedAt: roulette.CreatedAt,
+	}
+
+	if win != nil {
+		round.Winner = &config.Pocket{Number: win.Number, Color: win.Color}
+	}
+
+	wagered, paid := 0, 0
+	players := make(map[int64]bool)
+
+	for _, bet := range bets {
+		players[bet.UserID] = true
+
+		if bet.Currency != money.Default {
+			continue
+		}
+
+		wagered += bet.Amount
+		paid += h.payout(bet, win)
+	}
+
+	round.TotalWagered = money.Cents(wagered)
+	round.TotalPaid = money.Cents(paid)
+	round.Players = len(players)
+
+	return round
+}
+
+// payout is what the bet was paid, or what it pays once a rolled round is settled.
+func (h *History) payout(bet model.RouletteBet, win *model.RouletteWinner) int {
+	if bet.SettledAt != nil {
+		return bet.Payout
+	}
+
+	if win == nil {
+		return 0
+	}
+
+	return bet.Amount * h.wheel.Multiplier(bet.Type, bet.Value, config.Pocket{Number: win.Number, Color: win.Color})
+}
+
+// queryInt reads a positive query parameter, max 0 means unbounded.
+func queryInt(r *http.Request, name string, def int, min int, max int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return def
+	}
+
+	if value < min {
+		return min
+	}
+
+	if max > 0 && value > max {
+		return max
+	}
+
+	return value
+}
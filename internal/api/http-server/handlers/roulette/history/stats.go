@@ -0,0 +1,96 @@
+package history
+
+import (
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"sort"
+)
+
+const hotColdSize = 5
+
+type Streak struct {
+	Color  config.Color `json:"color,omitempty"`
+	Length int          `json:"length"`
+}
+
+type NumberCount struct {
+	Number int `json:"number"`
+	Count  int `json:"count"`
+}
+
+type Stats struct {
+	Rounds         int                  `json:"rounds"`
+	Colors         map[config.Color]int `json:"colors"`
+	CurrentStreak  Streak               `json:"current_streak"`
+	LongestStreaks map[config.Color]int `json:"longest_streaks"`
+	Hot            []NumberCount        `json:"hot"`
+	Cold           []NumberCount        `json:"cold"`
+}
+
+// NewStats summarises the given wins, newest first. Every pocket of the
+// wheel takes part in the hot/cold ranking, so numbers that never landed
+// show up as cold.
+func NewStats(wheel config.Wheel, wins []model.RouletteWinner) Stats {
+	stats := Stats{
+		Rounds:         len(wins),
+		Colors:         make(map[config.Color]int),
+		LongestStreaks: make(map[config.Color]int),
+	}
+
+	counts := make(map[int]int, len(wheel.Pockets))
+	for _, pocket := range wheel.Pockets {
+		counts[pocket.Number] = 0
+		stats.Colors[pocket.Color] = 0
+	}
+
+	var streak Streak
+
+	// Walk from the oldest win so the streak left over is the current one.
+	for i := len(wins) - 1; i >= 0; i-- {
+		win := wins[i]
+
+		stats.Colors[win.Color]++
+		counts[win.Number]++
+
+		if win.Color == streak.Color {
+			streak.Length++
+		} else {
+			streak = Streak{Color: win.Color, Length: 1}
+		}
+
+		if streak.Length > stats.LongestStreaks[streak.Color] {
+			stats.LongestStreaks[streak.Color] = streak.Length
+		}
+	}
+
+	stats.CurrentStreak = streak
+
+	numbers := make([]NumberCount, 0, len(counts))
+	for number, count := range counts {
+		numbers = append(numbers, NumberCount{Number: number, Count: count})
+	}
+
+	stats.Hot = rank(numbers, func(a, b int) bool { return a > b })
+	stats.Cold = rank(numbers, func(a, b int) bool { return a < b })
+
+	return stats
+}
+
+// rank orders the counts with before, ties go to the lower number, and keeps the first few.
+func rank(numbers []NumberCount, before func(a, b int) bool) []NumberCount {
+	ranked := append([]NumberCount(nil), numbers...)
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return before(ranked[i].Count, ranked[j].Count)
+		}
+
+		return ranked[i].Number < ranked[j].Number
+	})
+
+	if len(ranked) > hotColdSize {
+		ranked = ranked[:hotColdSize]
+	}
+
+	return ranked
+}
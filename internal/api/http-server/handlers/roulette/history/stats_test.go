@@ -0,0 +1,56 @@
+package history
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"testing"
+)
+
+func TestNewStats(t *testing.T) {
+	wheel := config.Wheel{
+		Pockets: []config.Pocket{
+			{Number: 0, Color: config.Green},
+			{Number: 1, Color: config.Red},
+			{Number: 2, Color: config.Black},
+			{Number: 3, Color: config.Red},
+			{Number: 4, Color: config.Black},
+			{Number: 5, Color: config.Red},
+			{Number: 6, Color: config.Black},
+		},
+	}
+
+	win := func(number int, color config.Color) model.RouletteWinner {
+		return model.RouletteWinner{Number: number, Color: color}
+	}
+
+	// Newest first: red, red, black, red, red, red, green.
+	wins := []model.RouletteWinner{
+		win(1, config.Red),
+		win(3, config.Red),
+		win(2, config.Black),
+		win(1, config.Red),
+		win(5, config.Red),
+		win(1, config.Red),
+		win(0, config.Green),
+	}
+
+	stats := NewStats(wheel, wins)
+
+	assert.Equal(t, 7, stats.Rounds)
+	assert.Equal(t, map[config.Color]int{config.Red: 5, config.Black: 1, config.Green: 1}, stats.Colors)
+	assert.Equal(t, Streak{Color: config.Red, Length: 2}, stats.CurrentStreak)
+	assert.Equal(t, map[config.Color]int{config.Red: 3, config.Black: 1, config.Green: 1}, stats.LongestStreaks)
+	assert.Equal(t, []NumberCount{{1, 3}, {0, 1}, {2, 1}, {3, 1}, {5, 1}}, stats.Hot)
+	assert.Equal(t, []NumberCount{{4, 0}, {6, 0}, {0, 1}, {2, 1}, {3, 1}}, stats.Cold)
+}
+
+func TestNewStatsWithoutWins(t *testing.T) {
+	wheel := config.Wheel{Pockets: []config.Pocket{{Number: 0, Color: config.Green}, {Number: 1, Color: config.Red}}}
+
+	stats := NewStats(wheel, nil)
+
+	assert.Equal(t, 0, stats.Rounds)
+	assert.Equal(t, Streak{}, stats.CurrentStreak)
+	assert.Equal(t, []NumberCount{{0, 0}, {1, 0}}, stats.Hot)
+}
@@ -0,0 +1,157 @@
+package start
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/job"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/lib/logger/sl"
+	"golang.org/x/exp/slog"
+	"time"
+)
+
+type recoveryAction string
+
+const (
+	// recoveryReschedule puts the roll job of a round still taking bets back in the queue.
+	recoveryReschedule recoveryAction = "reschedule"
+	// recoveryRoll closes, rolls and settles a round whose bet window is over.
+	recoveryRoll recoveryAction = "roll"
+	// recoverySettle pays out a round that was already rolled.
+	recoverySettle recoveryAction = "settle"
+	// recoveryRefund gives back the bets of a round that was never rolled.
+	recoveryRefund recoveryAction = "refund"
+)
+
+// RouletteRecovery finishes the rounds a previous process left behind and
+// writes an audit entry for everything it does.
+type RouletteRecovery struct {
+	log         *slog.Logger
+	rouletteRep repository.RouletteRepository
+	auditRep    repository.AuditRepository
+	start       *RouletteStart
+	settler     *RouletteSettler
+	policy      config.RecoveryPolicy
+}
+
+func NewRouletteRecovery(
+	log *slog.Logger,
+	rouletteRep repository.RouletteRepository,
+	auditRep repository.AuditRepository,
+	start *RouletteStart,
+	settler *RouletteSettler,
+	policy config.RecoveryPolicy) *RouletteRecovery {
+	return &RouletteRecovery{
+		log:         log,
+		rouletteRep: rouletteRep,
+		auditRep:    auditRep,
+		start:       start,
+		settler:     settler,
+		policy:      policy,
+	}
+}
+
+// Recover must run before the server accepts requests. A round that cannot
+// be recovered is logged and audited, the others are still processed.
+func (r *RouletteRecovery) Recover() (int, error) {
+	const op = "handlers.roulette.start.Recover"
+
+	var (
+		err       error
+		roulettes []model.Roulette
+		recovered int
+	)
+
+	roulettes, err = r.rouletteRep.GetIncompleteRoulettes()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range roulettes {
+		if err = r.recover(&roulettes[i]); err != nil {
+			r.log.Error("failed to recover roulette", slog.Int64("roulette_id", roulettes[i].ID), sl.Err(err))
+
+			continue
+		}
+
+		recovered++
+	}
+
+	return recovered, nil
+}
+
+func (r *RouletteRecovery) recover(roulette *model.Roulette) error {
+	var (
+		err      error
+		refunded int
+	)
+
+	action := recoveryActionFor(*roulette, r.policy, time.Now())
+
+	details := map[string]interface{}{
+		"round":  roulette.Round,
+		"status": roulette.Status,
+		"policy": r.policy,
+	}
+
+	switch action {
+	case recoveryReschedule:
+		job.Dispatch(&RouletteRollJob{RouletteStart: r.start, RouletteID: roulette.ID}, time.Until(roulette.BetDeadline))
+
+		details["bet_deadline"] = roulette.BetDeadline
+	case recoveryRoll, recoverySettle:
+		err = r.start.RollRound(roulette.ID)
+	case recoveryRefund:
+		refunded, err = r.settler.Refund(roulette)
+
+		details["refunded_bets"] = refunded
+	}
+
+	if err != nil {
+		details["error"] = err.Error()
+
+		r.audit(roulette.ID, "recovery."+string(action)+".failed", details)
+
+		return err
+	}
+
+	r.log.Info("roulette recovered", slog.Int64("roulette_id", roulette.ID), slog.String("action", string(action)))
+
+	r.audit(roulette.ID, "recovery."+string(action), details)
+
+	return nil
+}
+
+func (r *RouletteRecovery) audit(rouletteID int64, action string, details map[string]interface{}) {
+	data, err := json.Marshal(details)
+	if err != nil {
+		r.log.Error("failed to encode audit details", sl.Err(err))
+	}
+
+	err = r.auditRep.SaveAuditEntry(model.AuditEntry{
+		Game:    config.Roulette,
+		GameID:  rouletteID,
+		Action:  action,
+		Details: string(data),
+	})
+	if err != nil {
+		r.log.Error("failed to save audit entry", sl.Err(err))
+	}
+}
+
+// recoveryActionFor picks what to do with an incomplete round. Rolled rounds
+// are always settled since their result may already be public.
+func recoveryActionFor(roulette model.Roulette, policy config.RecoveryPolicy, now time.Time) recoveryAction {
+	switch {
+	case roulette.Status.Rolled():
+		return recoverySettle
+	case roulette.Status == config.RoundOpen && now.Before(roulette.BetDeadline):
+		return recoveryReschedule
+	case policy == config.RecoveryRefund:
+		return recoveryRefund
+	default:
+		return recoveryRoll
+	}
+}
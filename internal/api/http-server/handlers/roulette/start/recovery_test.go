@@ -0,0 +1,56 @@
+package start
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"testing"
+	"time"
+)
+
+func TestRecoveryActionFor(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		roulette model.Roulette
+		policy   config.RecoveryPolicy
+		want     recoveryAction
+	}{
+		{
+			name:     "open round still taking bets",
+			roulette: model.Roulette{Status: config.RoundOpen, BetDeadline: now.Add(time.Second)},
+			policy:   config.RecoveryRefund,
+			want:     recoveryReschedule,
+		},
+		{
+			name:     "open round past its deadline is rolled",
+			roulette: model.Roulette{Status: config.RoundOpen, BetDeadline: now.Add(-time.Second)},
+			policy:   config.RecoverySettle,
+			want:     recoveryRoll,
+		},
+		{
+			name:     "closed round is rolled",
+			roulette: model.Roulette{Status: config.RoundClosed, BetDeadline: now.Add(-time.Second)},
+			policy:   config.RecoverySettle,
+			want:     recoveryRoll,
+		},
+		{
+			name:     "closed round is refunded",
+			roulette: model.Roulette{Status: config.RoundClosed, BetDeadline: now.Add(-time.Second)},
+			policy:   config.RecoveryRefund,
+			want:     recoveryRefund,
+		},
+		{
+			name:     "rolled round is settled whatever the policy",
+			roulette: model.Roulette{Status: config.RoundRolled, BetDeadline: now.Add(-time.Second)},
+			policy:   config.RecoveryRefund,
+			want:     recoverySettle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, recoveryActionFor(tt.roulette, tt.policy, now))
+		})
+	}
+}
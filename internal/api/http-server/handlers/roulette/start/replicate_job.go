@@ -0,0 +1,18 @@
+package start
+
+import (
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/logger/sl"
+)
+
+// RouletteBetReplicateJob places the bets of active auto-bet strategies on a new round.
+type RouletteBetReplicateJob struct {
+	RouletteStart *RouletteStart
+	Roulette      *model.Roulette
+}
+
+func (job *RouletteBetReplicateJob) Execute() {
+	if err := job.RouletteStart.autoBets.PlaceRound(job.Roulette); err != nil {
+		job.RouletteStart.log.Error("failed to replicate auto bets", sl.Err(err))
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"go-outpost/internal/api/http-server/handlers/provably_fair"
 	"go-outpost/internal/api/http-server/model"
 	"go-outpost/internal/api/repository"
+	"go-outpost/internal/game"
 	"go-outpost/internal/lib/logger/sl"
 	"golang.org/x/exp/slog"
 )
 
 type RouletteRoller struct {
-	RouletteColors           map[config.Color]config.RouletteColorConfig
+	Wheel                    config.Wheel
+	engine                   *game.Roulette
 	ProvablyFair             *provably_fair.ProvablyFair
 	RouletteWinnerRepository repository.RouletteWinnerRepository
 	log                      *slog.Logger
@@ -21,10 +23,12 @@ type RouletteRoller struct {
 func NewRouletteRoller(
 	RouletteWinnerRepository repository.RouletteWinnerRepository,
 	ProvablyFair *provably_fair.ProvablyFair,
+	wheel config.Wheel,
 	log *slog.Logger,
 ) *RouletteRoller {
 	return &RouletteRoller{
-		RouletteColors:           config.RouletteWheelConfig.Colors,
+		Wheel:                    wheel,
+		engine:                   game.NewRoulette(wheel),
 		ProvablyFair:             ProvablyFair,
 		RouletteWinnerRepository: RouletteWinnerRepository,
 		log:                      log,
@@ -36,52 +40,33 @@ type RouletteWinColorAndNumberData struct {
 	Number int          `json:"number"`
 }
 
-type RouletteColorData struct {
-	Color       config.Color `json:"color"`
-	Probability float64      `json:"probability"`
-}
-
 func (r *RouletteRoller) Roll(roulette *model.Roulette) (*RouletteWinColorAndNumberData, error) {
 	const op = "handlers.roulette.start.Roll"
 
 	var (
 		drawID           int64
 		err              error
-		number           int
-		color            config.Color
-		colors           map[config.Color]RouletteColorData
-		stopAt           float64
+		result           game.Result
 		provablyFairData provably_fair.ProvablyFairData
-		maxProbability   int
 		clientSeed       string
-		probability      config.RouletteColorConfig
 	)
 
-	maxProbability = config.RouletteWheelConfig.MaxWinProbability
-
 	clientSeed = uuid.New().String()
 
-	provablyFairData = r.ProvablyFair.GetRandomNumber(clientSeed, maxProbability)
-	stopAt = provablyFairData.Result
-
-	colors = make(map[config.Color]RouletteColorData)
-	for color, probability = range r.RouletteColors {
-		colors[color] = RouletteColorData{
-			Color:       color,
-			Probability: probability.Probability,
-		}
-	}
-
-	color = r.GetWinner(colors, stopAt)
+	provablyFairData = r.ProvablyFair.GetRandomIndex(clientSeed, len(r.Wheel.Pockets))
 
-	number, err = r.RouletteWinnerRepository.GetNumberByColor(color)
+	result, err = r.engine.Resolve(game.Wager{}, game.RNG{
+		ServerSeed: provablyFairData.ServerSeed,
+		ClientSeed: provablyFairData.ClientSeed,
+		Nonce:      provablyFairData.Nonce,
+	})
 	if err != nil {
-		r.log.Error("failed to get number by color", sl.Err(err))
-
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if err = r.RouletteWinnerRepository.SaveWin(roulette, color, number); err != nil {
+	pocket := result.Value.(config.Pocket)
+
+	if err = r.RouletteWinnerRepository.SaveWin(roulette, pocket.Color, pocket.Number); err != nil {
 		r.log.Error("failed to save roulette winner", sl.Err(err))
 
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -101,24 +86,7 @@ func (r *RouletteRoller) Roll(roulette *model.Roulette) (*RouletteWinColorAndNum
 	}
 
 	return &RouletteWinColorAndNumberData{
-		Color:  color,
-		Number: number,
+		Color:  pocket.Color,
+		Number: pocket.Number,
 	}, nil
 }
-
-func (r *RouletteRoller) GetWinner(colors map[config.Color]RouletteColorData, stopAt float64) config.Color {
-	var (
-		color              config.Color
-		currentProbability float64
-	)
-
-	for _, colorData := range colors {
-		currentProbability += colorData.Probability
-
-		if currentProbability >= stopAt {
-			return colorData.Color
-		}
-	}
-
-	return color
-}
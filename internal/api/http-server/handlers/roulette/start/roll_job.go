@@ -0,0 +1,15 @@
+package start
+
+import "go-outpost/internal/lib/logger/sl"
+
+// RouletteRollJob runs when the bet window of a round is over.
+type RouletteRollJob struct {
+	RouletteStart *RouletteStart
+	RouletteID    int64
+}
+
+func (job *RouletteRollJob) Execute() {
+	if err := job.RouletteStart.RollRound(job.RouletteID); err != nil {
+		job.RouletteStart.log.Error("failed to roll roulette", sl.Err(err))
+	}
+}
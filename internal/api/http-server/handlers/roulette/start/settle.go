@@ -0,0 +1,291 @@
+package start
+
+import (
+	"database/sql"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/leaderboard"
+	"go-outpost/internal/api/http-server/handlers/roulette/autobet"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/game"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+)
+
+type BetLister interface {
+	GetBetsByRouletteID(rouletteID int64) ([]model.RouletteBet, error)
+}
+
+type RoundMarker interface {
+	MarkRouletteSettled(rouletteID int64, status config.RoundStatus) (bool, error)
+	GetUnsettledRoulettes() ([]model.Roulette, error)
+}
+
+type WinFinder interface {
+	FindWinByRouletteID(rouletteID int64) (*model.RouletteWinner, error)
+}
+
+type AutoBets interface {
+	Settle(rouletteID int64, pocket config.Pocket) error
+	Release(rouletteID int64) error
+}
+
+// BetPayer claims a bet with its payout and credits the payout. It reports
+// whether this call claimed the bet, a bet is only ever claimed once.
+type BetPayer interface {
+	Pay(bet model.RouletteBet, payout int) (bool, error)
+}
+
+// RouletteSettler pays out the bets of a rolled round against the pocket of
+// that same round. Every bet is claimed and paid on one transaction and the
+// round is marked settled at the end, so settling twice never pays twice and
+// a run that stops halfway leaves no bet claimed but unpaid.
+type RouletteSettler struct {
+	log         *slog.Logger
+	rouletteRep RoundMarker
+	betRep      BetLister
+	winRep      WinFinder
+	payer       BetPayer
+	engine      *game.Roulette
+	leaderboard leaderboard.Recorder
+	autoBets    AutoBets
+}
+
+func NewRouletteSettler(
+	log *slog.Logger,
+	rouletteRep repository.RouletteRepository,
+	rouletteBetRep repository.RouletteBetRepository,
+	rouletteWinRep repository.RouletteWinnerRepository,
+	wheel config.Wheel,
+	balance *balance.Balance,
+	transaction repository.Transaction,
+	leaderboard leaderboard.Recorder,
+	autoBets *autobet.Runner) *RouletteSettler {
+	return &RouletteSettler{
+		log:         log,
+		rouletteRep: &rouletteRep,
+		betRep:      &rouletteBetRep,
+		winRep:      &rouletteWinRep,
+		payer:       &txPayer{rouletteBetRep: rouletteBetRep, balance: balance, transaction: transaction},
+		engine:      game.NewRoulette(wheel),
+		leaderboard: leaderboard,
+		autoBets:    autoBets,
+	}
+}
+
+func (s *RouletteSettler) Settle(roulette *model.Roulette, pocket config.Pocket) error {
+	const op = "handlers.roulette.start.Settle"
+
+	var (
+		err     error
+		log     *slog.Logger
+		bets    []model.RouletteBet
+		payout  int
+		claimed bool
+		marked  bool
+	)
+
+	log = s.log.With(
+		slog.String("op", op),
+		slog.Int64("roulette_id", roulette.ID),
+	)
+
+	if roulette.SettledAt != nil {
+		log.Info("roulette already settled")
+
+		return nil
+	}
+
+	bets, err = s.betRep.GetBetsByRouletteID(roulette.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, bet := range bets {
+		if bet.SettledAt != nil {
+			continue
+		}
+
+		payout = s.engine.Settle(game.Wager{
+			Amount: bet.Amount,
+			Choice: game.RouletteChoice{Type: bet.Type, Value: bet.Value},
+		}, game.Result{Value: pocket})
+
+		claimed, err = s.payer.Pay(bet, payout)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !claimed {
+			continue
+		}
+
+		s.leaderboard.Record(bet.UserID, config.Roulette, money.New(int64(bet.Amount), bet.Currency),
+			money.New(int64(payout), bet.Currency))
+
+		if payout == 0 {
+			continue
+		}
+
+		log.Info("bet paid out",
+			sl.Any("bet_id", bet.ID),
+			sl.Any("user_id", bet.UserID),
+			sl.Any("payout", payout))
+	}
+
+	if err = s.autoBets.Settle(roulette.ID, pocket); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	marked, err = s.rouletteRep.MarkRouletteSettled(roulette.ID, config.RoundSettled)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !marked {
+		log.Info("roulette settled by another run")
+
+		return nil
+	}
+
+	log.Info("roulette settled", slog.Int("bets", len(bets)))
+
+	return nil
+}
+
+// Refund gives back every unsettled bet of a round that was never rolled.
+func (s *RouletteSettler) Refund(roulette *model.Roulette) (int, error) {
+	const op = "handlers.roulette.start.Refund"
+
+	var (
+		err      error
+		bets     []model.RouletteBet
+		claimed  bool
+		marked   bool
+		refunded int
+	)
+
+	if roulette.SettledAt != nil {
+		return 0, nil
+	}
+
+	bets, err = s.betRep.GetBetsByRouletteID(roulette.ID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, bet := range bets {
+		if bet.SettledAt != nil {
+			continue
+		}
+
+		claimed, err = s.payer.Pay(bet, bet.Amount)
+		if err != nil {
+			return refunded, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if claimed {
+			refunded++
+		}
+	}
+
+	if err = s.autoBets.Release(roulette.ID); err != nil {
+		return refunded, fmt.Errorf("%s: %w", op, err)
+	}
+
+	marked, err = s.rouletteRep.MarkRouletteSettled(roulette.ID, config.RoundRefunded)
+	if err != nil {
+		return refunded, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !marked {
+		s.log.Info("roulette refunded by another run", slog.Int64("roulette_id", roulette.ID))
+	}
+
+	return refunded, nil
+}
+
+// Reconcile settles every round that was rolled but never settled, for
+// example because the process stopped in between. It returns the number of
+// rounds it settled.
+func (s *RouletteSettler) Reconcile() (int, error) {
+	const op = "handlers.roulette.start.Reconcile"
+
+	var (
+		err       error
+		roulettes []model.Roulette
+		win       *model.RouletteWinner
+		settled   int
+	)
+
+	roulettes, err = s.rouletteRep.GetUnsettledRoulettes()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range roulettes {
+		win, err = s.winRep.FindWinByRouletteID(roulettes[i].ID)
+		if err != nil {
+			return settled, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if win == nil {
+			s.log.Warn("rolled roulette has no winning pocket", slog.Int64("roulette_id", roulettes[i].ID))
+
+			continue
+		}
+
+		if err = s.Settle(&roulettes[i], config.Pocket{Number: win.Number, Color: win.Color}); err != nil {
+			return settled, fmt.Errorf("%s: %w", op, err)
+		}
+
+		settled++
+	}
+
+	return settled, nil
+}
+
+// txPayer is the BetPayer of the server, bets are claimed in the database.
+type txPayer struct {
+	rouletteBetRep repository.RouletteBetRepository
+	balance        *balance.Balance
+	transaction    repository.Transaction
+}
+
+// Pay claims a bet and credits its payout on one transaction.
+func (p *txPayer) Pay(bet model.RouletteBet, payout int) (bool, error) {
+	var (
+		err     error
+		tx      *sql.Tx
+		credit  *balance.Tx
+		claimed bool
+	)
+
+	tx, err = p.transaction.StartTransaction()
+	if err != nil {
+		return false, err
+	}
+
+	credit = p.balance.WithTx(tx)
+	defer credit.Rollback()
+
+	claimed, err = p.rouletteBetRep.WithTx(tx).ClaimBetSettlement(bet.ID, payout)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if payout > 0 {
+		if err = credit.Income(bet.UserID, money.New(int64(payout), bet.Currency), config.Roulette); err != nil {
+			return false, err
+		}
+	}
+
+	if err = credit.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
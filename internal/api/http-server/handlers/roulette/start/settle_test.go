@@ -0,0 +1,295 @@
+package start
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/game"
+	"go-outpost/internal/lib/logger/handler/slogdiscard"
+	"go-outpost/internal/lib/money"
+	"testing"
+	"time"
+)
+
+var testWheel = config.Wheel{
+	Pockets: []config.Pocket{{Number: 0, Color: config.Green}, {Number: 1, Color: config.Red},
+		{Number: 2, Color: config.Black}},
+	Payouts: config.Payouts{Color: map[config.Color]int{config.Red: 2, config.Black: 2, config.Green: 14}, Number: 3},
+}
+
+// book keeps the rounds, bets and balances of the settler in memory. A bet is
+// claimed once like ClaimBetSettlement does and a round marked once like
+// MarkRouletteSettled does.
+type book struct {
+	bets      map[int64][]model.RouletteBet
+	wins      map[int64]*model.RouletteWinner
+	unsettled []model.Roulette
+	claimed   map[int64]bool
+	marked    map[int64]config.RoundStatus
+	credited  map[int64]int
+}
+
+func newBook(bets ...model.RouletteBet) *book {
+	b := &book{
+		bets:     make(map[int64][]model.RouletteBet),
+		wins:     make(map[int64]*model.RouletteWinner),
+		claimed:  make(map[int64]bool),
+		marked:   make(map[int64]config.RoundStatus),
+		credited: make(map[int64]int),
+	}
+
+	for _, bet := range bets {
+		b.bets[bet.RouletteID] = append(b.bets[bet.RouletteID], bet)
+	}
+
+	return b
+}
+
+func (b *book) GetBetsByRouletteID(rouletteID int64) ([]model.RouletteBet, error) {
+	return b.bets[rouletteID], nil
+}
+
+func (b *book) MarkRouletteSettled(rouletteID int64, status config.RoundStatus) (bool, error) {
+	if _, ok := b.marked[rouletteID]; ok {
+		return false, nil
+	}
+
+	b.marked[rouletteID] = status
+
+	return true, nil
+}
+
+func (b *book) GetUnsettledRoulettes() ([]model.Roulette, error) {
+	return b.unsettled, nil
+}
+
+func (b *book) FindWinByRouletteID(rouletteID int64) (*model.RouletteWinner, error) {
+	return b.wins[rouletteID], nil
+}
+
+func (b *book) Pay(bet model.RouletteBet, payout int) (bool, error) {
+	if bet.SettledAt != nil || b.claimed[bet.ID] {
+		return false, nil
+	}
+
+	b.claimed[bet.ID] = true
+	b.credited[bet.UserID] += payout
+
+	return true, nil
+}
+
+func (b *book) Settle(int64, config.Pocket) error { return nil }
+
+func (b *book) Release(int64) error { return nil }
+
+func (b *book) Record(int64, config.Game, money.Money, money.Money) {}
+
+func newTestSettler(b *book) *RouletteSettler {
+	return &RouletteSettler{
+		log:         slogdiscard.NewDiscardLogger(),
+		rouletteRep: b,
+		betRep:      b,
+		winRep:      b,
+		payer:       b,
+		engine:      game.NewRoulette(testWheel),
+		leaderboard: b,
+		autoBets:    b,
+	}
+}
+
+func TestSettle(t *testing.T) {
+	red := config.Pocket{Number: 1, Color: config.Red}
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		roulette   model.Roulette
+		bets       []model.RouletteBet
+		runs       int
+		wantCredit map[int64]int
+		wantMarked map[int64]config.RoundStatus
+	}{
+		{
+			name:     "pays the winning bets",
+			roulette: model.Roulette{ID: 1},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100},
+				{ID: 2, RouletteID: 1, UserID: 2, Type: config.BetColor, Value: "black", Amount: 100},
+				{ID: 3, RouletteID: 1, UserID: 2, Type: config.BetNumber, Value: "1", Amount: 10},
+			},
+			runs:       1,
+			wantCredit: map[int64]int{1: 200, 2: 30},
+			wantMarked: map[int64]config.RoundStatus{1: config.RoundSettled},
+		},
+		{
+			name:     "settling twice pays once",
+			roulette: model.Roulette{ID: 1},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100},
+			},
+			runs:       2,
+			wantCredit: map[int64]int{1: 200},
+			wantMarked: map[int64]config.RoundStatus{1: config.RoundSettled},
+		},
+		{
+			name:     "bet settled by an earlier run is skipped",
+			roulette: model.Roulette{ID: 1},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100, SettledAt: &now},
+				{ID: 2, RouletteID: 1, UserID: 2, Type: config.BetColor, Value: "red", Amount: 50},
+			},
+			runs:       1,
+			wantCredit: map[int64]int{2: 100},
+			wantMarked: map[int64]config.RoundStatus{1: config.RoundSettled},
+		},
+		{
+			name:     "settled round is left alone",
+			roulette: model.Roulette{ID: 1, SettledAt: &now},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100},
+			},
+			runs:       1,
+			wantCredit: map[int64]int{},
+			wantMarked: map[int64]config.RoundStatus{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBook(tt.bets...)
+			s := newTestSettler(b)
+
+			for i := 0; i < tt.runs; i++ {
+				require.NoError(t, s.Settle(&tt.roulette, red))
+			}
+
+			assert.Equal(t, tt.wantCredit, b.credited)
+			assert.Equal(t, tt.wantMarked, b.marked)
+		})
+	}
+}
+
+func TestRefund(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		roulette     model.Roulette
+		bets         []model.RouletteBet
+		wantRefunded []int
+		wantCredit   map[int64]int
+		wantMarked   map[int64]config.RoundStatus
+	}{
+		{
+			name:     "rolled but unsettled round gives the stakes back",
+			roulette: model.Roulette{ID: 1, Status: config.RoundRolled},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100},
+				{ID: 2, RouletteID: 1, UserID: 2, Type: config.BetColor, Value: "black", Amount: 40},
+			},
+			wantRefunded: []int{2, 0},
+			wantCredit:   map[int64]int{1: 100, 2: 40},
+			wantMarked:   map[int64]config.RoundStatus{1: config.RoundRefunded},
+		},
+		{
+			name:     "bet paid before the run stopped is not refunded",
+			roulette: model.Roulette{ID: 1, Status: config.RoundRolled},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100, SettledAt: &now},
+				{ID: 2, RouletteID: 1, UserID: 2, Type: config.BetColor, Value: "black", Amount: 40},
+			},
+			wantRefunded: []int{1},
+			wantCredit:   map[int64]int{2: 40},
+			wantMarked:   map[int64]config.RoundStatus{1: config.RoundRefunded},
+		},
+		{
+			name:     "settled round is left alone",
+			roulette: model.Roulette{ID: 1, Status: config.RoundSettled, SettledAt: &now},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100},
+			},
+			wantRefunded: []int{0},
+			wantCredit:   map[int64]int{},
+			wantMarked:   map[int64]config.RoundStatus{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBook(tt.bets...)
+			s := newTestSettler(b)
+
+			for _, want := range tt.wantRefunded {
+				refunded, err := s.Refund(&tt.roulette)
+				require.NoError(t, err)
+				assert.Equal(t, want, refunded)
+			}
+
+			assert.Equal(t, tt.wantCredit, b.credited)
+			assert.Equal(t, tt.wantMarked, b.marked)
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name        string
+		unsettled   []model.Roulette
+		wins        map[int64]*model.RouletteWinner
+		bets        []model.RouletteBet
+		wantSettled int
+		wantCredit  map[int64]int
+		wantMarked  map[int64]config.RoundStatus
+	}{
+		{
+			name:      "settles the stuck rounds",
+			unsettled: []model.Roulette{{ID: 1, Status: config.RoundRolled}, {ID: 2, Status: config.RoundRolled}},
+			wins: map[int64]*model.RouletteWinner{
+				1: {RouletteID: 1, Number: 1, Color: config.Red},
+				2: {RouletteID: 2, Number: 0, Color: config.Green},
+			},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100},
+				{ID: 2, RouletteID: 2, UserID: 1, Type: config.BetColor, Value: "green", Amount: 10},
+			},
+			wantSettled: 2,
+			wantCredit:  map[int64]int{1: 340},
+			wantMarked:  map[int64]config.RoundStatus{1: config.RoundSettled, 2: config.RoundSettled},
+		},
+		{
+			name:      "round without a winning pocket is skipped",
+			unsettled: []model.Roulette{{ID: 1, Status: config.RoundRolled}, {ID: 2, Status: config.RoundRolled}},
+			wins: map[int64]*model.RouletteWinner{
+				2: {RouletteID: 2, Number: 2, Color: config.Black},
+			},
+			bets: []model.RouletteBet{
+				{ID: 1, RouletteID: 1, UserID: 1, Type: config.BetColor, Value: "red", Amount: 100},
+				{ID: 2, RouletteID: 2, UserID: 2, Type: config.BetColor, Value: "black", Amount: 100},
+			},
+			wantSettled: 1,
+			wantCredit:  map[int64]int{2: 200},
+			wantMarked:  map[int64]config.RoundStatus{2: config.RoundSettled},
+		},
+		{
+			name:        "nothing to reconcile",
+			wantSettled: 0,
+			wantCredit:  map[int64]int{},
+			wantMarked:  map[int64]config.RoundStatus{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBook(tt.bets...)
+			b.unsettled = tt.unsettled
+			if tt.wins != nil {
+				b.wins = tt.wins
+			}
+
+			settled, err := newTestSettler(b).Reconcile()
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantSettled, settled)
+			assert.Equal(t, tt.wantCredit, b.credited)
+			assert.Equal(t, tt.wantMarked, b.marked)
+		})
+	}
+}
@@ -9,9 +9,10 @@ import (
 	"go-outpost/internal/api/config"
 	"go-outpost/internal/api/http-server/handlers/event"
 	"go-outpost/internal/api/http-server/handlers/job"
-	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/handlers/roulette/autobet"
 	"go-outpost/internal/api/http-server/model"
 	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
 	resp "go-outpost/internal/lib/api/response"
 	"go-outpost/internal/lib/logger/sl"
 	"golang.org/x/exp/slog"
@@ -22,46 +23,53 @@ import (
 type RouletteStart struct {
 	log            *slog.Logger
 	rouletteRep    repository.RouletteRepository
-	rouletteBetRep repository.RouletteBetRepository
+	rouletteWinRep repository.RouletteWinnerRepository
 	cache          *cache.Cache
 	event          *event.PusherEvent
 	rouletteRoller *RouletteRoller
-	balance        balance.Interface
+	settler        *RouletteSettler
 	transaction    repository.Transaction
+	autoBets       *autobet.Runner
+	betWindow      time.Duration
 }
 
 func NewRouletteStart(
 	log *slog.Logger,
 	rouletteRep repository.RouletteRepository,
-	rouletteBetRep repository.RouletteBetRepository,
+	rouletteWinRep repository.RouletteWinnerRepository,
 	eventClient *event.PusherEvent,
 	rouletteRoller *RouletteRoller,
-	balance balance.Interface,
-	transaction repository.Transaction) *RouletteStart {
+	settler *RouletteSettler,
+	transaction repository.Transaction,
+	autoBets *autobet.Runner,
+	betWindow time.Duration) *RouletteStart {
 	return &RouletteStart{
 		log:            log,
 		rouletteRep:    rouletteRep,
-		rouletteBetRep: rouletteBetRep,
+		rouletteWinRep: rouletteWinRep,
 		cache:          cache.New(5*time.Minute, 10*time.Minute),
 		event:          eventClient,
 		rouletteRoller: rouletteRoller,
-		balance:        balance,
+		settler:        settler,
 		transaction:    transaction,
+		autoBets:       autoBets,
+		betWindow:      betWindow,
 	}
 }
 
+// New opens a round for betting. The round is closed, rolled and settled by
+// RouletteRollJob once its bet window is over.
 func (s *RouletteStart) New() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.roulette.start.New"
 
 		var (
-			err                   error
-			log                   *slog.Logger
-			roulette              *model.Roulette
-			winColorAndNumberData *RouletteWinColorAndNumberData
-			rouletteID            int64
-			tx                    *sql.Tx
-			round                 int64
+			err        error
+			log        *slog.Logger
+			roulette   *model.Roulette
+			rouletteID int64
+			tx         *sql.Tx
+			round      int64
 		)
 
 		log = s.log.With(
@@ -87,8 +95,10 @@ func (s *RouletteStart) New() http.HandlerFunc {
 		round = s.getRoundFromCacheOrDB()
 
 		roulette = &model.Roulette{
-			UUID:  uuid.New(),
-			Round: round,
+			UUID:        uuid.New(),
+			Round:       round,
+			Status:      config.RoundOpen,
+			BetDeadline: time.Now().Add(s.betWindow),
 		}
 
 		rouletteID, err = s.rouletteRep.SaveRoulette(*roulette)
@@ -121,11 +131,6 @@ func (s *RouletteStart) New() http.HandlerFunc {
 			return
 		}
 
-		/*autoBets := getAutoBetsFromCache()
-		if len(autoBets) != 0 {
-			job.Dispatch(&RouletteBetReplicateJob{rouletteID: rouletteID}, 0)
-		}*/
-
 		err = s.sendNewRoundEvent(roulette)
 		if err != nil {
 			log.Error("failed to send new round event", sl.Err(err))
@@ -141,51 +146,9 @@ func (s *RouletteStart) New() http.HandlerFunc {
 
 		log.Info("new round event sent")
 
-		winColorAndNumberData, err = s.rouletteRoller.Roll(roulette)
-		if err != nil {
-			log.Error("failed to roll roulette", sl.Err(err))
-
-			render.JSON(w, r, resp.Error("failed to roll roulette", http.StatusInternalServerError))
-
-			if err = tx.Rollback(); err != nil {
-				log.Error("failed to rollback transaction", sl.Err(err))
-			}
-
-			return
-		}
-
-		log.Info("roulette rolled",
-			slog.Any("win_color", winColorAndNumberData.Color),
-			slog.Any("win_number", winColorAndNumberData.Number))
-
-		if err = s.handleWinners(rouletteID, winColorAndNumberData.Color); err != nil {
-			log.Error("failed to handle winners", sl.Err(err))
-
-			render.JSON(w, r, resp.Error("failed to handle winners", http.StatusInternalServerError))
-
-			if err = tx.Rollback(); err != nil {
-				log.Error("failed to rollback transaction", sl.Err(err))
-			}
-
-			return
-		}
-
-		log.Info("winners handled")
-
-		delay := 15 * time.Second
+		job.Dispatch(&RouletteBetReplicateJob{RouletteStart: s, Roulette: roulette}, 0)
 
-		eventMessage := event.Message{
-			Channel: "roulette",
-			Event:   "winner",
-			Data: map[string]interface{}{
-				"color":  winColorAndNumberData.Color,
-				"number": winColorAndNumberData.Number,
-			},
-		}
-
-		job.Dispatch(&job.SendEventJob{EventMessage: eventMessage, Event: s.event}, delay)
-
-		job.Dispatch(&RouletteStartJob{RouletteStart: s, RouletteID: rouletteID}, delay)
+		job.Dispatch(&RouletteRollJob{RouletteStart: s, RouletteID: rouletteID}, time.Until(roulette.BetDeadline))
 
 		if err = tx.Commit(); err != nil {
 			log.Error("failed to commit transaction", sl.Err(err))
@@ -197,36 +160,83 @@ func (s *RouletteStart) New() http.HandlerFunc {
 	}
 }
 
-func (s *RouletteStart) UpdateRouletteUpdateAt(rouletteID int64) {
-	const op = "handlers.roulette.start.UpdateRouletteUpdateAt"
+// RollRound closes the round, rolls it and settles its own bets. Each step is
+// skipped when the round already went through it, so running it again for
+// the same round is safe.
+func (s *RouletteStart) RollRound(rouletteID int64) error {
+	const op = "handlers.roulette.start.RollRound"
 
 	var (
 		err      error
 		log      *slog.Logger
 		roulette *model.Roulette
+		win      *model.RouletteWinner
+		pocket   config.Pocket
+		rolled   *RouletteWinColorAndNumberData
 	)
 
 	log = s.log.With(
 		slog.String("op", op),
+		slog.Int64("roulette_id", rouletteID),
 	)
 
-	now := time.Now()
+	roulette, err = s.rouletteRep.GetRouletteByID(rouletteID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 
-	roulette = &model.Roulette{
-		ID:       rouletteID,
-		PlayedAt: &now,
+	if roulette.Status == config.RoundOpen {
+		if err = s.rouletteRep.UpdateRouletteStatus(roulette.ID, config.RoundClosed); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		roulette.Status = config.RoundClosed
+
+		log.Info("roulette closed for bets")
 	}
 
-	err = s.rouletteRep.UpdateRoulettePlayedAt(roulette)
+	// A win may already be stored when the process stopped right after the
+	// draw, it is never drawn twice.
+	win, err = s.rouletteWinRep.FindWinByRouletteID(roulette.ID)
 	if err != nil {
-		log.Error("failed to update roulette", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if win != nil {
+		pocket = config.Pocket{Color: win.Color, Number: win.Number}
+	} else if roulette.Status == config.RoundClosed {
+		rolled, err = s.rouletteRoller.Roll(roulette)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
 
-		return
+		pocket = config.Pocket{Color: rolled.Color, Number: rolled.Number}
+	} else {
+		return fmt.Errorf("%s: roulette %d is %s without a winning pocket", op, roulette.ID, roulette.Status)
 	}
 
-	log.Info("roulette updated")
+	if roulette.Status == config.RoundClosed {
+		now := time.Now()
 
-	return
+		roulette.Status = config.RoundRolled
+		roulette.PlayedAt = &now
+
+		if err = s.rouletteRep.UpdateRoulettePlayedAt(roulette); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("roulette rolled", slog.Any("win_color", pocket.Color), slog.Any("win_number", pocket.Number))
+
+		if err = s.event.Trigger(events.RouletteWinner{Color: pocket.Color, Number: pocket.Number}); err != nil {
+			log.Error("failed to send winner event", sl.Err(err))
+		}
+	}
+
+	if err = s.settler.Settle(roulette, pocket); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
 }
 
 func (s *RouletteStart) getRoundFromCacheOrDB() int64 {
@@ -263,75 +273,9 @@ func (s *RouletteStart) updateCacheRound(round int64) {
 }
 
 func (s *RouletteStart) sendNewRoundEvent(roulette *model.Roulette) error {
-	data := map[string]interface{}{
-		"uuid":       roulette.UUID.String(),
-		"created_at": roulette.CreatedAt,
-		"round":      roulette.Round,
-	}
-
-	message := event.Message{
-		Channel: "roulette",
-		Event:   "start",
-		Data:    data,
-	}
-
-	return s.event.TriggerEvent(message)
-}
-
-func (s *RouletteStart) handleWinners(rouletteID int64, color config.Color) error {
-	const op = "handlers.roulette.start.handleWinners"
-
-	var (
-		err        error
-		bets       []model.RouletteBet
-		multiplier int
-		roulette   *model.Roulette
-	)
-
-	roulette, err = s.rouletteRep.GetPreviousRouletteID(rouletteID)
-	if err != nil {
-		s.log.Error("failed to get previous roulette id", sl.Err(err))
-
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	s.log.Info("previous roulette", sl.Any("rouletteID", roulette.ID))
-
-	bets, err = s.rouletteBetRep.GetBetsByRouletteIDAndColor(roulette.ID, color)
-	if err != nil {
-		s.log.Error("failed to get winners by roulette id", sl.Err(err))
-
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	multiplier = s.getMultiplierByColor(color)
-
-	s.log.Info("multiplier", sl.Any("multiplier", multiplier))
-
-	if len(bets) == 0 {
-		s.log.Info("No bets found")
-
-		return nil
-	}
-
-	for _, winner := range bets {
-		if err = s.balance.Income(winner.UserID, winner.Amount*multiplier, config.Roulette); err != nil {
-			s.log.Error("failed to update user balance", sl.Err(err))
-
-			return fmt.Errorf("%s: %w", op, err)
-		}
-
-		s.log.Info("user balance updated", sl.Any("user_id", winner.UserID))
-	}
-
-	return nil
-}
-
-func (s *RouletteStart) getMultiplierByColor(color config.Color) int {
-	colorConfig, ok := config.RouletteWheelConfig.Colors[color]
-	if !ok {
-		return 0
-	}
-
-	return colorConfig.Multiplier
+	return s.event.Trigger(events.RouletteStarted{
+		UUID:      roulette.UUID.String(),
+		Round:     roulette.Round,
+		CreatedAt: roulette.CreatedAt,
+	})
 }
@@ -0,0 +1,195 @@
+package seed
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go-outpost/internal/api/http-server/handlers/provably_fair"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"golang.org/x/exp/slog"
+	"net/http"
+)
+
+type RotateRequest struct {
+	UserUUID   string `json:"user_uuid" validate:"required"`
+	ClientSeed string `json:"client_seed" validate:"omitempty,max=64"`
+}
+
+type Response struct {
+	resp.Response
+	Seed *model.UserSeed `json:"seed"`
+}
+
+// RevealedSeed is a retired seed pair, its server seed can now be checked
+// against the hash shown while it was active.
+type RevealedSeed struct {
+	*model.UserSeed
+	ServerSeed string `json:"server_seed"`
+}
+
+type RotateResponse struct {
+	resp.Response
+	Previous RevealedSeed    `json:"previous"`
+	Seed     *model.UserSeed `json:"seed"`
+}
+
+// ActiveGames is a multi-step game whose outcome is drawn from the seed pair
+// when it starts. Rotating would reveal the server seed, and with it the rest
+// of the game, so the seed pair stays until the game is over.
+type ActiveGames interface {
+	HasActiveGame(userID int64) (bool, error)
+}
+
+var ErrGameInProgress = errors.New("finish the game in progress before rotating the seed")
+
+type Seed struct {
+	log          *slog.Logger
+	validator    *validator.Validate
+	userRep      repository.UserRepository
+	provablyFair *provably_fair.ProvablyFair
+	games        []ActiveGames
+}
+
+func NewSeed(
+	log *slog.Logger,
+	userRep repository.UserRepository,
+	provablyFair *provably_fair.ProvablyFair,
+	games []ActiveGames) *Seed {
+	return &Seed{
+		log:          log,
+		validator:    validator.New(),
+		userRep:      userRep,
+		provablyFair: provablyFair,
+		games:        games,
+	}
+}
+
+// Show handles GET /fair/seed?user_uuid=.
+func (s *Seed) Show() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.seed.Show"
+
+		var (
+			err  error
+			log  *slog.Logger
+			user *model.User
+			seed *model.UserSeed
+		)
+
+		log = s.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = s.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		seed, err = s.provablyFair.ActiveSeed(user.ID)
+		if err != nil {
+			log.Error("failed to get seed", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to get seed", http.StatusInternalServerError))
+
+			return
+		}
+
+		render.JSON(w, r, Response{Response: resp.OK(), Seed: seed})
+	}
+}
+
+// Rotate handles POST /fair/seed/rotate.
+func (s *Seed) Rotate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.seed.Rotate"
+
+		var (
+			err      error
+			log      *slog.Logger
+			req      RotateRequest
+			user     *model.User
+			previous *model.UserSeed
+			next     *model.UserSeed
+		)
+
+		log = s.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = s.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		user, err = s.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		err = s.checkGames(user.ID)
+		if err == nil {
+			previous, next, err = s.provablyFair.RotateSeed(user.ID, req.ClientSeed)
+		}
+		if err != nil {
+			if errors.Is(err, ErrGameInProgress) {
+				render.JSON(w, r, resp.ErrorCode(ErrGameInProgress.Error(), http.StatusConflict, "game_in_progress"))
+
+				return
+			}
+
+			log.Error("failed to rotate seed", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to rotate seed", http.StatusInternalServerError))
+
+			return
+		}
+
+		render.JSON(w, r, RotateResponse{
+			Response: resp.OK(),
+			Previous: RevealedSeed{UserSeed: previous, ServerSeed: previous.ServerSeed},
+			Seed:     next,
+		})
+	}
+}
+
+func (s *Seed) checkGames(userID int64) error {
+	for _, game := range s.games {
+		active, err := game.HasActiveGame(userID)
+		if err != nil {
+			return err
+		}
+
+		if active {
+			return ErrGameInProgress
+		}
+	}
+
+	return nil
+}
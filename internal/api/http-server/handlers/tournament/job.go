@@ -0,0 +1,20 @@
+package tournament
+
+import (
+	"go-outpost/internal/lib/logger/sl"
+	"golang.org/x/exp/slog"
+)
+
+// TournamentJob moves a tournament on: it starts it, pushes its leaderboard
+// while it runs and pays it out at the end.
+type TournamentJob struct {
+	Tournaments  *Tournaments
+	TournamentID int64
+}
+
+func (job *TournamentJob) Execute() {
+	if err := job.Tournaments.Advance(job.TournamentID); err != nil {
+		job.Tournaments.log.Error("failed to advance tournament", sl.Err(err),
+			slog.Int64("tournament_id", job.TournamentID))
+	}
+}
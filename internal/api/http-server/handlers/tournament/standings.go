@@ -0,0 +1,61 @@
+package tournament
+
+import (
+	"go-outpost/internal/api/http-server/model"
+	"sort"
+)
+
+type standing struct {
+	entry model.TournamentEntry
+	rank  int
+	score float64
+	prize int
+}
+
+// rank orders the entries by score, ties go to the player who joined first.
+// Prizes go down the ranking to players who scored at all.
+func rank(entries []model.TournamentEntry, scores map[int64]float64, prizes []int) []standing {
+	standings := make([]standing, 0, len(entries))
+	for _, entry := range entries {
+		standings = append(standings, standing{entry: entry, score: scores[entry.ID]})
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+
+		if a.score != b.score {
+			return a.score > b.score
+		}
+
+		if !a.entry.JoinedAt.Equal(b.entry.JoinedAt) {
+			return a.entry.JoinedAt.Before(b.entry.JoinedAt)
+		}
+
+		return a.entry.ID < b.entry.ID
+	})
+
+	for i := range standings {
+		standings[i].rank = i + 1
+
+		if i < len(prizes) && standings[i].score > 0 {
+			standings[i].prize = prizes[i]
+		}
+	}
+
+	return standings
+}
+
+// stored returns the standings saved when the tournament finished.
+func stored(entries []model.TournamentEntry) []standing {
+	standings := make([]standing, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.Rank == nil {
+			continue
+		}
+
+		standings = append(standings, standing{entry: entry, rank: *entry.Rank, score: entry.Score, prize: entry.Prize})
+	}
+
+	return standings
+}
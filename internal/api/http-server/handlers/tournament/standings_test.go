@@ -0,0 +1,67 @@
+package tournament
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-outpost/internal/api/http-server/model"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Now()
+
+	entries := []model.TournamentEntry{
+		{ID: 1, UserID: 10, JoinedAt: now},
+		{ID: 2, UserID: 20, JoinedAt: now.Add(time.Minute)},
+		{ID: 3, UserID: 30, JoinedAt: now.Add(-time.Minute)},
+		{ID: 4, UserID: 40, JoinedAt: now},
+	}
+
+	tests := []struct {
+		name   string
+		scores map[int64]float64
+		prizes []int
+		users  []int64
+		paid   []int
+	}{
+		{
+			name:   "highest score first",
+			scores: map[int64]float64{1: 500, 2: 900, 3: 100, 4: 300},
+			prizes: []int{1000, 500},
+			users:  []int64{20, 10, 40, 30},
+			paid:   []int{1000, 500, 0, 0},
+		},
+		{
+			name:   "tie goes to the first to join",
+			scores: map[int64]float64{1: 500, 2: 500, 3: 500, 4: 500},
+			prizes: []int{1000, 500, 250},
+			users:  []int64{30, 10, 40, 20},
+			paid:   []int{1000, 500, 250, 0},
+		},
+		{
+			name:   "no prize without a score",
+			scores: map[int64]float64{2: 2.5},
+			prizes: []int{1000, 500},
+			users:  []int64{20, 30, 10, 40},
+			paid:   []int{1000, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standings := rank(entries, tt.scores, tt.prizes)
+
+			users := make([]int64, 0, len(standings))
+			paid := make([]int, 0, len(standings))
+
+			for i, s := range standings {
+				assert.Equal(t, i+1, s.rank)
+
+				users = append(users, s.entry.UserID)
+				paid = append(paid, s.prize)
+			}
+
+			assert.Equal(t, tt.users, users)
+			assert.Equal(t, tt.paid, paid)
+		})
+	}
+}
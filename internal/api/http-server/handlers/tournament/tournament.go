@@ -0,0 +1,693 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/handlers/job"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// finishGrace leaves bets placed right before the end of a tournament the
+// time to be recorded before it is ranked.
+const finishGrace = 5 * time.Second
+
+// CreateRequest takes amounts as decimals. No starts_at starts the
+// tournament right away.
+type CreateRequest struct {
+	Name       string                  `json:"name" validate:"required,max=64"`
+	Metric     config.TournamentMetric `json:"metric" validate:"required,oneof=wagered multiplier"`
+	Games      []config.Game           `json:"games"`
+	EntryFee   money.Money             `json:"entry_fee" validate:"min=0"`
+	MinStake   money.Money             `json:"min_stake" validate:"min=0"`
+	MaxPlayers int                     `json:"max_players" validate:"min=0"`
+	Prizes     []money.Money           `json:"prizes" validate:"required,min=1,dive,gt=0"`
+	StartsAt   *time.Time              `json:"starts_at"`
+	EndsAt     time.Time               `json:"ends_at" validate:"required"`
+}
+
+type JoinRequest struct {
+	UserUUID string `json:"user_uuid" validate:"required"`
+}
+
+type Response struct {
+	resp.Response
+	Tournament Tournament `json:"tournament"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Tournaments []Tournament `json:"tournaments"`
+}
+
+type ShowResponse struct {
+	resp.Response
+	Tournament Tournament                  `json:"tournament"`
+	Standings  []events.TournamentStanding `json:"standings"`
+}
+
+type JoinResponse struct {
+	resp.Response
+	Tournament Tournament `json:"tournament"`
+	JoinedAt   time.Time  `json:"joined_at"`
+}
+
+// Tournament is a tournament as shown to players. Amounts are decimal strings.
+type Tournament struct {
+	UUID       string                  `json:"uuid"`
+	Name       string                  `json:"name"`
+	Metric     config.TournamentMetric `json:"metric"`
+	Games      []config.Game           `json:"games"`
+	EntryFee   money.Money             `json:"entry_fee"`
+	MinStake   money.Money             `json:"min_stake"`
+	MaxPlayers int                     `json:"max_players"`
+	Players    int                     `json:"players"`
+	Prizes     []money.Money           `json:"prizes"`
+	Status     config.TournamentStatus `json:"status"`
+	StartsAt   time.Time               `json:"starts_at"`
+	EndsAt     time.Time               `json:"ends_at"`
+	FinishedAt *time.Time              `json:"finished_at"`
+}
+
+var (
+	ErrNoBalance           = errors.New("user has no balance")
+	ErrInsufficientBalance = errors.New("user has insufficient balance")
+	ErrTournamentOver      = errors.New("tournament is over")
+	ErrTournamentFull      = errors.New("tournament is full")
+	ErrAlreadyJoined       = errors.New("user already joined the tournament")
+)
+
+type Tournaments struct {
+	log           *slog.Logger
+	validator     *validator.Validate
+	tournamentRep repository.TournamentRepository
+	userRep       repository.UserRepository
+	balance       balance.Interface
+	event         *event.PusherEvent
+	rules         config.TournamentRules
+}
+
+func NewTournaments(
+	log *slog.Logger,
+	tournamentRep repository.TournamentRepository,
+	userRep repository.UserRepository,
+	balance balance.Interface,
+	eventClient *event.PusherEvent,
+	rules config.TournamentRules) *Tournaments {
+	return &Tournaments{
+		log:           log,
+		validator:     money.WithValidation(validator.New()),
+		tournamentRep: tournamentRep,
+		userRep:       userRep,
+		balance:       balance,
+		event:         eventClient,
+		rules:         rules,
+	}
+}
+
+// Create handles POST /tournaments.
+func (t *Tournaments) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tournament.Create"
+
+		var (
+			err        error
+			req        CreateRequest
+			log        *slog.Logger
+			tournament *model.Tournament
+		)
+
+		log = t.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !t.decode(w, r, log, &req) {
+			return
+		}
+
+		tournament, err = t.create(req)
+		if err != nil {
+			log.Error("failed to create tournament", sl.Err(err))
+
+			render.JSON(w, r, tournamentError(err))
+
+			return
+		}
+
+		log.Info("tournament scheduled", slog.Int64("tournament_id", tournament.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Tournament: view(*tournament)})
+	}
+}
+
+// List handles GET /tournaments?status=, the running and scheduled ones by
+// default.
+func (t *Tournaments) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tournament.List"
+
+		log := t.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		statuses := []config.TournamentStatus{config.TournamentRunning, config.TournamentScheduled}
+		if status := r.URL.Query().Get("status"); status != "" {
+			statuses = []config.TournamentStatus{config.TournamentStatus(status)}
+		}
+
+		views := make([]Tournament, 0)
+
+		for _, status := range statuses {
+			tournaments, err := t.tournamentRep.GetTournamentsByStatus(status)
+			if err != nil {
+				log.Error("failed to load tournaments", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("failed to load tournaments", http.StatusInternalServerError))
+
+				return
+			}
+
+			for _, tournament := range tournaments {
+				views = append(views, view(tournament))
+			}
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Tournaments: views})
+	}
+}
+
+// Show handles GET /tournaments/{uuid} with the live standings, or the final
+// ones once the tournament is over.
+func (t *Tournaments) Show() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tournament.Show"
+
+		var (
+			err        error
+			log        *slog.Logger
+			tournament *model.Tournament
+			standings  []standing
+		)
+
+		log = t.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		tournament, err = t.tournamentRep.FindTournamentByUUID(chi.URLParam(r, "uuid"))
+		if err == nil {
+			standings, err = t.standings(*tournament)
+		}
+		if err != nil {
+			log.Error("failed to load tournament", sl.Err(err))
+
+			render.JSON(w, r, tournamentError(err))
+
+			return
+		}
+
+		render.JSON(w, r, ShowResponse{
+			Response:   resp.OK(),
+			Tournament: view(*tournament),
+			Standings:  standingViews(tournament.Metric, standings),
+		})
+	}
+}
+
+// Join handles POST /tournaments/{uuid}/join. The entry fee is debited, bets
+// count from the time the player joined.
+func (t *Tournaments) Join() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.tournament.Join"
+
+		var (
+			err        error
+			req        JoinRequest
+			log        *slog.Logger
+			user       *model.User
+			tournament *model.Tournament
+			entry      *model.TournamentEntry
+		)
+
+		log = t.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !t.decode(w, r, log, &req) {
+			return
+		}
+
+		user, err = t.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		tournament, err = t.tournamentRep.FindTournamentByUUID(chi.URLParam(r, "uuid"))
+		if err == nil {
+			entry, err = t.join(tournament, user.ID)
+		}
+		if err != nil {
+			log.Error("failed to join tournament", sl.Err(err))
+
+			render.JSON(w, r, tournamentError(err))
+
+			return
+		}
+
+		tournament.Players++
+
+		render.JSON(w, r, JoinResponse{Response: resp.OK(), Tournament: view(*tournament), JoinedAt: entry.JoinedAt})
+	}
+}
+
+// Advance moves a tournament to where its schedule says it should be and
+// plans the next step. Finished tournaments are left alone.
+func (t *Tournaments) Advance(id int64) error {
+	const op = "handlers.tournament.Advance"
+
+	tournament, err := t.tournamentRep.FindTournamentByID(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch tournament.Status {
+	case config.TournamentFinished:
+		return nil
+	case config.TournamentScheduled:
+		if wait := time.Until(tournament.StartsAt); wait > 0 {
+			job.Dispatch(&TournamentJob{Tournaments: t, TournamentID: id}, wait)
+
+			return nil
+		}
+
+		started, err := t.tournamentRep.SetTournamentStatus(id, config.TournamentScheduled, config.TournamentRunning)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !started {
+			return nil
+		}
+
+		tournament.Status = config.TournamentRunning
+
+		t.log.Info("tournament started", slog.Int64("tournament_id", id))
+	}
+
+	if wait := time.Until(tournament.EndsAt.Add(finishGrace)); wait > 0 {
+		t.sendLeaderboard(*tournament)
+
+		if wait > t.rules.LeaderboardInterval {
+			wait = t.rules.LeaderboardInterval
+		}
+
+		job.Dispatch(&TournamentJob{Tournaments: t, TournamentID: id}, wait)
+
+		return nil
+	}
+
+	if err = t.finish(tournament); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Resume plans every tournament that is not over, their jobs were lost with
+// the last restart. Tournaments that ended meanwhile are paid out now. It
+// returns the number of tournaments resumed.
+func (t *Tournaments) Resume() (int, error) {
+	const op = "handlers.tournament.Resume"
+
+	resumed := 0
+
+	for _, status := range []config.TournamentStatus{config.TournamentRunning, config.TournamentScheduled} {
+		tournaments, err := t.tournamentRep.GetTournamentsByStatus(status)
+		if err != nil {
+			return resumed, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, tournament := range tournaments {
+			if err = t.Advance(tournament.ID); err != nil {
+				return resumed, fmt.Errorf("%s: %w", op, err)
+			}
+
+			resumed++
+		}
+	}
+
+	return resumed, nil
+}
+
+func (t *Tournaments) create(req CreateRequest) (*model.Tournament, error) {
+	const op = "handlers.tournament.create"
+
+	var err error
+
+	now := time.Now()
+
+	startsAt := now
+	if req.StartsAt != nil && req.StartsAt.After(now) {
+		startsAt = *req.StartsAt
+	}
+
+	prizes := make([]int, 0, len(req.Prizes))
+	for _, prize := range req.Prizes {
+		prizes = append(prizes, int(prize.Minor()))
+	}
+
+	if req.Games == nil {
+		req.Games = []config.Game{}
+	}
+
+	if err = t.rules.CheckTournament(req.Metric, req.Games, prizes, startsAt, req.EndsAt); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tournament := &model.Tournament{
+		UUID:       uuid.New().String(),
+		Name:       req.Name,
+		Metric:     req.Metric,
+		Games:      req.Games,
+		EntryFee:   int(req.EntryFee.Minor()),
+		MinStake:   int(req.MinStake.Minor()),
+		MaxPlayers: req.MaxPlayers,
+		Prizes:     prizes,
+		Status:     config.TournamentScheduled,
+		StartsAt:   startsAt,
+		EndsAt:     req.EndsAt,
+		CreatedAt:  now,
+	}
+
+	tournament.ID, err = t.tournamentRep.SaveTournament(*tournament)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.Dispatch(&TournamentJob{Tournaments: t, TournamentID: tournament.ID}, time.Until(startsAt))
+
+	return tournament, nil
+}
+
+func (t *Tournaments) join(tournament *model.Tournament, userID int64) (*model.TournamentEntry, error) {
+	const op = "handlers.tournament.join"
+
+	var (
+		err         error
+		entry       *model.TournamentEntry
+		userBalance *model.UserBalance
+		seated      bool
+	)
+
+	if tournament.Status == config.TournamentFinished || !time.Now().Before(tournament.EndsAt) {
+		return nil, fmt.Errorf("%s: %w", op, ErrTournamentOver)
+	}
+
+	entry, err = t.tournamentRep.FindEntry(tournament.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if entry != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrAlreadyJoined)
+	}
+
+	if tournament.EntryFee > 0 {
+		userBalance, err = t.userRep.FindUserBalanceByID(userID, money.Default)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if userBalance == nil || userBalance.Balance.IsNegative() {
+			return nil, fmt.Errorf("%s: %w", op, ErrNoBalance)
+		}
+
+		if !userBalance.Balance.Covers(money.Cents(tournament.EntryFee)) {
+			return nil, fmt.Errorf("%s: %w", op, ErrInsufficientBalance)
+		}
+
+		if err = t.balance.Outcome(userID, money.Cents(tournament.EntryFee), config.Tournament); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	seated, err = t.tournamentRep.TakeSeat(tournament.ID)
+	if err == nil && !seated {
+		err = ErrTournamentFull
+	}
+	if err != nil {
+		t.refundFee(tournament, userID)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	entry = &model.TournamentEntry{TournamentID: tournament.ID, UserID: userID, JoinedAt: time.Now()}
+
+	entry.ID, err = t.tournamentRep.SaveEntry(*entry)
+	if err != nil {
+		if releaseErr := t.tournamentRep.ReleaseSeat(tournament.ID); releaseErr != nil {
+			t.log.Error("failed to release tournament seat", sl.Err(releaseErr))
+		}
+
+		t.refundFee(tournament, userID)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entry, nil
+}
+
+func (t *Tournaments) refundFee(tournament *model.Tournament, userID int64) {
+	if tournament.EntryFee == 0 {
+		return
+	}
+
+	if err := t.balance.Income(userID, money.Cents(tournament.EntryFee), config.Tournament); err != nil {
+		t.log.Error("failed to refund tournament entry fee", sl.Err(err),
+			slog.Int64("tournament_id", tournament.ID), slog.Int64("user_id", userID))
+	}
+}
+
+// finish stores the final standings and pays the prizes. Every prize is
+// claimed before it is paid, so finishing again after a crash only pays
+// the prizes that are still due.
+func (t *Tournaments) finish(tournament *model.Tournament) error {
+	const op = "handlers.tournament.finish"
+
+	standings, err := t.standings(*tournament)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	winners := make([]standing, 0)
+
+	for _, s := range standings {
+		if err = t.tournamentRep.SaveStanding(s.entry.ID, s.rank, s.score); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if s.prize == 0 {
+			continue
+		}
+
+		winners = append(winners, s)
+
+		claimed, err := t.tournamentRep.ClaimPrize(s.entry.ID, s.prize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !claimed {
+			continue
+		}
+
+		if err = t.balance.Income(s.entry.UserID, money.Cents(s.prize), config.Tournament); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	finished, err := t.tournamentRep.SetTournamentStatus(tournament.ID, config.TournamentRunning,
+		config.TournamentFinished)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !finished {
+		return nil
+	}
+
+	tournament.Status = config.TournamentFinished
+
+	t.log.Info("tournament finished", slog.Int64("tournament_id", tournament.ID), slog.Int("winners", len(winners)))
+
+	t.sendLeaderboard(*tournament)
+
+	if err = t.event.Trigger(events.TournamentFinished{
+		UUID:    tournament.UUID,
+		Winners: standingViews(tournament.Metric, winners),
+	}); err != nil {
+		t.log.Error("failed to send tournament finished event", sl.Err(err))
+	}
+
+	return nil
+}
+
+// standings ranks the players on their bets so far, or returns the stored
+// ranking of a finished tournament.
+func (t *Tournaments) standings(tournament model.Tournament) ([]standing, error) {
+	entries, err := t.tournamentRep.GetEntries(tournament.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tournament.Status == config.TournamentFinished {
+		return stored(entries), nil
+	}
+
+	var scores map[int64]float64
+
+	if tournament.Metric == config.TournamentMultiplier {
+		scores, err = t.tournamentRep.GetBestMultipliers(tournament)
+	} else {
+		scores, err = t.tournamentRep.GetWagered(tournament)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rank(entries, scores, tournament.Prizes), nil
+}
+
+func (t *Tournaments) sendLeaderboard(tournament model.Tournament) {
+	standings, err := t.standings(tournament)
+	if err != nil {
+		t.log.Error("failed to rank tournament", sl.Err(err), slog.Int64("tournament_id", tournament.ID))
+
+		return
+	}
+
+	if len(standings) > t.rules.LeaderboardSize {
+		standings = standings[:t.rules.LeaderboardSize]
+	}
+
+	if err = t.event.Trigger(events.TournamentLeaderboard{
+		UUID:      tournament.UUID,
+		Title:     tournament.Name,
+		Metric:    tournament.Metric,
+		Status:    tournament.Status,
+		EndsAt:    tournament.EndsAt,
+		Players:   tournament.Players,
+		Standings: standingViews(tournament.Metric, standings),
+	}); err != nil {
+		t.log.Error("failed to send tournament leaderboard", sl.Err(err))
+	}
+}
+
+func (t *Tournaments) decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+		return false
+	}
+
+	if err := t.validator.Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+
+		log.Error("invalid request", sl.Err(err))
+
+		render.JSON(w, r, resp.ValidationError(validateErr))
+
+		return false
+	}
+
+	return true
+}
+
+func view(t model.Tournament) Tournament {
+	prizes := make([]money.Money, 0, len(t.Prizes))
+	for _, prize := range t.Prizes {
+		prizes = append(prizes, money.Cents(prize))
+	}
+
+	return Tournament{
+		UUID:       t.UUID,
+		Name:       t.Name,
+		Metric:     t.Metric,
+		Games:      t.Games,
+		EntryFee:   money.Cents(t.EntryFee),
+		MinStake:   money.Cents(t.MinStake),
+		MaxPlayers: t.MaxPlayers,
+		Players:    t.Players,
+		Prizes:     prizes,
+		Status:     t.Status,
+		StartsAt:   t.StartsAt,
+		EndsAt:     t.EndsAt,
+		FinishedAt: t.FinishedAt,
+	}
+}
+
+func standingViews(metric config.TournamentMetric, standings []standing) []events.TournamentStanding {
+	views := make([]events.TournamentStanding, 0, len(standings))
+
+	for _, s := range standings {
+		score := strconv.FormatFloat(s.score, 'f', 2, 64)
+		if metric == config.TournamentWagered {
+			score = money.Cents(int(s.score)).String()
+		}
+
+		views = append(views, events.TournamentStanding{
+			Rank:     s.rank,
+			UserUUID: s.entry.UserUUID,
+			Score:    score,
+			Prize:    money.Cents(s.prize),
+		})
+	}
+
+	return views
+}
+
+func tournamentError(err error) resp.Response {
+	switch {
+	case errors.Is(err, repository.ErrTournamentNotFound):
+		return resp.ErrorCode("failed to find tournament", http.StatusNotFound, "tournament_not_found")
+	case errors.Is(err, config.ErrInvalidTournament):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_tournament")
+	case errors.Is(err, ErrTournamentOver):
+		return resp.ErrorCode(ErrTournamentOver.Error(), http.StatusConflict, "tournament_over")
+	case errors.Is(err, ErrTournamentFull):
+		return resp.ErrorCode(ErrTournamentFull.Error(), http.StatusConflict, "tournament_full")
+	case errors.Is(err, ErrAlreadyJoined):
+		return resp.ErrorCode(ErrAlreadyJoined.Error(), http.StatusConflict, "already_joined")
+	case errors.Is(err, ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to process tournament", http.StatusInternalServerError)
+}
@@ -0,0 +1,77 @@
+package balance
+
+import (
+	"database/sql"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+)
+
+// Tx moves money on a database transaction together with the writes of
+// other repositories on it, a claim and the credit it allows either both
+// happen or neither does. Balance events are sent once Commit succeeds.
+type Tx struct {
+	balance *Balance
+	userRep *repository.UserRepository
+	tx      *sql.Tx
+	changes []events.BalanceChanged
+}
+
+func (t *Tx) Income(userID int64, amount money.Money, game config.Game) error {
+	const op = "handlers.user.balance.Tx.Income"
+
+	changed, err := t.balance.move(t.userRep, userID, amount, config.Income, game)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	t.changes = append(t.changes, changed)
+
+	return nil
+}
+
+func (t *Tx) Outcome(userID int64, amount money.Money, game config.Game) error {
+	const op = "handlers.user.balance.Tx.Outcome"
+
+	changed, err := t.balance.move(t.userRep, userID, amount, config.Outcome, game)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	t.changes = append(t.changes, changed)
+
+	return nil
+}
+
+// Commit commits the transaction and then tells the players about their new
+// balances. A failed event does not undo the committed moves.
+func (t *Tx) Commit() error {
+	const op = "handlers.user.balance.Tx.Commit"
+
+	if err := t.tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, changed := range t.changes {
+		if err := t.balance.pusher.Trigger(changed); err != nil {
+			t.balance.log.Error("failed to send balance event", sl.Err(err))
+		}
+	}
+
+	return nil
+}
+
+// Rollback drops the transaction and the events of its moves. Rolling back a
+// committed transaction is a no-op, so it is safe to defer.
+func (t *Tx) Rollback() error {
+	t.changes = nil
+
+	if err := t.tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		return err
+	}
+
+	return nil
+}
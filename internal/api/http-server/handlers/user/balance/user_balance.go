@@ -1,13 +1,15 @@
 package balance
 
 import (
+	"database/sql"
 	"fmt"
 	"go-outpost/internal/api/config"
 	"go-outpost/internal/api/http-server/handlers/event"
 	"go-outpost/internal/api/http-server/model"
 	"go-outpost/internal/api/repository"
-	"go-outpost/internal/lib/converter"
+	"go-outpost/internal/events"
 	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
 	"golang.org/x/exp/slog"
 )
 
@@ -17,9 +19,10 @@ type Balance struct {
 	pusher  *event.PusherEvent
 }
 
+// Interface moves money in and out of the wallet of the amount's currency.
 type Interface interface {
-	Income(userID int64, amount int, game config.Game) error
-	Outcome(userID int64, amount int, game config.Game) error
+	Income(userID int64, amount money.Money, game config.Game) error
+	Outcome(userID int64, amount money.Money, game config.Game) error
 }
 
 func NewBalance(
@@ -33,120 +36,97 @@ func NewBalance(
 	}
 }
 
-func (b *Balance) Income(userID int64, amount int, game config.Game) error {
+func (b *Balance) Income(userID int64, amount money.Money, game config.Game) error {
 	const op = "handlers.user.balance.Income"
 
-	var (
-		err         error
-		user        *model.User
-		userBalance *model.UserBalance
-		message     event.Message
-	)
-
-	if err = b.userRep.IncomeToUserBalance(userID, amount); err != nil {
-		b.log.Error("failed to income to user balance", sl.Err(err))
-
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	b.log.Info("user balance updated")
-
-	if err = b.userRep.CreateUserBalanceTransaction(userID, amount, config.Income, game); err != nil {
-		b.log.Error("failed to create user balance transaction", sl.Err(err))
-
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	b.log.Info("user balance transaction created")
-
-	user, err = b.userRep.GetUserByID(userID)
+	changed, err := b.move(&b.userRep, userID, amount, config.Income, game)
 	if err != nil {
-		b.log.Error("failed to find user by id", sl.Err(err))
-
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	b.log.Info("user found")
+	return b.pusher.Trigger(changed)
+}
 
-	userBalance, err = b.userRep.FindUserBalanceByID(user.ID)
-	if err != nil {
-		b.log.Error("failed to find user balance by id", sl.Err(err))
+func (b *Balance) Outcome(userID int64, amount money.Money, game config.Game) error {
+	const op = "handlers.user.balance.Outcome"
 
+	changed, err := b.move(&b.userRep, userID, amount, config.Outcome, game)
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	b.log.Info("user balance found")
+	return b.pusher.Trigger(changed)
+}
 
-	message = event.Message{
-		Channel: "balance-channel",
-		Event:   "income-event",
-		Data: map[string]interface{}{
-			"user_uuid":      user.UUID,
-			"amount":         converter.ConvertAmountIntToSting(amount),
-			"operation_type": config.Income,
-			"module":         config.Roulette,
-			"balance":        converter.ConvertAmountIntToSting(userBalance.Balance),
-		},
+// WithTx returns a balance whose moves run on tx. Nothing is announced to the
+// player until the transaction is committed through it.
+func (b *Balance) WithTx(tx *sql.Tx) *Tx {
+	return &Tx{
+		balance: b,
+		userRep: b.userRep.WithTx(tx),
+		tx:      tx,
 	}
-
-	return b.pusher.TriggerEvent(message)
 }
 
-func (b *Balance) Outcome(userID int64, amount int, game config.Game) error {
-	const op = "handlers.user.balance.Outcome"
-
+// move books amount on the wallet and the ledger and returns the event that
+// tells the player about it.
+func (b *Balance) move(
+	userRep *repository.UserRepository,
+	userID int64,
+	amount money.Money,
+	operation config.BalanceType,
+	game config.Game) (events.BalanceChanged, error) {
 	var (
 		err         error
 		user        *model.User
 		userBalance *model.UserBalance
-		message     event.Message
 	)
 
-	if err = b.userRep.OutcomeFromUserBalance(userID, amount); err != nil {
-		b.log.Error("failed to outcome from user balance", sl.Err(err))
+	if operation == config.Income {
+		err = userRep.IncomeToUserBalance(userID, amount)
+	} else {
+		err = userRep.OutcomeFromUserBalance(userID, amount)
+	}
+	if err != nil {
+		b.log.Error("failed to update user balance", sl.Err(err))
 
-		return fmt.Errorf("%s: %w", op, err)
+		return events.BalanceChanged{}, err
 	}
 
 	b.log.Info("user balance updated")
 
-	if err = b.userRep.CreateUserBalanceTransaction(userID, amount, config.Outcome, game); err != nil {
+	if err = userRep.CreateUserBalanceTransaction(userID, amount, operation, game); err != nil {
 		b.log.Error("failed to create user balance transaction", sl.Err(err))
 
-		return fmt.Errorf("%s: %w", op, err)
+		return events.BalanceChanged{}, err
 	}
 
 	b.log.Info("user balance transaction created")
 
-	user, err = b.userRep.GetUserByID(userID)
+	user, err = userRep.GetUserByID(userID)
 	if err != nil {
 		b.log.Error("failed to find user by id", sl.Err(err))
 
-		return fmt.Errorf("%s: %w", op, err)
+		return events.BalanceChanged{}, err
 	}
 
 	b.log.Info("user found")
 
-	userBalance, err = b.userRep.FindUserBalanceByID(user.ID)
+	userBalance, err = userRep.FindUserBalanceByID(user.ID, amount.Currency())
 	if err != nil {
 		b.log.Error("failed to find user balance by id", sl.Err(err))
 
-		return fmt.Errorf("%s: %w", op, err)
+		return events.BalanceChanged{}, err
 	}
 
 	b.log.Info("user balance found")
 
-	message = event.Message{
-		Channel: "balance-channel",
-		Event:   "outcome-event",
-		Data: map[string]interface{}{
-			"user_uuid":      user.UUID,
-			"amount":         converter.ConvertAmountIntToSting(amount),
-			"operation_type": config.Outcome,
-			"module":         config.Roulette,
-			"balance":        converter.ConvertAmountIntToSting(userBalance.Balance),
-		},
-	}
-
-	return b.pusher.TriggerEvent(message)
+	return events.BalanceChanged{
+		UserUUID:      user.UUID,
+		Currency:      amount.Currency(),
+		Amount:        amount,
+		OperationType: operation,
+		Module:        game,
+		Balance:       userBalance.Balance,
+	}, nil
 }
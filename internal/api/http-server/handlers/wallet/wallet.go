@@ -0,0 +1,338 @@
+package wallet
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"golang.org/x/exp/slog"
+	"math"
+	"net/http"
+)
+
+// SwapRequest exchanges Amount, read in the From currency, for the To one.
+type SwapRequest struct {
+	UserUUID string         `json:"user_uuid" validate:"required"`
+	From     money.Currency `json:"from" validate:"required"`
+	To       money.Currency `json:"to" validate:"required,nefield=From"`
+	Amount   money.Amount   `json:"amount" validate:"required"`
+}
+
+type Wallet struct {
+	Currency money.Currency `json:"currency"`
+	Balance  money.Money    `json:"balance"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Wallets []Wallet `json:"wallets"`
+}
+
+// RatesResponse lists the value of one unit of every currency in the default
+// one, as exact decimals.
+type RatesResponse struct {
+	resp.Response
+	Base    money.Currency            `json:"base"`
+	Rates   map[money.Currency]string `json:"rates"`
+	SwapFee float64                   `json:"swap_fee"`
+}
+
+// SwapResponse holds what left the From wallet, what reached the To one and
+// the fee kept, in the To currency.
+type SwapResponse struct {
+	resp.Response
+	Debited  money.Money `json:"debited"`
+	Credited money.Money `json:"credited"`
+	Fee      money.Money `json:"fee"`
+}
+
+var (
+	ErrNoBalance           = errors.New("user has no balance")
+	ErrInsufficientBalance = errors.New("user has insufficient balance")
+	ErrSwapTooSmall        = errors.New("swap amount is too small")
+)
+
+type Wallets struct {
+	log         *slog.Logger
+	validator   *validator.Validate
+	userRep     repository.UserRepository
+	balance     *balance.Balance
+	transaction repository.Transaction
+	rules       config.WalletRules
+	rates       money.Rates
+}
+
+func NewWallets(
+	log *slog.Logger,
+	userRep repository.UserRepository,
+	balance *balance.Balance,
+	transaction repository.Transaction,
+	rules config.WalletRules,
+	rates money.Rates) *Wallets {
+	return &Wallets{
+		log:         log,
+		validator:   validator.New(),
+		userRep:     userRep,
+		balance:     balance,
+		transaction: transaction,
+		rules:       rules,
+		rates:       rates,
+	}
+}
+
+// List handles GET /wallets?user_uuid=. Every accepted currency is listed, a
+// wallet the user never funded shows a zero balance.
+func (ws *Wallets) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.wallet.List"
+
+		var (
+			err      error
+			log      *slog.Logger
+			user     *model.User
+			balances []model.UserBalance
+		)
+
+		log = ws.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = ws.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		balances, err = ws.userRep.GetUserBalances(user.ID)
+		if err != nil {
+			log.Error("failed to get user balances", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to get wallets", http.StatusInternalServerError))
+
+			return
+		}
+
+		held := make(map[money.Currency]money.Money, len(balances))
+		for _, b := range balances {
+			held[b.Currency] = b.Balance
+		}
+
+		wallets := make([]Wallet, 0, len(ws.rules.Currencies))
+		for _, currency := range ws.rules.Currencies {
+			b, ok := held[currency]
+			if !ok {
+				b = money.New(0, currency)
+			}
+
+			wallets = append(wallets, Wallet{Currency: currency, Balance: b})
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Wallets: wallets})
+	}
+}
+
+// Rates handles GET /wallets/rates.
+func (ws *Wallets) Rates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rates := make(map[money.Currency]string, len(ws.rules.Currencies))
+		for _, currency := range ws.rules.Currencies {
+			if currency == money.Default {
+				rates[currency] = "1"
+
+				continue
+			}
+
+			rates[currency] = ws.rules.Rates[currency]
+		}
+
+		render.JSON(w, r, RatesResponse{
+			Response: resp.OK(),
+			Base:     money.Default,
+			Rates:    rates,
+			SwapFee:  ws.rules.SwapFee,
+		})
+	}
+}
+
+// Swap handles POST /wallets/swap. The amount is converted at the current
+// rates, rounded down, and the swap fee is kept from the credited side.
+func (ws *Wallets) Swap() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.wallet.Swap"
+
+		var (
+			err      error
+			req      SwapRequest
+			log      *slog.Logger
+			amount   money.Money
+			credited money.Money
+			fee      money.Money
+			user     *model.User
+		)
+
+		log = ws.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = ws.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		if !ws.rules.Accepts(req.From) || !ws.rules.Accepts(req.To) {
+			render.JSON(w, r, resp.ErrorCode(config.ErrCurrencyNotAccepted.Error(), http.StatusBadRequest,
+				"currency_not_accepted"))
+
+			return
+		}
+
+		amount, err = req.Amount.In(req.From)
+		if err == nil && amount.Minor() < 1 {
+			err = money.ErrInvalidAmount
+		}
+		if err != nil {
+			log.Error("invalid amount", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
+
+			return
+		}
+
+		user, err = ws.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		credited, fee, err = ws.swap(user.ID, amount, req.To)
+		if err != nil {
+			log.Error("failed to swap", sl.Err(err))
+
+			render.JSON(w, r, swapError(err))
+
+			return
+		}
+
+		log.Info("wallets swapped",
+			slog.Int64("user_id", user.ID),
+			slog.String("debited", amount.String()+" "+string(amount.Currency())),
+			slog.String("credited", credited.String()+" "+string(credited.Currency())))
+
+		render.JSON(w, r, SwapResponse{Response: resp.OK(), Debited: amount, Credited: credited, Fee: fee})
+	}
+}
+
+// swap debits the amount and credits its value in the other currency less
+// the fee on one transaction. The debit only goes through when the wallet
+// still covers it.
+func (ws *Wallets) swap(userID int64, amount money.Money, to money.Currency) (money.Money, money.Money, error) {
+	const op = "handlers.wallet.swap"
+
+	var (
+		err         error
+		converted   money.Money
+		userBalance *model.UserBalance
+		tx          *sql.Tx
+		moves       *balance.Tx
+	)
+
+	converted, err = ws.rates.Convert(amount, to)
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fee := money.New(int64(math.Ceil(float64(converted.Minor())*ws.rules.SwapFee/100)), to)
+
+	credited, err := converted.Sub(fee)
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if credited.Minor() < 1 {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, ErrSwapTooSmall)
+	}
+
+	userBalance, err = ws.userRep.FindUserBalanceByID(userID, amount.Currency())
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if userBalance == nil || userBalance.Balance.IsNegative() {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, ErrNoBalance)
+	}
+
+	if !userBalance.Balance.Covers(amount) {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, ErrInsufficientBalance)
+	}
+
+	tx, err = ws.transaction.StartTransaction()
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	moves = ws.balance.WithTx(tx)
+	defer moves.Rollback()
+
+	err = moves.Outcome(userID, amount, config.Swap)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		err = ErrInsufficientBalance
+	}
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = moves.Income(userID, credited, config.Swap); err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = moves.Commit(); err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return credited, fee, nil
+}
+
+func swapError(err error) resp.Response {
+	switch {
+	case errors.Is(err, money.ErrNoRate):
+		return resp.ErrorCode(err.Error(), http.StatusBadRequest, "no_rate")
+	case errors.Is(err, ErrSwapTooSmall):
+		return resp.ErrorCode(ErrSwapTooSmall.Error(), http.StatusBadRequest, "swap_too_small")
+	case errors.Is(err, ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to swap", http.StatusInternalServerError)
+}
@@ -0,0 +1,19 @@
+package withdrawal
+
+import (
+	"go-outpost/internal/lib/logger/sl"
+	"golang.org/x/exp/slog"
+)
+
+// WithdrawalJob sends an approved withdrawal through its provider.
+type WithdrawalJob struct {
+	Withdrawals  *Withdrawals
+	WithdrawalID int64
+}
+
+func (job *WithdrawalJob) Execute() {
+	if err := job.Withdrawals.Send(job.WithdrawalID); err != nil {
+		job.Withdrawals.log.Error("failed to send withdrawal", sl.Err(err),
+			slog.Int64("withdrawal_id", job.WithdrawalID))
+	}
+}
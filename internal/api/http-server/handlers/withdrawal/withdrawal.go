@@ -0,0 +1,672 @@
+package withdrawal
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/event"
+	"go-outpost/internal/api/http-server/handlers/job"
+	"go-outpost/internal/api/http-server/handlers/user/balance"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/events"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/lib/money"
+	"go-outpost/internal/payment"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	historySize = 50
+
+	// sendRetry is how long a withdrawal whose send ended with an unknown
+	// outcome waits before it is sent again with the same idempotency key.
+	sendRetry = time.Minute
+)
+
+// Request withdraws Amount of the wallet in Currency, the default one when
+// none is given, to Destination.
+type Request struct {
+	UserUUID    string         `json:"user_uuid" validate:"required"`
+	Amount      money.Amount   `json:"amount" validate:"required"`
+	Currency    money.Currency `json:"currency"`
+	Destination string         `json:"destination" validate:"required,max=255"`
+}
+
+type RejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type Response struct {
+	resp.Response
+	Withdrawal Withdrawal `json:"withdrawal"`
+}
+
+type ListResponse struct {
+	resp.Response
+	Withdrawals []Withdrawal `json:"withdrawals"`
+}
+
+type Withdrawal struct {
+	UUID          string                  `json:"uuid"`
+	Provider      string                  `json:"provider"`
+	Currency      money.Currency          `json:"currency"`
+	Amount        money.Money             `json:"amount"`
+	Destination   string                  `json:"destination"`
+	Status        config.WithdrawalStatus `json:"status"`
+	Reference     string                  `json:"reference,omitempty"`
+	FailureReason string                  `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
+
+var (
+	ErrNoBalance           = errors.New("user has no balance")
+	ErrInsufficientBalance = errors.New("user has insufficient balance")
+	ErrStatusChanged       = errors.New("withdrawal changed, reload it")
+)
+
+// Withdrawals holds the amount of a withdrawal from the wallet as soon as it
+// is requested. It is approved by hand or, up to the auto approve limit,
+// right away, then sent by a job through the provider. A rejected withdrawal
+// or one the provider declined gives the held amount back.
+type Withdrawals struct {
+	log           *slog.Logger
+	validator     *validator.Validate
+	withdrawalRep repository.WithdrawalRepository
+	userRep       repository.UserRepository
+	balance       *balance.Balance
+	transaction   repository.Transaction
+	providers     *payment.Registry
+	event         *event.PusherEvent
+	rules         config.PaymentRules
+	wallets       config.WalletRules
+	rates         money.Rates
+}
+
+func NewWithdrawals(
+	log *slog.Logger,
+	withdrawalRep repository.WithdrawalRepository,
+	userRep repository.UserRepository,
+	balance *balance.Balance,
+	transaction repository.Transaction,
+	providers *payment.Registry,
+	eventClient *event.PusherEvent,
+	rules config.PaymentRules,
+	wallets config.WalletRules,
+	rates money.Rates) *Withdrawals {
+	return &Withdrawals{
+		log:           log,
+		validator:     validator.New(),
+		withdrawalRep: withdrawalRep,
+		userRep:       userRep,
+		balance:       balance,
+		transaction:   transaction,
+		providers:     providers,
+		event:         eventClient,
+		rules:         rules,
+		wallets:       wallets,
+		rates:         rates,
+	}
+}
+
+// Request handles POST /withdrawals.
+func (wd *Withdrawals) Request() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.withdrawal.Request"
+
+		var (
+			err        error
+			req        Request
+			log        *slog.Logger
+			amount     money.Money
+			user       *model.User
+			withdrawal *model.Withdrawal
+		)
+
+		log = wd.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !wd.decode(w, r, log, &req) {
+			return
+		}
+
+		if req.Currency == "" {
+			req.Currency = money.Default
+		}
+
+		if !wd.wallets.Accepts(req.Currency) {
+			render.JSON(w, r, resp.ErrorCode(config.ErrCurrencyNotAccepted.Error(), http.StatusBadRequest,
+				"currency_not_accepted"))
+
+			return
+		}
+
+		amount, err = req.Amount.In(req.Currency)
+		if err == nil && amount.Minor() < 1 {
+			err = money.ErrInvalidAmount
+		}
+		if err != nil {
+			log.Error("invalid amount", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorCode(err.Error(), http.StatusBadRequest, "invalid_amount"))
+
+			return
+		}
+
+		user, err = wd.userRep.FindUserByUUID(req.UserUUID)
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		withdrawal, err = wd.request(user.ID, amount, req.Destination)
+		if err != nil {
+			log.Error("failed to request withdrawal", sl.Err(err))
+
+			render.JSON(w, r, withdrawalError(err))
+
+			return
+		}
+
+		log.Info("withdrawal requested", slog.Int64("withdrawal_id", withdrawal.ID),
+			slog.String("status", string(withdrawal.Status)))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Withdrawal: view(*withdrawal)})
+	}
+}
+
+// List handles GET /withdrawals?user_uuid=.
+func (wd *Withdrawals) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.withdrawal.List"
+
+		var (
+			err         error
+			log         *slog.Logger
+			user        *model.User
+			withdrawals []model.Withdrawal
+		)
+
+		log = wd.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err = wd.userRep.FindUserByUUID(r.URL.Query().Get("user_uuid"))
+		if err != nil || user == nil {
+			log.Error("failed to find user", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to find user", http.StatusNotFound))
+
+			return
+		}
+
+		withdrawals, err = wd.withdrawalRep.GetWithdrawalsByUserID(user.ID, historySize)
+		if err != nil {
+			log.Error("failed to get withdrawals", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to get withdrawals", http.StatusInternalServerError))
+
+			return
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Withdrawals: views(withdrawals)})
+	}
+}
+
+// Pending handles GET /withdrawals/pending, the withdrawals waiting for a
+// review, the oldest first.
+func (wd *Withdrawals) Pending() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.withdrawal.Pending"
+
+		withdrawals, err := wd.withdrawalRep.GetWithdrawalsByStatus(config.WithdrawalRequested)
+		if err != nil {
+			wd.log.Error("failed to get withdrawals", sl.Err(fmt.Errorf("%s: %w", op, err)))
+
+			render.JSON(w, r, resp.Error("failed to get withdrawals", http.StatusInternalServerError))
+
+			return
+		}
+
+		render.JSON(w, r, ListResponse{Response: resp.OK(), Withdrawals: views(withdrawals)})
+	}
+}
+
+// Approve handles POST /withdrawals/{uuid}/approve. The withdrawal is sent
+// in the background.
+func (wd *Withdrawals) Approve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.withdrawal.Approve"
+
+		var (
+			err        error
+			log        *slog.Logger
+			withdrawal *model.Withdrawal
+		)
+
+		log = wd.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		withdrawal, err = wd.withdrawalRep.FindWithdrawalByUUID(chi.URLParam(r, "uuid"))
+		if err == nil {
+			err = wd.approve(withdrawal)
+		}
+		if err != nil {
+			log.Error("failed to approve withdrawal", sl.Err(err))
+
+			render.JSON(w, r, withdrawalError(err))
+
+			return
+		}
+
+		log.Info("withdrawal approved", slog.Int64("withdrawal_id", withdrawal.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Withdrawal: view(*withdrawal)})
+	}
+}
+
+// Reject handles POST /withdrawals/{uuid}/reject, the held amount goes back
+// to the wallet.
+func (wd *Withdrawals) Reject() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.withdrawal.Reject"
+
+		var (
+			err        error
+			req        RejectRequest
+			log        *slog.Logger
+			withdrawal *model.Withdrawal
+		)
+
+		log = wd.log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if !wd.decode(w, r, log, &req) {
+			return
+		}
+
+		withdrawal, err = wd.withdrawalRep.FindWithdrawalByUUID(chi.URLParam(r, "uuid"))
+		if err == nil {
+			if withdrawal.Status != config.WithdrawalRequested {
+				err = fmt.Errorf("%w: %s", config.ErrWithdrawalStatus, withdrawal.Status)
+			} else {
+				err = wd.fail(withdrawal, req.Reason)
+			}
+		}
+		if err != nil {
+			log.Error("failed to reject withdrawal", sl.Err(err))
+
+			render.JSON(w, r, withdrawalError(err))
+
+			return
+		}
+
+		log.Info("withdrawal rejected", slog.Int64("withdrawal_id", withdrawal.ID))
+
+		render.JSON(w, r, Response{Response: resp.OK(), Withdrawal: view(*withdrawal)})
+	}
+}
+
+// Send pays an approved withdrawal out through its provider. The withdrawal
+// is claimed as sending first, so only one call hands it to the provider, and
+// the UUID is passed as the idempotency key so a retry of a sending one never
+// pays twice. When the provider's answer is unknown the withdrawal stays
+// sending and is retried later, only a decline gives the amount back.
+func (wd *Withdrawals) Send(id int64) error {
+	const op = "handlers.withdrawal.Send"
+
+	var (
+		err        error
+		withdrawal *model.Withdrawal
+		provider   payment.Provider
+		reference  string
+	)
+
+	withdrawal, err = wd.withdrawalRep.FindWithdrawalByID(id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch withdrawal.Status {
+	case config.WithdrawalApproved:
+		if err = wd.move(withdrawal, config.WithdrawalSending, "", ""); err != nil {
+			if errors.Is(err, ErrStatusChanged) {
+				return nil
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	case config.WithdrawalSending:
+		wd.log.Info("resending withdrawal", slog.Int64("withdrawal_id", id))
+	default:
+		return nil
+	}
+
+	provider, err = wd.providers.Get(withdrawal.Provider)
+	if err == nil {
+		reference, err = provider.Send(*withdrawal, withdrawal.UUID)
+	}
+	if err != nil {
+		if !errors.Is(err, payment.ErrDeclined) && !errors.Is(err, payment.ErrUnknownProvider) {
+			job.Dispatch(&WithdrawalJob{Withdrawals: wd, WithdrawalID: id}, sendRetry)
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		wd.log.Error("withdrawal declined", sl.Err(err), slog.Int64("withdrawal_id", id))
+
+		if err = wd.fail(withdrawal, err.Error()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	}
+
+	if err = wd.move(withdrawal, config.WithdrawalSent, reference, ""); err != nil {
+		if errors.Is(err, ErrStatusChanged) {
+			return nil
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	wd.log.Info("withdrawal sent", slog.Int64("withdrawal_id", id), slog.String("reference", reference))
+
+	return nil
+}
+
+// Resume sends the withdrawals approved before a restart and sends again
+// the ones a restart caught halfway. It returns how many it dispatched.
+func (wd *Withdrawals) Resume() (int, error) {
+	const op = "handlers.withdrawal.Resume"
+
+	withdrawals, err := wd.withdrawalRep.GetWithdrawalsByStatus(config.WithdrawalApproved, config.WithdrawalSending)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, withdrawal := range withdrawals {
+		job.Dispatch(&WithdrawalJob{Withdrawals: wd, WithdrawalID: withdrawal.ID}, 0)
+	}
+
+	return len(withdrawals), nil
+}
+
+// request holds the amount and records the withdrawal on one transaction,
+// approving it at once when it is worth no more than the auto approve limit.
+func (wd *Withdrawals) request(userID int64, amount money.Money, destination string) (*model.Withdrawal, error) {
+	const op = "handlers.withdrawal.request"
+
+	var (
+		err         error
+		userBalance *model.UserBalance
+		value       money.Money
+		tx          *sql.Tx
+		hold        *balance.Tx
+	)
+
+	userBalance, err = wd.userRep.FindUserBalanceByID(userID, amount.Currency())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if userBalance == nil || userBalance.Balance.IsNegative() {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoBalance)
+	}
+
+	tx, err = wd.transaction.StartTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hold = wd.balance.WithTx(tx)
+	defer hold.Rollback()
+
+	if err = hold.Outcome(userID, amount, config.Withdrawal); err != nil {
+		if errors.Is(err, repository.ErrInsufficientBalance) {
+			err = ErrInsufficientBalance
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+
+	withdrawal := &model.Withdrawal{
+		UUID:        uuid.New().String(),
+		UserID:      userID,
+		Provider:    wd.rules.Provider,
+		Currency:    amount.Currency(),
+		Amount:      int(amount.Minor()),
+		Destination: destination,
+		Status:      config.WithdrawalRequested,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	withdrawal.ID, err = wd.withdrawalRep.WithTx(tx).SaveWithdrawal(*withdrawal)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = hold.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	wd.notify(withdrawal)
+
+	value, err = wd.rates.Convert(amount, money.Default)
+	if err != nil {
+		wd.log.Error("failed to value withdrawal", sl.Err(err), slog.Int64("withdrawal_id", withdrawal.ID))
+
+		return withdrawal, nil
+	}
+
+	if wd.rules.AutoApprove > 0 && value.Minor() <= int64(wd.rules.AutoApprove) {
+		if err = wd.approve(withdrawal); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return withdrawal, nil
+}
+
+func (wd *Withdrawals) approve(withdrawal *model.Withdrawal) error {
+	const op = "handlers.withdrawal.approve"
+
+	if err := wd.move(withdrawal, config.WithdrawalApproved, "", ""); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	job.Dispatch(&WithdrawalJob{Withdrawals: wd, WithdrawalID: withdrawal.ID}, 0)
+
+	return nil
+}
+
+// fail ends a withdrawal that was not sent and gives the held amount back,
+// both on one transaction.
+func (wd *Withdrawals) fail(withdrawal *model.Withdrawal, reason string) error {
+	const op = "handlers.withdrawal.fail"
+
+	var (
+		err    error
+		tx     *sql.Tx
+		refund *balance.Tx
+		moved  bool
+	)
+
+	if !withdrawal.Status.CanMoveTo(config.WithdrawalFailed) {
+		return fmt.Errorf("%s: %w: %s to %s", op, config.ErrWithdrawalStatus, withdrawal.Status,
+			config.WithdrawalFailed)
+	}
+
+	tx, err = wd.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	refund = wd.balance.WithTx(tx)
+	defer refund.Rollback()
+
+	moved, err = wd.withdrawalRep.WithTx(tx).MoveWithdrawal(withdrawal.ID, withdrawal.Status,
+		config.WithdrawalFailed, "", reason)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !moved {
+		return fmt.Errorf("%s: %w", op, ErrStatusChanged)
+	}
+
+	amount := money.New(int64(withdrawal.Amount), withdrawal.Currency)
+
+	if err = refund.Income(withdrawal.UserID, amount, config.Withdrawal); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = refund.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	withdrawal.Status = config.WithdrawalFailed
+	withdrawal.Reference = ""
+	withdrawal.FailureReason = reason
+	withdrawal.UpdatedAt = time.Now()
+
+	wd.notify(withdrawal)
+
+	return nil
+}
+
+// move stores the next status of a withdrawal. Only the call that moved it
+// goes on, a concurrent one gets ErrStatusChanged.
+func (wd *Withdrawals) move(
+	withdrawal *model.Withdrawal,
+	to config.WithdrawalStatus,
+	reference string,
+	reason string) error {
+	const op = "handlers.withdrawal.move"
+
+	if !withdrawal.Status.CanMoveTo(to) {
+		return fmt.Errorf("%s: %w: %s to %s", op, config.ErrWithdrawalStatus, withdrawal.Status, to)
+	}
+
+	moved, err := wd.withdrawalRep.MoveWithdrawal(withdrawal.ID, withdrawal.Status, to, reference, reason)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !moved {
+		return fmt.Errorf("%s: %w", op, ErrStatusChanged)
+	}
+
+	withdrawal.Status = to
+	withdrawal.Reference = reference
+	withdrawal.FailureReason = reason
+	withdrawal.UpdatedAt = time.Now()
+
+	wd.notify(withdrawal)
+
+	return nil
+}
+
+func (wd *Withdrawals) notify(withdrawal *model.Withdrawal) {
+	user, err := wd.userRep.GetUserByID(withdrawal.UserID)
+	if err == nil {
+		err = wd.event.Trigger(events.WithdrawalUpdated{
+			UUID:          withdrawal.UUID,
+			UserUUID:      user.UUID,
+			Status:        withdrawal.Status,
+			Currency:      withdrawal.Currency,
+			Amount:        money.New(int64(withdrawal.Amount), withdrawal.Currency),
+			Reference:     withdrawal.Reference,
+			FailureReason: withdrawal.FailureReason,
+		})
+	}
+	if err != nil {
+		wd.log.Error("failed to send withdrawal event", sl.Err(err), slog.Int64("withdrawal_id", withdrawal.ID))
+	}
+}
+
+func (wd *Withdrawals) decode(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+
+		render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+		return false
+	}
+
+	if err := wd.validator.Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+
+		log.Error("invalid request", sl.Err(err))
+
+		render.JSON(w, r, resp.ValidationError(validateErr))
+
+		return false
+	}
+
+	return true
+}
+
+func withdrawalError(err error) resp.Response {
+	switch {
+	case errors.Is(err, repository.ErrWithdrawalNotFound):
+		return resp.ErrorCode("failed to find withdrawal", http.StatusNotFound, "withdrawal_not_found")
+	case errors.Is(err, config.ErrWithdrawalStatus):
+		return resp.ErrorCode(err.Error(), http.StatusConflict, "invalid_status")
+	case errors.Is(err, ErrStatusChanged):
+		return resp.ErrorCode(ErrStatusChanged.Error(), http.StatusConflict, "status_changed")
+	case errors.Is(err, ErrNoBalance):
+		return resp.Error("user has no balance", http.StatusNotFound)
+	case errors.Is(err, ErrInsufficientBalance):
+		return resp.Error("user has insufficient balance", http.StatusNotFound)
+	}
+
+	return resp.Error("failed to process withdrawal", http.StatusInternalServerError)
+}
+
+func views(withdrawals []model.Withdrawal) []Withdrawal {
+	list := make([]Withdrawal, 0, len(withdrawals))
+	for _, withdrawal := range withdrawals {
+		list = append(list, view(withdrawal))
+	}
+
+	return list
+}
+
+func view(withdrawal model.Withdrawal) Withdrawal {
+	return Withdrawal{
+		UUID:          withdrawal.UUID,
+		Provider:      withdrawal.Provider,
+		Currency:      withdrawal.Currency,
+		Amount:        money.New(int64(withdrawal.Amount), withdrawal.Currency),
+		Destination:   withdrawal.Destination,
+		Status:        withdrawal.Status,
+		Reference:     withdrawal.Reference,
+		FailureReason: withdrawal.FailureReason,
+		CreatedAt:     withdrawal.CreatedAt,
+		UpdatedAt:     withdrawal.UpdatedAt,
+	}
+}
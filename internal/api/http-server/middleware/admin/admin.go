@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"github.com/go-chi/render"
+	resp "go-outpost/internal/lib/api/response"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strings"
+)
+
+// New lets through only requests carrying adminKey as a bearer token, it
+// guards the operator routes that players must not reach.
+func New(log *slog.Logger, adminKey string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log = log.With(
+			slog.String("component", "middleware/admin"),
+		)
+
+		log.Info("admin middleware initialized")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			if adminKey == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminKey)) != 1 {
+				log.Warn("unauthorized admin request",
+					slog.String("url", r.URL.Path),
+					slog.String("remote_addr", r.RemoteAddr),
+				)
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error("unauthorized", http.StatusUnauthorized))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"time"
+)
+
+// AuditEntry records an action the backend took on its own, such as the
+// startup recovery finishing a round. Details is a JSON document.
+type AuditEntry struct {
+	ID        int64       `json:"id"`
+	Game      config.Game `json:"game"`
+	GameID    int64       `json:"game_id"`
+	Action    string      `json:"action"`
+	Details   string      `json:"details"`
+	CreatedAt time.Time   `json:"created_at"`
+}
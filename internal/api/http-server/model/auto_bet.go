@@ -0,0 +1,31 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"time"
+)
+
+// AutoBet is a strategy that places the same roulette bet every round until
+// one of its limits is hit. Amounts are in cents like every other balance.
+type AutoBet struct {
+	ID            int64                    `json:"id"`
+	UserID        int64                    `json:"user_id"`
+	Type          config.BetType           `json:"type"`
+	Value         string                   `json:"value"`
+	BaseAmount    int                      `json:"base_amount"`
+	CurrentAmount int                      `json:"current_amount"`
+	Strategy      config.AutoBetStrategy   `json:"strategy"`
+	Rounds        int                      `json:"rounds"`
+	RoundsPlayed  int                      `json:"rounds_played"`
+	StopOnProfit  int                      `json:"stop_on_profit"`
+	StopOnLoss    int                      `json:"stop_on_loss"`
+	Profit        int                      `json:"profit"`
+	Status        config.AutoBetStatus     `json:"status"`
+	StopReason    config.AutoBetStopReason `json:"stop_reason,omitempty"`
+	// PendingRouletteID is the round whose bet is not settled yet, zero when none.
+	PendingRouletteID int64 `json:"pending_roulette_id"`
+	// PendingBetID is the bet placed on that round, the player may cancel it.
+	PendingBetID int64     `json:"pending_bet_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"time"
+)
+
+// CoinflipLobby is a coinflip between the player who opened it and the one
+// who joined it. Both put in the same stake, amounts are in cents. The flip
+// is drawn from ServerSeed, only its hash is shown until then, and the
+// client seeds both players brought in.
+type CoinflipLobby struct {
+	ID                 int64              `json:"id"`
+	UUID               string             `json:"uuid"`
+	CreatorID          int64              `json:"creator_id"`
+	CreatorSide        config.CoinSide    `json:"creator_side"`
+	OpponentID         *int64             `json:"opponent_id"`
+	Stake              int                `json:"stake"`
+	Status             config.LobbyStatus `json:"status"`
+	Result             config.CoinSide    `json:"result,omitempty"`
+	WinnerID           *int64             `json:"winner_id"`
+	Payout             int                `json:"payout"`
+	Rake               int                `json:"rake"`
+	ServerSeed         string             `json:"-"`
+	ServerSeedHash     string             `json:"server_seed_hash"`
+	CreatorClientSeed  string             `json:"creator_client_seed"`
+	OpponentClientSeed string             `json:"opponent_client_seed"`
+	ExpiresAt          time.Time          `json:"expires_at"`
+	CreatedAt          time.Time          `json:"created_at"`
+	ResolvedAt         *time.Time         `json:"resolved_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+// Deposit is money sent to a wallet through a payment provider. Reference is
+// the id the provider gives the checkout, Amount is in minor units of
+// Currency and is what the provider reports received once completed.
+type Deposit struct {
+	ID          int64                `json:"id"`
+	UUID        string               `json:"uuid"`
+	UserID      int64                `json:"user_id"`
+	Provider    string               `json:"provider"`
+	Reference   string               `json:"reference"`
+	Currency    money.Currency       `json:"currency"`
+	Amount      int                  `json:"amount"`
+	Status      config.DepositStatus `json:"status"`
+	CreatedAt   time.Time            `json:"created_at"`
+	CompletedAt *time.Time           `json:"completed_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"time"
+)
+
+// Escrow is a stake taken from a player balance and held for a game until it
+// is resolved.
+type Escrow struct {
+	ID        int64               `json:"id"`
+	Game      config.Game         `json:"game"`
+	GameID    int64               `json:"game_id"`
+	UserID    int64               `json:"user_id"`
+	Amount    int                 `json:"amount"`
+	Status    config.EscrowStatus `json:"status"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
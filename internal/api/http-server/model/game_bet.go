@@ -0,0 +1,25 @@
+package model
+
+import (
+	"encoding/json"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+// GameBet is a bet on an instant game played through the game engine. Params
+// are the choices of the player and Result the serialized draw, both as the
+// engine of the game defines them. Amounts are in minor units of Currency.
+type GameBet struct {
+	ID        int64           `json:"id"`
+	UUID      string          `json:"uuid"`
+	UserID    int64           `json:"user_id"`
+	Game      config.Game     `json:"game"`
+	Currency  money.Currency  `json:"currency"`
+	Amount    int             `json:"amount"`
+	Params    json.RawMessage `json:"params"`
+	Result    json.RawMessage `json:"result"`
+	Payout    int             `json:"payout"`
+	Nonce     int             `json:"nonce"`
+	CreatedAt time.Time       `json:"created_at"`
+}
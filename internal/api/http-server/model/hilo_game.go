@@ -0,0 +1,33 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+// HiloGame is one hilo session. Deck is drawn when the game starts and stays
+// hidden, the first card is dealt right away and each guess turns the next
+// one. Amounts are in minor units of Currency.
+type HiloGame struct {
+	ID             int64              `json:"id"`
+	UUID           string             `json:"uuid"`
+	UserID         int64              `json:"user_id"`
+	Currency       money.Currency     `json:"currency"`
+	Amount         int                `json:"amount"`
+	Deck           []int              `json:"-"`
+	Guesses        []config.HiloGuess `json:"guesses"`
+	Multiplier     float64            `json:"multiplier"`
+	Status         config.HiloStatus  `json:"status"`
+	Payout         int                `json:"payout"`
+	ClientSeed     string             `json:"client_seed"`
+	ServerSeedHash string             `json:"server_seed_hash"`
+	Nonce          int                `json:"nonce"`
+	CreatedAt      time.Time          `json:"created_at"`
+	FinishedAt     *time.Time         `json:"finished_at"`
+}
+
+// Cards returns the cards turned so far.
+func (g HiloGame) Cards() []int {
+	return g.Deck[:len(g.Guesses)+1]
+}
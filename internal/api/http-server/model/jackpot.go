@@ -0,0 +1,36 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"time"
+)
+
+// JackpotPot is a shared pot. Every cent deposited is a ticket, numbered from
+// zero in deposit order, and Total is the number of tickets sold. The winning
+// ticket is drawn from ServerSeed, only its hash is shown until then.
+type JackpotPot struct {
+	ID             int64            `json:"id"`
+	UUID           string           `json:"uuid"`
+	Status         config.PotStatus `json:"status"`
+	Total          int              `json:"total"`
+	WinningTicket  *int             `json:"winning_ticket"`
+	WinnerID       *int64           `json:"winner_id"`
+	Payout         int              `json:"payout"`
+	Rake           int              `json:"rake"`
+	ServerSeed     string           `json:"-"`
+	ServerSeedHash string           `json:"server_seed_hash"`
+	EndsAt         time.Time        `json:"ends_at"`
+	CreatedAt      time.Time        `json:"created_at"`
+	DrawnAt        *time.Time       `json:"drawn_at"`
+}
+
+// JackpotDeposit holds the tickets TicketFrom to TicketTo, both included.
+type JackpotDeposit struct {
+	ID         int64     `json:"id"`
+	PotID      int64     `json:"pot_id"`
+	UserID     int64     `json:"user_id"`
+	Amount     int       `json:"amount"`
+	TicketFrom int       `json:"ticket_from"`
+	TicketTo   int       `json:"ticket_to"`
+	CreatedAt  time.Time `json:"created_at"`
+}
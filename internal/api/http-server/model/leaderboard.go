@@ -0,0 +1,11 @@
+package model
+
+// LeaderboardRank is the place of a player on a leaderboard. Score is in
+// cents, whatever the metric.
+type LeaderboardRank struct {
+	Rank     int    `json:"rank"`
+	UserID   int64  `json:"user_id"`
+	UserUUID string `json:"user_uuid"`
+	Score    int    `json:"score"`
+	Bets     int    `json:"bets"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+// MinesGame is one mines session. The board is drawn when the game starts and
+// stays hidden until it is over, amounts are in minor units of Currency.
+type MinesGame struct {
+	ID             int64              `json:"id"`
+	UUID           string             `json:"uuid"`
+	UserID         int64              `json:"user_id"`
+	Currency       money.Currency     `json:"currency"`
+	Amount         int                `json:"amount"`
+	Mines          int                `json:"mines"`
+	Board          []int              `json:"-"`
+	Revealed       []int              `json:"revealed"`
+	Status         config.MinesStatus `json:"status"`
+	Payout         int                `json:"payout"`
+	ClientSeed     string             `json:"client_seed"`
+	ServerSeedHash string             `json:"server_seed_hash"`
+	Nonce          int                `json:"nonce"`
+	CreatedAt      time.Time          `json:"created_at"`
+	FinishedAt     *time.Time         `json:"finished_at"`
+}
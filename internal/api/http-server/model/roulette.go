@@ -2,14 +2,18 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
 	"time"
 )
 
 type Roulette struct {
-	ID        int64      `json:"id"`
-	UUID      uuid.UUID  `json:"uuid"`
-	Round     int64      `json:"round"`
-	PlayedAt  *time.Time `json:"played_at"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID          int64              `json:"id"`
+	UUID        uuid.UUID          `json:"uuid"`
+	Round       int64              `json:"round"`
+	Status      config.RoundStatus `json:"status"`
+	BetDeadline time.Time          `json:"bet_deadline"`
+	PlayedAt    *time.Time         `json:"played_at"`
+	SettledAt   *time.Time         `json:"settled_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
 }
@@ -2,15 +2,21 @@ package model
 
 import (
 	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
 	"time"
 )
 
 type RouletteBet struct {
-	ID         int64        `json:"id"`
-	RouletteID int64        `json:"roulette_id"`
-	Amount     int          `json:"amount"`
-	Color      config.Color `json:"color"`
-	UserID     int64        `json:"user_id"`
-	CreatedAt  time.Time    `json:"created_at"`
-	UpdatedAt  time.Time    `json:"updated_at"`
+	ID         int64          `json:"id"`
+	RouletteID int64          `json:"roulette_id"`
+	Currency   money.Currency `json:"currency"`
+	Amount     int            `json:"amount"`
+	Type       config.BetType `json:"type"`
+	Value      string         `json:"value"`
+	Color      config.Color   `json:"color"`
+	UserID     int64          `json:"user_id"`
+	Payout     int            `json:"payout"`
+	SettledAt  *time.Time     `json:"settled_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
 }
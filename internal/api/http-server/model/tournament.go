@@ -0,0 +1,41 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"time"
+)
+
+// Tournament ranks its entrants on their bets between StartsAt and EndsAt,
+// counted from the time each one joined. Amounts are in cents, Prizes from
+// the first place down.
+type Tournament struct {
+	ID         int64                   `json:"id"`
+	UUID       string                  `json:"uuid"`
+	Name       string                  `json:"name"`
+	Metric     config.TournamentMetric `json:"metric"`
+	Games      []config.Game           `json:"games"`
+	EntryFee   int                     `json:"entry_fee"`
+	MinStake   int                     `json:"min_stake"`
+	MaxPlayers int                     `json:"max_players"`
+	Players    int                     `json:"players"`
+	Prizes     []int                   `json:"prizes"`
+	Status     config.TournamentStatus `json:"status"`
+	StartsAt   time.Time               `json:"starts_at"`
+	EndsAt     time.Time               `json:"ends_at"`
+	CreatedAt  time.Time               `json:"created_at"`
+	FinishedAt *time.Time              `json:"finished_at"`
+}
+
+// TournamentEntry is a player taking part in a tournament. Rank and Score
+// are stored once the tournament is over, PaidAt once the prize is paid.
+type TournamentEntry struct {
+	ID           int64      `json:"id"`
+	TournamentID int64      `json:"tournament_id"`
+	UserID       int64      `json:"user_id"`
+	UserUUID     string     `json:"user_uuid"`
+	Rank         *int       `json:"rank"`
+	Score        float64    `json:"score"`
+	Prize        int        `json:"prize"`
+	JoinedAt     time.Time  `json:"joined_at"`
+	PaidAt       *time.Time `json:"paid_at"`
+}
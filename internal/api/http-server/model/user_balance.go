@@ -1,10 +1,15 @@
 package model
 
-import "time"
+import (
+	"go-outpost/internal/lib/money"
+	"time"
+)
 
+// UserBalance is one wallet of a user, a user holds one per currency.
 type UserBalance struct {
-	ID        int64      `json:"id"`
-	Balance   int        `json:"balance"`
-	UserID    int64      `json:"user_id"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID        int64          `json:"id"`
+	Currency  money.Currency `json:"currency"`
+	Balance   money.Money    `json:"balance"`
+	UserID    int64          `json:"user_id"`
+	UpdatedAt *time.Time     `json:"updated_at"`
 }
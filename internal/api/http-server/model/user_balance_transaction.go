@@ -3,12 +3,15 @@ package model
 import (
 	"encoding/json"
 	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
 	"time"
 )
 
 type UserBalanceTransaction struct {
 	ID        int64              `json:"id"`
 	UserID    int64              `json:"user_id"`
+	Currency  money.Currency     `json:"currency"`
+	Value     money.Money        `json:"value"`
 	Type      config.BalanceType `json:"type"`
 	Module    string             `json:"module"`
 	Details   json.RawMessage    `json:"details"`
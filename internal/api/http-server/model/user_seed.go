@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// UserSeed is the seed pair instant games draw from for one player. Only the
+// hash of the server seed is shown until the pair is rotated, every draw
+// uses the next nonce.
+type UserSeed struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	ClientSeed     string     `json:"client_seed"`
+	ServerSeed     string     `json:"-"`
+	ServerSeedHash string     `json:"server_seed_hash"`
+	Nonce          int        `json:"nonce"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RevealedAt     *time.Time `json:"revealed_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+// Withdrawal is money sent out of a wallet to Destination, an address or
+// account the provider understands. Amount is in minor units of Currency and
+// is held from the wallet until the withdrawal is sent or fails. Reference is
+// the provider transfer id once sent.
+type Withdrawal struct {
+	ID            int64                   `json:"id"`
+	UUID          string                  `json:"uuid"`
+	UserID        int64                   `json:"user_id"`
+	Provider      string                  `json:"provider"`
+	Currency      money.Currency          `json:"currency"`
+	Amount        int                     `json:"amount"`
+	Destination   string                  `json:"destination"`
+	Status        config.WithdrawalStatus `json:"status"`
+	Reference     string                  `json:"reference"`
+	FailureReason string                  `json:"failure_reason"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"fmt"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+type AuditRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewAuditRepository(dbhandler mysql.Handler) *AuditRepository {
+	return &AuditRepository{dbhandler: dbhandler}
+}
+
+func (repo *AuditRepository) SaveAuditEntry(entry model.AuditEntry) error {
+	const op = "repository.audit.SaveAuditEntry"
+
+	const query = "INSERT INTO audit_entries(game, game_id, action, details, created_at) VALUES(?, ?, ?, ?, ?)"
+
+	_, err := repo.dbhandler.PrepareAndExecute(query, entry.Game, entry.GameID, entry.Action, entry.Details, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+const autoBetColumns = "id, user_id, type, value, base_amount, current_amount, strategy, rounds, rounds_played, " +
+	"stop_on_profit, stop_on_loss, profit, status, stop_reason, pending_roulette_id, pending_bet_id, created_at, updated_at"
+
+type AutoBetRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewAutoBetRepository(dbhandler mysql.Handler) *AutoBetRepository {
+	return &AutoBetRepository{dbhandler: dbhandler}
+}
+
+func (repo *AutoBetRepository) SaveAutoBet(autoBet model.AutoBet) (int64, error) {
+	const op = "repository.auto_bet.SaveAutoBet"
+
+	now := time.Now()
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO roulette_auto_bets(user_id, type, value, base_amount, current_amount, strategy, rounds, "+
+			"rounds_played, stop_on_profit, stop_on_loss, profit, status, stop_reason, pending_roulette_id, "+
+			"pending_bet_id, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		autoBet.UserID, autoBet.Type, autoBet.Value, autoBet.BaseAmount, autoBet.CurrentAmount, autoBet.Strategy,
+		autoBet.Rounds, autoBet.RoundsPlayed, autoBet.StopOnProfit, autoBet.StopOnLoss, autoBet.Profit,
+		autoBet.Status, autoBet.StopReason, autoBet.PendingRouletteID, autoBet.PendingBetID, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateAutoBet stores the progress of a strategy after a round.
+func (repo *AutoBetRepository) UpdateAutoBet(autoBet model.AutoBet) error {
+	const op = "repository.auto_bet.UpdateAutoBet"
+
+	_, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE roulette_auto_bets SET current_amount = ?, rounds_played = ?, profit = ?, status = ?, "+
+			"stop_reason = ?, pending_roulette_id = ?, pending_bet_id = ?, updated_at = ? WHERE id = ?",
+		autoBet.CurrentAmount, autoBet.RoundsPlayed, autoBet.Profit, autoBet.Status, autoBet.StopReason,
+		autoBet.PendingRouletteID, autoBet.PendingBetID, time.Now(), autoBet.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (repo *AutoBetRepository) FindAutoBetByID(id int64) (*model.AutoBet, error) {
+	const op = "repository.auto_bet.FindAutoBetByID"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow("SELECT "+autoBetColumns+" FROM roulette_auto_bets WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	autoBet := &model.AutoBet{}
+
+	err = row.Scan(autoBetFields(autoBet)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return autoBet, nil
+}
+
+func (repo *AutoBetRepository) GetActiveAutoBets() ([]model.AutoBet, error) {
+	const op = "repository.auto_bet.GetActiveAutoBets"
+
+	autoBets, err := repo.query("SELECT "+autoBetColumns+" FROM roulette_auto_bets WHERE status = ? ORDER BY id",
+		config.AutoBetActive)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return autoBets, nil
+}
+
+func (repo *AutoBetRepository) GetAutoBetsByUserID(userID int64) ([]model.AutoBet, error) {
+	const op = "repository.auto_bet.GetAutoBetsByUserID"
+
+	autoBets, err := repo.query("SELECT "+autoBetColumns+" FROM roulette_auto_bets WHERE user_id = ? ORDER BY id DESC",
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return autoBets, nil
+}
+
+func (repo *AutoBetRepository) query(query string, args ...interface{}) ([]model.AutoBet, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	autoBets := make([]model.AutoBet, 0)
+
+	for rows.Next() {
+		var autoBet model.AutoBet
+
+		if err = rows.Scan(autoBetFields(&autoBet)...); err != nil {
+			return nil, err
+		}
+
+		autoBets = append(autoBets, autoBet)
+	}
+
+	return autoBets, rows.Err()
+}
+
+func autoBetFields(a *model.AutoBet) []interface{} {
+	return []interface{}{
+		&a.ID, &a.UserID, &a.Type, &a.Value, &a.BaseAmount, &a.CurrentAmount, &a.Strategy, &a.Rounds,
+		&a.RoundsPlayed, &a.StopOnProfit, &a.StopOnLoss, &a.Profit, &a.Status, &a.StopReason,
+		&a.PendingRouletteID, &a.PendingBetID, &a.CreatedAt, &a.UpdatedAt,
+	}
+}
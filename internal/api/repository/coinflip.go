@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+const lobbyColumns = "id, uuid, creator_id, creator_side, opponent_id, stake, status, result, winner_id, payout, " +
+	"rake, server_seed, server_seed_hash, creator_client_seed, opponent_client_seed, expires_at, created_at, " +
+	"resolved_at"
+
+var ErrLobbyNotFound = errors.New("coinflip lobby not found")
+
+type CoinflipRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewCoinflipRepository(dbhandler mysql.Handler) *CoinflipRepository {
+	return &CoinflipRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo CoinflipRepository) WithTx(tx *sql.Tx) *CoinflipRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+func (repo *CoinflipRepository) SaveLobby(lobby model.CoinflipLobby) (int64, error) {
+	const op = "repository.coinflip.SaveLobby"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO coinflip_lobbies(uuid, creator_id, creator_side, stake, status, server_seed, server_seed_hash, "+
+			"creator_client_seed, expires_at, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		lobby.UUID, lobby.CreatorID, lobby.CreatorSide, lobby.Stake, lobby.Status, lobby.ServerSeed,
+		lobby.ServerSeedHash, lobby.CreatorClientSeed, lobby.ExpiresAt, lobby.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (repo *CoinflipRepository) FindLobbyByUUID(uuid string) (*model.CoinflipLobby, error) {
+	const op = "repository.coinflip.FindLobbyByUUID"
+
+	return repo.findLobby(op, "SELECT "+lobbyColumns+" FROM coinflip_lobbies WHERE uuid = ?", uuid)
+}
+
+func (repo *CoinflipRepository) FindLobbyByID(id int64) (*model.CoinflipLobby, error) {
+	const op = "repository.coinflip.FindLobbyByID"
+
+	return repo.findLobby(op, "SELECT "+lobbyColumns+" FROM coinflip_lobbies WHERE id = ?", id)
+}
+
+// JoinLobby seats the opponent with their client seed in an open lobby that
+// has not expired. It reports false when someone else joined first or the
+// lobby is gone.
+func (repo *CoinflipRepository) JoinLobby(id int64, opponentID int64, clientSeed string) (bool, error) {
+	const op = "repository.coinflip.JoinLobby"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE coinflip_lobbies SET opponent_id = ?, opponent_client_seed = ? WHERE id = ? AND status = ? "+
+			"AND opponent_id IS NULL AND expires_at > ?",
+		opponentID, clientSeed, id, config.LobbyOpen, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// ResolveLobby stores the result of a lobby. It reports false when the lobby
+// was already resolved or expired.
+func (repo *CoinflipRepository) ResolveLobby(lobby model.CoinflipLobby) (bool, error) {
+	const op = "repository.coinflip.ResolveLobby"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE coinflip_lobbies SET status = ?, result = ?, winner_id = ?, payout = ?, rake = ?, resolved_at = ? "+
+			"WHERE id = ? AND status = ?",
+		config.LobbyResolved, lobby.Result, lobby.WinnerID, lobby.Payout, lobby.Rake, lobby.ResolvedAt, lobby.ID,
+		config.LobbyOpen)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// ExpireLobby closes an open lobby nobody joined. It reports false when the
+// lobby was joined or closed in the meantime.
+func (repo *CoinflipRepository) ExpireLobby(id int64) (bool, error) {
+	const op = "repository.coinflip.ExpireLobby"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE coinflip_lobbies SET status = ?, resolved_at = ? WHERE id = ? AND status = ? AND opponent_id IS NULL",
+		config.LobbyExpired, time.Now(), id, config.LobbyOpen)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// GetOpenLobbies returns the lobbies waiting for an opponent, newest first.
+func (repo *CoinflipRepository) GetOpenLobbies() ([]model.CoinflipLobby, error) {
+	const op = "repository.coinflip.GetOpenLobbies"
+
+	lobbies, err := repo.query("SELECT "+lobbyColumns+" FROM coinflip_lobbies "+
+		"WHERE status = ? AND opponent_id IS NULL AND expires_at > ? ORDER BY id DESC", config.LobbyOpen, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lobbies, nil
+}
+
+// GetStaleLobbies returns the open lobbies past their expiry, oldest first.
+func (repo *CoinflipRepository) GetStaleLobbies() ([]model.CoinflipLobby, error) {
+	const op = "repository.coinflip.GetStaleLobbies"
+
+	lobbies, err := repo.query("SELECT "+lobbyColumns+" FROM coinflip_lobbies "+
+		"WHERE status = ? AND expires_at <= ? ORDER BY id", config.LobbyOpen, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lobbies, nil
+}
+
+func (repo *CoinflipRepository) findLobby(op string, query string, args ...interface{}) (*model.CoinflipLobby, error) {
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lobby := &model.CoinflipLobby{}
+
+	var result sql.NullString
+
+	err = row.Scan(lobbyFields(lobby, &result)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, ErrLobbyNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lobby.Result = config.CoinSide(result.String)
+
+	return lobby, nil
+}
+
+func (repo *CoinflipRepository) query(query string, args ...interface{}) ([]model.CoinflipLobby, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lobbies := make([]model.CoinflipLobby, 0)
+
+	for rows.Next() {
+		var (
+			lobby  model.CoinflipLobby
+			result sql.NullString
+		)
+
+		if err = rows.Scan(lobbyFields(&lobby, &result)...); err != nil {
+			return nil, err
+		}
+
+		lobby.Result = config.CoinSide(result.String)
+
+		lobbies = append(lobbies, lobby)
+	}
+
+	return lobbies, rows.Err()
+}
+
+func lobbyFields(l *model.CoinflipLobby, result *sql.NullString) []interface{} {
+	return []interface{}{
+		&l.ID, &l.UUID, &l.CreatorID, &l.CreatorSide, &l.OpponentID, &l.Stake, &l.Status, result, &l.WinnerID,
+		&l.Payout, &l.Rake, &l.ServerSeed, &l.ServerSeedHash, &l.CreatorClientSeed, &l.OpponentClientSeed,
+		&l.ExpiresAt, &l.CreatedAt, &l.ResolvedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+const walletDepositColumns = "id, uuid, user_id, provider, reference, currency, amount, status, created_at, completed_at"
+
+var ErrDepositNotFound = errors.New("deposit not found")
+
+type DepositRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewDepositRepository(dbhandler mysql.Handler) *DepositRepository {
+	return &DepositRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo DepositRepository) WithTx(tx *sql.Tx) *DepositRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+func (repo *DepositRepository) SaveDeposit(d model.Deposit) (int64, error) {
+	const op = "repository.deposit.SaveDeposit"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO deposits(uuid, user_id, provider, reference, currency, amount, status, created_at) "+
+			"VALUES(?, ?, ?, ?, ?, ?, ?, ?)",
+		d.UUID, d.UserID, d.Provider, d.Reference, d.Currency, d.Amount, d.Status, d.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (repo *DepositRepository) FindDepositByReference(provider string, reference string) (*model.Deposit, error) {
+	const op = "repository.deposit.FindDepositByReference"
+
+	deposits, err := repo.queryDeposits("SELECT "+walletDepositColumns+" FROM deposits "+
+		"WHERE provider = ? AND reference = ?", provider, reference)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(deposits) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrDepositNotFound)
+	}
+
+	return &deposits[0], nil
+}
+
+// GetDepositsByUserID returns the last deposits of a user, newest first.
+func (repo *DepositRepository) GetDepositsByUserID(userID int64, limit int) ([]model.Deposit, error) {
+	const op = "repository.deposit.GetDepositsByUserID"
+
+	deposits, err := repo.queryDeposits("SELECT "+walletDepositColumns+" FROM deposits WHERE user_id = ? "+
+		"ORDER BY id DESC LIMIT ?", userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deposits, nil
+}
+
+// SettleDeposit ends a pending deposit with the amount the provider received
+// and reports whether this call did it, the caller credits only then and on
+// the same transaction.
+func (repo *DepositRepository) SettleDeposit(id int64, status config.DepositStatus, amount int) (bool, error) {
+	const op = "repository.deposit.SettleDeposit"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE deposits SET status = ?, amount = ?, completed_at = ? WHERE id = ? AND status = ?",
+		status, amount, time.Now(), id, config.DepositPending)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *DepositRepository) queryDeposits(query string, args ...interface{}) ([]model.Deposit, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deposits := make([]model.Deposit, 0)
+
+	for rows.Next() {
+		var d model.Deposit
+
+		err = rows.Scan(&d.ID, &d.UUID, &d.UserID, &d.Provider, &d.Reference, &d.Currency, &d.Amount, &d.Status,
+			&d.CreatedAt, &d.CompletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		deposits = append(deposits, d)
+	}
+
+	return deposits, rows.Err()
+}
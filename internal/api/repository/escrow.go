@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+type EscrowRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewEscrowRepository(dbhandler mysql.Handler) *EscrowRepository {
+	return &EscrowRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo EscrowRepository) WithTx(tx *sql.Tx) *EscrowRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+func (repo *EscrowRepository) SaveEscrow(escrow model.Escrow) (int64, error) {
+	const op = "repository.escrow.SaveEscrow"
+
+	now := time.Now()
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO escrows(game, game_id, user_id, amount, status, created_at, updated_at) "+
+			"VALUES(?, ?, ?, ?, ?, ?, ?)",
+		escrow.Game, escrow.GameID, escrow.UserID, escrow.Amount, escrow.Status, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// CloseEscrow moves a held escrow to its final status. It reports false when
+// the escrow was already closed, so its funds are never moved twice.
+func (repo *EscrowRepository) CloseEscrow(id int64, status config.EscrowStatus) (bool, error) {
+	const op = "repository.escrow.CloseEscrow"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE escrows SET status = ?, updated_at = ? WHERE id = ? AND status = ?",
+		status, time.Now(), id, config.EscrowHeld)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// GetHeldEscrows returns the stakes still held for a game.
+func (repo *EscrowRepository) GetHeldEscrows(game config.Game, gameID int64) ([]model.Escrow, error) {
+	const op = "repository.escrow.GetHeldEscrows"
+
+	rows, err := repo.dbhandler.PrepareAndQuery(
+		"SELECT id, game, game_id, user_id, amount, status, created_at, updated_at FROM escrows "+
+			"WHERE game = ? AND game_id = ? AND status = ? ORDER BY id", game, gameID, config.EscrowHeld)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	escrows := make([]model.Escrow, 0)
+
+	for rows.Next() {
+		var escrow model.Escrow
+
+		err = rows.Scan(&escrow.ID, &escrow.Game, &escrow.GameID, &escrow.UserID, &escrow.Amount, &escrow.Status,
+			&escrow.CreatedAt, &escrow.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		escrows = append(escrows, escrow)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return escrows, nil
+}
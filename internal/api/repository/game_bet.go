@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+)
+
+var ErrGameBetNotFound = errors.New("game bet not found")
+
+type GameBetRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewGameBetRepository(dbhandler mysql.Handler) *GameBetRepository {
+	return &GameBetRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo GameBetRepository) WithTx(tx *sql.Tx) *GameBetRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+func (repo *GameBetRepository) SaveGameBet(bet model.GameBet) (int64, error) {
+	const op = "repository.game_bet.SaveGameBet"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO game_bets(uuid, user_id, game, currency, amount, params, result, payout, nonce, created_at) "+
+			"VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		bet.UUID, bet.UserID, bet.Game, bet.Currency, bet.Amount, string(bet.Params), string(bet.Result), bet.Payout, bet.Nonce,
+		bet.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (repo *GameBetRepository) FindGameBetByUUID(uuid string) (*model.GameBet, error) {
+	const op = "repository.game_bet.FindGameBetByUUID"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow(
+		"SELECT id, uuid, user_id, game, currency, amount, params, result, payout, nonce, created_at FROM game_bets "+
+			"WHERE uuid = ?", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		bet    model.GameBet
+		params string
+		result string
+	)
+
+	err = row.Scan(&bet.ID, &bet.UUID, &bet.UserID, &bet.Game, &bet.Currency, &bet.Amount, &params, &result, &bet.Payout,
+		&bet.Nonce, &bet.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, ErrGameBetNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bet.Params = []byte(params)
+	bet.Result = []byte(result)
+
+	return &bet, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+)
+
+const hiloColumns = "id, uuid, user_id, currency, amount, deck, guesses, multiplier, status, payout, client_seed, " +
+	"server_seed_hash, nonce, created_at, finished_at"
+
+var ErrHiloGameNotFound = errors.New("hilo game not found")
+
+type HiloRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewHiloRepository(dbhandler mysql.Handler) *HiloRepository {
+	return &HiloRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo HiloRepository) WithTx(tx *sql.Tx) *HiloRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+func (repo *HiloRepository) SaveHiloGame(game model.HiloGame) (int64, error) {
+	const op = "repository.hilo.SaveHiloGame"
+
+	deck, err := json.Marshal(game.Deck)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO hilo_games(uuid, user_id, currency, amount, deck, guesses, multiplier, status, payout, "+
+			"client_seed, server_seed_hash, nonce, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		game.UUID, game.UserID, game.Currency, game.Amount, string(deck), "[]", game.Multiplier, game.Status, game.Payout,
+		game.ClientSeed, game.ServerSeedHash, game.Nonce, game.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (repo *HiloRepository) FindHiloGameByUUID(uuid string) (*model.HiloGame, error) {
+	const op = "repository.hilo.FindHiloGameByUUID"
+
+	game, err := repo.find("SELECT "+hiloColumns+" FROM hilo_games WHERE uuid = ?", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return game, nil
+}
+
+// FindActiveHiloGame returns the game a player left unfinished, nil when none.
+func (repo *HiloRepository) FindActiveHiloGame(userID int64) (*model.HiloGame, error) {
+	const op = "repository.hilo.FindActiveHiloGame"
+
+	game, err := repo.find("SELECT "+hiloColumns+" FROM hilo_games WHERE user_id = ? AND status = ? "+
+		"ORDER BY id DESC LIMIT 1", userID, config.HiloActive)
+	if err != nil {
+		if errors.Is(err, ErrHiloGameNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return game, nil
+}
+
+// UpdateHiloGame stores a move. Like mines, the update only applies to an
+// active game that still has guessesBefore guesses, and reports whether the
+// move was stored.
+func (repo *HiloRepository) UpdateHiloGame(game model.HiloGame, guessesBefore int) (bool, error) {
+	const op = "repository.hilo.UpdateHiloGame"
+
+	guesses, err := json.Marshal(game.Guesses)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE hilo_games SET guesses = ?, multiplier = ?, status = ?, payout = ?, finished_at = ? "+
+			"WHERE id = ? AND status = ? AND JSON_LENGTH(guesses) = ?",
+		string(guesses), game.Multiplier, game.Status, game.Payout, game.FinishedAt, game.ID, config.HiloActive,
+		guessesBefore)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *HiloRepository) find(query string, args ...interface{}) (*model.HiloGame, error) {
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		game    model.HiloGame
+		deck    string
+		guesses string
+	)
+
+	err = row.Scan(&game.ID, &game.UUID, &game.UserID, &game.Currency, &game.Amount, &deck, &guesses, &game.Multiplier,
+		&game.Status, &game.Payout, &game.ClientSeed, &game.ServerSeedHash, &game.Nonce, &game.CreatedAt,
+		&game.FinishedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrHiloGameNotFound
+		}
+
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(deck), &game.Deck); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(guesses), &game.Guesses); err != nil {
+		return nil, err
+	}
+
+	return &game, nil
+}
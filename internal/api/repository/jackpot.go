@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	driver "github.com/go-sql-driver/mysql"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+const (
+	potColumns = "id, uuid, status, total, winning_ticket, winner_id, payout, rake, server_seed, server_seed_hash, " +
+		"ends_at, created_at, drawn_at"
+
+	depositColumns = "id, pot_id, user_id, amount, ticket_from, ticket_to, created_at"
+)
+
+// errDuplicateKey is the MySQL error number of a unique key violation.
+const errDuplicateKey = 1062
+
+var (
+	ErrPotNotFound    = errors.New("jackpot pot not found")
+	ErrPotAlreadyOpen = errors.New("a jackpot pot is already open")
+)
+
+type JackpotRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewJackpotRepository(dbhandler mysql.Handler) *JackpotRepository {
+	return &JackpotRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo JackpotRepository) WithTx(tx *sql.Tx) *JackpotRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+// SavePot stores a new pot. Only one pot can be open at a time, saving a
+// second one returns ErrPotAlreadyOpen.
+func (repo *JackpotRepository) SavePot(pot model.JackpotPot) (int64, error) {
+	const op = "repository.jackpot.SavePot"
+
+	var mysqlErr *driver.MySQLError
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO jackpot_pots(uuid, status, total, payout, rake, server_seed, server_seed_hash, ends_at, created_at) "+
+			"VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		pot.UUID, pot.Status, pot.Total, pot.Payout, pot.Rake, pot.ServerSeed, pot.ServerSeedHash, pot.EndsAt,
+		pot.CreatedAt)
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateKey {
+		return 0, fmt.Errorf("%s: %w", op, ErrPotAlreadyOpen)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// FindOpenPot returns the pot taking deposits, nil when there is none.
+func (repo *JackpotRepository) FindOpenPot() (*model.JackpotPot, error) {
+	const op = "repository.jackpot.FindOpenPot"
+
+	pot, err := repo.openPot("")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pot, nil
+}
+
+// LockOpenPot returns the pot taking deposits like FindOpenPot and locks it
+// until the transaction ends, so deposits into a pot run one at a time.
+func (repo *JackpotRepository) LockOpenPot() (*model.JackpotPot, error) {
+	const op = "repository.jackpot.LockOpenPot"
+
+	pot, err := repo.openPot(" FOR UPDATE")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pot, nil
+}
+
+func (repo *JackpotRepository) FindPotByID(id int64) (*model.JackpotPot, error) {
+	const op = "repository.jackpot.FindPotByID"
+
+	pot, err := repo.findPot("SELECT "+potColumns+" FROM jackpot_pots WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pot, nil
+}
+
+// LockPotByID returns a pot like FindPotByID and locks it until the
+// transaction ends.
+func (repo *JackpotRepository) LockPotByID(id int64) (*model.JackpotPot, error) {
+	const op = "repository.jackpot.LockPotByID"
+
+	pot, err := repo.findPot("SELECT "+potColumns+" FROM jackpot_pots WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pot, nil
+}
+
+func (repo *JackpotRepository) FindPotByUUID(uuid string) (*model.JackpotPot, error) {
+	const op = "repository.jackpot.FindPotByUUID"
+
+	pot, err := repo.findPot("SELECT "+potColumns+" FROM jackpot_pots WHERE uuid = ?", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pot, nil
+}
+
+// AddTickets sells amount tickets of an open pot and returns the new total,
+// the tickets of the deposit are the last amount ones. It reports false when
+// the pot stopped taking deposits.
+func (repo *JackpotRepository) AddTickets(potID int64, amount int) (int, bool, error) {
+	const op = "repository.jackpot.AddTickets"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE jackpot_pots SET total = LAST_INSERT_ID(total + ?) WHERE id = ? AND status = ? AND ends_at > ?",
+		amount, potID, config.PotOpen, time.Now())
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return 0, false, nil
+	}
+
+	total, err := res.LastInsertId()
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(total), true, nil
+}
+
+// ClosePot stores the outcome of a pot. It reports false when the pot was
+// already drawn or refunded.
+func (repo *JackpotRepository) ClosePot(pot model.JackpotPot) (bool, error) {
+	const op = "repository.jackpot.ClosePot"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE jackpot_pots SET status = ?, winning_ticket = ?, winner_id = ?, payout = ?, rake = ?, drawn_at = ? "+
+			"WHERE id = ? AND status = ?",
+		pot.Status, pot.WinningTicket, pot.WinnerID, pot.Payout, pot.Rake, pot.DrawnAt, pot.ID, config.PotOpen)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// GetStalePots returns the open pots past their end, oldest first.
+func (repo *JackpotRepository) GetStalePots() ([]model.JackpotPot, error) {
+	const op = "repository.jackpot.GetStalePots"
+
+	pots, err := repo.queryPots("SELECT "+potColumns+" FROM jackpot_pots WHERE status = ? AND ends_at <= ? "+
+		"ORDER BY id", config.PotOpen, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pots, nil
+}
+
+// GetFinishedPots returns drawn and refunded pots, newest first.
+func (repo *JackpotRepository) GetFinishedPots(limit int, offset int) ([]model.JackpotPot, error) {
+	const op = "repository.jackpot.GetFinishedPots"
+
+	pots, err := repo.queryPots("SELECT "+potColumns+" FROM jackpot_pots WHERE status != ? "+
+		"ORDER BY id DESC LIMIT ? OFFSET ?", config.PotOpen, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pots, nil
+}
+
+func (repo *JackpotRepository) CountFinishedPots() (int, error) {
+	const op = "repository.jackpot.CountFinishedPots"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow("SELECT COUNT(*) FROM jackpot_pots WHERE status != ?",
+		config.PotOpen)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var count int
+
+	if err = row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (repo *JackpotRepository) SaveDeposit(deposit model.JackpotDeposit) (int64, error) {
+	const op = "repository.jackpot.SaveDeposit"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO jackpot_deposits(pot_id, user_id, amount, ticket_from, ticket_to, created_at) "+
+			"VALUES(?, ?, ?, ?, ?, ?)",
+		deposit.PotID, deposit.UserID, deposit.Amount, deposit.TicketFrom, deposit.TicketTo, deposit.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetDepositsByPotIDs returns the deposits of the pots in ticket order.
+func (repo *JackpotRepository) GetDepositsByPotIDs(potIDs []int64) ([]model.JackpotDeposit, error) {
+	const op = "repository.jackpot.GetDepositsByPotIDs"
+
+	if len(potIDs) == 0 {
+		return []model.JackpotDeposit{}, nil
+	}
+
+	rows, err := repo.dbhandler.PrepareAndQuery("SELECT "+depositColumns+" FROM jackpot_deposits "+
+		"WHERE pot_id IN ("+placeholders(len(potIDs))+") ORDER BY pot_id, ticket_from", int64Args(potIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deposits := make([]model.JackpotDeposit, 0)
+
+	for rows.Next() {
+		var d model.JackpotDeposit
+
+		err = rows.Scan(&d.ID, &d.PotID, &d.UserID, &d.Amount, &d.TicketFrom, &d.TicketTo, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		deposits = append(deposits, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deposits, nil
+}
+
+func (repo *JackpotRepository) openPot(lock string) (*model.JackpotPot, error) {
+	pot, err := repo.findPot("SELECT "+potColumns+" FROM jackpot_pots WHERE status = ? AND ends_at > ? "+
+		"ORDER BY id DESC LIMIT 1"+lock, config.PotOpen, time.Now())
+	if errors.Is(err, ErrPotNotFound) {
+		return nil, nil
+	}
+
+	return pot, err
+}
+
+func (repo *JackpotRepository) findPot(query string, args ...interface{}) (*model.JackpotPot, error) {
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	pot := &model.JackpotPot{}
+
+	if err = row.Scan(potFields(pot)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPotNotFound
+		}
+
+		return nil, err
+	}
+
+	return pot, nil
+}
+
+func (repo *JackpotRepository) queryPots(query string, args ...interface{}) ([]model.JackpotPot, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pots := make([]model.JackpotPot, 0)
+
+	for rows.Next() {
+		var pot model.JackpotPot
+
+		if err = rows.Scan(potFields(&pot)...); err != nil {
+			return nil, err
+		}
+
+		pots = append(pots, pot)
+	}
+
+	return pots, rows.Err()
+}
+
+func potFields(p *model.JackpotPot) []interface{} {
+	return []interface{}{
+		&p.ID, &p.UUID, &p.Status, &p.Total, &p.WinningTicket, &p.WinnerID, &p.Payout, &p.Rake, &p.ServerSeed,
+		&p.ServerSeedHash, &p.EndsAt, &p.CreatedAt, &p.DrawnAt,
+	}
+}
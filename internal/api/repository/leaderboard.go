@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"strings"
+	"time"
+)
+
+// leaderboardScores folds the rows of a player on every game of a window
+// into the score of a metric.
+var leaderboardScores = map[config.LeaderboardMetric]string{
+	config.Wagered:    "SUM(s.wagered)",
+	config.Profit:     "SUM(s.profit)",
+	config.BiggestWin: "MAX(s.biggest_win)",
+}
+
+var ErrNotRanked = errors.New("user is not ranked")
+
+// LeaderboardRepository keeps one row per player, game and window in
+// leaderboard_scores, updated as bets settle.
+type LeaderboardRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewLeaderboardRepository(dbhandler mysql.Handler) *LeaderboardRepository {
+	return &LeaderboardRepository{dbhandler: dbhandler}
+}
+
+// AddBet counts a settled bet in every window it falls into.
+func (repo *LeaderboardRepository) AddBet(userID int64, game config.Game, amount int, payout int, at time.Time) error {
+	const op = "repository.leaderboard.AddBet"
+
+	profit := payout - amount
+
+	biggestWin := profit
+	if biggestWin < 0 {
+		biggestWin = 0
+	}
+
+	values := make([]string, 0, len(config.LeaderboardPeriods))
+	args := make([]interface{}, 0, 8*len(config.LeaderboardPeriods))
+
+	for _, period := range config.LeaderboardPeriods {
+		start, err := config.PeriodStart(period, at)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, 1, ?)")
+		args = append(args, period, start, game, userID, amount, profit, biggestWin, at)
+	}
+
+	_, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO leaderboard_scores(period, period_start, game, user_id, wagered, profit, biggest_win, bets, "+
+			"updated_at) VALUES "+strings.Join(values, ", ")+" ON DUPLICATE KEY UPDATE "+
+			"wagered = wagered + VALUES(wagered), profit = profit + VALUES(profit), "+
+			"biggest_win = GREATEST(biggest_win, VALUES(biggest_win)), bets = bets + 1, updated_at = VALUES(updated_at)",
+		args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetRanking returns a page of a leaderboard, best first. Ties go to the
+// player who registered first. An empty game ranks on every game.
+func (repo *LeaderboardRepository) GetRanking(
+	metric config.LeaderboardMetric,
+	period config.LeaderboardPeriod,
+	start time.Time,
+	game config.Game,
+	limit int,
+	offset int,
+) ([]model.LeaderboardRank, error) {
+	const op = "repository.leaderboard.GetRanking"
+
+	scores, where, args, err := leaderboardQuery(metric, period, start, game)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := repo.dbhandler.PrepareAndQuery("SELECT s.user_id, u.uuid, "+scores+", SUM(s.bets) "+
+		"FROM leaderboard_scores s JOIN users u ON u.id = s.user_id WHERE "+where+
+		" GROUP BY s.user_id, u.uuid ORDER BY 3 DESC, s.user_id LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	ranking := make([]model.LeaderboardRank, 0)
+
+	for rows.Next() {
+		r := model.LeaderboardRank{Rank: offset + len(ranking) + 1}
+
+		if err = rows.Scan(&r.UserID, &r.UserUUID, &r.Score, &r.Bets); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		ranking = append(ranking, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ranking, nil
+}
+
+// CountRanked returns the number of players on a leaderboard.
+func (repo *LeaderboardRepository) CountRanked(
+	period config.LeaderboardPeriod,
+	start time.Time,
+	game config.Game,
+) (int, error) {
+	const op = "repository.leaderboard.CountRanked"
+
+	_, where, args, err := leaderboardQuery(config.Wagered, period, start, game)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	row, err := repo.dbhandler.PrepareAndQueryRow("SELECT COUNT(DISTINCT s.user_id) FROM leaderboard_scores s "+
+		"WHERE "+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var count int
+
+	if err = row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// FindRank returns the place of a player on a leaderboard, in the order of
+// GetRanking. It fails with ErrNotRanked when the player has no bet there.
+func (repo *LeaderboardRepository) FindRank(
+	userID int64,
+	metric config.LeaderboardMetric,
+	period config.LeaderboardPeriod,
+	start time.Time,
+	game config.Game,
+) (*model.LeaderboardRank, error) {
+	const op = "repository.leaderboard.FindRank"
+
+	scores, where, args, err := leaderboardQuery(metric, period, start, game)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	row, err := repo.dbhandler.PrepareAndQueryRow("SELECT s.user_id, u.uuid, "+scores+", SUM(s.bets) "+
+		"FROM leaderboard_scores s JOIN users u ON u.id = s.user_id WHERE "+where+" AND s.user_id = ? "+
+		"GROUP BY s.user_id, u.uuid", append(args, userID)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rank := &model.LeaderboardRank{}
+
+	if err = row.Scan(&rank.UserID, &rank.UserUUID, &rank.Score, &rank.Bets); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, ErrNotRanked)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	row, err = repo.dbhandler.PrepareAndQueryRow("SELECT COUNT(*) FROM (SELECT s.user_id, "+scores+" AS score "+
+		"FROM leaderboard_scores s WHERE "+where+" GROUP BY s.user_id) r "+
+		"WHERE r.score > ? OR (r.score = ? AND r.user_id < ?)", append(args, rank.Score, rank.Score, userID)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = row.Scan(&rank.Rank); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rank.Rank++
+
+	return rank, nil
+}
+
+func leaderboardQuery(
+	metric config.LeaderboardMetric,
+	period config.LeaderboardPeriod,
+	start time.Time,
+	game config.Game,
+) (string, string, []interface{}, error) {
+	scores, ok := leaderboardScores[metric]
+	if !ok {
+		return "", "", nil, fmt.Errorf("%w: %s", config.ErrInvalidLeaderboard, metric)
+	}
+
+	where := "s.period = ? AND s.period_start = ?"
+	args := []interface{}{period, start}
+
+	if game != "" {
+		where += " AND s.game = ?"
+		args = append(args, game)
+	}
+
+	return scores, where, args, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+)
+
+const minesColumns = "id, uuid, user_id, currency, amount, mines, board, revealed, status, payout, client_seed, " +
+	"server_seed_hash, nonce, created_at, finished_at"
+
+var ErrMinesGameNotFound = errors.New("mines game not found")
+
+type MinesRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewMinesRepository(dbhandler mysql.Handler) *MinesRepository {
+	return &MinesRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo MinesRepository) WithTx(tx *sql.Tx) *MinesRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+func (repo *MinesRepository) SaveMinesGame(game model.MinesGame) (int64, error) {
+	const op = "repository.mines.SaveMinesGame"
+
+	board, err := json.Marshal(game.Board)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO mines_games(uuid, user_id, currency, amount, mines, board, revealed, status, payout, "+
+			"client_seed, server_seed_hash, nonce, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		game.UUID, game.UserID, game.Currency, game.Amount, game.Mines, string(board), "[]", game.Status, game.Payout,
+		game.ClientSeed, game.ServerSeedHash, game.Nonce, game.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (repo *MinesRepository) FindMinesGameByUUID(uuid string) (*model.MinesGame, error) {
+	const op = "repository.mines.FindMinesGameByUUID"
+
+	game, err := repo.find("SELECT "+minesColumns+" FROM mines_games WHERE uuid = ?", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return game, nil
+}
+
+// FindActiveMinesGame returns the game a player left unfinished, nil when none.
+func (repo *MinesRepository) FindActiveMinesGame(userID int64) (*model.MinesGame, error) {
+	const op = "repository.mines.FindActiveMinesGame"
+
+	game, err := repo.find("SELECT "+minesColumns+" FROM mines_games WHERE user_id = ? AND status = ? "+
+		"ORDER BY id DESC LIMIT 1", userID, config.MinesActive)
+	if err != nil {
+		if errors.Is(err, ErrMinesGameNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return game, nil
+}
+
+// UpdateMinesGame stores a move. The update only applies to an active game
+// that still has revealedBefore tiles open, so two concurrent moves on the
+// same game cannot both go through. It reports whether the move was stored.
+func (repo *MinesRepository) UpdateMinesGame(game model.MinesGame, revealedBefore int) (bool, error) {
+	const op = "repository.mines.UpdateMinesGame"
+
+	revealed, err := json.Marshal(game.Revealed)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE mines_games SET revealed = ?, status = ?, payout = ?, finished_at = ? "+
+			"WHERE id = ? AND status = ? AND JSON_LENGTH(revealed) = ?",
+		string(revealed), game.Status, game.Payout, game.FinishedAt, game.ID, config.MinesActive, revealedBefore)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *MinesRepository) find(query string, args ...interface{}) (*model.MinesGame, error) {
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		game     model.MinesGame
+		board    string
+		revealed string
+	)
+
+	err = row.Scan(&game.ID, &game.UUID, &game.UserID, &game.Currency, &game.Amount, &game.Mines, &board, &revealed,
+		&game.Status, &game.Payout, &game.ClientSeed, &game.ServerSeedHash, &game.Nonce, &game.CreatedAt, &game.FinishedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMinesGameNotFound
+		}
+
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(board), &game.Board); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal([]byte(revealed), &game.Revealed); err != nil {
+		return nil, err
+	}
+
+	return &game, nil
+}
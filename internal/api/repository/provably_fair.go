@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
+	"go-outpost/internal/api/config"
 	"go-outpost/internal/api/http-server/handlers/mysql"
 	model "go-outpost/internal/api/http-server/model"
 )
@@ -14,6 +16,13 @@ func NewProvablyFairRepository(dbhandler mysql.Handler) *ProvablyFairRepository
 	return &ProvablyFairRepository{dbhandler: dbhandler}
 }
 
+// WithTx returns a copy of the repository that runs on tx.
+func (repo ProvablyFairRepository) WithTx(tx *sql.Tx) *ProvablyFairRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
 func (repo *ProvablyFairRepository) SaveProvablyFair(provablyFair model.ProvablyFair) error {
 	const op = "repository.provably_fair.SaveProvablyFair"
 
@@ -67,3 +76,57 @@ func (repo *ProvablyFairRepository) SaveGameDraw(gameDraw model.GameDraw) (int64
 
 	return id, nil
 }
+
+// SaveUserGameDraw stores a draw of a game played by a single user.
+func (repo *ProvablyFairRepository) SaveUserGameDraw(gameDraw model.GameDraw) (int64, error) {
+	const op = "repository.provably_fair.SaveUserGameDraw"
+
+	const query = "INSERT INTO game_draws(game_id, user_id, game, created_at, updated_at) VALUES(?, ?, ?, ?, ?)"
+
+	res, err := repo.dbhandler.PrepareAndExecute(query,
+		gameDraw.GameID,
+		gameDraw.UserID,
+		gameDraw.Game,
+		gameDraw.CreatedAt,
+		gameDraw.UpdatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// FindProvablyFairByGame returns the fairness data of a game draw, nil when
+// the game was never drawn.
+func (repo *ProvablyFairRepository) FindProvablyFairByGame(gameID int64, game config.Game) (*model.ProvablyFair, error) {
+	const op = "repository.provably_fair.FindProvablyFairByGame"
+
+	const query = "SELECT p.id, p.game_draw_id, p.client_seed, p.server_seed, p.resulted_hash, " +
+		"p.resulted_random_number, p.min, p.max, p.nonce, p.created_at, p.updated_at " +
+		"FROM provably_fairs p JOIN game_draws d ON d.id = p.game_draw_id " +
+		"WHERE d.game_id = ? AND d.game = ? ORDER BY p.id DESC LIMIT 1"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, gameID, game)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pf := &model.ProvablyFair{}
+
+	err = row.Scan(&pf.ID, &pf.GameDrawID, &pf.ClientSeed, &pf.ServerSeed, &pf.ResultedHash,
+		&pf.ResultedRandomNumber, &pf.Min, &pf.Max, &pf.Nonce, &pf.CreatedAt, &pf.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pf, nil
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "strings"
+
+// placeholders returns "?, ?, ..." for an IN clause of n values.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func int64Args(values []int64) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+
+	return args
+}
@@ -2,12 +2,18 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"go-outpost/internal/api/config"
 	"go-outpost/internal/api/http-server/handlers/mysql"
 	"go-outpost/internal/api/http-server/model"
 	"time"
 )
 
+var ErrRouletteNotFound = errors.New("roulette not found")
+
+const rouletteColumns = "id,uuid,round,status,bet_deadline,played_at,settled_at,created_at"
+
 type RouletteRepository struct {
 	dbhandler mysql.Handler
 }
@@ -16,10 +22,43 @@ func NewRouletteRepository(dbhandler mysql.Handler) *RouletteRepository {
 	return &RouletteRepository{dbhandler: dbhandler}
 }
 
+// WithTx returns a copy of the repository that runs on tx.
+func (repo RouletteRepository) WithTx(tx *sql.Tx) *RouletteRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+// LockRoulette takes a row lock on the round that is held until the
+// transaction ends, so placements on the same round run one at a time. It
+// returns the round as it stands under the lock.
+func (repo *RouletteRepository) LockRoulette(id int64) (*model.Roulette, error) {
+	const op = "repository.roulette.LockRoulette"
+
+	const query = "SELECT " + rouletteColumns + " FROM roulettes WHERE id = ? FOR UPDATE"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roulette := &model.Roulette{}
+
+	if err = row.Scan(rouletteFields(roulette)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, ErrRouletteNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roulette, nil
+}
+
 func (repo *RouletteRepository) FindRouletteByUUID(uuid string) (*model.Roulette, error) {
 	const op = "repository.roulette.FindRouletteByUUID"
 
-	const query = "SELECT id,round,played_at FROM roulettes WHERE uuid = ?"
+	const query = "SELECT " + rouletteColumns + " FROM roulettes WHERE uuid = ?"
 
 	row, err := repo.dbhandler.PrepareAndQueryRow(query, uuid)
 	if err != nil {
@@ -28,10 +67,10 @@ func (repo *RouletteRepository) FindRouletteByUUID(uuid string) (*model.Roulette
 
 	roulette := &model.Roulette{}
 
-	err = row.Scan(&roulette.ID, &roulette.Round, &roulette.PlayedAt)
+	err = row.Scan(rouletteFields(roulette)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, fmt.Errorf("%s: %w", op, ErrRouletteNotFound)
 		}
 
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -43,11 +82,13 @@ func (repo *RouletteRepository) FindRouletteByUUID(uuid string) (*model.Roulette
 func (repo *RouletteRepository) SaveRoulette(roulette model.Roulette) (int64, error) {
 	const op = "repository.roulette.SaveRoulette"
 
-	const query = "INSERT INTO roulettes(uuid, round, created_at, updated_at) VALUES(?, ?, ?, ?)"
+	const query = "INSERT INTO roulettes(uuid, round, status, bet_deadline, created_at, updated_at) " +
+		"VALUES(?, ?, ?, ?, ?, ?)"
 
 	now := time.Now()
 
-	res, err := repo.dbhandler.PrepareAndExecute(query, roulette.UUID, roulette.Round, now, now)
+	res, err := repo.dbhandler.PrepareAndExecute(query, roulette.UUID, roulette.Round, roulette.Status,
+		roulette.BetDeadline, now, now)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -87,7 +128,7 @@ func (repo *RouletteRepository) GetLastRound() (int64, error) {
 func (repo *RouletteRepository) GetRouletteByID(id int64) (*model.Roulette, error) {
 	const op = "repository.roulette.GetRouletteByID"
 
-	const query = "SELECT id,uuid,round,played_at,created_at FROM roulettes WHERE id = ?"
+	const query = "SELECT " + rouletteColumns + " FROM roulettes WHERE id = ?"
 
 	row, err := repo.dbhandler.PrepareAndQueryRow(query, id)
 	if err != nil {
@@ -96,10 +137,10 @@ func (repo *RouletteRepository) GetRouletteByID(id int64) (*model.Roulette, erro
 
 	roulette := &model.Roulette{}
 
-	err = row.Scan(&roulette.ID, &roulette.UUID, &roulette.Round, &roulette.PlayedAt, &roulette.CreatedAt)
+	err = row.Scan(rouletteFields(roulette)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, fmt.Errorf("%s: %w", op, ErrRouletteNotFound)
 		}
 
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -111,9 +152,23 @@ func (repo *RouletteRepository) GetRouletteByID(id int64) (*model.Roulette, erro
 func (repo *RouletteRepository) UpdateRoulettePlayedAt(roulette *model.Roulette) error {
 	const op = "repository.roulette.UpdateRoulettePlayedAt"
 
-	const query = "UPDATE roulettes SET played_at = ? WHERE id = ?"
+	const query = "UPDATE roulettes SET played_at = ?, status = ? WHERE id = ?"
+
+	_, err := repo.dbhandler.PrepareAndExecute(query, roulette.PlayedAt, roulette.Status, roulette.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateRouletteStatus moves a round to the given status.
+func (repo *RouletteRepository) UpdateRouletteStatus(rouletteID int64, status config.RoundStatus) error {
+	const op = "repository.roulette.UpdateRouletteStatus"
+
+	const query = "UPDATE roulettes SET status = ?, updated_at = ? WHERE id = ?"
 
-	_, err := repo.dbhandler.PrepareAndExecute(query, roulette.PlayedAt, roulette.ID)
+	_, err := repo.dbhandler.PrepareAndExecute(query, status, time.Now(), rouletteID)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -121,26 +176,110 @@ func (repo *RouletteRepository) UpdateRoulettePlayedAt(roulette *model.Roulette)
 	return nil
 }
 
-func (repo *RouletteRepository) GetPreviousRouletteID(rouletteID int64) (*model.Roulette, error) {
-	const op = "repository.roulette.GetPreviousRoulette"
+// MarkRouletteSettled sets settled_at once together with the final status
+// (settled or refunded), it reports false when the round was already settled.
+func (repo *RouletteRepository) MarkRouletteSettled(rouletteID int64, status config.RoundStatus) (bool, error) {
+	const op = "repository.roulette.MarkRouletteSettled"
+
+	const query = "UPDATE roulettes SET status = ?, settled_at = ?, updated_at = ? WHERE id = ? AND settled_at IS NULL"
+
+	now := time.Now()
+
+	res, err := repo.dbhandler.PrepareAndExecute(query, status, now, now, rouletteID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// GetUnsettledRoulettes returns the rounds that were rolled but never settled, oldest first.
+func (repo *RouletteRepository) GetUnsettledRoulettes() ([]model.Roulette, error) {
+	const op = "repository.roulette.GetUnsettledRoulettes"
+
+	roulettes, err := repo.query("SELECT "+rouletteColumns+" FROM roulettes "+
+		"WHERE status = ? AND settled_at IS NULL ORDER BY id", config.RoundRolled)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roulettes, nil
+}
 
-	const query = "SELECT id FROM roulettes WHERE id = (SELECT id FROM roulettes WHERE id < ? ORDER BY id DESC LIMIT 1)"
+// GetIncompleteRoulettes returns every round that has not reached settlement yet, oldest first.
+func (repo *RouletteRepository) GetIncompleteRoulettes() ([]model.Roulette, error) {
+	const op = "repository.roulette.GetIncompleteRoulettes"
 
-	row, err := repo.dbhandler.PrepareAndQueryRow(query, rouletteID)
+	roulettes, err := repo.query("SELECT "+rouletteColumns+" FROM roulettes "+
+		"WHERE status IN (?, ?, ?) AND settled_at IS NULL ORDER BY id",
+		config.RoundOpen, config.RoundClosed, config.RoundRolled)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	roulette := &model.Roulette{}
+	return roulettes, nil
+}
+
+// GetFinishedRoulettes returns a page of rolled rounds, newest first.
+func (repo *RouletteRepository) GetFinishedRoulettes(limit int, offset int) ([]model.Roulette, error) {
+	const op = "repository.roulette.GetFinishedRoulettes"
 
-	err = row.Scan(&roulette.ID)
+	roulettes, err := repo.query("SELECT "+rouletteColumns+" FROM roulettes "+
+		"WHERE status IN (?, ?) ORDER BY id DESC LIMIT ? OFFSET ?", config.RoundRolled, config.RoundSettled, limit, offset)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roulettes, nil
+}
+
+func (repo *RouletteRepository) CountFinishedRoulettes() (int, error) {
+	const op = "repository.roulette.CountFinishedRoulettes"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow("SELECT COUNT(*) FROM roulettes WHERE status IN (?, ?)",
+		config.RoundRolled, config.RoundSettled)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var count int
+
+	if err = row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (repo *RouletteRepository) query(query string, args ...interface{}) ([]model.Roulette, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roulettes := make([]model.Roulette, 0)
+
+	for rows.Next() {
+		var roulette model.Roulette
+
+		if err = rows.Scan(rouletteFields(&roulette)...); err != nil {
+			return nil, err
 		}
 
-		return nil, fmt.Errorf("%s: %w", op, err)
+		roulettes = append(roulettes, roulette)
 	}
 
-	return roulette, nil
+	return roulettes, rows.Err()
+}
+
+func rouletteFields(r *model.Roulette) []interface{} {
+	return []interface{}{
+		&r.ID, &r.UUID, &r.Round, &r.Status, &r.BetDeadline, &r.PlayedAt, &r.SettledAt, &r.CreatedAt,
+	}
 }
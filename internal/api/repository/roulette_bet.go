@@ -1,13 +1,18 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
-	"go-outpost/internal/api/config"
 	"go-outpost/internal/api/http-server/handlers/mysql"
 	"go-outpost/internal/api/http-server/model"
 	"time"
 )
 
+var ErrBetNotFound = errors.New("bet not found")
+
+const betColumns = "id, type, value, color, currency, amount, roulette_id, user_id, payout, settled_at, created_at, updated_at"
+
 type RouletteBetRepository struct {
 	dbhandler mysql.Handler
 }
@@ -16,15 +21,22 @@ func NewBetRepository(dbhandler mysql.Handler) *RouletteBetRepository {
 	return &RouletteBetRepository{dbhandler: dbhandler}
 }
 
+// WithTx returns a copy of the repository that runs on tx.
+func (repo RouletteBetRepository) WithTx(tx *sql.Tx) *RouletteBetRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
 func (repo *RouletteBetRepository) SaveBet(bet model.RouletteBet) (int64, error) {
 	const op = "repository.bet.SaveBet"
 
 	now := time.Now()
 
 	res, err := repo.dbhandler.PrepareAndExecute(
-		"INSERT INTO roulette_bets(color, amount, roulette_id, user_id, created_at, updated_at) "+
-			"VALUES(?, ?, ?, ?, ?, ?)",
-		bet.Color, bet.Amount, bet.RouletteID, bet.UserID, now, now)
+		"INSERT INTO roulette_bets(type, value, color, currency, amount, roulette_id, user_id, created_at, "+
+			"updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		bet.Type, bet.Value, bet.Color, bet.Currency, bet.Amount, bet.RouletteID, bet.UserID, now, now)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
@@ -56,26 +68,23 @@ func (repo *RouletteBetRepository) CountBetsByRouletteAndUser(rouletteID int64,
 	return count, nil
 }
 
-func (repo *RouletteBetRepository) GetBetsByRouletteIDAndColor(
-	rouletteID int64,
-	color config.Color,
-) ([]model.RouletteBet, error) {
-	const op = "repository.bet.GetBetsByRouletteIDAndColor"
+func (repo *RouletteBetRepository) GetBetsByRouletteID(rouletteID int64) ([]model.RouletteBet, error) {
+	const op = "repository.bet.GetBetsByRouletteID"
 
-	const query = "SELECT id, color, amount, roulette_id, user_id, created_at, updated_at " +
-		"FROM roulette_bets WHERE roulette_id = ? AND color = ?"
+	const query = "SELECT " + betColumns + " FROM roulette_bets WHERE roulette_id = ?"
 
-	rows, err := repo.dbhandler.PrepareAndQuery(query, rouletteID, color)
+	rows, err := repo.dbhandler.PrepareAndQuery(query, rouletteID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer rows.Close()
 
 	bets := make([]model.RouletteBet, 0)
 
 	for rows.Next() {
 		var bet model.RouletteBet
 
-		err = rows.Scan(&bet.ID, &bet.Color, &bet.Amount, &bet.RouletteID, &bet.UserID, &bet.CreatedAt, &bet.UpdatedAt)
+		err = rows.Scan(betFields(&bet)...)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
@@ -85,3 +94,105 @@ func (repo *RouletteBetRepository) GetBetsByRouletteIDAndColor(
 
 	return bets, nil
 }
+
+func (repo *RouletteBetRepository) FindBetByID(id int64) (*model.RouletteBet, error) {
+	const op = "repository.bet.FindBetByID"
+
+	const query = "SELECT " + betColumns + " FROM roulette_bets WHERE id = ?"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bet := &model.RouletteBet{}
+
+	err = row.Scan(betFields(bet)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, ErrBetNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bet, nil
+}
+
+// DeleteBet removes an unsettled bet and reports whether this call did it,
+// the caller refunds only then.
+func (repo *RouletteBetRepository) DeleteBet(id int64) (bool, error) {
+	const op = "repository.bet.DeleteBet"
+
+	res, err := repo.dbhandler.PrepareAndExecute("DELETE FROM roulette_bets WHERE id = ? AND settled_at IS NULL", id)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *RouletteBetRepository) GetBetsByRouletteIDs(rouletteIDs []int64) ([]model.RouletteBet, error) {
+	const op = "repository.bet.GetBetsByRouletteIDs"
+
+	if len(rouletteIDs) == 0 {
+		return []model.RouletteBet{}, nil
+	}
+
+	query := "SELECT " + betColumns + " FROM roulette_bets WHERE roulette_id IN (" + placeholders(len(rouletteIDs)) + ")"
+
+	rows, err := repo.dbhandler.PrepareAndQuery(query, int64Args(rouletteIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	bets := make([]model.RouletteBet, 0)
+
+	for rows.Next() {
+		var bet model.RouletteBet
+
+		err = rows.Scan(betFields(&bet)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		bets = append(bets, bet)
+	}
+
+	return bets, nil
+}
+
+// ClaimBetSettlement records the payout of a bet once. It reports false when
+// the bet was already settled, so a payout is never made twice.
+func (repo *RouletteBetRepository) ClaimBetSettlement(betID int64, payout int) (bool, error) {
+	const op = "repository.bet.ClaimBetSettlement"
+
+	const query = "UPDATE roulette_bets SET payout = ?, settled_at = ?, updated_at = ? WHERE id = ? AND settled_at IS NULL"
+
+	now := time.Now()
+
+	res, err := repo.dbhandler.PrepareAndExecute(query, payout, now, now, betID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+func betFields(b *model.RouletteBet) []interface{} {
+	return []interface{}{
+		&b.ID, &b.Type, &b.Value, &b.Color, &b.Currency, &b.Amount, &b.RouletteID, &b.UserID, &b.Payout, &b.SettledAt,
+		&b.CreatedAt, &b.UpdatedAt,
+	}
+}
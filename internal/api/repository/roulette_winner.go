@@ -1,11 +1,11 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"go-outpost/internal/api/config"
 	"go-outpost/internal/api/http-server/handlers/mysql"
 	"go-outpost/internal/api/http-server/model"
-	"math/rand"
 	"time"
 )
 
@@ -33,17 +33,79 @@ func (repo *RouletteWinnerRepository) SaveWin(roulette *model.Roulette, color co
 	return nil
 }
 
-func (repo *RouletteWinnerRepository) GetNumberByColor(color config.Color) (int, error) {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+func (repo *RouletteWinnerRepository) FindWinByRouletteID(rouletteID int64) (*model.RouletteWinner, error) {
+	const op = "repository.roulette_winner.FindWinByRouletteID"
 
-	switch color {
-	case config.Red:
-		return rnd.Intn(7) + 1, nil
-	case config.Black:
-		return rnd.Intn(7) + 8, nil
-	case config.Green:
-		return 0, nil
+	const query = "SELECT id, roulette_id, color, number, created_at, updated_at FROM roulette_wins " +
+		"WHERE roulette_id = ?"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, rouletteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	win := &model.RouletteWinner{}
+
+	err = row.Scan(&win.ID, &win.RouletteID, &win.Color, &win.Number, &win.CreatedAt, &win.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return win, nil
+}
+
+func (repo *RouletteWinnerRepository) GetWinsByRouletteIDs(rouletteIDs []int64) ([]model.RouletteWinner, error) {
+	const op = "repository.roulette_winner.GetWinsByRouletteIDs"
+
+	if len(rouletteIDs) == 0 {
+		return []model.RouletteWinner{}, nil
+	}
+
+	wins, err := repo.query("SELECT w.id, w.roulette_id, w.color, w.number, w.created_at, w.updated_at "+
+		"FROM roulette_wins w WHERE w.roulette_id IN ("+placeholders(len(rouletteIDs))+")", int64Args(rouletteIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return wins, nil
+}
+
+// GetLastWins returns the winning pockets of the last rolled rounds, newest first.
+func (repo *RouletteWinnerRepository) GetLastWins(limit int) ([]model.RouletteWinner, error) {
+	const op = "repository.roulette_winner.GetLastWins"
+
+	wins, err := repo.query("SELECT w.id, w.roulette_id, w.color, w.number, w.created_at, w.updated_at "+
+		"FROM roulette_wins w JOIN roulettes r ON r.id = w.roulette_id WHERE r.status IN (?, ?) "+
+		"ORDER BY w.roulette_id DESC LIMIT ?", config.RoundRolled, config.RoundSettled, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return wins, nil
+}
+
+func (repo *RouletteWinnerRepository) query(query string, args ...interface{}) ([]model.RouletteWinner, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wins := make([]model.RouletteWinner, 0)
+
+	for rows.Next() {
+		var win model.RouletteWinner
+
+		if err = rows.Scan(&win.ID, &win.RouletteID, &win.Color, &win.Number, &win.CreatedAt, &win.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		wins = append(wins, win)
 	}
 
-	return 0, fmt.Errorf("invalid color: %s", color)
+	return wins, rows.Err()
 }
@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+const (
+	tournamentColumns = "id, uuid, name, metric, games, entry_fee, min_stake, max_players, players, prizes, status, " +
+		"starts_at, ends_at, created_at, finished_at"
+
+	entryColumns = "e.id, e.tournament_id, e.user_id, u.uuid, e.`rank`, e.score, e.prize, e.joined_at, e.paid_at"
+)
+
+// multiplierTables are the bet tables of config.MultiplierGames, a game draw
+// points at a row of one of them through its game id.
+var multiplierTables = map[config.Game]string{
+	config.Dice:   "game_bets",
+	config.Limbo:  "game_bets",
+	config.Mines:  "mines_games",
+	config.Hilo:   "hilo_games",
+	config.Plinko: "game_bets",
+}
+
+var (
+	ErrTournamentNotFound = errors.New("tournament not found")
+	ErrEntryNotFound      = errors.New("tournament entry not found")
+)
+
+type TournamentRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewTournamentRepository(dbhandler mysql.Handler) *TournamentRepository {
+	return &TournamentRepository{dbhandler: dbhandler}
+}
+
+func (repo *TournamentRepository) SaveTournament(t model.Tournament) (int64, error) {
+	const op = "repository.tournament.SaveTournament"
+
+	games, err := json.Marshal(t.Games)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	prizes, err := json.Marshal(t.Prizes)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO tournaments(uuid, name, metric, games, entry_fee, min_stake, max_players, players, prizes, "+
+			"status, starts_at, ends_at, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?)",
+		t.UUID, t.Name, t.Metric, string(games), t.EntryFee, t.MinStake, t.MaxPlayers, string(prizes), t.Status,
+		t.StartsAt, t.EndsAt, t.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (repo *TournamentRepository) FindTournamentByID(id int64) (*model.Tournament, error) {
+	const op = "repository.tournament.FindTournamentByID"
+
+	t, err := repo.findTournament("SELECT "+tournamentColumns+" FROM tournaments WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, nil
+}
+
+func (repo *TournamentRepository) FindTournamentByUUID(uuid string) (*model.Tournament, error) {
+	const op = "repository.tournament.FindTournamentByUUID"
+
+	t, err := repo.findTournament("SELECT "+tournamentColumns+" FROM tournaments WHERE uuid = ?", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, nil
+}
+
+// GetTournamentsByStatus returns the tournaments in the given status, the
+// next to end first.
+func (repo *TournamentRepository) GetTournamentsByStatus(status config.TournamentStatus) ([]model.Tournament, error) {
+	const op = "repository.tournament.GetTournamentsByStatus"
+
+	tournaments, err := repo.queryTournaments("SELECT "+tournamentColumns+" FROM tournaments WHERE status = ? "+
+		"ORDER BY ends_at, id", status)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tournaments, nil
+}
+
+// SetTournamentStatus moves a tournament on from the status it was read in
+// and reports whether this call did it.
+func (repo *TournamentRepository) SetTournamentStatus(
+	id int64,
+	from config.TournamentStatus,
+	to config.TournamentStatus) (bool, error) {
+	const op = "repository.tournament.SetTournamentStatus"
+
+	var finishedAt *time.Time
+	if to == config.TournamentFinished {
+		now := time.Now()
+		finishedAt = &now
+	}
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE tournaments SET status = ?, finished_at = ? WHERE id = ? AND status = ?", to, finishedAt, id, from)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// TakeSeat counts a new player in while the tournament has room for one,
+// it reports whether a seat was taken.
+func (repo *TournamentRepository) TakeSeat(id int64) (bool, error) {
+	const op = "repository.tournament.TakeSeat"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE tournaments SET players = players + 1 WHERE id = ? AND status <> ? AND ends_at > ? "+
+			"AND (max_players = 0 OR players < max_players)", id, config.TournamentFinished, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *TournamentRepository) ReleaseSeat(id int64) error {
+	const op = "repository.tournament.ReleaseSeat"
+
+	_, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE tournaments SET players = players - 1 WHERE id = ? AND players > 0", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (repo *TournamentRepository) SaveEntry(entry model.TournamentEntry) (int64, error) {
+	const op = "repository.tournament.SaveEntry"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO tournament_entries(tournament_id, user_id, score, prize, joined_at) VALUES(?, ?, 0, 0, ?)",
+		entry.TournamentID, entry.UserID, entry.JoinedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// FindEntry returns the entry of a player, nil when the player did not join.
+func (repo *TournamentRepository) FindEntry(tournamentID int64, userID int64) (*model.TournamentEntry, error) {
+	const op = "repository.tournament.FindEntry"
+
+	entries, err := repo.queryEntries("SELECT "+entryColumns+" FROM tournament_entries e "+
+		"JOIN users u ON u.id = e.user_id WHERE e.tournament_id = ? AND e.user_id = ?", tournamentID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return &entries[0], nil
+}
+
+// GetEntries returns the players of a tournament, by rank once it is over
+// and in the order they joined before.
+func (repo *TournamentRepository) GetEntries(tournamentID int64) ([]model.TournamentEntry, error) {
+	const op = "repository.tournament.GetEntries"
+
+	entries, err := repo.queryEntries("SELECT "+entryColumns+" FROM tournament_entries e "+
+		"JOIN users u ON u.id = e.user_id WHERE e.tournament_id = ? "+
+		"ORDER BY e.`rank` IS NULL, e.`rank`, e.joined_at, e.id", tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+// SaveStanding stores the final rank and score of an entry.
+func (repo *TournamentRepository) SaveStanding(entryID int64, rank int, score float64) error {
+	const op = "repository.tournament.SaveStanding"
+
+	_, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE tournament_entries SET `rank` = ?, score = ? WHERE id = ?", rank, score, entryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClaimPrize marks the prize of an entry as paid and reports whether this
+// call did it, the caller pays only then.
+func (repo *TournamentRepository) ClaimPrize(entryID int64, prize int) (bool, error) {
+	const op = "repository.tournament.ClaimPrize"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE tournament_entries SET prize = ?, paid_at = ? WHERE id = ? AND paid_at IS NULL",
+		prize, time.Now(), entryID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+// GetWagered sums the stakes of every entrant from the balance transactions,
+// keyed by entry id. Stakes held and later refunded, such as an expired
+// coinflip lobby, still count. Tournaments are played in the default
+// currency, stakes in other wallets do not count.
+func (repo *TournamentRepository) GetWagered(t model.Tournament) (map[int64]float64, error) {
+	const op = "repository.tournament.GetWagered"
+
+	modules := "t.module <> ?"
+	args := []interface{}{config.Outcome, money.Default, t.StartsAt, t.EndsAt}
+
+	if len(t.Games) == 0 {
+		args = append(args, config.Tournament)
+	} else {
+		modules = "t.module IN (" + placeholders(len(t.Games)) + ")"
+		for _, game := range t.Games {
+			args = append(args, game)
+		}
+	}
+
+	rows, err := repo.dbhandler.PrepareAndQuery("SELECT e.id, SUM(t.value) FROM tournament_entries e "+
+		"JOIN user_balance_transactions t ON t.user_id = e.user_id AND t.type = ? AND t.currency = ? "+
+		"AND t.created_at >= GREATEST(e.joined_at, ?) AND t.created_at < ? "+
+		"WHERE "+modules+" AND e.tournament_id = ? GROUP BY e.id", append(args, t.ID)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	scores, err := scanScores(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return scores, nil
+}
+
+// GetBestMultipliers finds the best payout to stake ratio of a bet of at
+// least MinStake per entrant, keyed by entry id. A bet counts when its draw
+// was made inside the tournament and staked in the default currency.
+func (repo *TournamentRepository) GetBestMultipliers(t model.Tournament) (map[int64]float64, error) {
+	const op = "repository.tournament.GetBestMultipliers"
+
+	best := make(map[int64]float64)
+
+	for _, game := range t.Games {
+		table, ok := multiplierTables[game]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: %s", op, config.ErrInvalidTournament, game)
+		}
+
+		rows, err := repo.dbhandler.PrepareAndQuery("SELECT e.id, MAX(b.payout / b.amount) FROM tournament_entries e "+
+			"JOIN game_draws d ON d.user_id = e.user_id AND d.game = ? "+
+			"AND d.created_at >= GREATEST(e.joined_at, ?) AND d.created_at < ? "+
+			"JOIN "+table+" b ON b.id = d.game_id AND b.currency = ? AND b.amount >= ? AND b.amount > 0 "+
+			"WHERE e.tournament_id = ? GROUP BY e.id", game, t.StartsAt, t.EndsAt, money.Default, t.MinStake, t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		scores, err := scanScores(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for entryID, score := range scores {
+			if score > best[entryID] {
+				best[entryID] = score
+			}
+		}
+	}
+
+	return best, nil
+}
+
+func scanScores(rows *sql.Rows) (map[int64]float64, error) {
+	defer rows.Close()
+
+	scores := make(map[int64]float64)
+
+	for rows.Next() {
+		var (
+			entryID int64
+			score   sql.NullFloat64
+		)
+
+		if err := rows.Scan(&entryID, &score); err != nil {
+			return nil, err
+		}
+
+		scores[entryID] = score.Float64
+	}
+
+	return scores, rows.Err()
+}
+
+func (repo *TournamentRepository) findTournament(query string, args ...interface{}) (*model.Tournament, error) {
+	tournaments, err := repo.queryTournaments(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tournaments) == 0 {
+		return nil, ErrTournamentNotFound
+	}
+
+	return &tournaments[0], nil
+}
+
+func (repo *TournamentRepository) queryTournaments(query string, args ...interface{}) ([]model.Tournament, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tournaments := make([]model.Tournament, 0)
+
+	for rows.Next() {
+		var (
+			t      model.Tournament
+			games  string
+			prizes string
+		)
+
+		err = rows.Scan(&t.ID, &t.UUID, &t.Name, &t.Metric, &games, &t.EntryFee, &t.MinStake, &t.MaxPlayers,
+			&t.Players, &prizes, &t.Status, &t.StartsAt, &t.EndsAt, &t.CreatedAt, &t.FinishedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal([]byte(games), &t.Games); err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal([]byte(prizes), &t.Prizes); err != nil {
+			return nil, err
+		}
+
+		tournaments = append(tournaments, t)
+	}
+
+	return tournaments, rows.Err()
+}
+
+func (repo *TournamentRepository) queryEntries(query string, args ...interface{}) ([]model.TournamentEntry, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.TournamentEntry, 0)
+
+	for rows.Next() {
+		var e model.TournamentEntry
+
+		err = rows.Scan(&e.ID, &e.TournamentID, &e.UserID, &e.UserUUID, &e.Rank, &e.Score, &e.Prize, &e.JoinedAt,
+			&e.PaidAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
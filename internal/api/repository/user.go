@@ -2,13 +2,17 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	config "go-outpost/internal/api/config"
 	"go-outpost/internal/api/http-server/handlers/mysql"
 	model "go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/money"
 	"time"
 )
 
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
 type UserRepository struct {
 	dbhandler mysql.Handler
 }
@@ -17,6 +21,13 @@ func NewUserRepository(dbhandler mysql.Handler) *UserRepository {
 	return &UserRepository{dbhandler: dbhandler}
 }
 
+// WithTx returns a copy of the repository that runs on tx.
+func (repo UserRepository) WithTx(tx *sql.Tx) *UserRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
 func (repo *UserRepository) FindUserByUUID(uuid string) (*model.User, error) {
 	const query = "SELECT id FROM users WHERE uuid = ?"
 	row, err := repo.dbhandler.PrepareAndQueryRow(query, uuid)
@@ -38,18 +49,20 @@ func (repo *UserRepository) FindUserByUUID(uuid string) (*model.User, error) {
 	return user, nil
 }
 
-func (repo *UserRepository) FindUserBalanceByID(userID int64) (*model.UserBalance, error) {
+// FindUserBalanceByID returns the wallet of a user in the currency, nil when
+// the user has none.
+func (repo *UserRepository) FindUserBalanceByID(userID int64, currency money.Currency) (*model.UserBalance, error) {
 	const op = "repository.user.FindUserBalanceByID"
 
-	const query = "SELECT balance FROM user_balances WHERE user_id = ?"
-	row, err := repo.dbhandler.PrepareAndQueryRow(query, userID)
+	const query = "SELECT id, balance, updated_at FROM user_balances WHERE user_id = ? AND currency = ?"
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, userID, currency)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	userBalance := &model.UserBalance{}
+	userBalance := &model.UserBalance{UserID: userID, Currency: currency, Balance: money.New(0, currency)}
 
-	err = row.Scan(&userBalance.Balance)
+	err = row.Scan(&userBalance.ID, &userBalance.Balance, &userBalance.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -61,27 +74,79 @@ func (repo *UserRepository) FindUserBalanceByID(userID int64) (*model.UserBalanc
 	return userBalance, nil
 }
 
-func (repo *UserRepository) OutcomeFromUserBalance(userID int64, amount int) error {
+// GetUserBalances returns every wallet of a user.
+func (repo *UserRepository) GetUserBalances(userID int64) ([]model.UserBalance, error) {
+	const op = "repository.user.GetUserBalances"
+
+	const query = "SELECT id, currency, balance, updated_at FROM user_balances WHERE user_id = ? ORDER BY id"
+	rows, err := repo.dbhandler.PrepareAndQuery(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	balances := make([]model.UserBalance, 0)
+
+	for rows.Next() {
+		b := model.UserBalance{UserID: userID}
+
+		if err = rows.Scan(&b.ID, &b.Currency, &b.Balance, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		b.Balance = money.New(b.Balance.Minor(), b.Currency)
+
+		balances = append(balances, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return balances, nil
+}
+
+// OutcomeFromUserBalance debits the wallet of the amount's currency. The
+// debit only happens when the wallet covers it, so concurrent debits cannot
+// take it below zero, otherwise ErrInsufficientBalance is returned.
+func (repo *UserRepository) OutcomeFromUserBalance(userID int64, amount money.Money) error {
 	const op = "repository.user.OutcomeFromUserBalance"
 
+	if amount.Minor() == 0 {
+		return nil
+	}
+
 	now := time.Now()
 
-	const query = "UPDATE user_balances SET balance = balance - ?, updated_at = ? WHERE user_id = ?"
-	_, err := repo.dbhandler.PrepareAndExecute(query, amount, now, userID)
+	const query = "UPDATE user_balances SET balance = balance - ?, updated_at = ? " +
+		"WHERE user_id = ? AND currency = ? AND balance >= ?"
+	res, err := repo.dbhandler.PrepareAndExecute(query, amount, now, userID, amount.Currency(), amount)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrInsufficientBalance)
+	}
+
 	return nil
 }
 
-func (repo *UserRepository) IncomeToUserBalance(userID int64, amount int) error {
+// IncomeToUserBalance credits the wallet of the amount's currency, opening it
+// on the first credit.
+func (repo *UserRepository) IncomeToUserBalance(userID int64, amount money.Money) error {
 	const op = "repository.user.IncomeToUserBalance"
 
 	now := time.Now()
 
-	const query = "UPDATE user_balances SET balance = balance + ?, updated_at = ? WHERE user_id = ?"
-	_, err := repo.dbhandler.PrepareAndExecute(query, amount, now, userID)
+	const query = "INSERT INTO user_balances(user_id, currency, balance, updated_at) VALUES(?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE balance = balance + VALUES(balance), updated_at = VALUES(updated_at)"
+	_, err := repo.dbhandler.PrepareAndExecute(query, userID, amount.Currency(), amount, now)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -91,7 +156,7 @@ func (repo *UserRepository) IncomeToUserBalance(userID int64, amount int) error
 
 func (repo *UserRepository) CreateUserBalanceTransaction(
 	userID int64,
-	amount int,
+	amount money.Money,
 	balanceType config.BalanceType,
 	game config.Game,
 ) error {
@@ -101,12 +166,13 @@ func (repo *UserRepository) CreateUserBalanceTransaction(
 
 	const query = "INSERT INTO user_balance_transactions(" +
 		"user_id," +
+		" currency," +
 		" value," +
 		" type," +
 		" module," +
 		" created_at," +
-		" updated_at) VALUES(?, ?, ?, ?, ?, ?)"
-	_, err := repo.dbhandler.PrepareAndExecute(query, userID, amount, balanceType, game, now, now)
+		" updated_at) VALUES(?, ?, ?, ?, ?, ?, ?)"
+	_, err := repo.dbhandler.PrepareAndExecute(query, userID, amount.Currency(), amount, balanceType, game, now, now)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
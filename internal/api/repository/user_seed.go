@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+var ErrSeedNotActive = errors.New("seed pair is not active")
+
+type UserSeedRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewUserSeedRepository(dbhandler mysql.Handler) *UserSeedRepository {
+	return &UserSeedRepository{dbhandler: dbhandler}
+}
+
+func (repo *UserSeedRepository) FindActiveSeed(userID int64) (*model.UserSeed, error) {
+	const op = "repository.user_seed.FindActiveSeed"
+
+	const query = "SELECT id, user_id, client_seed, server_seed, server_seed_hash, nonce, active, created_at, " +
+		"revealed_at FROM user_seeds WHERE user_id = ? AND active = 1"
+
+	row, err := repo.dbhandler.PrepareAndQueryRow(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	seed := &model.UserSeed{}
+
+	err = row.Scan(&seed.ID, &seed.UserID, &seed.ClientSeed, &seed.ServerSeed, &seed.ServerSeedHash, &seed.Nonce,
+		&seed.Active, &seed.CreatedAt, &seed.RevealedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return seed, nil
+}
+
+func (repo *UserSeedRepository) SaveSeed(seed model.UserSeed) (int64, error) {
+	const op = "repository.user_seed.SaveSeed"
+
+	const query = "INSERT INTO user_seeds(user_id, client_seed, server_seed, server_seed_hash, nonce, active, " +
+		"created_at) VALUES(?, ?, ?, ?, ?, ?, ?)"
+
+	res, err := repo.dbhandler.PrepareAndExecute(query, seed.UserID, seed.ClientSeed, seed.ServerSeed,
+		seed.ServerSeedHash, seed.Nonce, seed.Active, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// NextNonce increments the nonce of a seed pair atomically and returns the new value.
+func (repo *UserSeedRepository) NextNonce(seedID int64) (int, error) {
+	const op = "repository.user_seed.NextNonce"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE user_seeds SET nonce = LAST_INSERT_ID(nonce + 1) WHERE id = ? AND active = 1", seedID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return 0, fmt.Errorf("%s: %w", op, ErrSeedNotActive)
+	}
+
+	nonce, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return int(nonce), nil
+}
+
+// RevealSeed retires a seed pair, from then on its server seed may be shown.
+func (repo *UserSeedRepository) RevealSeed(seedID int64) error {
+	const op = "repository.user_seed.RevealSeed"
+
+	_, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE user_seeds SET active = 0, revealed_at = ? WHERE id = ?", time.Now(), seedID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/mysql"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+const withdrawalColumns = "id, uuid, user_id, provider, currency, amount, destination, status, reference, " +
+	"failure_reason, created_at, updated_at"
+
+var ErrWithdrawalNotFound = errors.New("withdrawal not found")
+
+type WithdrawalRepository struct {
+	dbhandler mysql.Handler
+}
+
+func NewWithdrawalRepository(dbhandler mysql.Handler) *WithdrawalRepository {
+	return &WithdrawalRepository{dbhandler: dbhandler}
+}
+
+// WithTx returns a copy of the repository that runs on tx.
+func (repo WithdrawalRepository) WithTx(tx *sql.Tx) *WithdrawalRepository {
+	repo.dbhandler = repo.dbhandler.WithTx(tx)
+
+	return &repo
+}
+
+func (repo *WithdrawalRepository) SaveWithdrawal(w model.Withdrawal) (int64, error) {
+	const op = "repository.withdrawal.SaveWithdrawal"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"INSERT INTO withdrawals(uuid, user_id, provider, currency, amount, destination, status, reference, "+
+			"failure_reason, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, '', '', ?, ?)",
+		w.UUID, w.UserID, w.Provider, w.Currency, w.Amount, w.Destination, w.Status, w.CreatedAt, w.UpdatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (repo *WithdrawalRepository) FindWithdrawalByID(id int64) (*model.Withdrawal, error) {
+	const op = "repository.withdrawal.FindWithdrawalByID"
+
+	w, err := repo.findWithdrawal("SELECT "+withdrawalColumns+" FROM withdrawals WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return w, nil
+}
+
+func (repo *WithdrawalRepository) FindWithdrawalByUUID(uuid string) (*model.Withdrawal, error) {
+	const op = "repository.withdrawal.FindWithdrawalByUUID"
+
+	w, err := repo.findWithdrawal("SELECT "+withdrawalColumns+" FROM withdrawals WHERE uuid = ?", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return w, nil
+}
+
+// GetWithdrawalsByUserID returns the last withdrawals of a user, newest first.
+func (repo *WithdrawalRepository) GetWithdrawalsByUserID(userID int64, limit int) ([]model.Withdrawal, error) {
+	const op = "repository.withdrawal.GetWithdrawalsByUserID"
+
+	withdrawals, err := repo.queryWithdrawals("SELECT "+withdrawalColumns+" FROM withdrawals WHERE user_id = ? "+
+		"ORDER BY id DESC LIMIT ?", userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return withdrawals, nil
+}
+
+// GetWithdrawalsByStatus returns the withdrawals in any of the given
+// statuses, the oldest first.
+func (repo *WithdrawalRepository) GetWithdrawalsByStatus(statuses ...config.WithdrawalStatus) ([]model.Withdrawal, error) {
+	const op = "repository.withdrawal.GetWithdrawalsByStatus"
+
+	args := make([]interface{}, 0, len(statuses))
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	withdrawals, err := repo.queryWithdrawals("SELECT "+withdrawalColumns+" FROM withdrawals "+
+		"WHERE status IN ("+placeholders(len(statuses))+") ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return withdrawals, nil
+}
+
+// MoveWithdrawal moves a withdrawal on from the status it was read in and
+// reports whether this call did it. Reference and reason are stored as given.
+func (repo *WithdrawalRepository) MoveWithdrawal(
+	id int64,
+	from config.WithdrawalStatus,
+	to config.WithdrawalStatus,
+	reference string,
+	reason string) (bool, error) {
+	const op = "repository.withdrawal.MoveWithdrawal"
+
+	res, err := repo.dbhandler.PrepareAndExecute(
+		"UPDATE withdrawals SET status = ?, reference = ?, failure_reason = ?, updated_at = ? "+
+			"WHERE id = ? AND status = ?", to, reference, reason, time.Now(), id, from)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return affected == 1, nil
+}
+
+func (repo *WithdrawalRepository) findWithdrawal(query string, args ...interface{}) (*model.Withdrawal, error) {
+	withdrawals, err := repo.queryWithdrawals(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(withdrawals) == 0 {
+		return nil, ErrWithdrawalNotFound
+	}
+
+	return &withdrawals[0], nil
+}
+
+func (repo *WithdrawalRepository) queryWithdrawals(query string, args ...interface{}) ([]model.Withdrawal, error) {
+	rows, err := repo.dbhandler.PrepareAndQuery(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	withdrawals := make([]model.Withdrawal, 0)
+
+	for rows.Next() {
+		var w model.Withdrawal
+
+		err = rows.Scan(&w.ID, &w.UUID, &w.UserID, &w.Provider, &w.Currency, &w.Amount, &w.Destination, &w.Status,
+			&w.Reference, &w.FailureReason, &w.CreatedAt, &w.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		withdrawals = append(withdrawals, w)
+	}
+
+	return withdrawals, rows.Err()
+}
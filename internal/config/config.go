@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"github.com/joho/godotenv"
+	apiconfig "go-outpost/internal/api/config"
 	"log"
 	"os"
 	"time"
@@ -13,6 +15,18 @@ type Config struct {
 	Env        string `yaml:"env" env-default:"local"`
 	HTTPServer `yaml:"http_server"`
 	WSServer   `yaml:"ws_server"`
+	Roulette   `yaml:"roulette"`
+	Dice       apiconfig.DiceRules                 `yaml:"dice"`
+	Coinflip   apiconfig.CoinflipRules             `yaml:"coinflip"`
+	Mines      apiconfig.MinesRules                `yaml:"mines"`
+	Plinko     apiconfig.PlinkoRules               `yaml:"plinko"`
+	Jackpot    apiconfig.JackpotRules              `yaml:"jackpot"`
+	Limbo      apiconfig.LimboRules                `yaml:"limbo"`
+	Hilo       apiconfig.HiloRules                 `yaml:"hilo"`
+	Tournament apiconfig.TournamentRules           `yaml:"tournament"`
+	Wallets    apiconfig.WalletRules               `yaml:"wallets"`
+	Payments   apiconfig.PaymentRules              `yaml:"payments"`
+	Limits     map[apiconfig.Game]apiconfig.Limits `yaml:"limits"`
 }
 
 type HTTPServer struct {
@@ -22,9 +36,52 @@ type HTTPServer struct {
 }
 
 type WSServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8081"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	Address       string        `yaml:"address" env-default:"localhost:8081"`
+	Timeout       time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout   time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	APIKey        string        `yaml:"api_key" env:"WS_API_KEY"`
+	ChannelSecret string        `yaml:"channel_secret" env:"WS_CHANNEL_SECRET"`
+	Heartbeat     time.Duration `yaml:"heartbeat" env-default:"25s"`
+	Compression   bool          `yaml:"compression" env-default:"true"`
+	Limits        WSLimits      `yaml:"limits"`
+}
+
+type WSLimits struct {
+	AllowedOrigins      []string      `yaml:"allowed_origins"`
+	MaxConnectionsPerIP int           `yaml:"max_connections_per_ip" env-default:"20"`
+	MessagesPerSecond   float64       `yaml:"messages_per_second" env-default:"10"`
+	MessageBurst        int           `yaml:"message_burst" env-default:"20"`
+	MaxMessageSize      int64         `yaml:"max_message_size" env-default:"4096"`
+	MaxProtocolErrors   int           `yaml:"max_protocol_errors" env-default:"5"`
+	StrikeWindow        time.Duration `yaml:"strike_window" env-default:"1m"`
+	BanDuration         time.Duration `yaml:"ban_duration" env-default:"10m"`
+	TrustedProxies      []string      `yaml:"trusted_proxies"`
+}
+
+type Roulette struct {
+	Wheel     string                     `yaml:"wheel" env-default:"csgo"`
+	Wheels    map[string]apiconfig.Wheel `yaml:"wheels"`
+	BetWindow time.Duration              `yaml:"bet_window" env-default:"15s"`
+	Recovery  apiconfig.RecoveryPolicy   `yaml:"recovery_policy" env-default:"settle"`
+}
+
+// ActiveWheel returns the wheel selected by name, validated.
+func (r Roulette) ActiveWheel() (apiconfig.Wheel, error) {
+	wheel, ok := r.Wheels[r.Wheel]
+	if !ok {
+		return wheel, fmt.Errorf("roulette wheel %q is not defined", r.Wheel)
+	}
+
+	if err := wheel.Validate(); err != nil {
+		return wheel, fmt.Errorf("roulette wheel %q: %w", r.Wheel, err)
+	}
+
+	return wheel, nil
+}
+
+// GameLimits returns the betting limits of a game, no limits when none are configured.
+func (c Config) GameLimits(game apiconfig.Game) apiconfig.Limits {
+	return c.Limits[game]
 }
 
 func MustLoad() *Config {
@@ -47,5 +104,65 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %s", err)
 	}
 
+	if _, err := cfg.Roulette.ActiveWheel(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if cfg.Roulette.Recovery != apiconfig.RecoverySettle && cfg.Roulette.Recovery != apiconfig.RecoveryRefund {
+		log.Fatalf("invalid config: roulette recovery policy %q", cfg.Roulette.Recovery)
+	}
+
+	if err := cfg.Dice.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Coinflip.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Mines.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Plinko.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Jackpot.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Limbo.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Hilo.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Tournament.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Wallets.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	if err := cfg.Payments.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	for game, limits := range cfg.Limits {
+		if err := limits.Validate(); err != nil {
+			log.Fatalf("invalid config: %s limits: %s", game, err)
+		}
+
+		for currency := range limits.Currencies {
+			if !cfg.Wallets.Accepts(currency) {
+				log.Fatalf("invalid config: %s limits: %s: %s", game, apiconfig.ErrCurrencyNotAccepted, currency)
+			}
+		}
+	}
+
 	return &cfg
 }
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiconfig "go-outpost/internal/api/config"
+	"testing"
+)
+
+func TestLocalConfigWheels(t *testing.T) {
+	var cfg Config
+
+	require.NoError(t, cleanenv.ReadConfig("../../config/local.yaml", &cfg))
+
+	tests := []struct {
+		name    string
+		pockets int
+		greens  int
+	}{
+		{
+			name:    "csgo",
+			pockets: 15,
+			greens:  1,
+		},
+		{
+			name:    "european",
+			pockets: 37,
+			greens:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Roulette.Wheel = tt.name
+
+			wheel, err := cfg.Roulette.ActiveWheel()
+			require.NoError(t, err)
+
+			greens := 0
+			for _, pocket := range wheel.Pockets {
+				if pocket.Color == apiconfig.Green {
+					greens++
+				}
+			}
+
+			assert.Len(t, wheel.Pockets, tt.pockets)
+			assert.Equal(t, tt.greens, greens)
+		})
+	}
+}
+
+func TestActiveWheelRejectsInvalidWheels(t *testing.T) {
+	r := Roulette{
+		Wheel: "broken",
+		Wheels: map[string]apiconfig.Wheel{
+			"broken": {
+				Pockets: []apiconfig.Pocket{{Number: 0, Color: apiconfig.Green}},
+				Payouts: apiconfig.Payouts{Color: map[apiconfig.Color]int{apiconfig.Red: 2}},
+			},
+		},
+	}
+
+	_, err := r.ActiveWheel()
+	assert.Error(t, err)
+
+	r.Wheel = "missing"
+
+	_, err = r.ActiveWheel()
+	assert.Error(t, err)
+}
+
+func TestLocalConfigLimits(t *testing.T) {
+	var cfg Config
+
+	require.NoError(t, cleanenv.ReadConfig("../../config/local.yaml", &cfg))
+
+	limits := cfg.GameLimits(apiconfig.Roulette)
+
+	require.NoError(t, limits.Validate())
+	assert.Equal(t, 2, limits.MaxBetsPerRound)
+	assert.Equal(t, apiconfig.ExposureScale, limits.Exposure.Mode)
+}
+
+func TestLocalConfigPlinko(t *testing.T) {
+	var cfg Config
+
+	require.NoError(t, cleanenv.ReadConfig("../../config/local.yaml", &cfg))
+
+	require.NoError(t, cfg.Plinko.Validate())
+
+	for _, risk := range []apiconfig.PlinkoRisk{apiconfig.PlinkoLow, apiconfig.PlinkoMedium, apiconfig.PlinkoHigh} {
+		table, err := cfg.Plinko.Table(16, risk)
+		require.NoError(t, err)
+		assert.Len(t, table, 17)
+	}
+
+	_, err := cfg.Plinko.Table(9, apiconfig.PlinkoLow)
+	assert.ErrorIs(t, err, apiconfig.ErrInvalidPlinko)
+}
@@ -0,0 +1,243 @@
+//go:generate go run go-outpost/cmd/events-schema -out ../../docs/asyncapi.json
+
+package events
+
+import (
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+const (
+	ChannelRoulette = "roulette"
+	ChannelBalance  = "balance-channel"
+	ChannelCoinflip = "coinflip"
+	ChannelPlinko   = "plinko"
+	ChannelJackpot  = "jackpot"
+
+	ChannelTournament = "tournaments"
+)
+
+// Event is a typed payload published to the hub. Channel, Name and Version
+// end up in the message envelope, the struct itself becomes its data.
+type Event interface {
+	Channel() string
+	Name() string
+	Version() int
+}
+
+// RouletteStarted opens a new roulette round for betting.
+type RouletteStarted struct {
+	UUID      string    `json:"uuid" validate:"required,uuid"`
+	Round     int64     `json:"round" validate:"required,min=1"`
+	CreatedAt time.Time `json:"created_at" validate:"required"`
+}
+
+func (RouletteStarted) Channel() string { return ChannelRoulette }
+func (RouletteStarted) Name() string    { return "start" }
+func (RouletteStarted) Version() int    { return 1 }
+
+// RouletteWinner announces the landed pocket of a round.
+type RouletteWinner struct {
+	Color  config.Color `json:"color" validate:"required,oneof=red green black"`
+	Number int          `json:"number" validate:"min=0"`
+}
+
+func (RouletteWinner) Channel() string { return ChannelRoulette }
+func (RouletteWinner) Name() string    { return "winner" }
+func (RouletteWinner) Version() int    { return 1 }
+
+// CoinflipLobbyCreated opens a coinflip lobby waiting for an opponent. Stake is a decimal string.
+// ServerSeedHash commits to the seed of the flip before anyone joins, ClientSeed is the one the
+// creator brings to the flip.
+type CoinflipLobbyCreated struct {
+	UUID           string          `json:"uuid" validate:"required,uuid"`
+	UserUUID       string          `json:"user_uuid" validate:"required"`
+	Side           config.CoinSide `json:"side" validate:"required,oneof=heads tails"`
+	Stake          money.Money     `json:"stake" validate:"required"`
+	ExpiresAt      time.Time       `json:"expires_at" validate:"required"`
+	ServerSeedHash string          `json:"server_seed_hash" validate:"required"`
+	ClientSeed     string          `json:"client_seed" validate:"required"`
+}
+
+func (CoinflipLobbyCreated) Channel() string { return ChannelCoinflip }
+func (CoinflipLobbyCreated) Name() string    { return "lobby-created" }
+func (CoinflipLobbyCreated) Version() int    { return 1 }
+
+// CoinflipLobbyJoined announces the opponent of a lobby and the client seed they bring to the
+// flip, the flip follows.
+type CoinflipLobbyJoined struct {
+	UUID       string `json:"uuid" validate:"required,uuid"`
+	UserUUID   string `json:"user_uuid" validate:"required"`
+	ClientSeed string `json:"client_seed" validate:"required"`
+}
+
+func (CoinflipLobbyJoined) Channel() string { return ChannelCoinflip }
+func (CoinflipLobbyJoined) Name() string    { return "lobby-joined" }
+func (CoinflipLobbyJoined) Version() int    { return 1 }
+
+// CoinflipLobbyResolved announces the flipped side and the winner of a lobby,
+// and reveals the server seed the flip was drawn from.
+type CoinflipLobbyResolved struct {
+	UUID       string          `json:"uuid" validate:"required,uuid"`
+	Result     config.CoinSide `json:"result" validate:"required,oneof=heads tails"`
+	WinnerUUID string          `json:"winner_uuid" validate:"required"`
+	Payout     money.Money     `json:"payout" validate:"required"`
+	ServerSeed string          `json:"server_seed" validate:"required"`
+}
+
+func (CoinflipLobbyResolved) Channel() string { return ChannelCoinflip }
+func (CoinflipLobbyResolved) Name() string    { return "lobby-resolved" }
+func (CoinflipLobbyResolved) Version() int    { return 1 }
+
+// CoinflipLobbyExpired closes a lobby nobody joined, its stake is refunded.
+type CoinflipLobbyExpired struct {
+	UUID string `json:"uuid" validate:"required,uuid"`
+}
+
+func (CoinflipLobbyExpired) Channel() string { return ChannelCoinflip }
+func (CoinflipLobbyExpired) Name() string    { return "lobby-expired" }
+func (CoinflipLobbyExpired) Version() int    { return 1 }
+
+// PlinkoDropped carries the path of a plinko ball so it can be animated.
+// Path has one bit per row, 1 for a right turn. Amounts are decimal strings
+// in Currency.
+type PlinkoDropped struct {
+	UUID       string            `json:"uuid" validate:"required,uuid"`
+	UserUUID   string            `json:"user_uuid" validate:"required"`
+	Rows       int               `json:"rows" validate:"required,min=1"`
+	Risk       config.PlinkoRisk `json:"risk" validate:"required,oneof=low medium high"`
+	Path       []int             `json:"path" validate:"required,dive,oneof=0 1"`
+	Slot       int               `json:"slot" validate:"min=0"`
+	Multiplier float64           `json:"multiplier" validate:"min=0"`
+	Currency   money.Currency    `json:"currency" validate:"required"`
+	Amount     money.Money       `json:"amount" validate:"required"`
+	Payout     money.Money       `json:"payout" validate:"required"`
+}
+
+func (PlinkoDropped) Channel() string { return ChannelPlinko }
+func (PlinkoDropped) Name() string    { return "drop" }
+func (PlinkoDropped) Version() int    { return 1 }
+
+// JackpotPotUpdated is the live state of a pot, sent when it opens, on every
+// deposit and when it closes. Amounts are decimal strings. ServerSeedHash
+// commits to the seed of the draw from the moment the pot opens.
+type JackpotPotUpdated struct {
+	UUID           string           `json:"uuid" validate:"required,uuid"`
+	Status         config.PotStatus `json:"status" validate:"required,oneof=open drawn refunded"`
+	Total          money.Money      `json:"total" validate:"required"`
+	EndsAt         time.Time        `json:"ends_at" validate:"required"`
+	Entries        []JackpotEntry   `json:"entries" validate:"dive"`
+	ServerSeedHash string           `json:"server_seed_hash" validate:"required"`
+}
+
+// JackpotEntry is the share of one player in a pot, Chance is a percentage.
+type JackpotEntry struct {
+	UserUUID string      `json:"user_uuid" validate:"required"`
+	Amount   money.Money `json:"amount" validate:"required"`
+	Chance   float64     `json:"chance" validate:"min=0,max=100"`
+}
+
+func (JackpotPotUpdated) Channel() string { return ChannelJackpot }
+func (JackpotPotUpdated) Name() string    { return "pot-updated" }
+func (JackpotPotUpdated) Version() int    { return 1 }
+
+// JackpotDrawn announces the winning ticket of a pot and who held it, and
+// reveals the server seed the ticket was drawn from.
+type JackpotDrawn struct {
+	UUID          string      `json:"uuid" validate:"required,uuid"`
+	WinningTicket int         `json:"winning_ticket" validate:"min=0"`
+	WinnerUUID    string      `json:"winner_uuid" validate:"required"`
+	Payout        money.Money `json:"payout" validate:"required"`
+	ServerSeed    string      `json:"server_seed" validate:"required"`
+}
+
+func (JackpotDrawn) Channel() string { return ChannelJackpot }
+func (JackpotDrawn) Name() string    { return "drawn" }
+func (JackpotDrawn) Version() int    { return 1 }
+
+// TournamentLeaderboard is the live ranking of a tournament, pushed while it
+// runs and once more when it is over.
+type TournamentLeaderboard struct {
+	UUID      string                  `json:"uuid" validate:"required,uuid"`
+	Title     string                  `json:"title" validate:"required"`
+	Metric    config.TournamentMetric `json:"metric" validate:"required,oneof=wagered multiplier"`
+	Status    config.TournamentStatus `json:"status" validate:"required,oneof=scheduled running finished"`
+	EndsAt    time.Time               `json:"ends_at" validate:"required"`
+	Players   int                     `json:"players" validate:"min=0"`
+	Standings []TournamentStanding    `json:"standings" validate:"dive"`
+}
+
+// TournamentStanding is the place of one player. Score is a decimal string,
+// an amount for wagered tournaments and a multiplier for the others.
+type TournamentStanding struct {
+	Rank     int         `json:"rank" validate:"min=1"`
+	UserUUID string      `json:"user_uuid" validate:"required"`
+	Score    string      `json:"score" validate:"required"`
+	Prize    money.Money `json:"prize" validate:"required"`
+}
+
+func (TournamentLeaderboard) Channel() string { return ChannelTournament }
+func (TournamentLeaderboard) Name() string    { return "leaderboard" }
+func (TournamentLeaderboard) Version() int    { return 1 }
+
+// TournamentFinished announces the players paid a prize.
+type TournamentFinished struct {
+	UUID    string               `json:"uuid" validate:"required,uuid"`
+	Winners []TournamentStanding `json:"winners" validate:"dive"`
+}
+
+func (TournamentFinished) Channel() string { return ChannelTournament }
+func (TournamentFinished) Name() string    { return "finished" }
+func (TournamentFinished) Version() int    { return 1 }
+
+// BalanceChanged reports a movement of one wallet of a user. Amounts are
+// decimal strings in the wallet currency.
+type BalanceChanged struct {
+	UserUUID      string             `json:"user_uuid" validate:"required"`
+	Currency      money.Currency     `json:"currency" validate:"required"`
+	Amount        money.Money        `json:"amount" validate:"required"`
+	OperationType config.BalanceType `json:"operation_type" validate:"required,oneof=income outcome"`
+	Module        config.Game        `json:"module" validate:"required"`
+	Balance       money.Money        `json:"balance" validate:"required"`
+}
+
+func (BalanceChanged) Channel() string { return ChannelBalance }
+func (b BalanceChanged) Name() string {
+	if b.OperationType == config.Outcome {
+		return "outcome-event"
+	}
+
+	return "income-event"
+}
+func (BalanceChanged) Version() int { return 1 }
+
+// DepositUpdated reports a deposit the provider settled, paid or abandoned.
+// Amount is what reached the wallet, a decimal string in Currency.
+type DepositUpdated struct {
+	UUID     string               `json:"uuid" validate:"required,uuid"`
+	UserUUID string               `json:"user_uuid" validate:"required"`
+	Status   config.DepositStatus `json:"status" validate:"required,oneof=completed failed"`
+	Currency money.Currency       `json:"currency" validate:"required"`
+	Amount   money.Money          `json:"amount" validate:"required"`
+}
+
+func (DepositUpdated) Channel() string { return ChannelBalance }
+func (DepositUpdated) Name() string    { return "deposit-event" }
+func (DepositUpdated) Version() int    { return 1 }
+
+// WithdrawalUpdated reports every status a withdrawal goes through. Amount is
+// a decimal string in Currency, Reference the provider transfer id once sent.
+type WithdrawalUpdated struct {
+	UUID          string                  `json:"uuid" validate:"required,uuid"`
+	UserUUID      string                  `json:"user_uuid" validate:"required"`
+	Status        config.WithdrawalStatus `json:"status" validate:"required,oneof=requested approved sending sent failed"`
+	Currency      money.Currency          `json:"currency" validate:"required"`
+	Amount        money.Money             `json:"amount" validate:"required"`
+	Reference     string                  `json:"reference,omitempty"`
+	FailureReason string                  `json:"failure_reason,omitempty"`
+}
+
+func (WithdrawalUpdated) Channel() string { return ChannelBalance }
+func (WithdrawalUpdated) Name() string    { return "withdrawal-event" }
+func (WithdrawalUpdated) Version() int    { return 1 }
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"go-outpost/internal/api/config"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Patterned is implemented by events published on per-entity channels such as
+// private-user.<uuid>. The pattern ends with "*" and is matched as a prefix.
+type Patterned interface {
+	ChannelPattern() string
+}
+
+type Definition struct {
+	Channel string
+	Event   string
+	Version int
+	Type    reflect.Type
+}
+
+type Registry struct {
+	definitions map[string]Definition
+	validator   *validator.Validate
+}
+
+// Default holds every event the backend publishes.
+var Default = NewRegistry(
+	RouletteStarted{},
+	RouletteWinner{},
+	BalanceChanged{OperationType: config.Income},
+	BalanceChanged{OperationType: config.Outcome},
+	CoinflipLobbyCreated{},
+	CoinflipLobbyJoined{},
+	CoinflipLobbyResolved{},
+	CoinflipLobbyExpired{},
+	PlinkoDropped{},
+	JackpotPotUpdated{},
+	JackpotDrawn{},
+	TournamentLeaderboard{},
+	TournamentFinished{},
+	DepositUpdated{},
+	WithdrawalUpdated{},
+)
+
+func NewRegistry(events ...Event) *Registry {
+	r := &Registry{
+		definitions: make(map[string]Definition),
+		validator:   validator.New(),
+	}
+
+	for _, e := range events {
+		r.Register(e)
+	}
+
+	return r
+}
+
+func (r *Registry) Register(e Event) {
+	channel := e.Channel()
+	if p, ok := e.(Patterned); ok {
+		channel = p.ChannelPattern()
+	}
+
+	d := Definition{
+		Channel: channel,
+		Event:   e.Name(),
+		Version: e.Version(),
+		Type:    reflect.TypeOf(e),
+	}
+
+	r.definitions[definitionKey(d.Channel, d.Event, d.Version)] = d
+}
+
+func (r *Registry) Lookup(channel string, event string, version int) (Definition, error) {
+	const op = "events.Registry.Lookup"
+
+	if d, ok := r.definitions[definitionKey(channel, event, version)]; ok {
+		return d, nil
+	}
+
+	for _, d := range r.definitions {
+		if d.Event == event && d.Version == version && matchChannel(d.Channel, channel) {
+			return d, nil
+		}
+	}
+
+	return Definition{}, fmt.Errorf("%s: %w: %s/%s v%d", op, ErrUnknownEvent, channel, event, version)
+}
+
+// Validate checks an untyped payload, as received by the hub, against the
+// registered schema: unknown fields are rejected and validate tags enforced.
+func (r *Registry) Validate(channel string, event string, version int, data interface{}) error {
+	const op = "events.Registry.Validate"
+
+	var (
+		err     error
+		d       Definition
+		raw     []byte
+		payload reflect.Value
+	)
+
+	d, err = r.Lookup(channel, event, version)
+	if err != nil {
+		return err
+	}
+
+	raw, err = json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload = reflect.New(d.Type)
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+
+	if err = dec.Decode(payload.Interface()); err != nil {
+		return fmt.Errorf("%s: %s/%s: %w", op, channel, event, err)
+	}
+
+	if err = r.validator.Struct(payload.Interface()); err != nil {
+		return fmt.Errorf("%s: %s/%s: %w", op, channel, event, err)
+	}
+
+	return nil
+}
+
+// ValidateEvent checks a typed event before it is published.
+func (r *Registry) ValidateEvent(e Event) error {
+	const op = "events.Registry.ValidateEvent"
+
+	if _, err := r.Lookup(e.Channel(), e.Name(), e.Version()); err != nil {
+		return err
+	}
+
+	if err := r.validator.Struct(e); err != nil {
+		return fmt.Errorf("%s: %s/%s: %w", op, e.Channel(), e.Name(), err)
+	}
+
+	return nil
+}
+
+// Definitions returns the registered events ordered by channel, event and version.
+func (r *Registry) Definitions() []Definition {
+	definitions := make([]Definition, 0, len(r.definitions))
+	for _, d := range r.definitions {
+		definitions = append(definitions, d)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitionKey(definitions[i].Channel, definitions[i].Event, definitions[i].Version) <
+			definitionKey(definitions[j].Channel, definitions[j].Event, definitions[j].Version)
+	})
+
+	return definitions
+}
+
+func definitionKey(channel string, event string, version int) string {
+	return fmt.Sprintf("%s/%s/v%03d", channel, event, version)
+}
+
+func matchChannel(pattern string, channel string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(channel, strings.TrimSuffix(pattern, "*"))
+	}
+
+	return pattern == channel
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestRegistryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		event   string
+		version int
+		data    interface{}
+		wantErr bool
+	}{
+		{
+			name:    "typed payload",
+			channel: ChannelRoulette,
+			event:   "winner",
+			version: 1,
+			data:    RouletteWinner{Color: "red", Number: 3},
+		},
+		{
+			name:    "untyped payload",
+			channel: ChannelRoulette,
+			event:   "winner",
+			version: 1,
+			data:    map[string]interface{}{"color": "green", "number": 0},
+		},
+		{
+			name:    "unknown field",
+			channel: ChannelRoulette,
+			event:   "winner",
+			version: 1,
+			data:    map[string]interface{}{"color": "green", "number": 0, "extra": true},
+			wantErr: true,
+		},
+		{
+			name:    "invalid enum",
+			channel: ChannelRoulette,
+			event:   "winner",
+			version: 1,
+			data:    map[string]interface{}{"color": "blue", "number": 0},
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			channel: ChannelRoulette,
+			event:   "winner",
+			version: 2,
+			data:    RouletteWinner{Color: "red", Number: 3},
+			wantErr: true,
+		},
+		{
+			name:    "unknown event",
+			channel: ChannelRoulette,
+			event:   "loser",
+			version: 1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Default.Validate(tt.channel, tt.event, tt.version, tt.data)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAsyncAPIDocumentIsUpToDate(t *testing.T) {
+	want, err := Default.AsyncAPI("go-outpost realtime events", "1.0.0")
+	require.NoError(t, err)
+
+	got, err := os.ReadFile("../../docs/asyncapi.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(want), string(got), "run go generate ./internal/events")
+}
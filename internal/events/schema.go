@@ -0,0 +1,189 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-outpost/internal/lib/money"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const asyncAPIVersion = "2.6.0"
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	moneyType = reflect.TypeOf(money.Money{})
+)
+
+// AsyncAPI renders the registry as an AsyncAPI document. Payload schemas are
+// plain JSON Schema under components.schemas, so the frontend can generate
+// types from either view.
+func (r *Registry) AsyncAPI(title string, version string) ([]byte, error) {
+	const op = "events.Registry.AsyncAPI"
+
+	channels := make(map[string]interface{})
+	messages := make(map[string]interface{})
+	schemas := make(map[string]interface{})
+	refs := make(map[string][]interface{})
+
+	for _, d := range r.Definitions() {
+		name := d.Type.Name()
+		messageKey := strings.NewReplacer("*", "", ".", "-").Replace(d.Channel) + "." + d.Event + ".v" + strconv.Itoa(d.Version)
+		channel := strings.Replace(d.Channel, "*", "{id}", 1)
+
+		schemas[name] = JSONSchema(d.Type)
+		messages[messageKey] = map[string]interface{}{
+			"name":  d.Event,
+			"title": name,
+			"payload": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"channel", "event", "version", "data"},
+				"properties": map[string]interface{}{
+					"id":      map[string]interface{}{"type": "integer", "description": "hub sequence number, used for replay"},
+					"channel": map[string]interface{}{"type": "string"},
+					"event":   map[string]interface{}{"const": d.Event},
+					"version": map[string]interface{}{"const": d.Version},
+					"data":    map[string]interface{}{"$ref": "#/components/schemas/" + name},
+				},
+			},
+		}
+		refs[channel] = append(refs[channel], map[string]interface{}{"$ref": "#/components/messages/" + messageKey})
+	}
+
+	for channel, messageRefs := range refs {
+		item := map[string]interface{}{
+			"subscribe": map[string]interface{}{
+				"message": map[string]interface{}{"oneOf": messageRefs},
+			},
+		}
+
+		if strings.Contains(channel, "{id}") {
+			item["parameters"] = map[string]interface{}{
+				"id": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			}
+		}
+
+		channels[channel] = item
+	}
+
+	doc := map[string]interface{}{
+		"asyncapi": asyncAPIVersion,
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"defaultContentType": "application/json",
+		"channels":           channels,
+		"components": map[string]interface{}{
+			"messages": messages,
+			"schemas":  schemas,
+		},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// JSONSchema describes a Go type the way encoding/json serializes it.
+// Required fields and enums come from the validate tags.
+func JSONSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	if t == moneyType {
+		return map[string]interface{}{"type": "string", "format": "decimal", "pattern": `^-?[0-9]+(\.[0-9]+)?$`}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": JSONSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": JSONSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := JSONSchema(field.Type)
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			key, value, _ := strings.Cut(rule, "=")
+
+			switch key {
+			case "required":
+				required = append(required, name)
+			case "oneof":
+				schema["enum"] = strings.Fields(value)
+			case "uuid":
+				schema["format"] = "uuid"
+			case "min":
+				if n, err := strconv.ParseFloat(value, 64); err == nil && isNumeric(schema) {
+					schema["minimum"] = n
+				}
+			case "max":
+				if n, err := strconv.ParseFloat(value, 64); err == nil && isNumeric(schema) {
+					schema["maximum"] = n
+				}
+			}
+		}
+
+		properties[name] = schema
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func isNumeric(schema map[string]interface{}) bool {
+	return schema["type"] == "integer" || schema["type"] == "number"
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"math"
+)
+
+// DiceChoice takes the target as a percentage with two decimals, 0.00-99.99.
+type DiceChoice struct {
+	Target    float64              `json:"target"`
+	Direction config.DiceDirection `json:"direction"`
+}
+
+// DiceRoll is the result of a roll, Target and Roll are percentages.
+type DiceRoll struct {
+	Target     float64              `json:"target"`
+	Direction  config.DiceDirection `json:"direction"`
+	Multiplier float64              `json:"multiplier"`
+	Roll       float64              `json:"roll"`
+	Win        bool                 `json:"win"`
+}
+
+type Dice struct {
+	rules config.DiceRules
+}
+
+func NewDice(rules config.DiceRules) *Dice {
+	return &Dice{rules: rules}
+}
+
+func (d *Dice) Game() config.Game {
+	return config.Dice
+}
+
+func (d *Dice) Kind() Kind {
+	return Instant
+}
+
+func (d *Dice) Place(amount int, params json.RawMessage) (Wager, error) {
+	var choice DiceChoice
+
+	if err := decode(params, &choice); err != nil {
+		return Wager{}, err
+	}
+
+	target := hundredths(choice.Target)
+
+	if err := d.rules.ValidateBet(target, choice.Direction); err != nil {
+		return Wager{}, fmt.Errorf("%w: %v", ErrInvalidBet, err)
+	}
+
+	choice.Target = float64(target) / 100
+
+	return Wager{
+		Amount:    amount,
+		BetType:   config.BetType(choice.Direction),
+		MaxPayout: d.rules.Payout(amount, target, choice.Direction),
+		Choice:    choice,
+	}, nil
+}
+
+func (d *Dice) Resolve(wager Wager, rng RNG) (Result, error) {
+	choice := wager.Choice.(DiceChoice)
+	target := hundredths(choice.Target)
+	roll := rng.Index(config.DiceSides)
+
+	return Result{
+		Value: DiceRoll{
+			Target:     choice.Target,
+			Direction:  choice.Direction,
+			Multiplier: d.rules.Multiplier(target, choice.Direction),
+			Roll:       float64(roll) / 100,
+			Win:        d.rules.Wins(roll, target, choice.Direction),
+		},
+		Draw: float64(roll) / 100,
+		Min:  0,
+		Max:  config.DiceSides - 1,
+	}, nil
+}
+
+func (d *Dice) Settle(wager Wager, result Result) int {
+	if !result.Value.(DiceRoll).Win {
+		return 0
+	}
+
+	return wager.MaxPayout
+}
+
+func hundredths(value float64) int {
+	return int(math.Round(value * 100))
+}
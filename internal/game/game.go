@@ -0,0 +1,92 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+)
+
+// Kind tells how a game is resolved. Instant games draw from the seed pair of
+// the player on every bet, round games draw once for every bet of a round.
+// Stateful games draw from the seed pair when they start and are then played
+// in moves until they bust or cash out.
+type Kind string
+
+const (
+	Instant  Kind = "instant"
+	Round    Kind = "round"
+	Stateful Kind = "stateful"
+)
+
+var (
+	ErrUnknownGame = errors.New("game is not registered")
+	ErrInvalidBet  = errors.New("invalid bet")
+)
+
+// Wager is a validated bet. Choice holds the decoded choices of the game,
+// BetType selects the stake limit and MaxPayout is the most the wager can
+// win, checked against the payout limit before the stake is taken.
+type Wager struct {
+	Amount    int
+	BetType   config.BetType
+	MaxPayout int
+	Choice    interface{}
+}
+
+// Result is a resolved draw. Value is what the player is shown, Draw, Min and
+// Max are recorded with the fairness data.
+type Result struct {
+	Value interface{}
+	Draw  float64
+	Min   int
+	Max   int
+}
+
+// Engine holds the rules of one game and nothing else: balances, storage and
+// fairness records are handled by the Player for instant and stateful games
+// and by the round driver for round games.
+type Engine interface {
+	Game() config.Game
+	Kind() Kind
+	// Place validates a bet of amount cents with the game choices in params.
+	Place(amount int, params json.RawMessage) (Wager, error)
+	// Resolve draws a result. Round games ignore the wager, they resolve once
+	// per round.
+	Resolve(wager Wager, rng RNG) (Result, error)
+	// Settle returns what the wager pays on the result, 0 when it loses.
+	// Stateful games settle on the state of the game the moves left, passed
+	// as the result value.
+	Settle(wager Wager, result Result) int
+}
+
+// Verify replays a draw from revealed seeds. Instant and stateful games need
+// the choices of the bet since they can shape the draw, round games do not.
+func Verify(engine Engine, params json.RawMessage, rng RNG) (Result, error) {
+	var (
+		err   error
+		wager Wager
+	)
+
+	if engine.Kind() != Round {
+		wager, err = engine.Place(0, params)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	return engine.Resolve(wager, rng)
+}
+
+// decode reads the choices of a bet, unknown fields are left to the caller.
+func decode(params json.RawMessage, choice interface{}) error {
+	if len(params) == 0 {
+		return ErrInvalidBet
+	}
+
+	if err := json.Unmarshal(params, choice); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBet, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/handlers/provably_fair"
+	"testing"
+)
+
+var (
+	testRNG     = RNG{ServerSeed: "server", ClientSeed: "client", Nonce: 7}
+	plinkoRules = config.PlinkoRules{MinRows: 2, MaxRows: 2,
+		Payouts: map[config.PlinkoRisk]map[int][]float64{config.PlinkoLow: {2: {3, 0.5, 3}}}}
+)
+
+func TestPlace(t *testing.T) {
+	wheel := config.Wheel{
+		Pockets: []config.Pocket{{Number: 0, Color: config.Green}, {Number: 1, Color: config.Red}},
+		Payouts: config.Payouts{Color: map[config.Color]int{config.Red: 2, config.Green: 14}, Number: 2},
+	}
+
+	tests := []struct {
+		name      string
+		engine    Engine
+		params    string
+		betType   config.BetType
+		maxPayout int
+		wantErr   bool
+	}{
+		{name: "dice", engine: NewDice(config.DiceRules{HouseEdge: 1, MinTarget: 100, MaxTarget: 9800}),
+			params: `{"target": 50, "direction": "over"}`, betType: "over", maxPayout: 198},
+		{name: "dice target out of range", engine: NewDice(config.DiceRules{HouseEdge: 1, MinTarget: 100, MaxTarget: 9800}),
+			params: `{"target": 99, "direction": "over"}`, wantErr: true},
+		{name: "limbo", engine: NewLimbo(config.LimboRules{HouseEdge: 1, MinTarget: 1.01, MaxTarget: 1000}),
+			params: `{"target": 2.5}`, betType: "limbo", maxPayout: 250},
+		{name: "limbo target out of range", engine: NewLimbo(config.LimboRules{HouseEdge: 1, MinTarget: 1.01, MaxTarget: 1000}),
+			params: `{"target": 1}`, wantErr: true},
+		{name: "roulette pays the best pocket", engine: NewRoulette(wheel),
+			params: `{"type": "color", "value": "green"}`, betType: config.BetColor, maxPayout: 1400},
+		{name: "roulette bet not on the wheel", engine: NewRoulette(wheel),
+			params: `{"type": "color", "value": "black"}`, wantErr: true},
+		{name: "no params", engine: NewRoulette(wheel), wantErr: true},
+		{name: "plinko pays the best slot", engine: NewPlinko(plinkoRules),
+			params: `{"rows": 2, "risk": "low"}`, betType: "low", maxPayout: 300},
+		{name: "plinko table not configured", engine: NewPlinko(plinkoRules),
+			params: `{"rows": 3, "risk": "low"}`, wantErr: true},
+		{name: "mines", engine: NewMines(config.MinesRules{HouseEdge: 1, MinMines: 1, MaxMines: 24}),
+			params: `{"mines": 3}`, betType: "mines"},
+		{name: "mines out of range", engine: NewMines(config.MinesRules{HouseEdge: 1, MinMines: 1, MaxMines: 24}),
+			params: `{"mines": 25}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wager, err := tt.engine.Place(100, json.RawMessage(tt.params))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidBet)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.betType, wager.BetType)
+			assert.Equal(t, tt.maxPayout, wager.MaxPayout)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dice := NewDice(config.DiceRules{HouseEdge: 1, MinTarget: 100, MaxTarget: 9800})
+
+	result, err := Verify(dice, json.RawMessage(`{"target": 50, "direction": "under"}`), testRNG)
+	require.NoError(t, err)
+
+	roll := provably_fair.IndexFromHash(provably_fair.Hash("server", "client", 7), config.DiceSides)
+	assert.Equal(t, float64(roll)/100, result.Value.(DiceRoll).Roll)
+	assert.Equal(t, roll < 5000, result.Value.(DiceRoll).Win)
+
+	again, err := Verify(dice, json.RawMessage(`{"target": 50, "direction": "under"}`), testRNG)
+	require.NoError(t, err)
+	assert.Equal(t, result, again)
+}
+
+func TestSettle(t *testing.T) {
+	limbo := NewLimbo(config.LimboRules{HouseEdge: 1, MinTarget: 1.01, MaxTarget: 1000})
+
+	wager, err := limbo.Place(100, json.RawMessage(`{"target": 2}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, limbo.Settle(wager, Result{Value: LimboDraw{Target: 2, Drawn: 2, Win: true}}))
+	assert.Equal(t, 0, limbo.Settle(wager, Result{Value: LimboDraw{Target: 2, Drawn: 1.99}}))
+}
+
+func TestPlinkoResolve(t *testing.T) {
+	plinko := NewPlinko(plinkoRules)
+
+	wager, err := plinko.Place(100, json.RawMessage(`{"rows": 2, "risk": "low"}`))
+	require.NoError(t, err)
+
+	result, err := plinko.Resolve(wager, testRNG)
+	require.NoError(t, err)
+
+	drop := result.Value.(PlinkoDrop)
+	assert.Equal(t, provably_fair.Bits(testRNG.Hash(), 2), drop.Path)
+	assert.Equal(t, drop.Path[0]+drop.Path[1], drop.Slot)
+	assert.Equal(t, float64(drop.Slot), result.Draw)
+	assert.Equal(t, int(100*plinkoRules.Payouts[config.PlinkoLow][2][drop.Slot]), plinko.Settle(wager, result))
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(NewLimbo(config.LimboRules{}), NewDice(config.DiceRules{}))
+
+	engine, err := registry.Get(config.Dice)
+	require.NoError(t, err)
+	assert.Equal(t, config.Dice, engine.Game())
+
+	_, err = registry.Get(config.Crash)
+	assert.ErrorIs(t, err, ErrUnknownGame)
+
+	engines := registry.Engines()
+	require.Len(t, engines, 2)
+	assert.Equal(t, config.Dice, engines[0].Game())
+}
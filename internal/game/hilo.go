@@ -0,0 +1,139 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+var (
+	ErrHiloOver = errors.New("hilo game is over")
+	ErrNoGuess  = errors.New("guess a card before cashing out")
+)
+
+// HiloDeck is the draw of a hilo game, every card it can turn: the first
+// card and one per guess.
+type HiloDeck struct {
+	Cards []int `json:"cards"`
+}
+
+// Hilo is played in guesses on a deck drawn when the game starts. It takes
+// no choices and is settled on the *model.HiloGame the guesses left.
+type Hilo struct {
+	rules config.HiloRules
+}
+
+func NewHilo(rules config.HiloRules) *Hilo {
+	return &Hilo{rules: rules}
+}
+
+func (h *Hilo) Game() config.Game {
+	return config.Hilo
+}
+
+func (h *Hilo) Kind() Kind {
+	return Stateful
+}
+
+// Place leaves MaxPayout at 0, the payout is capped when the game ends.
+func (h *Hilo) Place(amount int, _ json.RawMessage) (Wager, error) {
+	return Wager{Amount: amount, BetType: config.BetType(config.Hilo)}, nil
+}
+
+func (h *Hilo) Resolve(_ Wager, rng RNG) (Result, error) {
+	floats := rng.Floats(h.rules.MaxSteps + 1)
+
+	deck := make([]int, 0, len(floats))
+	for _, f := range floats {
+		deck = append(deck, int(f*config.HiloCards))
+	}
+
+	return Result{
+		Value: HiloDeck{Cards: deck},
+		Draw:  float64(deck[0]),
+		Min:   0,
+		Max:   config.HiloCards - 1,
+	}, nil
+}
+
+func (h *Hilo) Settle(wager Wager, result Result) int {
+	game := result.Value.(*model.HiloGame)
+
+	if game.Status == config.HiloBusted {
+		return 0
+	}
+
+	return int(float64(wager.Amount) * game.Multiplier)
+}
+
+// Guess turns the next card of an active game. A right guess compounds the
+// multiplier, a wrong one busts the game, and the game cashes out on its own
+// once every card of the deck is turned.
+func (h *Hilo) Guess(game *model.HiloGame, guess config.HiloGuess, now time.Time) error {
+	if game.Status != config.HiloActive {
+		return ErrHiloOver
+	}
+
+	cards := game.Cards()
+	rank := config.HiloRank(cards[len(cards)-1])
+
+	step, err := h.rules.StepMultiplier(rank, guess)
+	if err != nil {
+		return err
+	}
+
+	game.Guesses = append(game.Guesses, guess)
+
+	if !h.rules.Wins(rank, config.HiloRank(game.Deck[len(game.Guesses)]), guess) {
+		game.Status = config.HiloBusted
+		game.Multiplier = 0
+		game.FinishedAt = &now
+
+		return nil
+	}
+
+	game.Multiplier *= step
+
+	if len(game.Guesses) == len(game.Deck)-1 {
+		return h.CashOut(game, now)
+	}
+
+	return nil
+}
+
+// CashOut ends an active game and pays the stake times the multiplier. A
+// game without a guess cannot be cashed out, it would hand the stake back
+// while counting it as wagered.
+func (h *Hilo) CashOut(game *model.HiloGame, now time.Time) error {
+	if game.Status != config.HiloActive {
+		return ErrHiloOver
+	}
+
+	if len(game.Guesses) == 0 {
+		return ErrNoGuess
+	}
+
+	game.Status = config.HiloCashedOut
+	game.Payout = h.Settle(Wager{Amount: game.Amount}, Result{Value: game})
+	game.FinishedAt = &now
+
+	return nil
+}
+
+// Odds returns the step multipliers of the next guess of an active game, a
+// guess that cannot lose is left out.
+func (h *Hilo) Odds(game *model.HiloGame) map[config.HiloGuess]float64 {
+	cards := game.Cards()
+	rank := config.HiloRank(cards[len(cards)-1])
+
+	odds := make(map[config.HiloGuess]float64, 2)
+	for _, g := range []config.HiloGuess{config.HiloHigher, config.HiloLower} {
+		if step, err := h.rules.StepMultiplier(rank, g); err == nil {
+			odds[g] = step
+		}
+	}
+
+	return odds
+}
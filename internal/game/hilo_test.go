@@ -0,0 +1,111 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"testing"
+	"time"
+)
+
+func TestHiloGuess(t *testing.T) {
+	hilo := NewHilo(config.HiloRules{HouseEdge: 1, MaxSteps: 2})
+	now := time.Now()
+
+	// Cards 0, 24, 44 and 51 are an ace, a seven, a queen and a king.
+	tests := []struct {
+		name       string
+		deck       []int
+		guesses    []config.HiloGuess
+		status     config.HiloStatus
+		multiplier float64
+		payout     int
+		wantErr    error
+	}{
+		{
+			name:       "right guess compounds",
+			deck:       []int{24, 51, 0},
+			guesses:    []config.HiloGuess{config.HiloHigher},
+			status:     config.HiloActive,
+			multiplier: 0.99 * 13 / 7,
+		},
+		{
+			name:    "wrong guess busts",
+			deck:    []int{24, 0, 51},
+			guesses: []config.HiloGuess{config.HiloHigher},
+			status:  config.HiloBusted,
+		},
+		{
+			name:    "guess that cannot lose",
+			deck:    []int{0, 24, 51},
+			guesses: []config.HiloGuess{config.HiloHigher},
+			status:  config.HiloActive,
+			wantErr: config.ErrInvalidGuess,
+		},
+		{
+			name:       "last card cashes out",
+			deck:       []int{24, 44, 0},
+			guesses:    []config.HiloGuess{config.HiloHigher, config.HiloLower},
+			status:     config.HiloCashedOut,
+			multiplier: 0.99 * 13 / 7 * 0.99 * 13 / 12,
+			payout:     197,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := &model.HiloGame{Amount: 100, Deck: tt.deck, Multiplier: 1, Status: config.HiloActive}
+
+			var err error
+			for _, g := range tt.guesses {
+				if err = hilo.Guess(game, g, now); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, game.Status)
+			assert.InDelta(t, tt.multiplier, game.Multiplier, 1e-9)
+			assert.Equal(t, tt.payout, game.Payout)
+		})
+	}
+
+	busted := &model.HiloGame{Deck: []int{24, 0}, Guesses: []config.HiloGuess{config.HiloHigher}, Status: config.HiloBusted}
+	assert.ErrorIs(t, hilo.Guess(busted, config.HiloLower, now), ErrHiloOver)
+}
+
+func TestHiloCashOut(t *testing.T) {
+	hilo := NewHilo(config.HiloRules{HouseEdge: 1, MaxSteps: 2})
+	now := time.Now()
+
+	untouched := &model.HiloGame{Amount: 100, Deck: []int{10, 20}, Multiplier: 1, Status: config.HiloActive}
+	assert.ErrorIs(t, hilo.CashOut(untouched, now), ErrNoGuess)
+	assert.Equal(t, config.HiloActive, untouched.Status)
+
+	game := &model.HiloGame{Amount: 100, Deck: []int{10, 20, 30}, Guesses: []config.HiloGuess{config.HiloHigher},
+		Multiplier: 1.5, Status: config.HiloActive}
+	require.NoError(t, hilo.CashOut(game, now))
+	assert.Equal(t, config.HiloCashedOut, game.Status)
+	assert.Equal(t, 150, game.Payout)
+}
+
+func TestHiloDeal(t *testing.T) {
+	hilo := NewHilo(config.HiloRules{HouseEdge: 1, MaxSteps: 50})
+
+	result, err := hilo.Resolve(Wager{}, testRNG)
+	require.NoError(t, err)
+
+	deck := result.Value.(HiloDeck).Cards
+	require.Len(t, deck, 51)
+	assert.Equal(t, float64(deck[0]), result.Draw)
+
+	for _, card := range deck {
+		assert.True(t, card >= 0 && card < config.HiloCards)
+	}
+}
@@ -0,0 +1,82 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"math"
+)
+
+// LimboChoice takes the target multiplier with two decimals, e.g. 2.5.
+type LimboChoice struct {
+	Target float64 `json:"target"`
+}
+
+type LimboDraw struct {
+	Target    float64 `json:"target"`
+	WinChance float64 `json:"win_chance"`
+	Drawn     float64 `json:"drawn"`
+	Win       bool    `json:"win"`
+}
+
+type Limbo struct {
+	rules config.LimboRules
+}
+
+func NewLimbo(rules config.LimboRules) *Limbo {
+	return &Limbo{rules: rules}
+}
+
+func (l *Limbo) Game() config.Game {
+	return config.Limbo
+}
+
+func (l *Limbo) Kind() Kind {
+	return Instant
+}
+
+func (l *Limbo) Place(amount int, params json.RawMessage) (Wager, error) {
+	var choice LimboChoice
+
+	if err := decode(params, &choice); err != nil {
+		return Wager{}, err
+	}
+
+	choice.Target = math.Round(choice.Target*100) / 100
+
+	if err := l.rules.ValidateTarget(choice.Target); err != nil {
+		return Wager{}, fmt.Errorf("%w: %v", ErrInvalidBet, err)
+	}
+
+	return Wager{
+		Amount:    amount,
+		BetType:   config.BetType(config.Limbo),
+		MaxPayout: int(float64(amount) * choice.Target),
+		Choice:    choice,
+	}, nil
+}
+
+func (l *Limbo) Resolve(wager Wager, rng RNG) (Result, error) {
+	choice := wager.Choice.(LimboChoice)
+	drawn := l.rules.Multiplier(rng.Floats(1)[0])
+
+	return Result{
+		Value: LimboDraw{
+			Target:    choice.Target,
+			WinChance: l.rules.WinChance(choice.Target),
+			Drawn:     drawn,
+			Win:       drawn >= choice.Target,
+		},
+		Draw: drawn,
+		Min:  1,
+		Max:  int(l.rules.MaxTarget),
+	}, nil
+}
+
+func (l *Limbo) Settle(wager Wager, result Result) int {
+	if !result.Value.(LimboDraw).Win {
+		return 0
+	}
+
+	return wager.MaxPayout
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"time"
+)
+
+var (
+	ErrInvalidTile = errors.New("tile is out of the board or already revealed")
+	ErrMinesOver   = errors.New("mines game is over")
+	ErrNoReveal    = errors.New("reveal a tile before cashing out")
+)
+
+type MinesChoice struct {
+	Mines int `json:"mines"`
+}
+
+// MinesBoard is the draw of a mines game, the tiles that hide a mine.
+type MinesBoard struct {
+	Mines []int `json:"mines"`
+}
+
+// Mines is played in reveals on a board drawn when the game starts. It is
+// settled on the *model.MinesGame the reveals left.
+type Mines struct {
+	rules config.MinesRules
+}
+
+func NewMines(rules config.MinesRules) *Mines {
+	return &Mines{rules: rules}
+}
+
+func (m *Mines) Game() config.Game {
+	return config.Mines
+}
+
+func (m *Mines) Kind() Kind {
+	return Stateful
+}
+
+// Place leaves MaxPayout at 0, the payout is capped when the game ends.
+func (m *Mines) Place(amount int, params json.RawMessage) (Wager, error) {
+	var choice MinesChoice
+
+	if err := decode(params, &choice); err != nil {
+		return Wager{}, err
+	}
+
+	if err := m.rules.ValidateMines(choice.Mines); err != nil {
+		return Wager{}, fmt.Errorf("%w: %v", ErrInvalidBet, err)
+	}
+
+	return Wager{Amount: amount, BetType: config.BetType(config.Mines), Choice: choice}, nil
+}
+
+func (m *Mines) Resolve(wager Wager, rng RNG) (Result, error) {
+	choice := wager.Choice.(MinesChoice)
+
+	return Result{
+		Value: MinesBoard{Mines: rng.Shuffle(config.MinesTiles)[:choice.Mines]},
+		Draw:  float64(choice.Mines),
+		Min:   0,
+		Max:   config.MinesTiles - 1,
+	}, nil
+}
+
+func (m *Mines) Settle(wager Wager, result Result) int {
+	game := result.Value.(*model.MinesGame)
+
+	if game.Status == config.MinesBusted {
+		return 0
+	}
+
+	return m.rules.Payout(wager.Amount, game.Mines, len(game.Revealed))
+}
+
+// Reveal opens a tile of an active game. The game is busted on a mine and
+// cashed out on its own once every safe tile is open.
+func (m *Mines) Reveal(game *model.MinesGame, tile int, now time.Time) error {
+	if game.Status != config.MinesActive {
+		return ErrMinesOver
+	}
+
+	if tile < 0 || tile >= config.MinesTiles || contains(game.Revealed, tile) {
+		return fmt.Errorf("%w: %d", ErrInvalidTile, tile)
+	}
+
+	game.Revealed = append(game.Revealed, tile)
+
+	if contains(game.Board, tile) {
+		game.Status = config.MinesBusted
+		game.FinishedAt = &now
+
+		return nil
+	}
+
+	if len(game.Revealed) == config.MinesTiles-game.Mines {
+		return m.CashOut(game, now)
+	}
+
+	return nil
+}
+
+// CashOut ends an active game and pays the multiplier of the tiles revealed.
+// A game without a revealed tile cannot be cashed out, it would hand the
+// stake back while counting it as wagered.
+func (m *Mines) CashOut(game *model.MinesGame, now time.Time) error {
+	if game.Status != config.MinesActive {
+		return ErrMinesOver
+	}
+
+	if len(game.Revealed) == 0 {
+		return ErrNoReveal
+	}
+
+	game.Status = config.MinesCashedOut
+	game.Payout = m.Settle(Wager{Amount: game.Amount}, Result{Value: game})
+	game.FinishedAt = &now
+
+	return nil
+}
+
+// Multiplier is what the stake is worth after revealed safe tiles.
+func (m *Mines) Multiplier(mines int, revealed int) float64 {
+	return m.rules.Multiplier(mines, revealed)
+}
+
+func contains(tiles []int, tile int) bool {
+	for _, t := range tiles {
+		if t == tile {
+			return true
+		}
+	}
+
+	return false
+}
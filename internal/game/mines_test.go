@@ -0,0 +1,80 @@
+package game
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"testing"
+	"time"
+)
+
+func TestMinesReveal(t *testing.T) {
+	mines := NewMines(config.MinesRules{HouseEdge: 1, MinMines: 1, MaxMines: 24})
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		mines   []int
+		tiles   []int
+		status  config.MinesStatus
+		payout  int
+		wantErr error
+	}{
+		{name: "safe tiles", mines: []int{0, 1, 2}, tiles: []int{3, 4}, status: config.MinesActive},
+		{name: "mine busts", mines: []int{0, 1, 2}, tiles: []int{3, 1}, status: config.MinesBusted},
+		{name: "same tile twice", mines: []int{0}, tiles: []int{3, 3}, status: config.MinesActive, wantErr: ErrInvalidTile},
+		{name: "off the board", mines: []int{0}, tiles: []int{25}, status: config.MinesActive, wantErr: ErrInvalidTile},
+		{
+			name:   "last safe tile cashes out",
+			mines:  []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23},
+			tiles:  []int{24},
+			status: config.MinesCashedOut,
+			payout: 2475,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := &model.MinesGame{Amount: 100, Mines: len(tt.mines), Board: tt.mines, Status: config.MinesActive}
+
+			var err error
+			for _, tile := range tt.tiles {
+				if err = mines.Reveal(game, tile, now); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.status, game.Status)
+			assert.Equal(t, tt.payout, game.Payout)
+		})
+	}
+
+	busted := &model.MinesGame{Amount: 100, Mines: 1, Board: []int{0}, Status: config.MinesBusted}
+	assert.ErrorIs(t, mines.Reveal(busted, 1, now), ErrMinesOver)
+}
+
+func TestMinesCashOut(t *testing.T) {
+	mines := NewMines(config.MinesRules{HouseEdge: 1, MinMines: 1, MaxMines: 24})
+	now := time.Now()
+
+	untouched := &model.MinesGame{Amount: 100, Mines: 3, Board: []int{0, 1, 2}, Revealed: []int{},
+		Status: config.MinesActive}
+	assert.ErrorIs(t, mines.CashOut(untouched, now), ErrNoReveal)
+	assert.Equal(t, config.MinesActive, untouched.Status)
+
+	busted := &model.MinesGame{Amount: 100, Mines: 1, Board: []int{0}, Revealed: []int{0},
+		Status: config.MinesBusted}
+	assert.ErrorIs(t, mines.CashOut(busted, now), ErrMinesOver)
+
+	game := &model.MinesGame{Amount: 100, Mines: 3, Board: []int{0, 1, 2}, Revealed: []int{5},
+		Status: config.MinesActive}
+	require.NoError(t, mines.CashOut(game, now))
+	assert.Equal(t, config.MinesCashedOut, game.Status)
+	assert.Positive(t, game.Payout)
+}
@@ -0,0 +1,385 @@
+package game
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/api/repository"
+	"go-outpost/internal/lib/money"
+	"time"
+)
+
+var (
+	ErrNotInstant          = errors.New("game is not an instant game")
+	ErrNotStateful         = errors.New("game is not a stateful game")
+	ErrNoBalance           = errors.New("user has no balance")
+	ErrInsufficientBalance = errors.New("user has insufficient balance")
+	ErrPayoutLimit         = errors.New("payout limit reached")
+)
+
+// Balance moves the money of a player on a transaction, so the stake and
+// the payout commit together with the bet they belong to.
+type Balance interface {
+	WithTx(tx *sql.Tx) Ledger
+}
+
+// Ledger holds the moves of one transaction. Rollback after Commit is a
+// no-op, so it is safe to defer.
+type Ledger interface {
+	Income(userID int64, amount money.Money, game config.Game) error
+	Outcome(userID int64, amount money.Money, game config.Game) error
+	Commit() error
+	Rollback() error
+}
+
+// Recorder counts a finished bet in the leaderboards.
+type Recorder interface {
+	Record(userID int64, game config.Game, amount money.Money, payout money.Money)
+}
+
+// Seeds hands out the next draw of a player's seed pair and records the draws
+// on the transaction of their bet so they can be verified once the server
+// seed is revealed.
+type Seeds interface {
+	Next(userID int64) (RNG, error)
+	Record(tx *sql.Tx, betID int64, userID int64, game config.Game, rng RNG, result Result) error
+}
+
+// Player runs instant and stateful games for their engines: it applies the
+// limits, moves the balance, draws from the player's seed pair and records
+// the bet with its fairness data. The stake, the bet and the payout are
+// written on one transaction.
+type Player struct {
+	registry    *Registry
+	gameBetRep  repository.GameBetRepository
+	userRep     repository.UserRepository
+	transaction repository.Transaction
+	balance     Balance
+	seeds       Seeds
+	leaderboard Recorder
+	limits      map[config.Game]config.Limits
+}
+
+func NewPlayer(
+	registry *Registry,
+	gameBetRep repository.GameBetRepository,
+	userRep repository.UserRepository,
+	transaction repository.Transaction,
+	balance Balance,
+	seeds Seeds,
+	leaderboard Recorder,
+	limits map[config.Game]config.Limits) *Player {
+	return &Player{
+		registry:    registry,
+		gameBetRep:  gameBetRep,
+		userRep:     userRep,
+		transaction: transaction,
+		balance:     balance,
+		seeds:       seeds,
+		leaderboard: leaderboard,
+		limits:      limits,
+	}
+}
+
+// Play debits the stake, resolves the bet with the next nonce of the
+// player's seed pair, stores it with its draw and credits the payout, all on
+// one transaction.
+func (p *Player) Play(
+	game config.Game,
+	userID int64,
+	amount money.Money,
+	params json.RawMessage) (*model.GameBet, RNG, error) {
+	const op = "game.Player.Play"
+
+	var (
+		err      error
+		engine   Engine
+		wager    Wager
+		result   Result
+		rng      RNG
+		tx       *sql.Tx
+		ledger   Ledger
+		currency = amount.Currency()
+	)
+
+	engine, err = p.engine(game, Instant)
+	if err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	wager, rng, err = p.wager(engine, userID, amount, params)
+	if err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err = engine.Resolve(wager, rng)
+	if err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bet := &model.GameBet{
+		UUID:      uuid.New().String(),
+		UserID:    userID,
+		Game:      game,
+		Currency:  currency,
+		Amount:    wager.Amount,
+		Payout:    engine.Settle(wager, result),
+		Nonce:     rng.Nonce,
+		CreatedAt: time.Now(),
+	}
+
+	bet.Params, err = json.Marshal(wager.Choice)
+	if err == nil {
+		bet.Result, err = json.Marshal(result.Value)
+	}
+	if err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err = p.transaction.StartTransaction()
+	if err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ledger = p.balance.WithTx(tx)
+	defer ledger.Rollback()
+
+	if err = debit(ledger, userID, amount, game); err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bet.ID, err = p.gameBetRep.WithTx(tx).SaveGameBet(*bet)
+	if err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = p.seeds.Record(tx, bet.ID, userID, game, rng, result); err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if bet.Payout > 0 {
+		if err = ledger.Income(userID, money.New(int64(bet.Payout), currency), game); err != nil {
+			return nil, rng, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = ledger.Commit(); err != nil {
+		return nil, rng, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.leaderboard.Record(userID, game, amount, money.New(int64(bet.Payout), currency))
+
+	return bet, rng, nil
+}
+
+// Start debits the stake of a stateful game and draws it from the next nonce
+// of the player's seed pair. The caller stores the game on tx in save and
+// returns its id, the draw is recorded against it on the same transaction.
+// The moves are left to the caller, which hands the game back to Finish once
+// it ends.
+func (p *Player) Start(
+	game config.Game,
+	userID int64,
+	amount money.Money,
+	params json.RawMessage,
+	save func(tx *sql.Tx, wager Wager, result Result, rng RNG) (int64, error)) error {
+	const op = "game.Player.Start"
+
+	var (
+		err    error
+		engine Engine
+		wager  Wager
+		result Result
+		rng    RNG
+		tx     *sql.Tx
+		ledger Ledger
+		id     int64
+	)
+
+	engine, err = p.engine(game, Stateful)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	wager, rng, err = p.wager(engine, userID, amount, params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err = engine.Resolve(wager, rng)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err = p.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ledger = p.balance.WithTx(tx)
+	defer ledger.Rollback()
+
+	if err = debit(ledger, userID, amount, game); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err = save(tx, wager, result, rng)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = p.seeds.Record(tx, id, userID, game, rng, result); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = ledger.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CapPayout lowers the payout of a stateful game to the payout limit of its
+// currency, the limit cannot be checked up front since it grows with every
+// move.
+func (p *Player) CapPayout(game config.Game, currency money.Currency, payout int) (int, error) {
+	limits, err := p.limits[game].In(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	if limits.MaxPayoutPerRound > 0 && payout > limits.MaxPayoutPerRound {
+		return limits.MaxPayoutPerRound, nil
+	}
+
+	return payout, nil
+}
+
+// Finish stores an ended stateful game through store and credits its payout
+// on one transaction, then counts it in the leaderboards. An error of store
+// rolls the credit back.
+func (p *Player) Finish(
+	game config.Game,
+	userID int64,
+	amount money.Money,
+	payout money.Money,
+	store func(tx *sql.Tx) error) error {
+	const op = "game.Player.Finish"
+
+	var (
+		err    error
+		tx     *sql.Tx
+		ledger Ledger
+	)
+
+	tx, err = p.transaction.StartTransaction()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ledger = p.balance.WithTx(tx)
+	defer ledger.Rollback()
+
+	if err = store(tx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if payout.Minor() > 0 {
+		if err = ledger.Income(userID, payout, game); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = ledger.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.leaderboard.Record(userID, game, amount, payout)
+
+	return nil
+}
+
+func (p *Player) engine(game config.Game, kind Kind) (Engine, error) {
+	engine, err := p.registry.Get(game)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case engine.Kind() == kind:
+		return engine, nil
+	case kind == Stateful:
+		return nil, fmt.Errorf("%w: %s", ErrNotStateful, game)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNotInstant, game)
+}
+
+// wager validates a bet against the limits of its currency and the balance
+// of the player and takes the next nonce of the player's seed pair. The stake
+// is debited by the caller on the transaction of the bet.
+func (p *Player) wager(engine Engine, userID int64, amount money.Money, params json.RawMessage) (Wager, RNG, error) {
+	var (
+		err         error
+		wager       Wager
+		rng         RNG
+		limits      config.Limits
+		userBalance *model.UserBalance
+		currency    = amount.Currency()
+		game        = engine.Game()
+	)
+
+	wager, err = engine.Place(int(amount.Minor()), params)
+	if err != nil {
+		return wager, rng, err
+	}
+
+	limits, err = p.limits[game].In(currency)
+	if err != nil {
+		return wager, rng, err
+	}
+
+	if err = limits.CheckStake(wager.BetType, wager.Amount); err != nil {
+		return wager, rng, err
+	}
+
+	if limits.MaxPayoutPerRound > 0 && wager.MaxPayout > limits.MaxPayoutPerRound {
+		return wager, rng, ErrPayoutLimit
+	}
+
+	userBalance, err = p.userRep.FindUserBalanceByID(userID, currency)
+	if err != nil {
+		return wager, rng, err
+	}
+
+	if userBalance == nil || userBalance.Balance.IsNegative() {
+		return wager, rng, ErrNoBalance
+	}
+
+	if !userBalance.Balance.Covers(amount) {
+		return wager, rng, ErrInsufficientBalance
+	}
+
+	rng, err = p.seeds.Next(userID)
+	if err != nil {
+		return wager, rng, err
+	}
+
+	return wager, rng, nil
+}
+
+// debit takes the stake off the wallet. The debit only goes through when the
+// wallet still covers it, a bet placed concurrently may have spent it since
+// the balance was checked.
+func debit(ledger Ledger, userID int64, amount money.Money, game config.Game) error {
+	err := ledger.Outcome(userID, amount, game)
+	if errors.Is(err, repository.ErrInsufficientBalance) {
+		return ErrInsufficientBalance
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-outpost/internal/api/config"
+)
+
+type PlinkoChoice struct {
+	Rows int               `json:"rows"`
+	Risk config.PlinkoRisk `json:"risk"`
+}
+
+// PlinkoDrop is the fall of a ball. Path holds one bit per row, 1 when the
+// ball went right, and the slot is the number of right turns.
+type PlinkoDrop struct {
+	Rows       int               `json:"rows"`
+	Risk       config.PlinkoRisk `json:"risk"`
+	Path       []int             `json:"path"`
+	Slot       int               `json:"slot"`
+	Multiplier float64           `json:"multiplier"`
+}
+
+type Plinko struct {
+	rules config.PlinkoRules
+}
+
+func NewPlinko(rules config.PlinkoRules) *Plinko {
+	return &Plinko{rules: rules}
+}
+
+func (p *Plinko) Game() config.Game {
+	return config.Plinko
+}
+
+func (p *Plinko) Kind() Kind {
+	return Instant
+}
+
+func (p *Plinko) Place(amount int, params json.RawMessage) (Wager, error) {
+	var choice PlinkoChoice
+
+	if err := decode(params, &choice); err != nil {
+		return Wager{}, err
+	}
+
+	table, err := p.rules.Table(choice.Rows, choice.Risk)
+	if err != nil {
+		return Wager{}, fmt.Errorf("%w: %v", ErrInvalidBet, err)
+	}
+
+	best := 0.0
+	for _, multiplier := range table {
+		if multiplier > best {
+			best = multiplier
+		}
+	}
+
+	return Wager{
+		Amount:    amount,
+		BetType:   config.BetType(choice.Risk),
+		MaxPayout: int(float64(amount) * best),
+		Choice:    choice,
+	}, nil
+}
+
+func (p *Plinko) Resolve(wager Wager, rng RNG) (Result, error) {
+	choice := wager.Choice.(PlinkoChoice)
+
+	table, err := p.rules.Table(choice.Rows, choice.Risk)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrInvalidBet, err)
+	}
+
+	path := rng.Bits(choice.Rows)
+
+	slot := 0
+	for _, bit := range path {
+		slot += bit
+	}
+
+	return Result{
+		Value: PlinkoDrop{
+			Rows:       choice.Rows,
+			Risk:       choice.Risk,
+			Path:       path,
+			Slot:       slot,
+			Multiplier: table[slot],
+		},
+		Draw: float64(slot),
+		Min:  0,
+		Max:  choice.Rows,
+	}, nil
+}
+
+func (p *Plinko) Settle(wager Wager, result Result) int {
+	return int(float64(wager.Amount) * result.Value.(PlinkoDrop).Multiplier)
+}
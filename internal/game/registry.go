@@ -0,0 +1,51 @@
+package game
+
+import (
+	"fmt"
+	"go-outpost/internal/api/config"
+	"sort"
+)
+
+// Registry holds the engine of every game the backend serves.
+type Registry struct {
+	engines map[config.Game]Engine
+}
+
+func NewRegistry(engines ...Engine) *Registry {
+	r := &Registry{engines: make(map[config.Game]Engine)}
+
+	for _, engine := range engines {
+		r.Register(engine)
+	}
+
+	return r
+}
+
+func (r *Registry) Register(engine Engine) {
+	r.engines[engine.Game()] = engine
+}
+
+func (r *Registry) Get(game config.Game) (Engine, error) {
+	const op = "game.Registry.Get"
+
+	engine, ok := r.engines[game]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownGame, game)
+	}
+
+	return engine, nil
+}
+
+// Engines returns the registered engines sorted by game.
+func (r *Registry) Engines() []Engine {
+	engines := make([]Engine, 0, len(r.engines))
+	for _, engine := range r.engines {
+		engines = append(engines, engine)
+	}
+
+	sort.Slice(engines, func(i, j int) bool {
+		return engines[i].Game() < engines[j].Game()
+	})
+
+	return engines
+}
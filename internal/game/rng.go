@@ -0,0 +1,37 @@
+package game
+
+import "go-outpost/internal/api/http-server/handlers/provably_fair"
+
+// RNG is the fair randomness of one draw, a seed pair and a nonce. Anyone
+// holding the revealed server seed can rebuild it and replay the draw.
+type RNG struct {
+	ServerSeed string
+	ClientSeed string
+	Nonce      int
+}
+
+func (r RNG) Hash() string {
+	return provably_fair.Hash(r.ServerSeed, r.ClientSeed, r.Nonce)
+}
+
+// Index draws an integer in [0, size) from the first bits of the hash.
+func (r RNG) Index(size int) int {
+	return provably_fair.IndexFromHash(r.Hash(), size)
+}
+
+func (r RNG) Floats(count int) []float64 {
+	return provably_fair.Floats(r.ServerSeed, r.ClientSeed, r.Nonce, count)
+}
+
+func (r RNG) Shuffle(size int) []int {
+	return provably_fair.Shuffle(r.ServerSeed, r.ClientSeed, r.Nonce, size)
+}
+
+func (r RNG) Bits(count int) []int {
+	return provably_fair.Bits(r.Hash(), count)
+}
+
+// ServerSeedHash commits to the server seed until the seed pair is rotated.
+func (r RNG) ServerSeedHash() string {
+	return provably_fair.HashServerSeed(r.ServerSeed)
+}
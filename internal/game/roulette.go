@@ -0,0 +1,69 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-outpost/internal/api/config"
+)
+
+type RouletteChoice struct {
+	Type  config.BetType `json:"type"`
+	Value string         `json:"value"`
+}
+
+// Roulette resolves once per round, every bet of the round is settled
+// against the same pocket.
+type Roulette struct {
+	wheel config.Wheel
+}
+
+func NewRoulette(wheel config.Wheel) *Roulette {
+	return &Roulette{wheel: wheel}
+}
+
+func (r *Roulette) Game() config.Game {
+	return config.Roulette
+}
+
+func (r *Roulette) Kind() Kind {
+	return Round
+}
+
+func (r *Roulette) Place(amount int, params json.RawMessage) (Wager, error) {
+	var choice RouletteChoice
+
+	if err := decode(params, &choice); err != nil {
+		return Wager{}, err
+	}
+
+	if err := r.wheel.ValidateBet(choice.Type, choice.Value); err != nil {
+		return Wager{}, fmt.Errorf("%w: %v", ErrInvalidBet, err)
+	}
+
+	wager := Wager{Amount: amount, BetType: choice.Type, Choice: choice}
+
+	for _, pocket := range r.wheel.Pockets {
+		if payout := r.Settle(wager, Result{Value: pocket}); payout > wager.MaxPayout {
+			wager.MaxPayout = payout
+		}
+	}
+
+	return wager, nil
+}
+
+func (r *Roulette) Resolve(_ Wager, rng RNG) (Result, error) {
+	index := rng.Index(len(r.wheel.Pockets))
+
+	return Result{
+		Value: r.wheel.Pockets[index],
+		Draw:  float64(index),
+		Min:   0,
+		Max:   len(r.wheel.Pockets) - 1,
+	}, nil
+}
+
+func (r *Roulette) Settle(wager Wager, result Result) int {
+	choice := wager.Choice.(RouletteChoice)
+
+	return wager.Amount * r.wheel.Multiplier(choice.Type, choice.Value, result.Value.(config.Pocket))
+}
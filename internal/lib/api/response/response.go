@@ -10,6 +10,7 @@ import (
 type Response struct {
 	Status int    `json:"status"`
 	Error  string `json:"error,omitempty"`
+	Code   string `json:"code,omitempty"`
 }
 
 const (
@@ -33,6 +34,14 @@ func Error(msg string, status int) Response {
 	}
 }
 
+// ErrorCode is an Error carrying a machine readable code for clients.
+func ErrorCode(msg string, status int, code string) Response {
+	response := Error(msg, status)
+	response.Code = code
+
+	return response
+}
+
 func ValidationError(errs validator.ValidationErrors) Response {
 	var errMsgs []string
 
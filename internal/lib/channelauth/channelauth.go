@@ -0,0 +1,59 @@
+package channelauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	PrivatePrefix  = "private-"
+	PresencePrefix = "presence-"
+	UserPrefix     = "private-user."
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// Sign returns the token a client presents to subscribe to protected channels as userID.
+func Sign(secret string, userID string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(userID))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func Verify(secret string, userID string, token string) bool {
+	if secret == "" || userID == "" || token == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, userID)), []byte(token))
+}
+
+func IsProtected(channel string) bool {
+	return strings.HasPrefix(channel, PrivatePrefix) || strings.HasPrefix(channel, PresencePrefix)
+}
+
+// Authorize checks whether userID holding token may subscribe to channel.
+// Public channels are open, private and presence channels need a valid token,
+// and private-user.<id> channels are reserved for that user.
+func Authorize(secret string, channel string, userID string, token string) error {
+	if !IsProtected(channel) {
+		return nil
+	}
+
+	if !Verify(secret, userID, token) {
+		return ErrUnauthorized
+	}
+
+	if strings.HasPrefix(channel, UserPrefix) && strings.TrimPrefix(channel, UserPrefix) != userID {
+		return ErrForbidden
+	}
+
+	return nil
+}
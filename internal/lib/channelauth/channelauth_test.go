@@ -0,0 +1,61 @@
+package channelauth
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	const secret = "secret"
+
+	tests := []struct {
+		name    string
+		channel string
+		userID  string
+		token   string
+		want    error
+	}{
+		{
+			name:    "public channel",
+			channel: "roulette",
+		},
+		{
+			name:    "presence without token",
+			channel: "presence-lobby",
+			userID:  "u1",
+			want:    ErrUnauthorized,
+		},
+		{
+			name:    "presence with token",
+			channel: "presence-lobby",
+			userID:  "u1",
+			token:   Sign(secret, "u1"),
+		},
+		{
+			name:    "own user channel",
+			channel: "private-user.u1",
+			userID:  "u1",
+			token:   Sign(secret, "u1"),
+		},
+		{
+			name:    "foreign user channel",
+			channel: "private-user.u2",
+			userID:  "u1",
+			token:   Sign(secret, "u1"),
+			want:    ErrForbidden,
+		},
+		{
+			name:    "token for another user",
+			channel: "private-user.u2",
+			userID:  "u2",
+			token:   Sign(secret, "u1"),
+			want:    ErrUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Authorize(secret, tt.channel, tt.userID, tt.token))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package money
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Amount is a decimal amount read from a request before its currency is
+// known. It accepts a JSON string or number and is turned into Money by In.
+type Amount string
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	} else if text == "" || !strings.ContainsRune("-0123456789", rune(text[0])) {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, text)
+	}
+
+	*a = Amount(text)
+
+	return nil
+}
+
+// In reads the amount in the minor units of currency. Like Parse, it never
+// rounds.
+func (a Amount) In(currency Currency) (Money, error) {
+	return Parse(string(a), currency)
+}
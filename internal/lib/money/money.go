@@ -0,0 +1,282 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 style code.
+type Currency string
+
+// Default is the currency of amounts that do not name one. Games keep their
+// books in it.
+const Default Currency = "USD"
+
+const (
+	USD Currency = "USD"
+	// COIN is the in-house play currency, it has no fractions.
+	COIN Currency = "COIN"
+	// BTC holds points worth their value in bitcoin, down to the satoshi.
+	BTC Currency = "BTC"
+)
+
+// minorUnits holds the number of decimals of every known currency.
+var minorUnits = map[Currency]int{
+	USD:  2,
+	COIN: 0,
+	BTC:  8,
+}
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrPrecision        = errors.New("amount has more decimals than its currency")
+	ErrOverflow         = errors.New("amount overflow")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+)
+
+// MinorUnits returns the number of decimals of the currency.
+func (c Currency) MinorUnits() (int, error) {
+	units, ok := minorUnits[c]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, string(c))
+	}
+
+	return units, nil
+}
+
+// Money is an exact amount in the minor units of its currency, cents for USD.
+// The zero value is zero in the Default currency. It is marshalled to JSON as
+// a decimal string and stored in SQL as an integer of minor units.
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New returns minor units of currency.
+func New(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// Cents returns an amount of the Default currency, as games count it.
+func Cents(amount int) Money {
+	return New(int64(amount), Default)
+}
+
+// Parse reads a decimal amount such as "12", "-0.5" or "1.23". It never
+// rounds: more decimals than the currency has is an error.
+func Parse(s string, currency Currency) (Money, error) {
+	units, err := currency.MinorUnits()
+	if err != nil {
+		return Money{}, err
+	}
+
+	digits := strings.TrimPrefix(s, "-")
+	negative := len(digits) < len(s)
+
+	whole, frac, hasFrac := strings.Cut(digits, ".")
+	if whole == "" || (hasFrac && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	if len(frac) > units {
+		return Money{}, fmt.Errorf("%w: %q in %s", ErrPrecision, s, currency)
+	}
+
+	minor, err := strconv.ParseInt(whole+frac+strings.Repeat("0", units-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return New(minor, currency), nil
+}
+
+// MustParse is Parse for amounts known to be valid, such as constants.
+func MustParse(s string, currency Currency) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+func (m Money) Currency() Currency {
+	if m.currency == "" {
+		return Default
+	}
+
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// String formats the amount with every decimal of its currency, "1.20" and
+// never "1.2". Amounts of an unknown currency are formatted in minor units.
+func (m Money) String() string {
+	units, _ := m.Currency().MinorUnits()
+
+	digits := strconv.FormatUint(abs(m.minor), 10)
+	if units > 0 {
+		if len(digits) <= units {
+			digits = strings.Repeat("0", units-len(digits)+1) + digits
+		}
+
+		digits = digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+	}
+
+	if m.minor < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+// Covers reports whether m is at least o. An amount of another currency
+// never covers o.
+func (m Money) Covers(o Money) bool {
+	return m.Currency() == o.Currency() && m.minor >= o.minor
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency() != o.Currency() {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency(), o.Currency())
+	}
+
+	if (o.minor > 0 && m.minor > math.MaxInt64-o.minor) || (o.minor < 0 && m.minor < math.MinInt64-o.minor) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, o)
+	}
+
+	return New(m.minor+o.minor, m.Currency()), nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.minor == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, o)
+	}
+
+	return m.Add(New(-o.minor, o.Currency()))
+}
+
+// Mul multiplies the amount by a whole factor.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.minor == 0 || n == 0 {
+		return New(0, m.Currency()), nil
+	}
+
+	product := m.minor * n
+	if product/n != m.minor || (m.minor == -1 && n == math.MinInt64) || (n == -1 && m.minor == math.MinInt64) {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, n)
+	}
+
+	return New(product, m.Currency()), nil
+}
+
+// MarshalJSON writes the amount as a decimal string.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON reads a decimal string or a JSON number, without going
+// through a float. The currency is kept, the Default one when unset.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	parsed, err := Parse(text, m.Currency())
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+// Value stores the amount as minor units.
+func (m Money) Value() (driver.Value, error) {
+	return m.minor, nil
+}
+
+// Scan reads minor units. The currency is kept, the Default one when unset.
+func (m *Money) Scan(src interface{}) error {
+	var (
+		minor int64
+		err   error
+	)
+
+	switch v := src.(type) {
+	case int64:
+		minor = v
+	case []byte:
+		minor, err = strconv.ParseInt(string(v), 10, 64)
+	case string:
+		minor, err = strconv.ParseInt(v, 10, 64)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, err)
+	}
+
+	*m = New(minor, m.Currency())
+
+	return nil
+}
+
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(^n) + 1
+	}
+
+	return uint64(n)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithValidation lets validate tags check amounts in minor units, so that
+// `validate:"required,min=1"` asks for at least one cent.
+func WithValidation(v *validator.Validate) *validator.Validate {
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if m, ok := field.Interface().(Money); ok {
+			return m.minor
+		}
+
+		return nil
+	}, Money{})
+
+	return v
+}
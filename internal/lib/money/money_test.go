@@ -0,0 +1,203 @@
+package money
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  string
+		want    int64
+		wantErr error
+	}{
+		{name: "decimal", amount: "1.23", want: 123},
+		{name: "not truncated", amount: "0.29", want: 29},
+		{name: "whole", amount: "12", want: 1200},
+		{name: "one decimal", amount: "0.5", want: 50},
+		{name: "zero", amount: "0", want: 0},
+		{name: "negative", amount: "-1.23", want: -123},
+		{name: "too precise", amount: "1.234", wantErr: ErrPrecision},
+		{name: "empty", amount: "", wantErr: ErrInvalidAmount},
+		{name: "no whole part", amount: ".5", wantErr: ErrInvalidAmount},
+		{name: "no decimals", amount: "5.", wantErr: ErrInvalidAmount},
+		{name: "exponent", amount: "1e2", wantErr: ErrInvalidAmount},
+		{name: "too large", amount: "92233720368547758.08", wantErr: ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.amount, USD)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Minor())
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  string
+	}{
+		{name: "cents", money: Cents(123), want: "1.23"},
+		{name: "below one", money: Cents(5), want: "0.05"},
+		{name: "trailing zero", money: Cents(120), want: "1.20"},
+		{name: "zero value", money: Money{}, want: "0.00"},
+		{name: "negative", money: Cents(-5), want: "-0.05"},
+		{name: "smallest", money: New(math.MinInt64, USD), want: "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.money.String())
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	sum, err := Cents(150).Add(Cents(-200))
+	require.NoError(t, err)
+	assert.Equal(t, "-0.50", sum.String())
+
+	diff, err := Cents(150).Sub(Cents(50))
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), diff.Minor())
+
+	product, err := Cents(25).Mul(4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), product.Minor())
+
+	_, err = New(math.MaxInt64, USD).Add(Cents(1))
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MinInt64, USD).Sub(Cents(1))
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = New(math.MaxInt64/2+1, USD).Mul(2)
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	_, err = Cents(1).Add(New(1, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestJSON(t *testing.T) {
+	var req struct {
+		Amount Money `json:"amount"`
+		Stake  Money `json:"stake"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 0.29, "stake": "10.5"}`), &req))
+	assert.Equal(t, int64(29), req.Amount.Minor())
+	assert.Equal(t, int64(1050), req.Stake.Minor())
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": "0.29", "stake": "10.50"}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": 0.001}`), &req))
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": true}`), &req))
+}
+
+func TestScan(t *testing.T) {
+	var m Money
+
+	require.NoError(t, m.Scan(int64(129)))
+	assert.Equal(t, "1.29", m.String())
+
+	require.NoError(t, m.Scan([]byte("-7")))
+	assert.Equal(t, int64(-7), m.Minor())
+
+	assert.ErrorIs(t, m.Scan(1.5), ErrInvalidAmount)
+
+	value, err := Cents(42).Value()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+}
+
+func TestWithValidation(t *testing.T) {
+	type request struct {
+		Amount Money `validate:"required,min=1"`
+	}
+
+	v := WithValidation(validator.New())
+
+	assert.NoError(t, v.Struct(request{Amount: Cents(1)}))
+	assert.Error(t, v.Struct(request{}))
+	assert.Error(t, v.Struct(request{Amount: Cents(-100)}))
+}
+
+func TestCovers(t *testing.T) {
+	assert.True(t, Cents(100).Covers(Cents(100)))
+	assert.False(t, Cents(99).Covers(Cents(100)))
+	assert.False(t, New(100, "EUR").Covers(Cents(1)))
+}
+
+func TestAmount(t *testing.T) {
+	var req struct {
+		Amount Amount `json:"amount"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 0.00000129}`), &req))
+
+	btc, err := req.Amount.In(BTC)
+	require.NoError(t, err)
+	assert.Equal(t, int64(129), btc.Minor())
+
+	_, err = req.Amount.In(USD)
+	assert.ErrorIs(t, err, ErrPrecision)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": "15"}`), &req))
+
+	coins, err := req.Amount.In(COIN)
+	require.NoError(t, err)
+	assert.Equal(t, "15", coins.String())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": true}`), &req))
+}
+
+func TestConvert(t *testing.T) {
+	rates, err := NewRates(map[Currency]string{COIN: "0.01", BTC: "60000"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		money Money
+		to    Currency
+		want  string
+	}{
+		{name: "same currency", money: Cents(123), to: USD, want: "1.23"},
+		{name: "dollars to coins", money: Cents(123), to: COIN, want: "123"},
+		{name: "coins to dollars", money: New(250, COIN), to: USD, want: "2.50"},
+		{name: "dollars to btc", money: Cents(6000), to: BTC, want: "0.00100000"},
+		{name: "rounded down", money: Cents(1), to: BTC, want: "0.00000016"},
+		{name: "btc to coins", money: New(1, BTC), to: COIN, want: "0"},
+		{name: "negative rounded down", money: Cents(-1), to: BTC, want: "-0.00000017"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.money, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.to, got.Currency())
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+
+	_, err = rates.Convert(Cents(1), "EUR")
+	assert.ErrorIs(t, err, ErrNoRate)
+
+	_, err = NewRates(map[Currency]string{COIN: "0"})
+	assert.ErrorIs(t, err, ErrNoRate)
+
+	_, err = NewRates(map[Currency]string{"EUR": "1.1"})
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
@@ -0,0 +1,103 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrNoRate = errors.New("no exchange rate")
+
+// Rates converts between currencies through the value of one whole unit of
+// each of them in the Default currency.
+type Rates struct {
+	rates map[Currency]*big.Rat
+}
+
+// NewRates takes the value of one unit of every currency in the Default
+// currency as exact decimals, such as "0.01" for a coin worth a cent. The
+// Default currency is always worth 1.
+func NewRates(rates map[Currency]string) (Rates, error) {
+	r := Rates{rates: map[Currency]*big.Rat{Default: big.NewRat(1, 1)}}
+
+	for currency, value := range rates {
+		if _, err := currency.MinorUnits(); err != nil {
+			return Rates{}, err
+		}
+
+		rate, ok := new(big.Rat).SetString(value)
+		if !ok || rate.Sign() <= 0 {
+			return Rates{}, fmt.Errorf("%w: %s rate %q is invalid", ErrNoRate, currency, value)
+		}
+
+		if currency == Default && rate.Cmp(big.NewRat(1, 1)) != 0 {
+			return Rates{}, fmt.Errorf("%w: %s is worth 1", ErrNoRate, Default)
+		}
+
+		r.rates[currency] = rate
+	}
+
+	return r, nil
+}
+
+// Rate returns the value of one unit of the currency in the Default one.
+func (r Rates) Rate(currency Currency) (*big.Rat, error) {
+	rate, ok := r.rates[currency]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoRate, currency)
+	}
+
+	return new(big.Rat).Set(rate), nil
+}
+
+// Convert returns the value of m in another currency, rounded down to its
+// smallest unit so a conversion never creates money.
+func (r Rates) Convert(m Money, to Currency) (Money, error) {
+	from := m.Currency()
+	if from == to {
+		return m, nil
+	}
+
+	fromRate, err := r.Rate(from)
+	if err != nil {
+		return Money{}, err
+	}
+
+	toRate, err := r.Rate(to)
+	if err != nil {
+		return Money{}, err
+	}
+
+	fromUnits, err := from.MinorUnits()
+	if err != nil {
+		return Money{}, err
+	}
+
+	toUnits, err := to.MinorUnits()
+	if err != nil {
+		return Money{}, err
+	}
+
+	// minor * fromRate / 10^fromUnits is the Default value, divided by toRate
+	// and scaled by 10^toUnits it is the minor units of the target.
+	value := new(big.Rat).SetInt64(m.minor)
+	value.Mul(value, fromRate)
+	value.Mul(value, new(big.Rat).SetInt(pow10(toUnits)))
+	value.Quo(value, toRate)
+	value.Quo(value, new(big.Rat).SetInt(pow10(fromUnits)))
+
+	minor := new(big.Int).Quo(value.Num(), value.Denom())
+	if value.Sign() < 0 && new(big.Int).Mul(minor, value.Denom()).Cmp(value.Num()) != 0 {
+		minor.Sub(minor, big.NewInt(1))
+	}
+
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s in %s", ErrOverflow, m, to)
+	}
+
+	return New(minor.Int64(), to), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,118 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/money"
+	"net/http"
+	"strings"
+)
+
+const (
+	FakeProvider        = "fake"
+	FakeSignatureHeader = "X-Fake-Signature"
+
+	// FakeFailPrefix makes the fake provider refuse withdrawals to a
+	// destination starting with it, to try the failure path.
+	FakeFailPrefix = "fail"
+)
+
+var ErrFakeSendFailed = fmt.Errorf("%w: fake destination", ErrDeclined)
+
+// FakeWebhook is the body of a fake provider webhook. Amount is a decimal in
+// Currency.
+type FakeWebhook struct {
+	Reference string               `json:"reference"`
+	Status    config.DepositStatus `json:"status"`
+	Amount    string               `json:"amount"`
+	Currency  money.Currency       `json:"currency"`
+}
+
+// Fake is a provider for local testing. Checkouts lead nowhere, a deposit is
+// paid by posting a FakeWebhook signed with the webhook secret in the
+// X-Fake-Signature header, for example:
+//
+//	body='{"reference":"fake_<uuid>","status":"completed","amount":"10.00","currency":"USD"}'
+//	sig=$(printf '%s' "$body" | openssl dgst -sha256 -hmac "$PAYMENT_WEBHOOK_SECRET" | cut -d' ' -f2)
+//	curl -H "X-Fake-Signature: $sig" -d "$body" localhost:8080/deposits/webhook/fake
+//
+// Withdrawals are sent at once unless their destination starts with "fail".
+type Fake struct {
+	secret string
+}
+
+func NewFake(secret string) *Fake {
+	return &Fake{secret: secret}
+}
+
+func (f *Fake) Name() string {
+	return FakeProvider
+}
+
+func (f *Fake) CreateDeposit(deposit model.Deposit) (Checkout, error) {
+	reference := "fake_" + deposit.UUID
+
+	return Checkout{Reference: reference, URL: "https://fake-payments.local/checkout/" + reference}, nil
+}
+
+func (f *Fake) ParseWebhook(body []byte, header http.Header) (Notification, error) {
+	const op = "payment.Fake.ParseWebhook"
+
+	var (
+		err     error
+		webhook FakeWebhook
+		n       Notification
+	)
+
+	if !Verify(f.secret, body, header.Get(FakeSignatureHeader)) {
+		return n, fmt.Errorf("%s: %w", op, ErrInvalidSignature)
+	}
+
+	if err = json.Unmarshal(body, &webhook); err != nil {
+		return n, fmt.Errorf("%s: %w: %s", op, ErrInvalidWebhook, err)
+	}
+
+	if webhook.Reference == "" {
+		return n, fmt.Errorf("%s: %w: no reference", op, ErrInvalidWebhook)
+	}
+
+	if webhook.Status != config.DepositCompleted && webhook.Status != config.DepositFailed {
+		return n, fmt.Errorf("%s: %w: status %q", op, ErrInvalidWebhook, webhook.Status)
+	}
+
+	n = Notification{Reference: webhook.Reference, Status: webhook.Status}
+
+	if webhook.Status == config.DepositCompleted {
+		n.Amount, err = money.Parse(webhook.Amount, webhook.Currency)
+		if err != nil {
+			return n, fmt.Errorf("%s: %w: %s", op, ErrInvalidWebhook, err)
+		}
+	}
+
+	return n, nil
+}
+
+// Send needs no state to be idempotent, the transfer id is derived from the
+// idempotency key.
+func (f *Fake) Send(withdrawal model.Withdrawal, idempotencyKey string) (string, error) {
+	if strings.HasPrefix(withdrawal.Destination, FakeFailPrefix) {
+		return "", ErrFakeSendFailed
+	}
+
+	return "fake_tx_" + idempotencyKey, nil
+}
+
+// Webhook builds a signed webhook, as the fake provider would post it.
+func (f *Fake) Webhook(webhook FakeWebhook) ([]byte, http.Header, error) {
+	body, err := json.Marshal(webhook)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := http.Header{}
+	header.Set(FakeSignatureHeader, Sign(f.secret, body))
+
+	return body, header, nil
+}
@@ -0,0 +1,101 @@
+package payment
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/money"
+	"net/http"
+	"testing"
+)
+
+func TestFakeParseWebhook(t *testing.T) {
+	fake := NewFake("secret")
+
+	signed := func(webhook FakeWebhook) ([]byte, http.Header) {
+		body, header, err := fake.Webhook(webhook)
+		require.NoError(t, err)
+
+		return body, header
+	}
+
+	t.Run("completed", func(t *testing.T) {
+		body, header := signed(FakeWebhook{Reference: "fake_1", Status: config.DepositCompleted, Amount: "12.50",
+			Currency: money.USD})
+
+		n, err := fake.ParseWebhook(body, header)
+		require.NoError(t, err)
+		assert.Equal(t, "fake_1", n.Reference)
+		assert.Equal(t, config.DepositCompleted, n.Status)
+		assert.Equal(t, money.New(1250, money.USD), n.Amount)
+	})
+
+	t.Run("failed has no amount", func(t *testing.T) {
+		body, header := signed(FakeWebhook{Reference: "fake_1", Status: config.DepositFailed})
+
+		n, err := fake.ParseWebhook(body, header)
+		require.NoError(t, err)
+		assert.Equal(t, config.DepositFailed, n.Status)
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		body, header := signed(FakeWebhook{Reference: "fake_1", Status: config.DepositCompleted, Amount: "1",
+			Currency: money.USD})
+		body[len(body)-2] = 'X'
+
+		_, err := fake.ParseWebhook(body, header)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		body, header, err := NewFake("other").Webhook(FakeWebhook{Reference: "fake_1",
+			Status: config.DepositCompleted, Amount: "1", Currency: money.USD})
+		require.NoError(t, err)
+
+		_, err = fake.ParseWebhook(body, header)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		body, header := signed(FakeWebhook{Reference: "fake_1", Status: config.DepositPending})
+
+		_, err := fake.ParseWebhook(body, header)
+		assert.ErrorIs(t, err, ErrInvalidWebhook)
+	})
+
+	t.Run("sub-unit amount", func(t *testing.T) {
+		body, header := signed(FakeWebhook{Reference: "fake_1", Status: config.DepositCompleted, Amount: "1.001",
+			Currency: money.USD})
+
+		_, err := fake.ParseWebhook(body, header)
+		assert.ErrorIs(t, err, ErrInvalidWebhook)
+	})
+}
+
+func TestFakeSend(t *testing.T) {
+	fake := NewFake("secret")
+
+	reference, err := fake.Send(model.Withdrawal{UUID: "w1", Destination: "acct-1"}, "w1")
+	require.NoError(t, err)
+	assert.Equal(t, "fake_tx_w1", reference)
+
+	again, err := fake.Send(model.Withdrawal{UUID: "w1", Destination: "acct-1"}, "w1")
+	require.NoError(t, err)
+	assert.Equal(t, reference, again)
+
+	_, err = fake.Send(model.Withdrawal{UUID: "w2", Destination: "fail-acct"}, "w2")
+	assert.ErrorIs(t, err, ErrFakeSendFailed)
+	assert.ErrorIs(t, err, ErrDeclined)
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(NewFake("secret"))
+
+	provider, err := registry.Get(FakeProvider)
+	require.NoError(t, err)
+	assert.Equal(t, FakeProvider, provider.Name())
+
+	_, err = registry.Get("stripe")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
@@ -0,0 +1,111 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-outpost/internal/api/config"
+	"go-outpost/internal/api/http-server/model"
+	"go-outpost/internal/lib/money"
+	"net/http"
+	"sort"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("payment provider is not registered")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidWebhook   = errors.New("invalid webhook")
+	// ErrDeclined is what a provider wraps when it surely did not pay a
+	// withdrawal out. Any other Send error leaves the outcome unknown.
+	ErrDeclined = errors.New("payment provider declined the transfer")
+)
+
+// Checkout is where the player pays a deposit. Reference is the provider id
+// of the checkout, later webhooks point at the deposit through it.
+type Checkout struct {
+	Reference string
+	URL       string
+}
+
+// Notification is a verified webhook about a deposit. Amount is what the
+// provider received, it may differ from what the checkout asked for.
+type Notification struct {
+	Reference string
+	Status    config.DepositStatus
+	Amount    money.Money
+}
+
+// Provider moves money between the players and the outside world. The
+// backend only talks to providers through it, they handle their own API,
+// signatures and payload formats.
+type Provider interface {
+	Name() string
+	// CreateDeposit opens a checkout for a pending deposit.
+	CreateDeposit(deposit model.Deposit) (Checkout, error)
+	// ParseWebhook verifies the signature of a deposit webhook and reads it.
+	ParseWebhook(body []byte, header http.Header) (Notification, error)
+	// Send pays an approved withdrawal out and returns the transfer id. It
+	// may be called again with the same idempotency key after a timeout or a
+	// restart and must not pay twice. A refusal wraps ErrDeclined.
+	Send(withdrawal model.Withdrawal, idempotencyKey string) (string, error)
+}
+
+// Registry holds every provider the backend can use.
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+
+	for _, provider := range providers {
+		r.Register(provider)
+	}
+
+	return r
+}
+
+func (r *Registry) Register(provider Provider) {
+	r.providers[provider.Name()] = provider
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	const op = "payment.Registry.Get"
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownProvider, name)
+	}
+
+	return provider, nil
+}
+
+// Names returns the registered providers sorted by name.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Sign returns the hex HMAC-SHA256 of a webhook body.
+func Sign(secret string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func Verify(secret string, body []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,272 @@
+package guard
+
+import (
+	"errors"
+	"expvar"
+	"go-outpost/internal/config"
+	"golang.org/x/exp/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DecisionOriginRejected = "origin_rejected"
+	DecisionBanned         = "banned"
+	DecisionConnectionCap  = "connection_cap"
+	DecisionRateLimited    = "rate_limited"
+	DecisionProtocolError  = "protocol_error"
+	DecisionBanIssued      = "ban_issued"
+)
+
+var (
+	ErrBanned        = errors.New("ip is temporarily banned")
+	ErrTooManyConns  = errors.New("too many connections from ip")
+	ErrOriginBlocked = errors.New("origin is not allowed")
+)
+
+// Decisions counts every guard decision by kind, exposed through /debug/vars.
+var Decisions = expvar.NewMap("ws_guard_decisions")
+
+type strikes struct {
+	count int
+	since time.Time
+}
+
+type Guard struct {
+	limits      config.WSLimits
+	origins     map[string]bool
+	anyOrigin   bool
+	proxies     []*net.IPNet
+	connections map[string]int
+	strikes     map[string]*strikes
+	bans        map[string]time.Time
+	mutex       sync.Mutex
+	now         func() time.Time
+	log         *slog.Logger
+}
+
+func New(log *slog.Logger, limits config.WSLimits) *Guard {
+	g := &Guard{
+		limits:      limits,
+		origins:     make(map[string]bool),
+		connections: make(map[string]int),
+		strikes:     make(map[string]*strikes),
+		bans:        make(map[string]time.Time),
+		now:         time.Now,
+		log:         log.With(slog.String("component", "ws/guard")),
+	}
+
+	for _, origin := range limits.AllowedOrigins {
+		if origin == "*" {
+			g.anyOrigin = true
+
+			continue
+		}
+
+		g.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	for _, proxy := range limits.TrustedProxies {
+		network, err := parseProxy(proxy)
+		if err != nil {
+			g.log.Error("invalid trusted proxy, ignoring", slog.String("proxy", proxy))
+
+			continue
+		}
+
+		g.proxies = append(g.proxies, network)
+	}
+
+	return g
+}
+
+// CheckOrigin is used as the websocket upgrader origin policy. Requests
+// without an Origin header come from non-browser clients and are allowed.
+// With an empty allow-list only same-host origins pass.
+func (g *Guard) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || g.anyOrigin {
+		return true
+	}
+
+	if len(g.origins) == 0 {
+		u, err := url.Parse(origin)
+		if err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+	} else if g.origins[strings.ToLower(origin)] {
+		return true
+	}
+
+	g.decide(DecisionOriginRejected, g.ClientIP(r), slog.String("origin", origin))
+
+	return false
+}
+
+// Admit registers a new connection from ip, refusing banned addresses and
+// addresses over the connection cap. Every admitted connection must be released.
+func (g *Guard) Admit(ip string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if until, ok := g.bans[ip]; ok {
+		if g.now().Before(until) {
+			g.decide(DecisionBanned, ip, slog.Time("until", until))
+
+			return ErrBanned
+		}
+
+		delete(g.bans, ip)
+		delete(g.strikes, ip)
+	}
+
+	if g.limits.MaxConnectionsPerIP > 0 && g.connections[ip] >= g.limits.MaxConnectionsPerIP {
+		g.decide(DecisionConnectionCap, ip, slog.Int("connections", g.connections[ip]))
+
+		return ErrTooManyConns
+	}
+
+	g.connections[ip]++
+
+	return nil
+}
+
+func (g *Guard) Release(ip string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.connections[ip]--
+	if g.connections[ip] <= 0 {
+		delete(g.connections, ip)
+	}
+}
+
+// Strike records a protocol error for ip and reports whether the address
+// is now banned, in which case the caller should drop the connection.
+// Strikes only add up within the strike window, older ones are forgotten.
+func (g *Guard) Strike(ip string, reason string) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := g.now()
+
+	s, ok := g.strikes[ip]
+	if !ok || (g.limits.StrikeWindow > 0 && !now.Before(s.since.Add(g.limits.StrikeWindow))) {
+		s = &strikes{since: now}
+		g.strikes[ip] = s
+	}
+
+	s.count++
+	g.decide(DecisionProtocolError, ip, slog.String("reason", reason), slog.Int("strikes", s.count))
+
+	if g.limits.MaxProtocolErrors <= 0 || s.count < g.limits.MaxProtocolErrors {
+		return false
+	}
+
+	delete(g.strikes, ip)
+
+	until := now.Add(g.limits.BanDuration)
+	g.bans[ip] = until
+	g.decide(DecisionBanIssued, ip, slog.Time("until", until))
+
+	return true
+}
+
+// RateLimited records that a message from ip was dropped by its limiter.
+func (g *Guard) RateLimited(ip string) bool {
+	g.decide(DecisionRateLimited, ip)
+
+	return g.Strike(ip, DecisionRateLimited)
+}
+
+func (g *Guard) MaxMessageSize() int64 {
+	return g.limits.MaxMessageSize
+}
+
+func (g *Guard) NewLimiter() *Limiter {
+	return NewLimiter(g.limits.MessagesPerSecond, g.limits.MessageBurst, g.now)
+}
+
+func (g *Guard) decide(decision string, ip string, attrs ...interface{}) {
+	Decisions.Add(decision, 1)
+
+	g.log.Warn("connection guard decision",
+		append([]interface{}{slog.String("decision", decision), slog.String("ip", ip)}, attrs...)...)
+}
+
+// ClientIP returns the address the request came from. Forwarding headers are
+// only read when the socket peer is a trusted proxy, any client can set them.
+// X-Forwarded-For is walked from the right and the first address that is not
+// a trusted proxy wins.
+func (g *Guard) ClientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+
+	if !g.trusted(peer) {
+		return peer
+	}
+
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+
+			if !g.trusted(hop) {
+				return hop
+			}
+		}
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return peer
+}
+
+func (g *Guard) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, proxy := range g.proxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseProxy accepts a CIDR range or a single address.
+func parseProxy(proxy string) (*net.IPNet, error) {
+	if strings.Contains(proxy, "/") {
+		_, network, err := net.ParseCIDR(proxy)
+
+		return network, err
+	}
+
+	ip := net.ParseIP(proxy)
+	if ip == nil {
+		return nil, &net.ParseError{Type: "IP address", Text: proxy}
+	}
+
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
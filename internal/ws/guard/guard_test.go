@@ -0,0 +1,139 @@
+package guard
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-outpost/internal/config"
+	"go-outpost/internal/lib/logger/handler/slogdiscard"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestGuard(limits config.WSLimits) (*Guard, *clock) {
+	c := &clock{now: time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)}
+
+	g := New(slogdiscard.NewDiscardLogger(), limits)
+	g.now = c.Now
+
+	return g, c
+}
+
+func TestGuardConnectionCap(t *testing.T) {
+	g, _ := newTestGuard(config.WSLimits{MaxConnectionsPerIP: 2})
+
+	assert.NoError(t, g.Admit("10.0.0.1"))
+	assert.NoError(t, g.Admit("10.0.0.1"))
+	assert.ErrorIs(t, g.Admit("10.0.0.1"), ErrTooManyConns)
+	assert.NoError(t, g.Admit("10.0.0.2"))
+
+	g.Release("10.0.0.1")
+	assert.NoError(t, g.Admit("10.0.0.1"))
+}
+
+func TestGuardBanAfterStrikes(t *testing.T) {
+	g, c := newTestGuard(config.WSLimits{MaxProtocolErrors: 3, BanDuration: time.Minute})
+
+	assert.False(t, g.Strike("10.0.0.1", "malformed_message"))
+	assert.False(t, g.Strike("10.0.0.1", "malformed_message"))
+	assert.True(t, g.Strike("10.0.0.1", "malformed_message"))
+
+	assert.ErrorIs(t, g.Admit("10.0.0.1"), ErrBanned)
+
+	c.now = c.now.Add(time.Minute)
+	assert.NoError(t, g.Admit("10.0.0.1"))
+	assert.False(t, g.Strike("10.0.0.1", "malformed_message"), "strikes reset after the ban expires")
+}
+
+func TestGuardStrikeWindow(t *testing.T) {
+	g, c := newTestGuard(config.WSLimits{MaxProtocolErrors: 3, StrikeWindow: time.Minute, BanDuration: time.Minute})
+
+	assert.False(t, g.Strike("10.0.0.1", "malformed_message"))
+	assert.False(t, g.Strike("10.0.0.1", "malformed_message"))
+
+	c.now = c.now.Add(time.Minute)
+	assert.False(t, g.Strike("10.0.0.1", "malformed_message"), "strikes outside the window are forgotten")
+	assert.False(t, g.Strike("10.0.0.1", "malformed_message"))
+	assert.True(t, g.Strike("10.0.0.1", "malformed_message"))
+}
+
+func TestGuardClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{name: "socket address", remote: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "headers from a client are ignored", remote: "203.0.113.7:5000", forwarded: "198.51.100.1", realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "forwarded by a trusted proxy", remote: "10.0.0.5:5000", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "spoofed hop before the proxy", remote: "10.0.0.5:5000", forwarded: "198.51.100.9, 198.51.100.1, 10.0.0.6", want: "198.51.100.1"},
+		{name: "real ip from a trusted proxy", remote: "10.0.0.5:5000", realIP: "198.51.100.2", want: "198.51.100.2"},
+		{name: "trusted proxy without headers", remote: "10.0.0.5:5000", want: "10.0.0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := newTestGuard(config.WSLimits{TrustedProxies: []string{"10.0.0.0/8"}})
+
+			r := httptest.NewRequest("GET", "http://example.com/ws", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			assert.Equal(t, tt.want, g.ClientIP(r))
+		})
+	}
+}
+
+func TestGuardCheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "no origin header", allowed: []string{"https://outpost.gg"}, want: true},
+		{name: "allowed origin", allowed: []string{"https://outpost.gg/"}, origin: "https://outpost.gg", want: true},
+		{name: "foreign origin", allowed: []string{"https://outpost.gg"}, origin: "https://evil.example"},
+		{name: "wildcard", allowed: []string{"*"}, origin: "https://evil.example", want: true},
+		{name: "same host by default", origin: "http://example.com", want: true},
+		{name: "cross host by default", origin: "http://evil.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := newTestGuard(config.WSLimits{AllowedOrigins: tt.allowed})
+
+			r := httptest.NewRequest("GET", "http://example.com/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+
+			assert.Equal(t, tt.want, g.CheckOrigin(r))
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	c := &clock{now: time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)}
+	l := NewLimiter(2, 2, c.Now)
+
+	assert.True(t, l.Allow())
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	c.now = c.now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+}
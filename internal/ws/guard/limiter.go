@@ -0,0 +1,46 @@
+package guard
+
+import "time"
+
+// Limiter is a token bucket owned by a single connection, so it needs no locking.
+type Limiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func NewLimiter(rate float64, burst int, now func() time.Time) *Limiter {
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now(),
+		now:    now,
+	}
+}
+
+// Allow takes one token, reporting false when the bucket is empty.
+// A non-positive rate disables limiting.
+func (l *Limiter) Allow() bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	l.last = now
+
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+
+	return true
+}
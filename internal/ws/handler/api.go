@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"sort"
+)
+
+type PublishRequest struct {
+	Channel string                 `json:"channel" validate:"required_without=Batch"`
+	Event   string                 `json:"event" validate:"required_without=Batch"`
+	Version int                    `json:"version"`
+	Data    map[string]interface{} `json:"data"`
+	Batch   []Message              `json:"batch" validate:"omitempty,max=100,dive"`
+}
+
+type PublishResponse struct {
+	resp.Response
+	Published int `json:"published"`
+}
+
+type ChannelInfo struct {
+	Name              string `json:"name"`
+	SubscriptionCount int    `json:"subscription_count"`
+}
+
+type ChannelsResponse struct {
+	resp.Response
+	Channels []ChannelInfo `json:"channels"`
+}
+
+type ChannelUsersResponse struct {
+	resp.Response
+	Users []string `json:"users"`
+}
+
+type API struct {
+	hub       *Hub
+	validator *validator.Validate
+	log       *slog.Logger
+}
+
+func NewAPI(hub *Hub, log *slog.Logger) *API {
+	return &API{
+		hub:       hub,
+		validator: validator.New(),
+		log:       log,
+	}
+}
+
+func (a *API) PublishEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "ws.handler.PublishEvents"
+
+		var (
+			err      error
+			req      PublishRequest
+			log      *slog.Logger
+			messages []Message
+		)
+
+		log = a.log.With(
+			slog.String("op", op),
+		)
+
+		if err = render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request body", http.StatusBadRequest))
+
+			return
+		}
+
+		if err = a.validator.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		messages = req.Batch
+		if len(messages) == 0 {
+			messages = []Message{{Channel: req.Channel, Event: req.Event, Version: req.Version, Data: req.Data}}
+		}
+
+		for _, message := range messages {
+			if err = a.hub.Validate(message); err != nil {
+				log.Error("invalid event", sl.Err(err))
+
+				render.JSON(w, r, resp.Error(err.Error(), http.StatusBadRequest))
+
+				return
+			}
+		}
+
+		for _, message := range messages {
+			a.hub.Publish(message)
+		}
+
+		log.Info("events published", slog.Int("count", len(messages)))
+
+		render.JSON(w, r, PublishResponse{
+			Response:  resp.OK(),
+			Published: len(messages),
+		})
+	}
+}
+
+func (a *API) Schema() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := a.hub.registry.AsyncAPI("go-outpost realtime events", "1.0.0")
+		if err != nil {
+			a.log.Error("failed to render events schema", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to render events schema", http.StatusInternalServerError))
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	}
+}
+
+func (a *API) ListChannels() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		channels := make([]ChannelInfo, 0)
+
+		for name, count := range a.hub.ChannelsInfo() {
+			channels = append(channels, ChannelInfo{Name: name, SubscriptionCount: count})
+		}
+
+		sort.Slice(channels, func(i, j int) bool {
+			return channels[i].Name < channels[j].Name
+		})
+
+		render.JSON(w, r, ChannelsResponse{
+			Response: resp.OK(),
+			Channels: channels,
+		})
+	}
+}
+
+func (a *API) ListChannelUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+
+		if !IsPresenceChannel(name) {
+			render.JSON(w, r, resp.Error("users are only available for presence channels", http.StatusBadRequest))
+
+			return
+		}
+
+		render.JSON(w, r, ChannelUsersResponse{
+			Response: resp.OK(),
+			Users:    a.hub.PresenceUsers(name),
+		})
+	}
+}
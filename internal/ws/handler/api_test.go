@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-outpost/internal/config"
+	"go-outpost/internal/events"
+	"go-outpost/internal/lib/logger/handler/slogdiscard"
+	"go-outpost/internal/ws/guard"
+	"go-outpost/internal/ws/middleware/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "test-api-key"
+
+// newTestAPI serves the API routes behind the API key the way the ws server
+// does.
+func newTestAPI() (*Hub, http.Handler) {
+	log := slogdiscard.NewDiscardLogger()
+
+	hub := NewHub(log, config.WSServer{APIKey: testAPIKey}, events.Default, guard.New(log, config.WSLimits{}))
+	hub.RunServer()
+
+	api := NewAPI(hub, log)
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(auth.New(log, testAPIKey))
+
+		r.Post("/events", api.PublishEvents())
+		r.Get("/channels", api.ListChannels())
+	})
+
+	return hub, router
+}
+
+func subscribe(hub *Hub, channel string, userID string) *Client {
+	client := NewClient(userID, EncodingJSON)
+
+	hub.Subscribe <- Subscription{Client: client, Channel: channel, UserID: userID}
+
+	return client
+}
+
+func serve(router http.Handler, method string, target string, body string, apiKey string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		r.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestPublishEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiKey        string
+		body          string
+		wantCode      int
+		wantStatus    int
+		wantPublished int
+	}{
+		{
+			name:          "publishes with the api key",
+			apiKey:        testAPIKey,
+			body:          `{"channel": "roulette", "event": "winner", "version": 1, "data": {"color": "red", "number": 3}}`,
+			wantCode:      http.StatusOK,
+			wantStatus:    http.StatusOK,
+			wantPublished: 1,
+		},
+		{
+			name:     "rejects a publish without the api key",
+			body:     `{"channel": "roulette", "event": "winner", "version": 1, "data": {"color": "red", "number": 3}}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "rejects a publish with another key",
+			apiKey:   "other-key",
+			body:     `{"channel": "roulette", "event": "winner", "version": 1, "data": {"color": "red", "number": 3}}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects an event off the registry",
+			apiKey:     testAPIKey,
+			body:       `{"channel": "roulette", "event": "winner", "version": 1, "data": {"color": "blue", "number": 3}}`,
+			wantCode:   http.StatusOK,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, router := newTestAPI()
+			client := subscribe(hub, events.ChannelRoulette, "")
+
+			w := serve(router, http.MethodPost, "/events", tt.body, tt.apiKey)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusOK {
+				assert.Empty(t, client.send)
+
+				return
+			}
+
+			var res PublishResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+			assert.Equal(t, tt.wantStatus, res.Status)
+			assert.Equal(t, tt.wantPublished, res.Published)
+
+			if tt.wantPublished == 0 {
+				return
+			}
+
+			select {
+			case f := <-client.send:
+				assert.Equal(t, "winner", f.message.Event)
+				assert.Equal(t, "red", f.message.Data["color"])
+			case <-time.After(time.Second):
+				t.Fatal("published event was not delivered")
+			}
+		})
+	}
+}
+
+func TestListChannels(t *testing.T) {
+	hub, router := newTestAPI()
+
+	subscribe(hub, events.ChannelRoulette, "1")
+	subscribe(hub, events.ChannelRoulette, "2")
+	subscribe(hub, events.ChannelCoinflip, "1")
+
+	// The hub loop registers a subscription once it has received it, publish
+	// on the loop so every subscription above is in before the listing.
+	hub.Publish(Message{Channel: events.ChannelJackpot, Event: "pot-updated"})
+
+	w := serve(router, http.MethodGet, "/channels", "", testAPIKey)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res ChannelsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+
+	assert.Equal(t, []ChannelInfo{
+		{Name: events.ChannelCoinflip, SubscriptionCount: 1},
+		{Name: events.ChannelRoulette, SubscriptionCount: 2},
+	}, res.Channels)
+
+	w = serve(router, http.MethodGet, "/channels", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -1,122 +1,123 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"github.com/go-chi/render"
 	"github.com/gorilla/websocket"
+	resp "go-outpost/internal/lib/api/response"
 	"go-outpost/internal/lib/logger/sl"
-	"golang.org/x/exp/slog"
+	"go-outpost/internal/ws/guard"
+	"go-outpost/internal/ws/middleware/auth"
 	"net/http"
-	"sync"
+	"time"
 )
 
-type Message struct {
-	Channel string                 `json:"channel"`
-	Event   string                 `json:"event"`
-	Data    map[string]interface{} `json:"data"`
-}
-
-type Subscription struct {
-	Conn    *websocket.Conn
-	Channel string
-}
+const writeWait = 10 * time.Second
 
-type Hub struct {
-	Channels  map[string]map[*websocket.Conn]bool
-	Broadcast chan Message
-	Subscribe chan Subscription
-	mutex     sync.RWMutex
-	log       *slog.Logger
-}
-
-func NewHub(
-	log *slog.Logger,
-) *Hub {
-	return &Hub{
-		Channels:  make(map[string]map[*websocket.Conn]bool),
-		Broadcast: make(chan Message),
-		Subscribe: make(chan Subscription),
-		log:       log,
+func newUpgrader(compression bool, checkOrigin func(r *http.Request) bool) websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		EnableCompression: compression,
+		Subprotocols:      []string{string(EncodingJSON), string(EncodingMsgPack)},
+		CheckOrigin:       checkOrigin,
 	}
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-}
-
-func (hub *Hub) run() {
+func (hub *Hub) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	var (
-		sub       Subscription
-		err       error
-		data      []byte
-		conn      *websocket.Conn
-		receivers map[*websocket.Conn]bool
-		ok        bool
+		err        error
+		ws         *websocket.Conn
+		p          []byte
+		message    *Message
+		client     *Client
+		encoding   Encoding
+		subscribed map[string]bool
+		limiter    *guard.Limiter
+		ip         string
+		trusted    bool
 	)
 
-	for {
-		select {
-		case sub = <-hub.Subscribe:
-			if hub.Channels[sub.Channel] == nil {
-				hub.Channels[sub.Channel] = make(map[*websocket.Conn]bool)
-			}
-			hub.Channels[sub.Channel][sub.Conn] = true
-		case message := <-hub.Broadcast:
-			if receivers, ok = hub.Channels[message.Channel]; ok {
-				data, err = json.Marshal(message)
-				if err != nil {
-					hub.log.Error("failed to marshal message", sl.Err(err))
-
-					continue
-				}
+	ip = hub.guard.ClientIP(r)
 
-				hub.log.Info("broadcasting message", sl.String("channel", message.Channel),
-					sl.String("event", message.Event),
-					sl.Any("data", message.Data))
+	// Backend publishers authenticate with the API key and are exempt from
+	// message limits, they relay every game and balance event.
+	trusted = auth.Authorized(r, hub.apiKey)
 
-				for conn = range receivers {
-					if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-						hub.log.Error("failed to write message", sl.Err(err))
-					}
-				}
-			}
-		}
-	}
-}
+	if err = hub.guard.Admit(ip); err != nil {
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, resp.Error(err.Error(), http.StatusTooManyRequests))
 
-func (hub *Hub) HandleConnection(w http.ResponseWriter, r *http.Request) {
-	var (
-		err     error
-		ws      *websocket.Conn
-		p       []byte
-		message *Message
-	)
+		return
+	}
+	defer hub.guard.Release(ip)
 
-	ws, err = upgrader.Upgrade(w, r, nil)
+	ws, err = hub.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		hub.log.Error("failed to upgrade connection", sl.Err(err))
 
 		return
 	}
-	defer func(ws *websocket.Conn) {
-		err = ws.Close()
-		if err != nil {
-			hub.log.Error("failed to close connection", sl.Err(err))
-		}
-	}(ws)
+
+	if !trusted {
+		ws.SetReadLimit(hub.guard.MaxMessageSize())
+	}
+
+	limiter = hub.guard.NewLimiter()
+
+	encoding = EncodingJSON
+	if ws.Subprotocol() == string(EncodingMsgPack) {
+		encoding = EncodingMsgPack
+	}
+
+	client = NewClient("", encoding)
+
+	defer func() {
+		hub.Unsubscribe <- client
+	}()
+
+	go hub.writePump(ws, client)
+
+	_ = ws.SetReadDeadline(time.Now().Add(2 * hub.heartbeat))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(2 * hub.heartbeat))
+	})
+
+	subscribed = make(map[string]bool)
 
 	for {
 		_, p, err = ws.ReadMessage()
 		if err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				hub.guard.Strike(ip, "message_too_large")
+			}
+
 			hub.log.Error("failed to read message", sl.Err(err))
 			return
 		}
 
+		// A publisher may stay busy writing, any frame from it proves it alive.
+		if trusted {
+			_ = ws.SetReadDeadline(time.Now().Add(2 * hub.heartbeat))
+		}
+
+		if !trusted && !limiter.Allow() {
+			if hub.guard.RateLimited(ip) {
+				return
+			}
+
+			continue
+		}
+
 		message = &Message{}
-		err = json.Unmarshal(p, message)
+		err = decodeMessage(encoding, p, message)
 		if err != nil {
 			hub.log.Error("failed to unmarshal message", sl.Err(err))
 
+			if hub.guard.Strike(ip, "malformed_message") {
+				return
+			}
+
 			continue
 		}
 
@@ -124,14 +125,106 @@ func (hub *Hub) HandleConnection(w http.ResponseWriter, r *http.Request) {
 			sl.String("event", message.Event),
 			sl.Any("data", message.Data))
 
-		if hub.Channels[message.Channel] == nil {
-			hub.Subscribe <- Subscription{Conn: ws, Channel: message.Channel}
+		if trusted {
+			if err = hub.Validate(*message); err != nil {
+				hub.log.Warn("invalid event rejected", sl.Err(err))
+
+				continue
+			}
+
+			hub.Broadcast <- *message
+
+			continue
+		}
+
+		if !subscribed[message.Channel] {
+			if err = hub.subscribeFromMessage(client, message); err != nil {
+				hub.log.Warn("subscription rejected",
+					sl.String("channel", message.Channel),
+					sl.Err(err))
+
+				if hub.guard.Strike(ip, "subscription_rejected") {
+					return
+				}
+
+				continue
+			}
+
+			subscribed[message.Channel] = true
 		}
 
-		hub.Broadcast <- *message
+		// Players only subscribe, events come from the backend publishers.
+		if message.Event != EventSubscribe {
+			hub.log.Warn("publish rejected", sl.String("channel", message.Channel),
+				sl.String("event", message.Event))
+
+			if hub.guard.Strike(ip, "publish_rejected") {
+				return
+			}
+		}
 	}
 }
 
-func (hub *Hub) RunServer() {
-	go hub.run()
+// writePump is the only writer of the websocket: it drains the client queue
+// and pings the peer every heartbeat interval.
+func (hub *Hub) writePump(ws *websocket.Conn, client *Client) {
+	var (
+		err    error
+		f      frame
+		ok     bool
+		ticker *time.Ticker
+	)
+
+	ticker = time.NewTicker(hub.heartbeat)
+	defer func() {
+		ticker.Stop()
+
+		if err = ws.Close(); err != nil {
+			hub.log.Error("failed to close connection", sl.Err(err))
+		}
+	}()
+
+	for {
+		select {
+		case f, ok = <-client.send:
+			_ = ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = ws.WriteMessage(websocket.CloseMessage, []byte{})
+
+				return
+			}
+
+			if err = ws.WritePreparedMessage(f.prepared); err != nil {
+				hub.log.Error("failed to write message", sl.Err(err))
+
+				return
+			}
+		case <-ticker.C:
+			_ = ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err = ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				hub.log.Error("failed to write ping", sl.Err(err))
+
+				return
+			}
+		}
+	}
+}
+
+func (hub *Hub) subscribeFromMessage(client *Client, message *Message) error {
+	userID, _ := message.Data["user_id"].(string)
+	token, _ := message.Data["auth"].(string)
+	lastEventID, _ := message.Data["last_event_id"].(float64)
+
+	if err := hub.Authorize(message.Channel, userID, token); err != nil {
+		return err
+	}
+
+	hub.Subscribe <- Subscription{
+		Client:      client,
+		Channel:     message.Channel,
+		UserID:      hub.verifiedUserID(userID, token),
+		LastEventID: int64(lastEventID),
+	}
+
+	return nil
 }
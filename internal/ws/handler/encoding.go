@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Encoding string
+
+// Encodings double as websocket subprotocols, so a client picks one with
+// the Sec-WebSocket-Protocol header during the handshake.
+const (
+	EncodingJSON    Encoding = "json"
+	EncodingMsgPack Encoding = "msgpack"
+)
+
+func (e Encoding) messageType() int {
+	if e == EncodingMsgPack {
+		return websocket.BinaryMessage
+	}
+
+	return websocket.TextMessage
+}
+
+func encodeMessage(encoding Encoding, message Message) ([]byte, error) {
+	const op = "ws.handler.encodeMessage"
+
+	var (
+		err  error
+		data []byte
+		buf  bytes.Buffer
+	)
+
+	switch encoding {
+	case EncodingMsgPack:
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		enc.UseCompactInts(true)
+
+		err = enc.Encode(message)
+		data = buf.Bytes()
+	default:
+		data, err = json.Marshal(message)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, nil
+}
+
+func decodeMessage(encoding Encoding, data []byte, message *Message) error {
+	const op = "ws.handler.decodeMessage"
+
+	var err error
+
+	switch encoding {
+	case EncodingMsgPack:
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.SetCustomStructTag("json")
+		dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+			return d.DecodeUntypedMap()
+		})
+
+		err = dec.Decode(message)
+	default:
+		err = json.Unmarshal(data, message)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// frameCache encodes a broadcast at most once per encoding. The prepared
+// websocket message also carries the deflated payload, so compression is
+// done once per negotiated compression setting rather than once per receiver.
+type frameCache struct {
+	message Message
+	frames  map[Encoding]frame
+}
+
+func newFrameCache(message Message) *frameCache {
+	return &frameCache{
+		message: message,
+		frames:  make(map[Encoding]frame, 2),
+	}
+}
+
+func (c *frameCache) get(encoding Encoding) (frame, error) {
+	const op = "ws.handler.frameCache.get"
+
+	var (
+		err      error
+		f        frame
+		ok       bool
+		data     []byte
+		prepared *websocket.PreparedMessage
+	)
+
+	if f, ok = c.frames[encoding]; ok {
+		return f, nil
+	}
+
+	data, err = encodeMessage(encoding, c.message)
+	if err != nil {
+		return f, fmt.Errorf("%s: %w", op, err)
+	}
+
+	prepared, err = websocket.NewPreparedMessage(encoding.messageType(), data)
+	if err != nil {
+		return f, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f = frame{message: c.message, data: data, prepared: prepared}
+	c.frames[encoding] = f
+
+	return f, nil
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecodeMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding Encoding
+	}{
+		{
+			name:     "json",
+			encoding: EncodingJSON,
+		},
+		{
+			name:     "msgpack",
+			encoding: EncodingMsgPack,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := Message{
+				ID:      7,
+				Channel: "crash",
+				Event:   "tick",
+				Data:    map[string]interface{}{"multiplier": "1.37"},
+			}
+
+			data, err := encodeMessage(tt.encoding, message)
+			require.NoError(t, err)
+
+			decoded := Message{}
+			require.NoError(t, decodeMessage(tt.encoding, data, &decoded))
+
+			assert.Equal(t, message, decoded)
+		})
+	}
+}
+
+func TestFrameCacheEncodesOncePerEncoding(t *testing.T) {
+	cache := newFrameCache(Message{Channel: "crash", Event: "tick"})
+
+	first, err := cache.get(EncodingMsgPack)
+	require.NoError(t, err)
+
+	second, err := cache.get(EncodingMsgPack)
+	require.NoError(t, err)
+
+	assert.Same(t, first.prepared, second.prepared)
+	assert.Len(t, cache.frames, 1)
+}
+
+// BenchmarkBroadcast pushes crash multiplier ticks to compressed websocket
+// receivers. "per-receiver" encodes and deflates the tick for every
+// connection, "per-encoding" goes through the hub frame cache.
+func BenchmarkBroadcast(b *testing.B) {
+	const receivers = 50
+
+	for _, encoding := range []Encoding{EncodingJSON, EncodingMsgPack} {
+		conns := dialReceivers(b, receivers, encoding)
+
+		b.Run(string(encoding)+"/per-receiver", func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				message := crashTick(i)
+
+				for _, conn := range conns {
+					data, err := encodeMessage(encoding, message)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					if err = conn.WriteMessage(encoding.messageType(), data); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(string(encoding)+"/per-encoding", func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				cache := newFrameCache(crashTick(i))
+
+				for _, conn := range conns {
+					f, err := cache.get(encoding)
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					if err = conn.WritePreparedMessage(f.prepared); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func crashTick(i int) Message {
+	return Message{
+		ID:      int64(i),
+		Channel: "crash",
+		Event:   "tick",
+		Data: map[string]interface{}{
+			"round_uuid": "5f0c6a2e-8f3b-4a57-9d55-0b7c52a9c1f4",
+			"multiplier": 1 + float64(i%1000)/100,
+			"elapsed_ms": i * 100,
+			"players":    []string{"alpha", "bravo", "charlie", "delta"},
+		},
+	}
+}
+
+// dialReceivers returns the server side of n compressed websocket
+// connections whose client side discards everything it reads.
+func dialReceivers(b *testing.B, n int, encoding Encoding) []*websocket.Conn {
+	conns := make(chan *websocket.Conn, n)
+	upgrader := newUpgrader(true, nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			b.Error(err)
+
+			return
+		}
+
+		conns <- conn
+	}))
+	b.Cleanup(srv.Close)
+
+	dialer := websocket.Dialer{
+		EnableCompression: true,
+		Subprotocols:      []string{string(encoding)},
+	}
+
+	result := make([]*websocket.Conn, 0, n)
+
+	for i := 0; i < n; i++ {
+		client, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { _ = client.Close() })
+
+		go func() {
+			for {
+				if _, _, err := client.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		result = append(result, <-conns)
+	}
+
+	return result
+}
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"github.com/gorilla/websocket"
+	"go-outpost/internal/config"
+	"go-outpost/internal/events"
+	"go-outpost/internal/lib/channelauth"
+	"go-outpost/internal/lib/logger/sl"
+	"go-outpost/internal/ws/guard"
+	"golang.org/x/exp/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventSubscribe = "subscribe"
+
+	historySize = 100
+	sendBuffer  = 256
+)
+
+type Message struct {
+	ID      int64                  `json:"id,omitempty"`
+	Channel string                 `json:"channel" validate:"required"`
+	Event   string                 `json:"event" validate:"required"`
+	Version int                    `json:"version,omitempty"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// Client is a transport-neutral subscriber. Websocket, SSE and long-polling
+// connections all receive broadcasts through its send queue.
+type Client struct {
+	UserID   string
+	encoding Encoding
+	send     chan frame
+	closed   bool
+}
+
+type frame struct {
+	message  Message
+	data     []byte
+	prepared *websocket.PreparedMessage
+}
+
+type Subscription struct {
+	Client      *Client
+	Channel     string
+	UserID      string
+	LastEventID int64
+}
+
+type Hub struct {
+	Channels    map[string]map[*Client]bool
+	Broadcast   chan Message
+	Subscribe   chan Subscription
+	Unsubscribe chan *Client
+	history     map[string][]Message
+	sequence    int64
+	secret      string
+	apiKey      string
+	heartbeat   time.Duration
+	upgrader    websocket.Upgrader
+	registry    *events.Registry
+	guard       *guard.Guard
+	mutex       sync.RWMutex
+	log         *slog.Logger
+}
+
+func NewHub(
+	log *slog.Logger,
+	cfg config.WSServer,
+	registry *events.Registry,
+	guard *guard.Guard,
+) *Hub {
+	return &Hub{
+		Channels:    make(map[string]map[*Client]bool),
+		Broadcast:   make(chan Message),
+		Subscribe:   make(chan Subscription),
+		Unsubscribe: make(chan *Client),
+		history:     make(map[string][]Message),
+		secret:      cfg.ChannelSecret,
+		apiKey:      cfg.APIKey,
+		heartbeat:   cfg.Heartbeat,
+		upgrader:    newUpgrader(cfg.Compression, guard.CheckOrigin),
+		registry:    registry,
+		guard:       guard,
+		log:         log,
+	}
+}
+
+func NewClient(userID string, encoding Encoding) *Client {
+	return &Client{
+		UserID:   userID,
+		encoding: encoding,
+		send:     make(chan frame, sendBuffer),
+	}
+}
+
+func (hub *Hub) run() {
+	var (
+		sub     Subscription
+		client  *Client
+		message Message
+	)
+
+	for {
+		select {
+		case sub = <-hub.Subscribe:
+			hub.mutex.Lock()
+			if sub.Client.closed {
+				hub.mutex.Unlock()
+
+				continue
+			}
+			if hub.Channels[sub.Channel] == nil {
+				hub.Channels[sub.Channel] = make(map[*Client]bool)
+			}
+			hub.Channels[sub.Channel][sub.Client] = true
+			if sub.Client.UserID == "" {
+				sub.Client.UserID = sub.UserID
+			}
+			hub.mutex.Unlock()
+
+			if sub.LastEventID > 0 {
+				hub.replay(sub)
+			}
+		case client = <-hub.Unsubscribe:
+			hub.removeClient(client)
+		case message = <-hub.Broadcast:
+			hub.broadcast(message)
+		}
+	}
+}
+
+func (hub *Hub) broadcast(message Message) {
+	var (
+		err   error
+		f     frame
+		cache *frameCache
+	)
+
+	hub.mutex.Lock()
+	hub.sequence++
+	message.ID = hub.sequence
+	hub.history[message.Channel] = append(hub.history[message.Channel], message)
+	if len(hub.history[message.Channel]) > historySize {
+		hub.history[message.Channel] = hub.history[message.Channel][1:]
+	}
+	hub.mutex.Unlock()
+
+	hub.log.Debug("broadcasting message", sl.String("channel", message.Channel),
+		sl.String("event", message.Event),
+		sl.Any("data", message.Data))
+
+	cache = newFrameCache(message)
+
+	for client := range hub.Channels[message.Channel] {
+		f, err = cache.get(client.encoding)
+		if err != nil {
+			hub.log.Error("failed to encode message", sl.Err(err))
+
+			continue
+		}
+
+		hub.deliver(client, f)
+	}
+}
+
+func (hub *Hub) replay(sub Subscription) {
+	var (
+		err error
+		f   frame
+	)
+
+	for _, message := range hub.History(sub.Channel, sub.LastEventID) {
+		f, err = newFrameCache(message).get(sub.Client.encoding)
+		if err != nil {
+			hub.log.Error("failed to encode message", sl.Err(err))
+
+			continue
+		}
+
+		hub.deliver(sub.Client, f)
+	}
+}
+
+// deliver never blocks the hub loop: a client whose queue is full is dropped
+// and its transport notices the closed queue.
+func (hub *Hub) deliver(client *Client, f frame) {
+	select {
+	case client.send <- f:
+	default:
+		hub.log.Warn("client send queue is full, dropping client", sl.String("user_id", client.UserID))
+
+		hub.removeClient(client)
+	}
+}
+
+func (hub *Hub) removeClient(client *Client) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if client.closed {
+		return
+	}
+
+	for channel, receivers := range hub.Channels {
+		delete(receivers, client)
+		if len(receivers) == 0 {
+			delete(hub.Channels, channel)
+		}
+	}
+
+	client.closed = true
+	close(client.send)
+}
+
+// Validate checks a message against the event registry before it is published.
+func (hub *Hub) Validate(message Message) error {
+	return hub.registry.Validate(message.Channel, message.Event, message.Version, message.Data)
+}
+
+// Publish hands the message over to the hub loop, which owns every client queue.
+func (hub *Hub) Publish(message Message) {
+	hub.Broadcast <- message
+}
+
+// History returns the buffered messages of a channel with an id above lastEventID.
+func (hub *Hub) History(channel string, lastEventID int64) []Message {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	messages := make([]Message, 0)
+	for _, message := range hub.history[channel] {
+		if message.ID > lastEventID {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages
+}
+
+func (hub *Hub) LastEventID() int64 {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	return hub.sequence
+}
+
+// ChannelsInfo returns the number of subscribed clients per channel.
+func (hub *Hub) ChannelsInfo() map[string]int {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	channels := make(map[string]int, len(hub.Channels))
+	for channel, receivers := range hub.Channels {
+		channels[channel] = len(receivers)
+	}
+
+	return channels
+}
+
+// PresenceUsers returns the distinct user ids subscribed to a presence channel.
+func (hub *Hub) PresenceUsers(channel string) []string {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	seen := make(map[string]bool)
+	users := make([]string, 0)
+
+	for client := range hub.Channels[channel] {
+		if client.UserID == "" || seen[client.UserID] {
+			continue
+		}
+
+		seen[client.UserID] = true
+		users = append(users, client.UserID)
+	}
+
+	sort.Strings(users)
+
+	return users
+}
+
+func (hub *Hub) Authorize(channel string, userID string, token string) error {
+	return channelauth.Authorize(hub.secret, channel, userID, token)
+}
+
+// verifiedUserID returns userID only when token proves it, so presence lists
+// never show ids a client merely claimed.
+func (hub *Hub) verifiedUserID(userID string, token string) string {
+	if !channelauth.Verify(hub.secret, userID, token) {
+		return ""
+	}
+
+	return userID
+}
+
+func (hub *Hub) RunServer() {
+	go hub.run()
+}
+
+func IsPresenceChannel(channel string) bool {
+	return strings.HasPrefix(channel, channelauth.PresencePrefix)
+}
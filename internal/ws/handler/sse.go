@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/go-chi/render"
+	resp "go-outpost/internal/lib/api/response"
+	"go-outpost/internal/lib/logger/sl"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PollResponse struct {
+	resp.Response
+	Messages    []Message `json:"messages"`
+	LastEventID int64     `json:"last_event_id"`
+}
+
+type streamRequest struct {
+	channels    []string
+	userID      string
+	token       string
+	lastEventID int64
+}
+
+// HandleSSE streams the requested channels as Server-Sent Events:
+// GET /sse?channels=roulette,private-user.x&user_id=x&auth=token
+func (hub *Hub) HandleSSE(w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		req    streamRequest
+		client *Client
+		rc     *http.ResponseController
+		f      frame
+		ok     bool
+		ticker *time.Ticker
+	)
+
+	ip := hub.guard.ClientIP(r)
+
+	if err = hub.guard.Admit(ip); err != nil {
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, resp.Error(err.Error(), http.StatusTooManyRequests))
+
+		return
+	}
+	defer hub.guard.Release(ip)
+
+	req, err = hub.parseStreamRequest(r, r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		hub.log.Warn("sse subscription rejected", sl.Err(err))
+
+		hub.guard.Strike(ip, "subscription_rejected")
+
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, resp.Error(err.Error(), http.StatusForbidden))
+
+		return
+	}
+
+	rc = http.NewResponseController(w)
+
+	// The stream outlives the server write timeout.
+	if err = rc.SetWriteDeadline(time.Time{}); err != nil {
+		hub.log.Error("failed to reset write deadline", sl.Err(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if err = rc.Flush(); err != nil {
+		hub.log.Error("failed to flush sse stream", sl.Err(err))
+
+		return
+	}
+
+	client = hub.subscribeAll(req)
+	defer func() {
+		hub.Unsubscribe <- client
+	}()
+
+	ticker = time.NewTicker(hub.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case f, ok = <-client.send:
+			if !ok {
+				return
+			}
+
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", f.message.ID, f.message.Event, f.data)
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+
+		if err != nil {
+			hub.log.Error("failed to write sse event", sl.Err(err))
+
+			return
+		}
+	}
+}
+
+// HandlePoll is the long-polling fallback: it answers as soon as a message
+// newer than last_event_id is available, or empty after one heartbeat interval.
+func (hub *Hub) HandlePoll(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		req      streamRequest
+		client   *Client
+		f        frame
+		ok       bool
+		messages []Message
+		timer    *time.Timer
+	)
+
+	ip := hub.guard.ClientIP(r)
+
+	if err = hub.guard.Admit(ip); err != nil {
+		render.Status(r, http.StatusTooManyRequests)
+		render.JSON(w, r, resp.Error(err.Error(), http.StatusTooManyRequests))
+
+		return
+	}
+	defer hub.guard.Release(ip)
+
+	req, err = hub.parseStreamRequest(r, "")
+	if err != nil {
+		hub.log.Warn("poll subscription rejected", sl.Err(err))
+
+		hub.guard.Strike(ip, "subscription_rejected")
+
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, resp.Error(err.Error(), http.StatusForbidden))
+
+		return
+	}
+
+	if err = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(hub.heartbeat + writeWait)); err != nil {
+		hub.log.Error("failed to extend write deadline", sl.Err(err))
+	}
+
+	if req.lastEventID == 0 {
+		req.lastEventID = hub.LastEventID()
+	}
+
+	client = hub.subscribeAll(req)
+	defer func() {
+		hub.Unsubscribe <- client
+	}()
+
+	messages = make([]Message, 0)
+
+	timer = time.NewTimer(hub.heartbeat)
+	defer timer.Stop()
+
+	select {
+	case <-r.Context().Done():
+		return
+	case <-timer.C:
+	case f, ok = <-client.send:
+		if ok {
+			messages = append(messages, f.message)
+		}
+	}
+
+	// Drain whatever else is already queued so bursts come back in one response.
+	for drained := false; !drained; {
+		select {
+		case f, ok = <-client.send:
+			if !ok {
+				drained = true
+
+				continue
+			}
+
+			messages = append(messages, f.message)
+		default:
+			drained = true
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+
+	lastEventID := req.lastEventID
+	if len(messages) > 0 {
+		lastEventID = messages[len(messages)-1].ID
+	}
+
+	render.JSON(w, r, PollResponse{
+		Response:    resp.OK(),
+		Messages:    messages,
+		LastEventID: lastEventID,
+	})
+}
+
+func (hub *Hub) parseStreamRequest(r *http.Request, lastEventIDHeader string) (streamRequest, error) {
+	var (
+		err error
+		req streamRequest
+		raw string
+	)
+
+	query := r.URL.Query()
+
+	for _, channel := range strings.Split(query.Get("channels"), ",") {
+		channel = strings.TrimSpace(channel)
+		if channel != "" {
+			req.channels = append(req.channels, channel)
+		}
+	}
+
+	if len(req.channels) == 0 {
+		return req, fmt.Errorf("channels are required")
+	}
+
+	req.userID = query.Get("user_id")
+	req.token = query.Get("auth")
+
+	for _, channel := range req.channels {
+		if err = hub.Authorize(channel, req.userID, req.token); err != nil {
+			return req, fmt.Errorf("%s: %w", channel, err)
+		}
+	}
+
+	raw = lastEventIDHeader
+	if raw == "" {
+		raw = query.Get("last_event_id")
+	}
+
+	if raw != "" {
+		req.lastEventID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return req, fmt.Errorf("invalid last_event_id: %w", err)
+		}
+	}
+
+	return req, nil
+}
+
+func (hub *Hub) subscribeAll(req streamRequest) *Client {
+	client := NewClient(hub.verifiedUserID(req.userID, req.token), EncodingJSON)
+
+	for _, channel := range req.channels {
+		hub.Subscribe <- Subscription{Client: client, Channel: channel, LastEventID: req.lastEventID}
+	}
+
+	return client
+}
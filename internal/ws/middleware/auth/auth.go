@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"github.com/go-chi/render"
+	resp "go-outpost/internal/lib/api/response"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strings"
+)
+
+func New(log *slog.Logger, apiKey string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log = log.With(
+			slog.String("component", "middleware/auth"),
+		)
+
+		log.Info("auth middleware initialized")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !Authorized(r, apiKey) {
+				log.Warn("unauthorized request",
+					slog.String("url", r.URL.Path),
+					slog.String("remote_addr", r.RemoteAddr),
+				)
+
+				render.Status(r, http.StatusUnauthorized)
+				render.JSON(w, r, resp.Error("unauthorized", http.StatusUnauthorized))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Authorized reports whether the request carries apiKey as a bearer token.
+func Authorized(r *http.Request, apiKey string) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return apiKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) == 1
+}
@@ -0,0 +1,3 @@
+ALTER TABLE roulette_bets
+    DROP COLUMN value,
+    DROP COLUMN type;
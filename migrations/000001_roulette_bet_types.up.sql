@@ -0,0 +1,6 @@
+-- Bets carry a type and the value it is placed on, existing bets are colour bets.
+ALTER TABLE roulette_bets
+    ADD COLUMN type VARCHAR(16) NOT NULL DEFAULT 'color' AFTER id,
+    ADD COLUMN value VARCHAR(64) NOT NULL DEFAULT '' AFTER type;
+
+UPDATE roulette_bets SET value = color WHERE value = '';
@@ -0,0 +1 @@
+DROP TABLE roulette_auto_bets;
@@ -0,0 +1,22 @@
+CREATE TABLE roulette_auto_bets
+(
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id          BIGINT UNSIGNED NOT NULL,
+    type             VARCHAR(16)     NOT NULL,
+    value            VARCHAR(64)     NOT NULL,
+    base_amount      BIGINT          NOT NULL,
+    current_amount   BIGINT          NOT NULL,
+    strategy         VARCHAR(16)     NOT NULL,
+    rounds           INT             NOT NULL DEFAULT 0,
+    rounds_played    INT             NOT NULL DEFAULT 0,
+    stop_on_profit   BIGINT          NOT NULL DEFAULT 0,
+    stop_on_loss     BIGINT          NOT NULL DEFAULT 0,
+    profit           BIGINT          NOT NULL DEFAULT 0,
+    status           VARCHAR(16)     NOT NULL,
+    stop_reason      VARCHAR(32)     NOT NULL DEFAULT '',
+    last_roulette_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at       DATETIME        NOT NULL,
+    updated_at       DATETIME        NOT NULL,
+    INDEX roulette_auto_bets_status (status),
+    INDEX roulette_auto_bets_user_id (user_id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE roulettes
+    DROP INDEX roulettes_status,
+    DROP COLUMN bet_deadline,
+    DROP COLUMN status;
@@ -0,0 +1,11 @@
+-- Rounds played before the bet window existed are closed, the bet window of
+-- every existing round ended when it was created.
+ALTER TABLE roulettes
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'open' AFTER round,
+    ADD COLUMN bet_deadline DATETIME NULL AFTER status,
+    ADD INDEX roulettes_status (status);
+
+UPDATE roulettes SET status = 'closed' WHERE played_at IS NOT NULL;
+UPDATE roulettes SET bet_deadline = created_at;
+
+ALTER TABLE roulettes MODIFY bet_deadline DATETIME NOT NULL;
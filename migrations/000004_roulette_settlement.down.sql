@@ -0,0 +1,11 @@
+ALTER TABLE roulette_auto_bets
+    CHANGE pending_roulette_id last_roulette_id BIGINT UNSIGNED NOT NULL DEFAULT 0;
+
+ALTER TABLE roulette_bets
+    DROP COLUMN settled_at,
+    DROP COLUMN payout;
+
+UPDATE roulettes SET status = 'closed' WHERE status IN ('rolled', 'settled', 'refunded');
+
+ALTER TABLE roulettes
+    DROP COLUMN settled_at;
@@ -0,0 +1,18 @@
+-- Rounds and bets settle once, marked by settled_at. Rounds that were already
+-- played were paid when they rolled, they are marked as settled so the
+-- recovery and the reconcile command leave them alone.
+ALTER TABLE roulettes
+    ADD COLUMN settled_at DATETIME NULL AFTER played_at;
+
+ALTER TABLE roulette_bets
+    ADD COLUMN payout     BIGINT   NOT NULL DEFAULT 0 AFTER user_id,
+    ADD COLUMN settled_at DATETIME NULL AFTER payout;
+
+UPDATE roulettes SET status = 'settled', settled_at = played_at WHERE played_at IS NOT NULL;
+
+UPDATE roulette_bets b JOIN roulettes r ON r.id = b.roulette_id
+SET b.settled_at = r.played_at
+WHERE r.played_at IS NOT NULL;
+
+ALTER TABLE roulette_auto_bets
+    CHANGE last_roulette_id pending_roulette_id BIGINT UNSIGNED NOT NULL DEFAULT 0;
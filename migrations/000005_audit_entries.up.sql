@@ -0,0 +1,10 @@
+CREATE TABLE audit_entries
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    game       VARCHAR(16)     NOT NULL,
+    game_id    BIGINT UNSIGNED NOT NULL,
+    action     VARCHAR(32)     NOT NULL,
+    details    JSON            NOT NULL,
+    created_at DATETIME        NOT NULL,
+    INDEX audit_entries_game (game, game_id)
+);
@@ -0,0 +1,6 @@
+DROP TABLE dice_bets;
+
+ALTER TABLE game_draws
+    DROP COLUMN user_id;
+
+DROP TABLE user_seeds;
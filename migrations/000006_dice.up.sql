@@ -0,0 +1,38 @@
+-- Each player has one active seed pair, the server seed is revealed when it
+-- is rotated.
+CREATE TABLE user_seeds
+(
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id          BIGINT UNSIGNED NOT NULL,
+    client_seed      VARCHAR(64)     NOT NULL,
+    server_seed      VARCHAR(64)     NOT NULL,
+    server_seed_hash CHAR(64)        NOT NULL,
+    nonce            INT             NOT NULL DEFAULT 0,
+    active           TINYINT(1)      NOT NULL DEFAULT 1,
+    created_at       DATETIME        NOT NULL,
+    revealed_at      DATETIME        NULL,
+    INDEX user_seeds_user_id_active (user_id, active)
+);
+
+-- Draws of single player games point at their player, roulette draws do not.
+ALTER TABLE game_draws
+    ADD COLUMN user_id BIGINT UNSIGNED NULL AFTER game_id;
+
+-- Target and roll are percentages in hundredths, 0-9999.
+CREATE TABLE dice_bets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid       CHAR(36)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    amount     BIGINT          NOT NULL,
+    target     INT             NOT NULL,
+    direction  VARCHAR(8)      NOT NULL,
+    multiplier DOUBLE          NOT NULL,
+    roll       INT             NOT NULL,
+    win        TINYINT(1)      NOT NULL,
+    payout     BIGINT          NOT NULL DEFAULT 0,
+    nonce      INT             NOT NULL,
+    created_at DATETIME        NOT NULL,
+    UNIQUE KEY dice_bets_uuid (uuid),
+    INDEX dice_bets_user_id (user_id)
+);
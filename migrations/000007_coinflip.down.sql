@@ -0,0 +1,3 @@
+DROP TABLE escrows;
+
+DROP TABLE coinflip_lobbies;
@@ -0,0 +1,33 @@
+CREATE TABLE coinflip_lobbies
+(
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid             CHAR(36)        NOT NULL,
+    creator_id       BIGINT UNSIGNED NOT NULL,
+    creator_side     VARCHAR(8)      NOT NULL,
+    opponent_id      BIGINT UNSIGNED NULL,
+    stake            BIGINT          NOT NULL,
+    status           VARCHAR(16)     NOT NULL,
+    result           VARCHAR(8)      NOT NULL DEFAULT '',
+    winner_id        BIGINT UNSIGNED NULL,
+    payout           BIGINT          NOT NULL DEFAULT 0,
+    rake             BIGINT          NOT NULL DEFAULT 0,
+    expires_at       DATETIME        NOT NULL,
+    created_at       DATETIME        NOT NULL,
+    resolved_at      DATETIME        NULL,
+    UNIQUE KEY coinflip_lobbies_uuid (uuid),
+    INDEX coinflip_lobbies_status_expires_at (status, expires_at)
+);
+
+-- Stakes held by the house until a game between players is resolved.
+CREATE TABLE escrows
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    game       VARCHAR(16)     NOT NULL,
+    game_id    BIGINT UNSIGNED NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    amount     BIGINT          NOT NULL,
+    status     VARCHAR(16)     NOT NULL,
+    created_at DATETIME        NOT NULL,
+    updated_at DATETIME        NOT NULL,
+    INDEX escrows_game_status (game, game_id, status)
+);
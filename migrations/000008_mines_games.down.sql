@@ -0,0 +1 @@
+DROP TABLE mines_games;
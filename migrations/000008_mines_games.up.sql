@@ -0,0 +1,21 @@
+-- Board holds the mine tiles drawn when the game starts, revealed the tiles
+-- opened so far, both as JSON arrays.
+CREATE TABLE mines_games
+(
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid             CHAR(36)        NOT NULL,
+    user_id          BIGINT UNSIGNED NOT NULL,
+    amount           BIGINT          NOT NULL,
+    mines            INT             NOT NULL,
+    board            JSON            NOT NULL,
+    revealed         JSON            NOT NULL,
+    status           VARCHAR(16)     NOT NULL,
+    payout           BIGINT          NOT NULL DEFAULT 0,
+    client_seed      VARCHAR(64)     NOT NULL,
+    server_seed_hash CHAR(64)        NOT NULL,
+    nonce            INT             NOT NULL,
+    created_at       DATETIME        NOT NULL,
+    finished_at      DATETIME        NULL,
+    UNIQUE KEY mines_games_uuid (uuid),
+    INDEX mines_games_user_id_status (user_id, status)
+);
@@ -0,0 +1 @@
+DROP TABLE plinko_bets;
@@ -0,0 +1,18 @@
+-- Path holds one bit per row as a JSON array, 1 when the ball went right.
+CREATE TABLE plinko_bets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid       CHAR(36)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    amount     BIGINT          NOT NULL,
+    `rows`     INT             NOT NULL,
+    risk       VARCHAR(8)      NOT NULL,
+    path       JSON            NOT NULL,
+    slot       INT             NOT NULL,
+    multiplier DOUBLE          NOT NULL,
+    payout     BIGINT          NOT NULL DEFAULT 0,
+    nonce      INT             NOT NULL,
+    created_at DATETIME        NOT NULL,
+    UNIQUE KEY plinko_bets_uuid (uuid),
+    INDEX plinko_bets_user_id (user_id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE jackpot_deposits;
+
+DROP TABLE jackpot_pots;
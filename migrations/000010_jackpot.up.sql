@@ -0,0 +1,29 @@
+-- Every deposit buys the tickets ticket_from to ticket_to of its pot.
+CREATE TABLE jackpot_pots
+(
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid             CHAR(36)        NOT NULL,
+    status           VARCHAR(16)     NOT NULL,
+    total            BIGINT          NOT NULL DEFAULT 0,
+    winning_ticket   BIGINT          NULL,
+    winner_id        BIGINT UNSIGNED NULL,
+    payout           BIGINT          NOT NULL DEFAULT 0,
+    rake             BIGINT          NOT NULL DEFAULT 0,
+    ends_at          DATETIME        NOT NULL,
+    created_at       DATETIME        NOT NULL,
+    drawn_at         DATETIME        NULL,
+    UNIQUE KEY jackpot_pots_uuid (uuid),
+    INDEX jackpot_pots_status_ends_at (status, ends_at)
+);
+
+CREATE TABLE jackpot_deposits
+(
+    id          BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    pot_id      BIGINT UNSIGNED NOT NULL,
+    user_id     BIGINT UNSIGNED NOT NULL,
+    amount      BIGINT          NOT NULL,
+    ticket_from BIGINT          NOT NULL,
+    ticket_to   BIGINT          NOT NULL,
+    created_at  DATETIME        NOT NULL,
+    INDEX jackpot_deposits_pot_id (pot_id, ticket_from)
+);
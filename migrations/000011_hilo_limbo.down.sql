@@ -0,0 +1,3 @@
+DROP TABLE limbo_bets;
+
+DROP TABLE hilo_games;
@@ -0,0 +1,38 @@
+-- Deck holds the cards drawn when the game starts, guesses the guesses made
+-- so far, both as JSON arrays.
+CREATE TABLE hilo_games
+(
+    id               BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid             CHAR(36)        NOT NULL,
+    user_id          BIGINT UNSIGNED NOT NULL,
+    amount           BIGINT          NOT NULL,
+    deck             JSON            NOT NULL,
+    guesses          JSON            NOT NULL,
+    multiplier       DOUBLE          NOT NULL DEFAULT 1,
+    status           VARCHAR(16)     NOT NULL,
+    payout           BIGINT          NOT NULL DEFAULT 0,
+    client_seed      VARCHAR(64)     NOT NULL,
+    server_seed_hash CHAR(64)        NOT NULL,
+    nonce            INT             NOT NULL,
+    created_at       DATETIME        NOT NULL,
+    finished_at      DATETIME        NULL,
+    UNIQUE KEY hilo_games_uuid (uuid),
+    INDEX hilo_games_user_id_status (user_id, status)
+);
+
+-- Target and drawn are multipliers.
+CREATE TABLE limbo_bets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid       CHAR(36)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    amount     BIGINT          NOT NULL,
+    target     DOUBLE          NOT NULL,
+    drawn      DOUBLE          NOT NULL,
+    win        TINYINT(1)      NOT NULL,
+    payout     BIGINT          NOT NULL DEFAULT 0,
+    nonce      INT             NOT NULL,
+    created_at DATETIME        NOT NULL,
+    UNIQUE KEY limbo_bets_uuid (uuid),
+    INDEX limbo_bets_user_id (user_id)
+);
@@ -0,0 +1,77 @@
+CREATE TABLE dice_bets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid       CHAR(36)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    amount     BIGINT          NOT NULL,
+    target     INT             NOT NULL,
+    direction  VARCHAR(8)      NOT NULL,
+    multiplier DOUBLE          NOT NULL,
+    roll       INT             NOT NULL,
+    win        TINYINT(1)      NOT NULL,
+    payout     BIGINT          NOT NULL DEFAULT 0,
+    nonce      INT             NOT NULL,
+    created_at DATETIME        NOT NULL,
+    UNIQUE KEY dice_bets_uuid (uuid),
+    INDEX dice_bets_user_id (user_id)
+);
+
+CREATE TABLE limbo_bets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid       CHAR(36)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    amount     BIGINT          NOT NULL,
+    target     DOUBLE          NOT NULL,
+    drawn      DOUBLE          NOT NULL,
+    win        TINYINT(1)      NOT NULL,
+    payout     BIGINT          NOT NULL DEFAULT 0,
+    nonce      INT             NOT NULL,
+    created_at DATETIME        NOT NULL,
+    UNIQUE KEY limbo_bets_uuid (uuid),
+    INDEX limbo_bets_user_id (user_id)
+);
+
+INSERT INTO dice_bets(uuid, user_id, amount, target, direction, multiplier, roll, win, payout, nonce, created_at)
+SELECT uuid,
+       user_id,
+       amount,
+       ROUND(result ->> '$.target' * 100),
+       result ->> '$.direction',
+       result ->> '$.multiplier',
+       ROUND(result ->> '$.roll' * 100),
+       result ->> '$.win' = 'true',
+       payout,
+       nonce,
+       created_at
+FROM game_bets
+WHERE game = 'dice'
+ORDER BY id;
+
+INSERT INTO limbo_bets(uuid, user_id, amount, target, drawn, win, payout, nonce, created_at)
+SELECT uuid,
+       user_id,
+       amount,
+       result ->> '$.target',
+       result ->> '$.drawn',
+       result ->> '$.win' = 'true',
+       payout,
+       nonce,
+       created_at
+FROM game_bets
+WHERE game = 'limbo'
+ORDER BY id;
+
+UPDATE game_draws d
+    JOIN game_bets b ON b.id = d.game_id
+    JOIN dice_bets o ON o.uuid = b.uuid
+SET d.game_id = o.id
+WHERE d.game = 'dice';
+
+UPDATE game_draws d
+    JOIN game_bets b ON b.id = d.game_id
+    JOIN limbo_bets o ON o.uuid = b.uuid
+SET d.game_id = o.id
+WHERE d.game = 'limbo';
+
+DROP TABLE game_bets;
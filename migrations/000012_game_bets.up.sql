@@ -0,0 +1,64 @@
+-- Bets of the instant games played through the game engine. Params and result
+-- are the JSON of the engine's choice and draw.
+CREATE TABLE game_bets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid       CHAR(36)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    game       VARCHAR(16)     NOT NULL,
+    amount     BIGINT          NOT NULL,
+    params     JSON            NOT NULL,
+    result     JSON            NOT NULL,
+    payout     BIGINT          NOT NULL DEFAULT 0,
+    nonce      INT             NOT NULL,
+    created_at DATETIME        NOT NULL,
+    UNIQUE KEY game_bets_uuid (uuid),
+    INDEX game_bets_user_id_game (user_id, game)
+);
+
+-- Dice and limbo bets move over with the JSON their engines write, and their
+-- draws are pointed at the new rows. Limbo's win chance is rebuilt with the
+-- default house edge of 1%.
+INSERT INTO game_bets(uuid, user_id, game, amount, params, result, payout, nonce, created_at)
+SELECT uuid,
+       user_id,
+       'dice',
+       amount,
+       JSON_OBJECT('target', target / 100, 'direction', direction),
+       JSON_OBJECT('target', target / 100, 'direction', direction, 'multiplier', multiplier,
+                   'roll', roll / 100, 'win', IF(win, CAST('true' AS JSON), CAST('false' AS JSON))),
+       payout,
+       nonce,
+       created_at
+FROM dice_bets
+ORDER BY id;
+
+INSERT INTO game_bets(uuid, user_id, game, amount, params, result, payout, nonce, created_at)
+SELECT uuid,
+       user_id,
+       'limbo',
+       amount,
+       JSON_OBJECT('target', target),
+       JSON_OBJECT('target', target, 'win_chance', 99 / target, 'drawn', drawn,
+                   'win', IF(win, CAST('true' AS JSON), CAST('false' AS JSON))),
+       payout,
+       nonce,
+       created_at
+FROM limbo_bets
+ORDER BY id;
+
+UPDATE game_draws d
+    JOIN dice_bets o ON o.id = d.game_id
+    JOIN game_bets b ON b.uuid = o.uuid
+SET d.game_id = b.id
+WHERE d.game = 'dice';
+
+UPDATE game_draws d
+    JOIN limbo_bets o ON o.id = d.game_id
+    JOIN game_bets b ON b.uuid = o.uuid
+SET d.game_id = b.id
+WHERE d.game = 'limbo';
+
+DROP TABLE dice_bets;
+
+DROP TABLE limbo_bets;
@@ -0,0 +1,9 @@
+ALTER TABLE game_draws
+    DROP INDEX game_draws_user_id_game;
+
+ALTER TABLE user_balance_transactions
+    DROP INDEX user_balance_transactions_user_id_type;
+
+DROP TABLE tournament_entries;
+
+DROP TABLE tournaments;
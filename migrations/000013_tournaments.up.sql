@@ -0,0 +1,41 @@
+-- Games and prizes are JSON arrays, prizes[i] is paid to rank i + 1.
+CREATE TABLE tournaments
+(
+    id          BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid        CHAR(36)        NOT NULL,
+    name        VARCHAR(64)     NOT NULL,
+    metric      VARCHAR(16)     NOT NULL,
+    games       JSON            NOT NULL,
+    entry_fee   BIGINT          NOT NULL DEFAULT 0,
+    min_stake   BIGINT          NOT NULL DEFAULT 0,
+    max_players INT             NOT NULL DEFAULT 0,
+    players     INT             NOT NULL DEFAULT 0,
+    prizes      JSON            NOT NULL,
+    status      VARCHAR(16)     NOT NULL,
+    starts_at   DATETIME        NOT NULL,
+    ends_at     DATETIME        NOT NULL,
+    created_at  DATETIME        NOT NULL,
+    finished_at DATETIME        NULL,
+    UNIQUE KEY tournaments_uuid (uuid),
+    INDEX tournaments_status_ends_at (status, ends_at)
+);
+
+CREATE TABLE tournament_entries
+(
+    id            BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    tournament_id BIGINT UNSIGNED NOT NULL,
+    user_id       BIGINT UNSIGNED NOT NULL,
+    `rank`        INT             NULL,
+    score         DOUBLE          NOT NULL DEFAULT 0,
+    prize         BIGINT          NOT NULL DEFAULT 0,
+    joined_at     DATETIME        NOT NULL,
+    paid_at       DATETIME        NULL,
+    UNIQUE KEY tournament_entries_tournament_id_user_id (tournament_id, user_id)
+);
+
+-- Scores are summed from the ledger and the draws of the players.
+ALTER TABLE user_balance_transactions
+    ADD INDEX user_balance_transactions_user_id_type (user_id, type, created_at);
+
+ALTER TABLE game_draws
+    ADD INDEX game_draws_user_id_game (user_id, game, created_at);
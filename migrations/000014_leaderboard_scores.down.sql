@@ -0,0 +1 @@
+DROP TABLE leaderboard_scores;
@@ -0,0 +1,17 @@
+-- One row per player, game and window, added to as bets settle. The unique
+-- key is what the upsert of a settled bet lands on.
+CREATE TABLE leaderboard_scores
+(
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    period       VARCHAR(16)     NOT NULL,
+    period_start DATETIME        NOT NULL,
+    game         VARCHAR(16)     NOT NULL,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    wagered      BIGINT          NOT NULL DEFAULT 0,
+    profit       BIGINT          NOT NULL DEFAULT 0,
+    biggest_win  BIGINT          NOT NULL DEFAULT 0,
+    bets         INT             NOT NULL DEFAULT 0,
+    updated_at   DATETIME        NOT NULL,
+    UNIQUE KEY leaderboard_scores_window (period, period_start, game, user_id),
+    INDEX leaderboard_scores_user_id (user_id)
+);
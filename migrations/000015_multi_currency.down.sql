@@ -0,0 +1,25 @@
+-- Only the default currency survives, there is nowhere to keep the others.
+-- The balance is keyed on the user alone again.
+DELETE FROM user_balances WHERE currency <> 'USD';
+
+ALTER TABLE hilo_games
+    DROP COLUMN currency;
+
+ALTER TABLE mines_games
+    DROP COLUMN currency;
+
+ALTER TABLE plinko_bets
+    DROP COLUMN currency;
+
+ALTER TABLE game_bets
+    DROP COLUMN currency;
+
+ALTER TABLE user_balance_transactions
+    DROP INDEX user_balance_transactions_user_id_type,
+    ADD INDEX user_balance_transactions_user_id_type (user_id, type, created_at),
+    DROP COLUMN currency;
+
+ALTER TABLE user_balances
+    ADD UNIQUE KEY user_balances_user_id (user_id),
+    DROP INDEX user_balances_user_id_currency,
+    DROP COLUMN currency;
@@ -0,0 +1,43 @@
+-- Amounts stay in minor units of their currency, rows written before wallets
+-- had a currency are in the default one. A bitcoin balance counts satoshis,
+-- hence the widened amounts.
+ALTER TABLE user_balances
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'USD' AFTER user_id,
+    MODIFY balance BIGINT NOT NULL DEFAULT 0,
+    ADD UNIQUE KEY user_balances_user_id_currency (user_id, currency);
+
+-- A balance is keyed on the user and the currency now. A unique key on user_id
+-- alone would make the upsert of a credit in a second currency land on the
+-- first balance, so it is dropped under whatever name it was created with.
+SET @user_id_key = (SELECT INDEX_NAME
+                    FROM information_schema.STATISTICS
+                    WHERE TABLE_SCHEMA = DATABASE()
+                      AND TABLE_NAME = 'user_balances'
+                      AND NON_UNIQUE = 0
+                      AND INDEX_NAME <> 'PRIMARY'
+                    GROUP BY INDEX_NAME
+                    HAVING COUNT(*) = 1 AND MAX(COLUMN_NAME) = 'user_id'
+                    LIMIT 1);
+SET @drop_user_id_key = IF(@user_id_key IS NULL, 'DO 0',
+                           CONCAT('ALTER TABLE user_balances DROP INDEX `', @user_id_key, '`'));
+PREPARE drop_user_id_key FROM @drop_user_id_key;
+EXECUTE drop_user_id_key;
+DEALLOCATE PREPARE drop_user_id_key;
+
+ALTER TABLE user_balance_transactions
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'USD' AFTER user_id,
+    MODIFY value BIGINT NOT NULL,
+    DROP INDEX user_balance_transactions_user_id_type,
+    ADD INDEX user_balance_transactions_user_id_type (user_id, type, currency, created_at);
+
+ALTER TABLE game_bets
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'USD' AFTER game;
+
+ALTER TABLE plinko_bets
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'USD' AFTER user_id;
+
+ALTER TABLE mines_games
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'USD' AFTER user_id;
+
+ALTER TABLE hilo_games
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'USD' AFTER user_id;
@@ -0,0 +1,3 @@
+DROP TABLE withdrawals;
+
+DROP TABLE deposits;
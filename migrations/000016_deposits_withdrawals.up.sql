@@ -0,0 +1,38 @@
+-- A deposit is known to its provider by reference, a webhook settles it once.
+CREATE TABLE deposits
+(
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid         CHAR(36)        NOT NULL,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    provider     VARCHAR(32)     NOT NULL,
+    reference    VARCHAR(128)    NOT NULL,
+    currency     VARCHAR(8)      NOT NULL,
+    amount       BIGINT          NOT NULL,
+    status       VARCHAR(16)     NOT NULL,
+    created_at   DATETIME        NOT NULL,
+    completed_at DATETIME        NULL,
+    UNIQUE KEY deposits_uuid (uuid),
+    UNIQUE KEY deposits_provider_reference (provider, reference),
+    INDEX deposits_user_id (user_id)
+);
+
+-- A withdrawal goes requested, approved, sending and then sent or failed.
+-- Sending claims it for a single worker while the provider is called.
+CREATE TABLE withdrawals
+(
+    id             BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid           CHAR(36)        NOT NULL,
+    user_id        BIGINT UNSIGNED NOT NULL,
+    provider       VARCHAR(32)     NOT NULL,
+    currency       VARCHAR(8)      NOT NULL,
+    amount         BIGINT          NOT NULL,
+    destination    VARCHAR(255)    NOT NULL,
+    status         VARCHAR(16)     NOT NULL,
+    reference      VARCHAR(128)    NOT NULL DEFAULT '',
+    failure_reason TEXT            NOT NULL,
+    created_at     DATETIME        NOT NULL,
+    updated_at     DATETIME        NOT NULL,
+    UNIQUE KEY withdrawals_uuid (uuid),
+    INDEX withdrawals_user_id (user_id),
+    INDEX withdrawals_status (status)
+);
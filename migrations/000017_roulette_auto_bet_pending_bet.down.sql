@@ -0,0 +1,2 @@
+ALTER TABLE roulette_auto_bets
+    DROP COLUMN pending_bet_id;
@@ -0,0 +1,3 @@
+-- The bet an auto-bet placed on its pending round, the player may cancel it.
+ALTER TABLE roulette_auto_bets
+    ADD COLUMN pending_bet_id BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER pending_roulette_id;
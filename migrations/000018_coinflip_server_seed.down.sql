@@ -0,0 +1,3 @@
+ALTER TABLE coinflip_lobbies
+    DROP COLUMN server_seed_hash,
+    DROP COLUMN server_seed;
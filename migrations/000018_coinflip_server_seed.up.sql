@@ -0,0 +1,9 @@
+-- A lobby commits to its own server seed through the hash when it opens.
+-- Open lobbies get a fresh seed, finished ones drew from the shared seed
+-- and keep none.
+ALTER TABLE coinflip_lobbies
+    ADD COLUMN server_seed      VARCHAR(64) NOT NULL DEFAULT '' AFTER rake,
+    ADD COLUMN server_seed_hash CHAR(64)    NOT NULL DEFAULT '' AFTER server_seed;
+
+UPDATE coinflip_lobbies SET server_seed = LOWER(HEX(RANDOM_BYTES(32))) WHERE status = 'open';
+UPDATE coinflip_lobbies SET server_seed_hash = SHA2(server_seed, 256) WHERE server_seed <> '';
@@ -0,0 +1,3 @@
+ALTER TABLE jackpot_pots
+    DROP COLUMN server_seed_hash,
+    DROP COLUMN server_seed;
@@ -0,0 +1,9 @@
+-- A pot commits to its own server seed through the hash when it opens.
+-- Open pots get a fresh seed, finished ones drew from the shared seed
+-- and keep none.
+ALTER TABLE jackpot_pots
+    ADD COLUMN server_seed      VARCHAR(64) NOT NULL DEFAULT '' AFTER rake,
+    ADD COLUMN server_seed_hash CHAR(64)    NOT NULL DEFAULT '' AFTER server_seed;
+
+UPDATE jackpot_pots SET server_seed = LOWER(HEX(RANDOM_BYTES(32))) WHERE status = 'open';
+UPDATE jackpot_pots SET server_seed_hash = SHA2(server_seed, 256) WHERE server_seed <> '';
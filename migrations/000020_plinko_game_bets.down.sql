@@ -0,0 +1,44 @@
+CREATE TABLE plinko_bets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid       CHAR(36)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    currency   VARCHAR(8)      NOT NULL DEFAULT 'USD',
+    amount     BIGINT          NOT NULL,
+    `rows`     INT             NOT NULL,
+    risk       VARCHAR(8)      NOT NULL,
+    path       JSON            NOT NULL,
+    slot       INT             NOT NULL,
+    multiplier DOUBLE          NOT NULL,
+    payout     BIGINT          NOT NULL DEFAULT 0,
+    nonce      INT             NOT NULL,
+    created_at DATETIME        NOT NULL,
+    UNIQUE KEY plinko_bets_uuid (uuid),
+    INDEX plinko_bets_user_id (user_id)
+);
+
+INSERT INTO plinko_bets(uuid, user_id, currency, amount, `rows`, risk, path, slot, multiplier, payout, nonce,
+                        created_at)
+SELECT uuid,
+       user_id,
+       currency,
+       amount,
+       result ->> '$.rows',
+       result ->> '$.risk',
+       result -> '$.path',
+       result ->> '$.slot',
+       result ->> '$.multiplier',
+       payout,
+       nonce,
+       created_at
+FROM game_bets
+WHERE game = 'plinko'
+ORDER BY id;
+
+UPDATE game_draws d
+    JOIN game_bets b ON b.id = d.game_id
+    JOIN plinko_bets o ON o.uuid = b.uuid
+SET d.game_id = o.id
+WHERE d.game = 'plinko';
+
+DELETE FROM game_bets WHERE game = 'plinko';
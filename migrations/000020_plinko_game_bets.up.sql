@@ -0,0 +1,23 @@
+-- Plinko is played through the game engine, its bets move to game_bets with
+-- the JSON the engine writes and their draws are pointed at the new rows.
+INSERT INTO game_bets(uuid, user_id, game, currency, amount, params, result, payout, nonce, created_at)
+SELECT uuid,
+       user_id,
+       'plinko',
+       currency,
+       amount,
+       JSON_OBJECT('rows', `rows`, 'risk', risk),
+       JSON_OBJECT('rows', `rows`, 'risk', risk, 'path', path, 'slot', slot, 'multiplier', multiplier),
+       payout,
+       nonce,
+       created_at
+FROM plinko_bets
+ORDER BY id;
+
+UPDATE game_draws d
+    JOIN plinko_bets o ON o.id = d.game_id
+    JOIN game_bets b ON b.uuid = o.uuid
+SET d.game_id = b.id
+WHERE d.game = 'plinko';
+
+DROP TABLE plinko_bets;
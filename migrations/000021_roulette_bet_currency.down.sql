@@ -0,0 +1,2 @@
+ALTER TABLE roulette_bets
+    DROP COLUMN currency;
@@ -0,0 +1,4 @@
+-- A roulette bet is placed in a currency, existing bets are in the default one.
+ALTER TABLE roulette_bets
+    ADD COLUMN currency VARCHAR(8) NOT NULL DEFAULT 'USD' AFTER color,
+    MODIFY amount BIGINT NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE coinflip_lobbies
+    DROP COLUMN opponent_client_seed,
+    DROP COLUMN creator_client_seed;
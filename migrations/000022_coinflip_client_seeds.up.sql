@@ -0,0 +1,5 @@
+-- The flip mixes the client seeds of both players into the draw, each one
+-- taken from the active seed pair of its player when they enter the lobby.
+ALTER TABLE coinflip_lobbies
+    ADD COLUMN creator_client_seed  VARCHAR(64) NOT NULL DEFAULT '' AFTER server_seed_hash,
+    ADD COLUMN opponent_client_seed VARCHAR(64) NOT NULL DEFAULT '' AFTER creator_client_seed;
@@ -0,0 +1,3 @@
+ALTER TABLE jackpot_pots
+    DROP INDEX jackpot_pots_open_pot,
+    DROP COLUMN open_pot;
@@ -0,0 +1,6 @@
+-- At most one pot takes deposits at a time. MySQL has no partial unique
+-- keys, open_pot is 1 for the open pot and NULL otherwise, and NULLs do not
+-- collide.
+ALTER TABLE jackpot_pots
+    ADD COLUMN open_pot TINYINT AS (IF(status = 'open', 1, NULL)) STORED AFTER status,
+    ADD UNIQUE KEY jackpot_pots_open_pot (open_pot);